@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/0x6d61/pentecter/internal/config"
+	"github.com/0x6d61/pentecter/internal/knowledge"
+)
+
+// runKB は `pentecter kb <subcommand>` を処理する。
+func runKB(args []string) int {
+	if len(args) == 0 || args[0] != "index" {
+		fmt.Fprintln(os.Stderr, "Usage: pentecter kb index [-force]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("kb index", flag.ExitOnError)
+	force := fs.Bool("force", false, "Discard the existing index and re-parse every file")
+	_ = fs.Parse(args[1:])
+
+	appCfg, err := config.Load("config/config.yaml")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return 1
+	}
+	if len(appCfg.Knowledge) == 0 {
+		fmt.Fprintln(os.Stderr, "No knowledge base configured (see config/config.example.yaml)")
+		return 1
+	}
+
+	status := 0
+	for _, entry := range appCfg.Knowledge {
+		ks, err := openKnowledgeStore(entry, appCfg.KnowledgeEmbeddings)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", entry.Name, err)
+			status = 1
+			continue
+		}
+		if ks == nil {
+			fmt.Fprintf(os.Stderr, "%s: path not found: %s\n", entry.Name, entry.Path)
+			status = 1
+			continue
+		}
+		start := time.Now()
+		stats, err := ks.Index(context.Background(), *force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", entry.Name, err)
+			status = 1
+			continue
+		}
+		fmt.Printf("%s: %d files, %d sections, %d terms (%d updated, %d removed) in %s\n",
+			entry.Name, stats.Files, stats.Sections, stats.Terms, stats.Updated, stats.Removed,
+			time.Since(start).Round(time.Millisecond))
+		if appCfg.KnowledgeEmbeddings.Model != "" {
+			fmt.Printf("  embeddings (%s): %d/%d sections (%d new)\n",
+				appCfg.KnowledgeEmbeddings.Model, stats.Vectors, stats.Sections, stats.Embedded)
+		}
+		fmt.Printf("  → %s\n", ks.IndexPath())
+	}
+	return status
+}
+
+// openKnowledgeStore は設定エントリから Store を作成し、形式・インデックスの保存先・埋め込みを設定する。
+// パスが存在しない場合は nil を返す。
+func openKnowledgeStore(entry config.KnowledgeEntry, emb config.EmbeddingsConfig) (*knowledge.Store, error) {
+	format, err := knowledge.ParseFormat(entry.Format)
+	if err != nil {
+		return nil, err
+	}
+	ks := knowledge.NewStore(entry.Path)
+	if ks == nil {
+		return nil, nil
+	}
+	indexPath := entry.Index
+	if indexPath == "" {
+		indexPath = knowledge.DefaultIndexPath(entry.Path)
+	}
+	ks = ks.WithFormat(format).WithIndexPath(indexPath)
+	if emb.Model != "" {
+		baseURL := emb.BaseURL
+		if baseURL == "" {
+			baseURL = os.Getenv("OLLAMA_BASE_URL")
+		}
+		ks = ks.WithEmbedder(knowledge.NewOllamaEmbedder(baseURL, emb.Model), emb.Weight)
+	}
+	return ks, nil
+}
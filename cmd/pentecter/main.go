@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sort"
 	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -18,15 +19,25 @@ import (
 	"github.com/0x6d61/pentecter/internal/knowledge"
 	"github.com/0x6d61/pentecter/internal/mcp"
 	"github.com/0x6d61/pentecter/internal/memory"
+	"github.com/0x6d61/pentecter/internal/playbook"
 	"github.com/0x6d61/pentecter/internal/skills"
 	"github.com/0x6d61/pentecter/internal/tools"
 	"github.com/0x6d61/pentecter/internal/tui"
+	"github.com/0x6d61/pentecter/internal/vulndb"
 )
 
 func main() {
 	// Load .env file if present (ignored if not found)
 	_ = godotenv.Load()
 
+	// サブコマンド
+	if len(os.Args) > 1 && os.Args[1] == "kb" {
+		os.Exit(runKB(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "mcp-serve" {
+		os.Exit(runMCPServe(os.Args[2:]))
+	}
+
 	var (
 		provider    = flag.String("provider", "", "LLM provider: anthropic, openai, ollama (auto-detect if empty)")
 		model       = flag.String("model", "", "Model name (default: provider's default)")
@@ -37,6 +48,8 @@ func main() {
 
 Usage:
   pentecter [flags] [target-ip...]
+  pentecter kb index [-force]      Build/refresh the knowledge base search index
+  pentecter mcp-serve [flags]      Serve targets, findings, knowledge and tools over MCP stdio
 
 Flags:
 `)
@@ -58,6 +71,7 @@ Chat commands:
   10.0.0.5             Enter an IP address to add a target
   /target example.com  Add a domain as target
   /web-recon           Run a skill (auto-loaded from skills/ directory)
+  Ctrl+P / Tab         Command palette / complete commands, skills and hosts
 `)
 	}
 	flag.Parse()
@@ -78,50 +92,53 @@ Chat commands:
 
 	// --- Tools ---（Brain より先にロードし、ツール名をシステムプロンプトに注入する）
 	registry := tools.NewRegistry()
-	if err := registry.LoadDir("tools"); err != nil {
+	if err := registry.LoadDir(toolsDir); err != nil {
 		fmt.Fprintf(os.Stderr, "tool load error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Registry からツール名を収集
-	var toolNames []string
-	for _, def := range registry.All() {
-		toolNames = append(toolNames, def.Name)
+	// --- Skills ---（カタログをシステムプロンプトに注入するため Brain より先にロード）
+	skillsReg := skills.NewRegistry()
+	if err := skillsReg.LoadDir(skillsDir); err != nil {
+		// 不正なスキルだけを除外して起動を続ける
+		fmt.Fprintf(os.Stderr, "skill load warning: %v\n", err)
 	}
 
 	// --- MCP ---
-	mcpMgr, mcpErr := mcp.NewManager("config/mcp.yaml")
+	mcpMgr, mcpErr := mcp.NewManager(mcpConfigPath)
 	if mcpErr != nil {
 		fmt.Fprintf(os.Stderr, "MCP config warning: %v\n", mcpErr)
 	}
-
-	brainCfg, err := brain.LoadConfig(brain.ConfigHint{
-		Provider: selectedProvider,
-		Model:    *model,
-	})
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "brain config error:", err)
-		os.Exit(1)
-	}
-	brainCfg.ToolNames = toolNames
-
-	// MCP ツールスキーマを Brain に注入
 	if mcpMgr != nil {
 		if err := mcpMgr.StartAll(context.Background()); err != nil {
 			fmt.Fprintf(os.Stderr, "MCP start warning: %v\n", err)
 		}
 		defer func() { _ = mcpMgr.Close() }()
-		for _, t := range mcpMgr.ListAllTools() {
-			brainCfg.MCPTools = append(brainCfg.MCPTools, brain.MCPToolInfo{
-				Server:      t.Server,
-				Name:        t.Name,
-				Description: t.Description,
-				InputSchema: t.InputSchema,
-			})
-		}
 	}
 
-	br, err := brain.New(brainCfg)
+	// --- App Config (knowledge + blacklist) ---
+	appCfg, cfgErr := config.Load(appConfigPath)
+	if cfgErr != nil {
+		fmt.Fprintf(os.Stderr, "Config warning: %v\n", cfgErr)
+		appCfg = &config.AppConfig{}
+	}
+
+	// --- Blacklist ---
+	blacklist := tools.NewBlacklist(blacklistPatterns(appCfg))
+
+	// --- Brain ---（ツール・MCP・スキル一覧をシステムプロンプトに注入。/reload で作り直す）
+	rl := &reloader{
+		registry:  registry,
+		skillsReg: skillsReg,
+		blacklist: blacklist,
+		mcpMgr:    mcpMgr,
+		routes:    modelRoutes(appCfg.Models),
+		failover:  failoverHints(appCfg.Models),
+	}
+	br, brainCfg, err := rl.newBrain(brain.ConfigHint{
+		Provider: selectedProvider,
+		Model:    *model,
+	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "brain init error:", err)
 		os.Exit(1)
@@ -129,71 +146,42 @@ Chat commands:
 
 	// --- SubBrain for SmartSubAgent ---
 	// Defaults to same config as main brain; override with SUBAGENT_MODEL / SUBAGENT_PROVIDER.
-	// IsSubAgent = true により SubAgent 用のシンプルなプロンプトが使われる
-	// （spawn_task 等の無限ループを防ぐ）。
-	subBrainCfg := brainCfg // copy main config
-	subBrainCfg.IsSubAgent = true
-	if model := os.Getenv("SUBAGENT_MODEL"); model != "" {
-		subBrainCfg.Model = model
-	}
-	if provider := os.Getenv("SUBAGENT_PROVIDER"); provider != "" {
-		subBrainCfg.Provider = brain.Provider(provider)
-		// Reload config for the new provider
-		reloaded, err := brain.LoadConfig(brain.ConfigHint{
-			Provider: brain.Provider(provider),
-			Model:    subBrainCfg.Model,
-		})
-		if err == nil {
-			subBrainCfg = reloaded
-			subBrainCfg.ToolNames = toolNames
-			subBrainCfg.IsSubAgent = true
-		}
-	}
-	subBrain, err := brain.New(subBrainCfg)
+	subBrain, err := rl.newSubBrain()
 	if err != nil {
 		// SubBrain creation failed — continue without SmartSubAgent
 		log.Printf("SubBrain creation failed (SmartSubAgent disabled): %v", err)
 		subBrain = nil
 	}
 
-	// --- App Config (knowledge + blacklist) ---
-	appCfg, cfgErr := config.Load("config/config.yaml")
-	if cfgErr != nil {
-		fmt.Fprintf(os.Stderr, "Config warning: %v\n", cfgErr)
-		appCfg = &config.AppConfig{}
-	}
-
-	// --- Blacklist ---
-	blacklist := tools.NewBlacklist(appCfg.Blacklist)
-	if len(appCfg.Blacklist) == 0 {
-		// デフォルトの安全パターン
-		blacklist = tools.NewBlacklist([]string{
-			`rm\s+-rf\s+/`,
-			`dd\s+if=`,
-			`mkfs`,
-			`\bshutdown\b`,
-			`\breboot\b`,
-		})
+	// --- Recon Playbooks ---
+	playbookReg := playbook.NewRegistry()
+	if err := playbookReg.LoadDir("playbooks"); err != nil {
+		log.Printf("Playbook load warning: %v", err)
 	}
 
-	// --- Skills ---
-	skillsReg := skills.NewRegistry()
-	_ = skillsReg.LoadDir("skills")
-
 	// --- Knowledge Base ---
-	var knowledgeStore *knowledge.Store
-	if len(appCfg.Knowledge) > 0 {
-		// 最初のエントリを使用（将来的に複数対応可能）
-		entry := appCfg.Knowledge[0]
-		ks := knowledge.NewStore(entry.Path)
-		if ks != nil {
-			knowledgeStore = ks
+	knowledgeBase := knowledge.NewLibrary()
+	for _, entry := range appCfg.Knowledge {
+		ks, err := openKnowledgeStore(entry, appCfg.KnowledgeEmbeddings)
+		switch {
+		case err != nil:
+			log.Printf("Knowledge base %s skipped: %v", entry.Name, err)
+		case ks == nil:
+			log.Printf("Knowledge base path not found: %s (e.g. git clone --depth 1 https://github.com/carlospolop/hacktricks.git)", entry.Path)
+		default:
+			knowledgeBase.Add(entry.Name, ks)
 			log.Printf("Knowledge base loaded: %s (%s)", entry.Name, entry.Path)
-		} else {
-			log.Printf("Knowledge base path not found: %s (run: git clone --depth 1 https://github.com/carlospolop/hacktricks.git)", entry.Path)
 		}
 	}
 
+	// --- Vulnerability DB (offline CVE enrichment) ---
+	vulnDB, vdbErr := vulndb.Open(appCfg.VulnDB.NVD, appCfg.VulnDB.ExploitDB)
+	if vdbErr != nil {
+		log.Printf("Vulnerability DB load failed (CVE enrichment disabled): %v", vdbErr)
+	} else if vulnDB != nil {
+		log.Printf("Vulnerability DB loaded: %d CVEs", vulnDB.Len())
+	}
+
 	// --- Memory ---
 	memoryStore := memory.NewStore("memory")
 
@@ -209,6 +197,16 @@ Chat commands:
 	approveMap := make(map[int]chan<- bool)
 	userMsgMap := make(map[int]chan<- string)
 
+	// TUI の実行中に起きたこと（フェイルオーバー・MCP のヘルスチェックなど）はシステムログとして TUI に出す。
+	// alt-screen の上に stderr を書くと画面が崩れるため log は使わない
+	systemNotice := func(msg string) {
+		select {
+		case events <- agent.Event{Type: agent.EventLog, Source: agent.SourceSystem, Message: msg}:
+		default:
+		}
+	}
+	rl.notice = systemNotice
+
 	team := agent.NewTeam(agent.TeamConfig{
 		Events:           events,
 		Brain:            br,
@@ -217,10 +215,18 @@ Chat commands:
 		SkillsReg:        skillsReg,
 		MemoryStore:      memoryStore,
 		MCPManager:       mcpMgr,
-		KnowledgeStore:   knowledgeStore,
+		KnowledgeBase:    knowledgeBase,
 		MaxParallelRecon: appCfg.Recon.MaxParallel,
+		VulnDB:           vulnDB,
+		Playbooks:        playbookReg,
 	})
 
+	// --- Model routing ---（用途・ターゲットごとのモデル。/model <route> で実行中に切り替える）
+	rl.team = team
+	if err := rl.applyRoutes(); err != nil {
+		fmt.Fprintf(os.Stderr, "Model routing warning: %v\n", err)
+	}
+
 	// CLI ターゲットを事前追加
 	var targets []*agent.Target
 	for _, ip := range flag.Args() {
@@ -230,9 +236,38 @@ Chat commands:
 		userMsgMap[target.ID] = userMsgCh
 	}
 
-	// --- TUI ---
+	// グレースフルシャットダウン
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// --- Notifications ---（承認待ち・停滞・重大な発見を TUI の外へ通知する。TUI の手前でイベントを中継）
+	var uiEvents <-chan agent.Event = events
+	notifier, notifyErr := newNotifier(appCfg.Notify)
+	if notifyErr != nil {
+		fmt.Fprintf(os.Stderr, "Notify config warning: %v\n", notifyErr)
+	}
+	if notifier.Len() > 0 {
+		notifier.OnError(func(route string, err error) {
+			select {
+			case events <- agent.Event{Type: agent.EventLog, Source: agent.SourceSystem,
+				Message: fmt.Sprintf("Notification failed (%s): %v", route, err)}:
+			default:
+			}
+		})
+		uiEvents = notifier.Tap(ctx, events)
+	}
+
+	// --- TUI ---（テーマはスタイルを作り直すため Model の生成前に適用する）
+	if err := tui.SetTheme(appCfg.TUI.Theme, appCfg.TUI.NoColor || os.Getenv("NO_COLOR") != ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Config warning: %v\n", err)
+	}
 	m := tui.NewWithTargets(targets)
-	m.ConnectTeam(team, events, approveMap, userMsgMap)
+	keys, keyErr := tui.NewKeyMap(keyBindings(appCfg))
+	if keyErr != nil {
+		fmt.Fprintf(os.Stderr, "Config warning: %v (using default key bindings)\n", keyErr)
+	}
+	m.Keys = keys
+	m.ConnectTeam(team, uiEvents, approveMap, userMsgMap)
 
 	// Set initial model info for status bar
 	m.CurrentProvider = string(selectedProvider)
@@ -241,19 +276,44 @@ Chat commands:
 	// Connect CommandRunner for /approve command
 	m.Runner = runner
 
-	// BrainFactory for /model command
+	// Skills / knowledge for Tab completion, the command palette and /kb
+	m.Skills = skillsReg
+	m.Knowledge = knowledgeBase
+
+	// BrainFactory for /model command（現在のツール・MCP・スキル一覧を引き継ぐ）
 	m.BrainFactory = func(hint brain.ConfigHint) (brain.Brain, error) {
-		cfg, err := brain.LoadConfig(hint)
-		if err != nil {
-			return nil, err
-		}
-		cfg.ToolNames = toolNames
-		return brain.New(cfg)
+		br, _, err := rl.newBrain(hint)
+		return br, err
+	}
+	m.ModelRouter = rl.SetModelRoute
+	m.ModelRoutes = rl.ModelRoutes
+
+	// --- Hot reload ---（/reload コマンドと、reload.watch 有効時のファイル監視）
+	m.Reloader = func() (string, error) { return rl.Reload(ctx) }
+	if appCfg.Reload.Watch {
+		go rl.watch(ctx, appCfg.Reload.Interval, func(summary string, err error) {
+			msg := "Config change detected — " + summary
+			if err != nil {
+				msg += fmt.Sprintf(" (errors: %v)", err)
+			}
+			select {
+			case events <- agent.Event{Type: agent.EventLog, Source: agent.SourceSystem, Message: msg}:
+			case <-ctx.Done():
+			}
+		})
 	}
 
-	// グレースフルシャットダウン
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
+	// --- MCP health ---（停止したサーバーを自動再起動し、ツール一覧の変化を Brain に反映する）
+	if mcpMgr != nil {
+		m.MCPManager = mcpMgr
+		mcpMgr.OnNotice(systemNotice)
+		mcpMgr.OnToolsChanged(func() {
+			if err := rl.refreshBrains(); err != nil {
+				systemNotice(fmt.Sprintf("MCP tools changed but brain refresh failed: %v", err))
+			}
+		})
+		go mcpMgr.Monitor(ctx)
+	}
 
 	// Agent Team を起動
 	team.Start(ctx)
@@ -265,3 +325,27 @@ Chat commands:
 		os.Exit(1)
 	}
 }
+
+// keyBindings は tui.keys をアクション名 → キーのマップに変換する。
+func keyBindings(cfg *config.AppConfig) map[string][]string {
+	out := make(map[string][]string, len(cfg.TUI.Keys))
+	for action, keys := range cfg.TUI.Keys {
+		out[action] = keys
+	}
+	return out
+}
+
+// skillCatalog はスキルレジストリから Brain のシステムプロンプトに注入するカタログを作る。
+func skillCatalog(reg *skills.Registry) []brain.SkillInfo {
+	all := reg.All()
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	catalog := make([]brain.SkillInfo, 0, len(all))
+	for _, sk := range all {
+		info := brain.SkillInfo{Name: sk.Name, Description: sk.Description, SubAgent: sk.SubAgent}
+		if len(sk.Params) > 0 {
+			info.Usage = sk.Usage()
+		}
+		catalog = append(catalog, info)
+	}
+	return catalog
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+	"github.com/0x6d61/pentecter/internal/brain"
+	"github.com/0x6d61/pentecter/internal/config"
+)
+
+// ルート名（/model <route> と models セクションのキー）。
+// exploit / recon / extract は agent.ModelRoute、subagent は SmartSubAgent 全体（Team.SetSubBrain）。
+const (
+	routeSubAgent     = "subagent"
+	targetRoutePrefix = "target:" // target:<host> はそのターゲットのメイン Loop だけを上書きする
+)
+
+// routeOrder は /models の表示順。
+var routeOrder = []string{string(agent.RouteExploit), routeSubAgent, string(agent.RouteRecon), string(agent.RouteExtract)}
+
+// modelRoutes は models セクションをルート名 → ConfigHint に変換する（未設定のルートは含めない）。
+func modelRoutes(cfg config.ModelsConfig) map[string]brain.ConfigHint {
+	routes := make(map[string]brain.ConfigHint)
+	add := func(route string, ref config.ModelRef) {
+		if !ref.IsZero() {
+			routes[route] = brain.ConfigHint{Provider: brain.Provider(ref.Provider), Model: ref.Model, BaseURL: ref.BaseURL}
+		}
+	}
+	add(string(agent.RouteExploit), cfg.Exploit)
+	add(routeSubAgent, cfg.SubAgent)
+	add(string(agent.RouteRecon), cfg.Recon)
+	add(string(agent.RouteExtract), cfg.Extract)
+	for host, ref := range cfg.Targets {
+		add(targetRoutePrefix+host, ref)
+	}
+	return routes
+}
+
+// failoverHints は models.failover を ConfigHint の一覧に変換する。
+func failoverHints(cfg config.ModelsConfig) []brain.ConfigHint {
+	var hints []brain.ConfigHint
+	for _, ref := range cfg.Failover {
+		if !ref.IsZero() {
+			hints = append(hints, brain.ConfigHint{Provider: brain.Provider(ref.Provider), Model: ref.Model, BaseURL: ref.BaseURL})
+		}
+	}
+	return hints
+}
+
+// validRoute はルート名を検証する。
+func validRoute(route string) error {
+	if host, ok := strings.CutPrefix(route, targetRoutePrefix); ok {
+		if host == "" {
+			return errors.New("model route target: needs a host")
+		}
+		return nil
+	}
+	for _, r := range routeOrder {
+		if route == r {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown model route %q (available: %s, target:<host>)", route, strings.Join(routeOrder, ", "))
+}
+
+// isSubAgentRoute は SubAgent 用プロンプト（IsSubAgent）の Brain を使うルートか。
+func isSubAgentRoute(route string) bool {
+	return route == routeSubAgent || route == string(agent.RouteRecon)
+}
+
+// resolveHint はルートの hint の空欄をメイン Brain の hint で補う。
+// provider が空ならメインのプロバイダー（model も空ならメインのモデル）を使う。
+func (r *reloader) resolveHint(h brain.ConfigHint) brain.ConfigHint {
+	r.mu.Lock()
+	main := r.hint
+	r.mu.Unlock()
+	if h.Provider == "" {
+		h.Provider = main.Provider
+		if h.Model == "" {
+			h.Model = main.Model
+		}
+	}
+	return h
+}
+
+// newRouteBrain はルート用の Brain を作る（ツール・MCP・スキル一覧はメインと同じ）。
+func (r *reloader) newRouteBrain(route string, hint brain.ConfigHint) (brain.Brain, error) {
+	cfg, err := brain.LoadConfig(r.resolveHint(hint))
+	if err != nil {
+		return nil, err
+	}
+	r.applyCatalog(&cfg)
+	cfg.IsSubAgent = isSubAgentRoute(route)
+	br, err := brain.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return r.withFailover(br, cfg), nil
+}
+
+// withFailover は models.failover の予備のモデルを primary の後ろに並べた Failover を返す（予備がなければ primary）。
+// 予備は primary と同じツール・スキル一覧とプロンプトで作る。認証情報がないなど作れない予備と、
+// primary と同じモデルの予備は飛ばす。
+func (r *reloader) withFailover(primary brain.Brain, cfg brain.Config) brain.Brain {
+	r.mu.Lock()
+	hints := r.failover
+	r.mu.Unlock()
+
+	candidates := []brain.Candidate{{Brain: primary, Label: configLabel(cfg)}}
+	for _, hint := range hints {
+		fcfg, err := brain.LoadConfig(r.resolveHint(hint))
+		if err != nil || (fcfg.Provider == cfg.Provider && fcfg.Model == cfg.Model) {
+			continue
+		}
+		r.applyCatalog(&fcfg)
+		fcfg.IsSubAgent = cfg.IsSubAgent
+		br, err := brain.New(fcfg)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, brain.Candidate{Brain: br, Label: configLabel(fcfg)})
+	}
+	if len(candidates) == 1 {
+		return primary
+	}
+	return brain.NewFailover(candidates, r.reportFailover)
+}
+
+// reportFailover はフェイルオーバーでモデルを切り替えたことを TUI に知らせる。
+func (r *reloader) reportFailover(sw brain.FailoverSwitch) {
+	r.mu.Lock()
+	notice := r.notice
+	r.mu.Unlock()
+	if notice != nil {
+		notice(fmt.Sprintf("Brain failover: %s → %s (%s: %v)", sw.From, sw.To, sw.Kind, sw.Err))
+	}
+}
+
+// configLabel は Brain 設定の "provider/model" 形式の表示名を返す。
+func configLabel(cfg brain.Config) string {
+	return hintLabel(brain.ConfigHint{Provider: cfg.Provider, Model: cfg.Model})
+}
+
+// applyRoute はルートの Brain を Team に反映する（nil = 既定に戻す）。
+func (r *reloader) applyRoute(route string, br brain.Brain) {
+	if r.team == nil {
+		return
+	}
+	switch route {
+	case routeSubAgent:
+		r.team.SetSubBrain(br)
+	case string(agent.RouteExploit):
+		r.team.SetExploitBrain(br)
+	case string(agent.RouteRecon):
+		r.team.SetReconBrain(br)
+	case string(agent.RouteExtract):
+		r.team.SetExtractBrain(br)
+	default:
+		if host, ok := strings.CutPrefix(route, targetRoutePrefix); ok {
+			r.team.SetTargetBrain(host, br)
+		}
+	}
+}
+
+// SetModelRoute はルートのモデルを切り替える（TUI の /model <route>）。
+// hint がゼロ値ならルートを解除して既定のモデルに戻す。
+func (r *reloader) SetModelRoute(route string, hint brain.ConfigHint) error {
+	if err := validRoute(route); err != nil {
+		return err
+	}
+	var br brain.Brain
+	if hint != (brain.ConfigHint{}) {
+		var err error
+		if br, err = r.newRouteBrain(route, hint); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	if br == nil {
+		delete(r.routes, route)
+	} else {
+		if r.routes == nil {
+			r.routes = make(map[string]brain.ConfigHint)
+		}
+		r.routes[route] = hint
+	}
+	r.mu.Unlock()
+
+	// subagent を解除したら SUBAGENT_* またはメインと同じ設定の SubBrain に戻す
+	if route == routeSubAgent && br == nil {
+		sub, err := r.newSubBrain()
+		if err != nil {
+			return err
+		}
+		br = sub
+	}
+	r.applyRoute(route, br)
+	return nil
+}
+
+// applyRoutes は設定済みの全ルートの Brain を作り直して Team に反映する（起動時・リロード時）。
+// subagent は newSubBrain が扱う。失敗したルートは既定のモデルのまま残りを反映する。
+func (r *reloader) applyRoutes() error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.routes))
+	for route := range r.routes {
+		names = append(names, route)
+	}
+	routes := make(map[string]brain.ConfigHint, len(r.routes))
+	for route, hint := range r.routes {
+		routes[route] = hint
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	var errs []error
+	for _, route := range names {
+		if route == routeSubAgent {
+			continue
+		}
+		if err := validRoute(route); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		br, err := r.newRouteBrain(route, routes[route])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("model route %s: %w", route, err))
+			continue
+		}
+		r.applyRoute(route, br)
+	}
+	return errors.Join(errs...)
+}
+
+// ModelRoutes は現在のモデルの割り当てを表示用に返す（/models）。
+func (r *reloader) ModelRoutes() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lines := []string{"main: " + hintLabel(r.hint)}
+	fallback := map[string]string{
+		string(agent.RouteExploit): "(main)",
+		routeSubAgent:              "(main)",
+		string(agent.RouteRecon):   "(subagent)",
+		string(agent.RouteExtract): "(main)",
+	}
+	for _, route := range routeOrder {
+		label := fallback[route]
+		if h, ok := r.routes[route]; ok {
+			label = hintLabel(h)
+		}
+		lines = append(lines, route+": "+label)
+	}
+	var targets []string
+	for route, h := range r.routes {
+		if strings.HasPrefix(route, targetRoutePrefix) {
+			targets = append(targets, route+": "+hintLabel(h))
+		}
+	}
+	sort.Strings(targets)
+	lines = append(lines, targets...)
+	if len(r.failover) > 0 {
+		labels := make([]string, len(r.failover))
+		for i, h := range r.failover {
+			labels[i] = hintLabel(h)
+		}
+		lines = append(lines, "failover: "+strings.Join(labels, " → "))
+	}
+	return lines
+}
+
+// hintLabel は "provider/model" 形式の表示名を返す。
+func hintLabel(h brain.ConfigHint) string {
+	label := string(h.Provider)
+	if label == "" {
+		label = "(main provider)"
+	}
+	if h.Model != "" {
+		label += "/" + h.Model
+	}
+	return label
+}
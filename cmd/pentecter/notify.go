@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/0x6d61/pentecter/internal/config"
+	"github.com/0x6d61/pentecter/internal/notify"
+)
+
+// newNotifier は notify セクションから Notifier を作る。
+// 設定に誤りのある通知先はスキップしてエラーにまとめ、残りの通知先で動かす。
+func newNotifier(cfg config.NotifyConfig) (*notify.Notifier, error) {
+	var routes []notify.Route
+	var errs []error
+	add := func(name string, sender notify.Sender, err error, filter config.NotifyFilter) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		kinds, err := notify.ParseKinds(filter.Events)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		severity, err := notify.ParseSeverity(filter.MinSeverity)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		routes = append(routes, notify.Route{Name: name, Sender: sender, Kinds: kinds, MinSeverity: severity})
+	}
+
+	for _, w := range cfg.Webhooks {
+		hook, err := notify.NewWebhook(w.URL, w.Format)
+		add("webhook "+webhookHost(w.URL), hook, err, w.NotifyFilter)
+	}
+	for _, e := range cfg.Email {
+		mail, err := notify.NewEmail(e.SMTP, e.Username, e.Password, e.From, e.To)
+		add("email "+e.SMTP, mail, err, e.NotifyFilter)
+	}
+	for _, c := range cfg.Commands {
+		cmd, err := notify.NewCommand(c.Command, c.Args)
+		add("command "+c.Command, cmd, err, c.NotifyFilter)
+	}
+	return notify.New(routes...), errors.Join(errs...)
+}
+
+// webhookHost はエラー表示用に webhook URL のホストだけを返す（パスのトークンを出さない）。
+func webhookHost(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "(invalid url)"
+}
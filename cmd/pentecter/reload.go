@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+	"github.com/0x6d61/pentecter/internal/brain"
+	"github.com/0x6d61/pentecter/internal/config"
+	"github.com/0x6d61/pentecter/internal/mcp"
+	"github.com/0x6d61/pentecter/internal/skills"
+	"github.com/0x6d61/pentecter/internal/tools"
+)
+
+// 設定ファイルの配置（カレントディレクトリ基準）
+const (
+	toolsDir      = "tools"
+	skillsDir     = "skills"
+	appConfigPath = "config/config.yaml"
+	mcpConfigPath = "config/mcp.yaml"
+)
+
+// defaultBlacklist は config.yaml に blacklist がない場合の安全パターン。
+var defaultBlacklist = []string{
+	`rm\s+-rf\s+/`,
+	`dd\s+if=`,
+	`mkfs`,
+	`\bshutdown\b`,
+	`\breboot\b`,
+}
+
+// blacklistPatterns は設定のブラックリスト（空ならデフォルト）を返す。
+func blacklistPatterns(cfg *config.AppConfig) []string {
+	if len(cfg.Blacklist) == 0 {
+		return defaultBlacklist
+	}
+	return cfg.Blacklist
+}
+
+// reloader は tools / skills / blacklist / MCP 設定を実行中に読み直し、
+// 新しいツール・MCP・スキル一覧をシステムプロンプトに持つ Brain へ差し替える。
+// 各レジストリはその場で差し替えるため、実行中の Loop は停止しない。
+type reloader struct {
+	registry  *tools.Registry
+	skillsReg *skills.Registry
+	blacklist *tools.Blacklist
+	mcpMgr    *mcp.MCPManager // nil = MCP 無効
+	team      *agent.Team     // nil = Brain の差し替えなし（Team 構築前）
+
+	mu     sync.Mutex
+	hint   brain.ConfigHint            // 現在のメイン Brain のプロバイダー・モデル（/model で更新）
+	routes map[string]brain.ConfigHint // 用途・ターゲットごとのモデル（models セクションと /model <route>）
+	// failover はエラーが続いたときに切り替える予備のモデル（models.failover）
+	failover []brain.ConfigHint
+	notice   func(msg string) // フェイルオーバーを TUI に知らせる（nil = 知らせない）
+}
+
+// applyCatalog は現在のツール名・MCP ツール・スキルカタログを Brain 設定に注入する。
+func (r *reloader) applyCatalog(cfg *brain.Config) {
+	cfg.ToolNames = nil
+	for _, def := range r.registry.All() {
+		cfg.ToolNames = append(cfg.ToolNames, def.Name)
+	}
+	sort.Strings(cfg.ToolNames)
+
+	cfg.MCPTools = nil
+	if r.mcpMgr != nil {
+		for _, t := range r.mcpMgr.ListAllTools() {
+			cfg.MCPTools = append(cfg.MCPTools, brain.MCPToolInfo{
+				Server:      t.Server,
+				Name:        t.Name,
+				Description: t.Description,
+				InputSchema: t.InputSchema,
+			})
+		}
+	}
+
+	cfg.Skills = skillCatalog(r.skillsReg)
+}
+
+// newBrain は hint のプロバイダー・モデルでメイン Brain を作り、以後のリロードで使う hint として記録する。
+func (r *reloader) newBrain(hint brain.ConfigHint) (brain.Brain, brain.Config, error) {
+	cfg, err := brain.LoadConfig(hint)
+	if err != nil {
+		return nil, cfg, err
+	}
+	r.applyCatalog(&cfg)
+	br, err := brain.New(cfg)
+	if err != nil {
+		return nil, cfg, err
+	}
+	r.mu.Lock()
+	r.hint = hint
+	r.mu.Unlock()
+	return r.withFailover(br, cfg), cfg, nil
+}
+
+// newSubBrain は SmartSubAgent 用の Brain を作る。
+// models.subagent（/model subagent）があればそれを使う。なければメイン Brain と同じ設定で、
+// SUBAGENT_MODEL / SUBAGENT_PROVIDER で上書きできる。
+// IsSubAgent = true により SubAgent 用のシンプルなプロンプトが使われる
+// （spawn_task 等の無限ループを防ぐ）。
+func (r *reloader) newSubBrain() (brain.Brain, error) {
+	r.mu.Lock()
+	hint := r.hint
+	route, routed := r.routes[routeSubAgent]
+	r.mu.Unlock()
+	if routed {
+		return r.newRouteBrain(routeSubAgent, route)
+	}
+
+	if model := os.Getenv("SUBAGENT_MODEL"); model != "" {
+		hint.Model = model
+	}
+	if provider := os.Getenv("SUBAGENT_PROVIDER"); provider != "" {
+		hint.Provider = brain.Provider(provider)
+	}
+	cfg, err := brain.LoadConfig(hint)
+	if err != nil {
+		return nil, err
+	}
+	r.applyCatalog(&cfg)
+	cfg.IsSubAgent = true
+	br, err := brain.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return r.withFailover(br, cfg), nil
+}
+
+// Reload は全設定を読み直す。一部の読み込みに失敗しても残りは反映し、
+// 反映内容のサマリーと失敗の一覧（errors.Join）を返す。
+func (r *reloader) Reload(ctx context.Context) (string, error) {
+	var errs []error
+	var parts []string
+
+	if err := r.registry.Reload(toolsDir); err != nil {
+		errs = append(errs, fmt.Errorf("tools: %w", err))
+	} else {
+		parts = append(parts, fmt.Sprintf("%d tools", len(r.registry.All())))
+	}
+
+	if err := r.skillsReg.Reload(skillsDir); err != nil {
+		errs = append(errs, fmt.Errorf("skills: %w", err))
+	} else {
+		parts = append(parts, fmt.Sprintf("%d skills", len(r.skillsReg.All())))
+	}
+
+	if appCfg, err := config.Load(appConfigPath); err != nil {
+		errs = append(errs, err)
+	} else {
+		r.blacklist.Replace(blacklistPatterns(appCfg))
+		parts = append(parts, fmt.Sprintf("%d blacklist patterns", r.blacklist.Len()))
+	}
+
+	if r.mcpMgr != nil {
+		// 一部のサーバーの停止・起動に失敗しても、反映できたサーバーはサマリーに出す
+		changed, err := r.mcpMgr.Reload(ctx, mcpConfigPath)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		switch {
+		case len(changed) > 0:
+			parts = append(parts, "MCP restarted: "+strings.Join(changed, ", "))
+		case err == nil:
+			parts = append(parts, "MCP unchanged")
+		}
+	}
+
+	if err := r.refreshBrains(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return "Reloaded: " + strings.Join(parts, ", "), errors.Join(errs...)
+}
+
+// refreshBrains は現在のツール・MCP・スキル一覧をシステムプロンプトに反映するため、
+// Brain を作り直して Team に差し替える（Team 構築前は何もしない）。
+func (r *reloader) refreshBrains() error {
+	if r.team == nil {
+		return nil
+	}
+	r.mu.Lock()
+	hint := r.hint
+	r.mu.Unlock()
+	br, _, err := r.newBrain(hint)
+	if err != nil {
+		return fmt.Errorf("brain: %w", err)
+	}
+	r.team.SetBrain(br)
+	if sub, err := r.newSubBrain(); err == nil {
+		r.team.SetSubBrain(sub)
+	}
+	return r.applyRoutes()
+}
+
+// watchedPaths はホットリロードで監視するファイル・ディレクトリ。
+var watchedPaths = []string{toolsDir, skillsDir, appConfigPath, mcpConfigPath}
+
+// fingerprint は監視対象のファイル一覧・サイズ・更新時刻を1つの文字列にまとめる。
+// fsnotify に依存しないポーリング方式の変更検知に使う。
+func fingerprint(paths []string) string {
+	var sb strings.Builder
+	for _, root := range paths {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			fmt.Fprintf(&sb, "%s|%d|%d\n", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+	}
+	return sb.String()
+}
+
+// watch は interval ごとに監視対象の変更を調べ、変更があれば Reload して notify に結果を渡す。
+// ctx のキャンセルで終了する。
+func (r *reloader) watch(ctx context.Context, interval time.Duration, notify func(summary string, err error)) {
+	last := fingerprint(watchedPaths)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current := fingerprint(watchedPaths)
+		if current == last {
+			continue
+		}
+		last = current
+		notify(r.Reload(ctx))
+	}
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+	"github.com/0x6d61/pentecter/internal/brain"
+	"github.com/0x6d61/pentecter/internal/config"
+	"github.com/0x6d61/pentecter/internal/knowledge"
+	"github.com/0x6d61/pentecter/internal/mcp"
+	"github.com/0x6d61/pentecter/internal/memory"
+	"github.com/0x6d61/pentecter/internal/playbook"
+	"github.com/0x6d61/pentecter/internal/skills"
+	"github.com/0x6d61/pentecter/internal/tools"
+)
+
+// memoryURIPrefix はメモリファイルを MCP リソースとして公開する URI の接頭辞。
+const memoryURIPrefix = "pentecter://memory/"
+
+// runMCPServe は `pentecter mcp-serve` を処理する。
+// stdin/stdout で MCP サーバーとして動作するため、ログは全て stderr に出す。
+func runMCPServe(args []string) int {
+	flags := flag.NewFlagSet("mcp-serve", flag.ExitOnError)
+	provider := flags.String("provider", "", "LLM provider for agents started by add_target (auto-detect if empty)")
+	model := flags.String("model", "", "Model name (default: provider's default)")
+	autoApprove := flags.Bool("auto-approve", false, "Run commands without approval (otherwise commands that need a proposal are refused)")
+	_ = flags.Parse(args)
+
+	log.SetOutput(os.Stderr)
+
+	registry := tools.NewRegistry()
+	if err := registry.LoadDir(toolsDir); err != nil {
+		fmt.Fprintf(os.Stderr, "tool load error: %v\n", err)
+		return 1
+	}
+	skillsReg := skills.NewRegistry()
+	if err := skillsReg.LoadDir(skillsDir); err != nil {
+		log.Printf("Skill load warning: %v", err)
+	}
+
+	appCfg, err := config.Load(appConfigPath)
+	if err != nil {
+		log.Printf("Config warning: %v", err)
+		appCfg = &config.AppConfig{}
+	}
+	blacklist := tools.NewBlacklist(blacklistPatterns(appCfg))
+
+	runner := tools.NewCommandRunner(registry, blacklist, tools.NewLogStore())
+	runner.SetAutoApprove(*autoApprove)
+
+	knowledgeBase := knowledge.NewLibrary()
+	for _, entry := range appCfg.Knowledge {
+		ks, err := openKnowledgeStore(entry, appCfg.KnowledgeEmbeddings)
+		if err != nil || ks == nil {
+			log.Printf("Knowledge base %s skipped", entry.Name)
+			continue
+		}
+		knowledgeBase.Add(entry.Name, ks)
+	}
+
+	memoryStore := memory.NewStore("memory")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	s := &mcpService{
+		runner:    runner,
+		knowledge: knowledgeBase,
+		memory:    memoryStore,
+		targets:   make(map[string]*agent.Target),
+	}
+
+	// Brain が利用できる場合のみ add_target でエージェントを起動する
+	selected := brain.Provider(*provider)
+	if selected == "" {
+		if detected := brain.DetectAvailableProviders(); len(detected) > 0 {
+			selected = detected[0]
+		}
+	}
+	if selected != "" {
+		rl := &reloader{registry: registry, skillsReg: skillsReg, blacklist: blacklist}
+		br, _, err := rl.newBrain(brain.ConfigHint{Provider: selected, Model: *model})
+		if err != nil {
+			log.Printf("Brain init failed (add_target disabled): %v", err)
+		} else {
+			subBrain, _ := rl.newSubBrain()
+			playbookReg := playbook.NewRegistry()
+			_ = playbookReg.LoadDir("playbooks")
+
+			events := make(chan agent.Event, 512)
+			s.team = agent.NewTeam(agent.TeamConfig{
+				Events:           events,
+				Brain:            br,
+				SubBrain:         subBrain,
+				Runner:           runner,
+				SkillsReg:        skillsReg,
+				MemoryStore:      memoryStore,
+				KnowledgeBase:    knowledgeBase,
+				MaxParallelRecon: appCfg.Recon.MaxParallel,
+				Playbooks:        playbookReg,
+			})
+			s.approve = make(map[int]chan<- bool)
+			go s.drainEvents(ctx, events)
+			s.team.Start(ctx)
+		}
+	}
+
+	srv := mcp.NewServer("pentecter", "dev")
+	s.register(srv)
+	if err := srv.Serve(ctx, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "mcp-serve: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// mcpService は mcp-serve で公開するツール・リソースの実装。
+type mcpService struct {
+	runner    *tools.CommandRunner
+	knowledge *knowledge.Library
+	memory    *memory.Store
+	team      *agent.Team // nil = LLM プロバイダー未設定（add_target 無効）
+
+	mu      sync.Mutex
+	targets map[string]*agent.Target // host → Target
+	approve map[int]chan<- bool      // Target ID → 承認チャネル
+}
+
+// drainEvents は Agent のイベントを stderr に記録する。
+// 人間の承認者がいないため、提案（proposal）は -auto-approve でない限り却下する。
+func (s *mcpService) drainEvents(ctx context.Context, events <-chan agent.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-events:
+			switch e.Type {
+			case agent.EventProposal:
+				approved := s.runner.AutoApprove()
+				s.mu.Lock()
+				ch := s.approve[e.TargetID]
+				s.mu.Unlock()
+				if ch != nil {
+					select {
+					case ch <- approved:
+					default:
+					}
+				}
+				if e.Proposal != nil {
+					log.Printf("[target %d] proposal %q approved=%v", e.TargetID, e.Proposal.Description, approved)
+				}
+			case agent.EventLog, agent.EventError:
+				if e.Message != "" {
+					log.Printf("[target %d] %s", e.TargetID, e.Message)
+				}
+			case agent.EventAddTarget:
+				if e.NewHost != "" {
+					s.addTarget(e.NewHost)
+				}
+			}
+		}
+	}
+}
+
+// addTarget は Team にターゲットを追加する。既に存在する場合は false を返す。
+func (s *mcpService) addTarget(host string) (*agent.Target, bool) {
+	target, approveCh, _ := s.team.AddTarget(host)
+	if approveCh == nil {
+		return target, false
+	}
+	s.mu.Lock()
+	s.targets[host] = target
+	s.approve[target.ID] = approveCh
+	s.mu.Unlock()
+	return target, true
+}
+
+// target は host の Target を返す。
+func (s *mcpService) target(host string) (*agent.Target, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.targets[host]
+	if !ok {
+		return nil, fmt.Errorf("unknown target %q (add it with add_target)", host)
+	}
+	return t, nil
+}
+
+// stringArg は必須の文字列引数を取り出す。
+func stringArg(args map[string]any, name string) (string, error) {
+	v, _ := args[name].(string)
+	if strings.TrimSpace(v) == "" {
+		return "", fmt.Errorf("missing required argument %q", name)
+	}
+	return strings.TrimSpace(v), nil
+}
+
+// objectSchema は文字列プロパティのみの JSON Schema を作る。
+func objectSchema(required []string, props map[string]string) map[string]any {
+	properties := make(map[string]any, len(props))
+	for name, desc := range props {
+		properties[name] = map[string]any{"type": "string", "description": desc}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// register はツールとリソースをサーバーに登録する。
+func (s *mcpService) register(srv *mcp.Server) {
+	hostProp := map[string]string{"host": "Target IP address or domain"}
+
+	srv.AddTool(mcp.ToolSchema{
+		Name:        "add_target",
+		Description: "Add a target host and start an autonomous pentest agent for it",
+		InputSchema: objectSchema([]string{"host"}, hostProp),
+	}, func(_ context.Context, args map[string]any) (string, error) {
+		host, err := stringArg(args, "host")
+		if err != nil {
+			return "", err
+		}
+		if s.team == nil {
+			return "", errors.New("no LLM provider configured; agents cannot be started")
+		}
+		target, added := s.addTarget(host)
+		if !added {
+			return fmt.Sprintf("Target %s already exists (id %d, status %s)", host, target.ID, target.GetStatus()), nil
+		}
+		return fmt.Sprintf("Target %s added (id %d); agent started", host, target.ID), nil
+	})
+
+	srv.AddTool(mcp.ToolSchema{
+		Name:        "list_targets",
+		Description: "List targets and their current status",
+		InputSchema: objectSchema(nil, nil),
+	}, func(_ context.Context, _ map[string]any) (string, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if len(s.targets) == 0 {
+			return "No targets.", nil
+		}
+		hosts := make([]string, 0, len(s.targets))
+		for host := range s.targets {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+		var sb strings.Builder
+		for _, host := range hosts {
+			t := s.targets[host]
+			fmt.Fprintf(&sb, "%d\t%s\t%s\n", t.ID, host, t.GetStatus())
+		}
+		return sb.String(), nil
+	})
+
+	srv.AddTool(mcp.ToolSchema{
+		Name:        "get_recon_tree",
+		Description: "Show the reconnaissance tree (ports, endpoints and pending tasks) for a target",
+		InputSchema: objectSchema([]string{"host"}, hostProp),
+	}, func(_ context.Context, args map[string]any) (string, error) {
+		host, err := stringArg(args, "host")
+		if err != nil {
+			return "", err
+		}
+		t, err := s.target(host)
+		if err != nil {
+			return "", err
+		}
+		rt := t.GetReconTree()
+		if rt == nil {
+			return "Recon tree not initialized yet.", nil
+		}
+		return rt.RenderTree(), nil
+	})
+
+	srv.AddTool(mcp.ToolSchema{
+		Name:        "get_findings",
+		Description: "Return recorded findings (vulnerabilities, credentials, artifacts) and extracted entities for a target",
+		InputSchema: objectSchema([]string{"host"}, hostProp),
+	}, func(_ context.Context, args map[string]any) (string, error) {
+		host, err := stringArg(args, "host")
+		if err != nil {
+			return "", err
+		}
+		var sb strings.Builder
+		sb.WriteString(s.memory.Read(host))
+		if t, err := s.target(host); err == nil {
+			if entities := t.SnapshotEntities(); len(entities) > 0 {
+				if sb.Len() > 0 {
+					sb.WriteString("\n")
+				}
+				sb.WriteString("## Entities\n")
+				for _, e := range entities {
+					fmt.Fprintf(&sb, "- %s: %s\n", e.Type, e.Value)
+				}
+			}
+		}
+		if sb.Len() == 0 {
+			return fmt.Sprintf("No findings recorded for %s.", host), nil
+		}
+		return sb.String(), nil
+	})
+
+	srv.AddTool(mcp.ToolSchema{
+		Name:        "search_knowledge",
+		Description: "Search the configured knowledge bases (e.g. HackTricks)",
+		InputSchema: objectSchema([]string{"query"}, map[string]string{
+			"query":  "Search keywords",
+			"source": "Knowledge base name (empty = all)",
+		}),
+	}, func(_ context.Context, args map[string]any) (string, error) {
+		query, err := stringArg(args, "query")
+		if err != nil {
+			return "", err
+		}
+		if s.knowledge.Len() == 0 {
+			return "", errors.New("no knowledge base configured")
+		}
+		source, _ := args["source"].(string)
+		results, err := s.knowledge.Search(query, source, 10)
+		if err != nil {
+			return "", err
+		}
+		if len(results) == 0 {
+			return "No results.", nil
+		}
+		var sb strings.Builder
+		for _, r := range results {
+			fmt.Fprintf(&sb, "[%s] %s — %s / %s\n%s\n\n", r.Source, r.File, r.Title, r.Section, r.Snippet)
+		}
+		return sb.String(), nil
+	})
+
+	srv.AddTool(mcp.ToolSchema{
+		Name:        "run_registered_tool",
+		Description: "Run a command whose binary is defined in tools/ (blacklist and approval rules apply)",
+		InputSchema: objectSchema([]string{"command"}, map[string]string{"command": "Full command line, e.g. \"nmap -sV 10.0.0.5\""}),
+	}, s.runRegisteredTool)
+
+	srv.SetResources(s.listMemory, s.readMemory)
+}
+
+// runRegisteredTool は tools/ に定義されたツールのみを CommandRunner 経由で実行する。
+// 承認が必要なコマンドは、承認者がいないため -auto-approve でない限り拒否する。
+func (s *mcpService) runRegisteredTool(ctx context.Context, args map[string]any) (string, error) {
+	command, err := stringArg(args, "command")
+	if err != nil {
+		return "", err
+	}
+	binary, _ := tools.ParseCommand(command)
+	if _, ok := s.runner.LookupTool(binary); !ok {
+		return "", fmt.Errorf("%q is not a registered tool", binary)
+	}
+	needsProposal, lines, result, err := s.runner.Run(ctx, command)
+	if err != nil {
+		return "", err
+	}
+	if needsProposal {
+		return "", fmt.Errorf("approval required for %q (restart mcp-serve with -auto-approve or set proposal_required: false)", command)
+	}
+	go func() {
+		for range lines {
+		}
+	}()
+	res := <-result
+	if res == nil {
+		return "", errors.New("command produced no result")
+	}
+	if res.Err != nil {
+		return "", fmt.Errorf("%v\n%s", res.Err, res.Truncated)
+	}
+	return fmt.Sprintf("exit code %d\n%s", res.ExitCode, res.Truncated), nil
+}
+
+// listMemory はメモリディレクトリ内のファイルをリソースとして列挙する。
+func (s *mcpService) listMemory() ([]mcp.Resource, error) {
+	var list []mcp.Resource
+	base := s.memory.BaseDir()
+	err := filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return nil
+		}
+		list = append(list, mcp.Resource{
+			URI:      memoryURIPrefix + filepath.ToSlash(rel),
+			Name:     filepath.ToSlash(rel),
+			MimeType: "text/plain",
+		})
+		return nil
+	})
+	return list, err
+}
+
+// readMemory はメモリファイルの内容を返す。メモリディレクトリ外のパスは拒否する。
+func (s *mcpService) readMemory(uri string) (string, error) {
+	rel, ok := strings.CutPrefix(uri, memoryURIPrefix)
+	if !ok {
+		return "", fmt.Errorf("unknown resource %q", uri)
+	}
+	rel = filepath.Clean(filepath.FromSlash(rel))
+	if rel == "." || filepath.IsAbs(rel) || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("invalid resource path %q", uri)
+	}
+	data, err := os.ReadFile(filepath.Join(s.memory.BaseDir(), rel))
+	if err != nil {
+		return "", fmt.Errorf("resource %q: %w", uri, err)
+	}
+	return string(data), nil
+}
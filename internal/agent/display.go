@@ -20,6 +20,7 @@ const (
 type DisplayBlock struct {
 	Type      BlockType
 	CreatedAt time.Time
+	Turn      int // 追加時点のターゲットのターン番号（0 = 最初のターン前）
 
 	// BlockCommand fields
 	Command   string
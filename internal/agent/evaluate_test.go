@@ -563,6 +563,24 @@ func TestBuildSnapshot_JSONFormat(t *testing.T) {
 	}
 }
 
+func TestBuildSnapshot_EntityMetadata(t *testing.T) {
+	l := &Loop{
+		target: NewTarget(1, "10.0.0.1"),
+		events: make(chan Event, 32),
+	}
+	l.target.AddEntities([]tools.Entity{
+		{Type: tools.EntityPort, Value: "22/tcp", Confidence: 1.0, Source: "nmap"},
+		{Type: tools.EntityHash, Value: "5f4dcc3b5aa765d61d8327deb882cf99", Detail: "md5", Confidence: 0.4, Source: "cat"},
+	})
+	got := l.buildSnapshot()
+	if !strings.Contains(got, `"22/tcp (nmap)"`) {
+		t.Errorf("port entity should include source, got: %s", got)
+	}
+	if !strings.Contains(got, `"5f4dcc3b5aa765d61d8327deb882cf99 [md5] (cat, 40%)"`) {
+		t.Errorf("hash entity should include type, source and confidence, got: %s", got)
+	}
+}
+
 // =============================================================================
 // evaluateResult: 連続失敗カウント (#90)
 // =============================================================================
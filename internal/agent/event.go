@@ -1,6 +1,10 @@
 package agent
 
-import "time"
+import (
+	"time"
+
+	"github.com/0x6d61/pentecter/pkg/schema"
+)
 
 // EventType は Agent から TUI へ送るイベントの種別。
 type EventType string
@@ -52,6 +56,10 @@ type Event struct {
 	TurnNumber int       // EventTurnStart 時のターン番号
 	ExitCode   int       // EventCmdDone 時の exit code
 	TaskID     string    // SubTask 関連イベント時の taskID
+	Host       string    // TargetID のホスト（Loop の emit で設定。通知用）
+
+	// Memory は発見物を記録したときの EventLog に付く（通知の深刻度フィルター用）
+	Memory *schema.Memory
 
 	// Block-based rendering fields
 	Duration   time.Duration // EventThinkDone, EventCmdDone のかかった時間
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 	"sync"
 	"time"
@@ -13,8 +12,10 @@ import (
 	"github.com/0x6d61/pentecter/internal/knowledge"
 	"github.com/0x6d61/pentecter/internal/mcp"
 	"github.com/0x6d61/pentecter/internal/memory"
+	"github.com/0x6d61/pentecter/internal/playbook"
 	"github.com/0x6d61/pentecter/internal/skills"
 	"github.com/0x6d61/pentecter/internal/tools"
+	"github.com/0x6d61/pentecter/internal/vulndb"
 	"github.com/0x6d61/pentecter/pkg/schema"
 )
 
@@ -46,14 +47,18 @@ type Loop struct {
 	target       *Target
 	br           brain.Brain
 	brMu         sync.Mutex // Brain の差し替え保護（/model コマンド対応）
+	exploitBr    brain.Brain // 脆弱性を記録した後の思考に使う Brain（nil = br。brMu で保護）
 	runner       *tools.CommandRunner
 	skillsReg    *skills.Registry  // スキルテンプレート（nil = 無効）
 	memoryStore  *memory.Store     // 発見物の永続化（nil = 無効）
 	mcpMgr       *mcp.MCPManager  // MCP サーバーマネージャー（nil = MCP 無効）
 	taskMgr      *TaskManager     // SubTask マネージャー（nil = SubTask 無効）
-	knowledgeStore *knowledge.Store // ナレッジベース検索（nil = 無効）
+	knowledgeBase *knowledge.Library // ナレッジベース検索（nil = 無効）
 	reconTree    *ReconTree    // 構造的偵察制御（nil = 無効）
 	reconRunner  *ReconRunner // リアクティブ偵察オーケストレーター（nil = 無効）
+	parsers      *ParserRegistry // ツール出力パーサー（組み込み + ツール定義の parser:）
+	vulnDB       *vulndb.DB      // オフライン CVE データベース（nil = 無効）
+	playbooks    *playbook.Registry // YAML 偵察プレイブック（nil = 組み込みワークフローのみ）
 
 	// TUI との通信チャネル
 	events  chan<- Event  // Agent → TUI
@@ -61,6 +66,7 @@ type Loop struct {
 	userMsg <-chan string // TUI → Agent（チャット入力）
 
 	lastToolOutput      string
+	lastRawOutput       string // 切り捨て前の全出力（パーサープラグイン用）
 	consecutiveFailures int
 
 	// Brain コンテキスト強化用：コマンド履歴
@@ -68,6 +74,13 @@ type Loop struct {
 	lastExitCode int            // 直前のコマンドの exit code
 	history      []commandEntry // 直近の実行履歴（最大10件）
 
+	// 自動ナレッジ検索（サービス・CVE 発見時）
+	knowledgeLookedUp map[string]bool // 検索済みトリガー
+	knowledgeInjected map[string]bool // 注入済み記事（"source:path"）
+
+	// 実行中スキルのステップ進捗（nil = なし）
+	activeSkill *skillRun
+
 	// ユーザーメッセージ即時処理用
 	pendingUserMsg string // post-drain で取得したユーザーメッセージ
 	turnCount      int    // 現在のターン番号
@@ -75,6 +88,12 @@ type Loop struct {
 	// コマンド実行時間計測用
 	cmdStartTime time.Time
 
+	// 脆弱性を記録済みか（以降の思考はエクスプロイトの判断として exploitBr を使う）
+	vulnRecorded bool
+
+	// 記録済みの memory（type・title・description）。パーサーの再実行で同じ発見物を重複記録しない
+	recordedMemories map[string]bool
+
 }
 
 // NewLoop は Loop を構築する。
@@ -93,6 +112,7 @@ func NewLoop(
 		events:  events,
 		approve: approve,
 		userMsg: userMsg,
+		parsers: DefaultParsers(),
 	}
 }
 
@@ -119,9 +139,9 @@ func (l *Loop) WithTaskManager(tm *TaskManager) *Loop {
 	return l
 }
 
-// WithKnowledge は KnowledgeStore をセットする（メソッドチェーン用）。
-func (l *Loop) WithKnowledge(ks *knowledge.Store) *Loop {
-	l.knowledgeStore = ks
+// WithKnowledge はナレッジソースの Library をセットする（メソッドチェーン用）。
+func (l *Loop) WithKnowledge(lib *knowledge.Library) *Loop {
+	l.knowledgeBase = lib
 	return l
 }
 
@@ -131,6 +151,18 @@ func (l *Loop) WithReconTree(rt *ReconTree) *Loop {
 	return l
 }
 
+// WithVulnDB はオフライン CVE データベースをセットする（メソッドチェーン用）。
+func (l *Loop) WithVulnDB(db *vulndb.DB) *Loop {
+	l.vulnDB = db
+	return l
+}
+
+// WithPlaybooks は YAML 偵察プレイブックをセットする（メソッドチェーン用）。
+func (l *Loop) WithPlaybooks(reg *playbook.Registry) *Loop {
+	l.playbooks = reg
+	return l
+}
+
 // SetBrain は実行中の Loop の Brain を差し替える（/model コマンド対応）。
 // TUI goroutine から呼ばれるため mutex で保護。
 func (l *Loop) SetBrain(br brain.Brain) {
@@ -139,6 +171,24 @@ func (l *Loop) SetBrain(br brain.Brain) {
 	l.br = br
 }
 
+// SetExploitBrain は脆弱性を記録した後の思考に使う Brain を差し替える（nil = メインの Brain）。
+func (l *Loop) SetExploitBrain(br brain.Brain) {
+	l.brMu.Lock()
+	defer l.brMu.Unlock()
+	l.exploitBr = br
+}
+
+// currentBrain は次の思考に使う Brain を返す。
+// 脆弱性を記録した後はエクスプロイトの判断になるため、exploit ルートの Brain があればそれを使う。
+func (l *Loop) currentBrain() brain.Brain {
+	l.brMu.Lock()
+	defer l.brMu.Unlock()
+	if l.vulnRecorded && l.exploitBr != nil {
+		return l.exploitBr
+	}
+	return l.br
+}
+
 // Run はエージェントループを実行する。別 goroutine で呼び出すこと。
 func (l *Loop) Run(ctx context.Context) {
 	l.emit(Event{Type: EventLog, Source: SourceSystem,
@@ -158,6 +208,7 @@ func (l *Loop) Run(ctx context.Context) {
 			TargetHost: l.target.Host,
 			TargetID:   l.target.ID,
 			MemDir:     memDir,
+			Playbooks:  l.playbooks,
 		})
 		l.target.SetReconTree(l.reconTree)
 	}
@@ -193,6 +244,7 @@ func (l *Loop) Run(ctx context.Context) {
 			l.consecutiveFailures = 0
 			l.target.SetStatusSafe(StatusScanning)
 		}
+		userMsg = l.applySkill(ctx, userMsg)
 
 		l.emit(Event{Type: EventTurnStart, TurnNumber: l.turnCount})
 
@@ -203,44 +255,63 @@ func (l *Loop) Run(ctx context.Context) {
 			} else {
 				l.lastToolOutput = completedOutput
 			}
+			// SubAgent が発見したパス・技術スタックで新たなプレイブックが起動する場合がある
+			if l.reconRunner != nil {
+				l.reconRunner.TriggerPlaybooks(ctx)
+			}
 		}
 
 		l.emit(Event{Type: EventThinkStart})
 
 		thinkStartTime := time.Now()
+		relevantTechniques := l.buildRelevantTechniques()
 
 		var action *schema.Action
 		var brainErr error
 		for attempt := 1; attempt <= maxBrainRetries; attempt++ {
-			l.brMu.Lock()
-			currentBrain := l.br
-			l.brMu.Unlock()
-			action, brainErr = currentBrain.Think(ctx, brain.Input{
-				TargetSnapshot: l.buildSnapshot(),
-				ToolOutput:     l.lastToolOutput,
-				LastCommand:    l.lastCommand,
-				LastExitCode:   l.lastExitCode,
-				CommandHistory: l.buildHistory(),
-				UserMessage:    userMsg,
-				TurnCount:      l.turnCount,
-				Memory:         l.buildMemory(),
-				ReconQueue:     l.buildReconQueue(),
+			action, brainErr = l.currentBrain().Think(ctx, brain.Input{
+				TargetSnapshot:     l.buildSnapshot(),
+				ToolOutput:         l.lastToolOutput,
+				LastCommand:        l.lastCommand,
+				LastExitCode:       l.lastExitCode,
+				CommandHistory:     l.buildHistory(),
+				UserMessage:        userMsg,
+				TurnCount:          l.turnCount,
+				Memory:             l.buildMemory(),
+				ReconQueue:         l.buildReconQueue(),
+				RelevantTechniques: relevantTechniques,
+				SkillProgress:      l.buildSkillProgress(),
 			})
-			if brainErr == nil {
+			if brainErr == nil || ctx.Err() != nil {
+				break
+			}
+			// 認証・リクエストの誤りは再試行しても同じ結果になる
+			if !brain.Classify(brainErr).Retryable() {
 				break
 			}
 			if attempt < maxBrainRetries {
+				// レート制限は retry-after に従い、それ以外は指数バックオフ + ジッター
+				wait := brain.Backoff(attempt, brainErr)
 				l.emit(Event{Type: EventLog, Source: SourceSystem,
-					Message: fmt.Sprintf("Brain error: %v — retrying (%d/%d)", brainErr, attempt, maxBrainRetries)})
+					Message: fmt.Sprintf("Brain error (%s): %v — retrying in %s (%d/%d)",
+						brain.Classify(brainErr), brainErr, wait.Round(100*time.Millisecond), attempt, maxBrainRetries)})
 				select {
 				case <-ctx.Done():
 					return
-				case <-time.After(time.Duration(attempt) * time.Second):
+				case <-time.After(wait):
 				}
 			}
 		}
+		if ctx.Err() != nil {
+			return
+		}
 		if brainErr != nil {
-			l.emit(Event{Type: EventError, Message: fmt.Sprintf("Brain error after %d retries: %v", maxBrainRetries, brainErr)})
+			kind := brain.Classify(brainErr)
+			msg := fmt.Sprintf("Brain error (%s) after %d retries: %v", kind, maxBrainRetries, brainErr)
+			if !kind.Retryable() {
+				msg = fmt.Sprintf("Brain error (%s): %v", kind, brainErr)
+			}
+			l.emit(Event{Type: EventError, Message: msg})
 			l.target.SetStatusSafe(StatusFailed)
 			return
 		}
@@ -296,6 +367,18 @@ func (l *Loop) Run(ctx context.Context) {
 		case schema.ActionReadKnowledge:
 			l.handleReadKnowledge(action)
 
+		case schema.ActionUseSkill:
+			l.handleUseSkill(ctx, action)
+
+		case schema.ActionListMCPResources:
+			l.handleListMCPResources(ctx, action)
+
+		case schema.ActionReadMCPResource:
+			l.handleReadMCPResource(ctx, action)
+
+		case schema.ActionGetMCPPrompt:
+			l.handleGetMCPPrompt(ctx, action)
+
 		case schema.ActionThink:
 			// 思考のみ
 
@@ -338,6 +421,7 @@ func (l *Loop) runCommand(ctx context.Context, command string) {
 		l.emit(Event{Type: EventLog, Source: SourceSystem, Message: "run: command is empty"})
 		return
 	}
+	l.lastRawOutput = ""
 
 	// リアクティブモード有効時: web recon ツールは HTTPAgent が担当 → メイン Agent はブロック
 	// SubBrain が未設定の場合は HTTPAgent を起動できないためブロックしない
@@ -386,6 +470,7 @@ func (l *Loop) runCommand(ctx context.Context, command string) {
 // AutoApprove が ON の場合はユーザー確認をスキップして即実行する。
 func (l *Loop) handlePropose(ctx context.Context, command, description string) bool {
 	l.lastCommand = command
+	l.lastRawOutput = ""
 
 	// AutoApprove ON → proposal UI をスキップして即実行
 	if l.runner.AutoApprove() {
@@ -397,29 +482,38 @@ func (l *Loop) handlePropose(ctx context.Context, command, description string) b
 		return true
 	}
 
-	p := &Proposal{
+	approved, ok := l.awaitApproval(ctx, &Proposal{
 		Description: description,
 		Tool:        command,
 		Args:        nil,
+	})
+	if !ok {
+		return false
+	}
+	if approved {
+		l.target.SetStatusSafe(StatusRunning)
+		linesCh, resultCh := l.runner.ForceRun(ctx, command)
+		l.streamAndCollect(ctx, linesCh, resultCh)
+	} else {
+		l.lastToolOutput = "User rejected: " + description
+		l.target.SetStatusSafe(StatusScanning)
 	}
+	return true
+}
+
+// awaitApproval は Proposal を TUI に表示し、ユーザーの承認・拒否を待つ。
+// ctx がキャンセルされた場合は ok = false を返す。
+func (l *Loop) awaitApproval(ctx context.Context, p *Proposal) (approved, ok bool) {
 	l.target.SetProposal(p)
 	l.emit(Event{Type: EventProposal, Proposal: p})
 
 	select {
 	case approved := <-l.approve:
 		l.target.ClearProposal()
-		if approved {
-			l.target.SetStatusSafe(StatusRunning)
-			linesCh, resultCh := l.runner.ForceRun(ctx, command)
-			l.streamAndCollect(ctx, linesCh, resultCh)
-		} else {
-			l.lastToolOutput = "User rejected: " + description
-			l.target.SetStatusSafe(StatusScanning)
-		}
-		return true
+		return approved, true
 	case <-ctx.Done():
 		l.target.ClearProposal()
-		return false
+		return false, false
 	}
 }
 
@@ -428,8 +522,15 @@ func (l *Loop) recordMemory(m *schema.Memory) {
 	if m == nil {
 		return
 	}
+	if m.Type == schema.MemoryVulnerability {
+		l.vulnRecorded = true
+	}
+	if l.recordedMemories == nil {
+		l.recordedMemories = make(map[string]bool)
+	}
+	l.recordedMemories[memoryKey(m)] = true
 	msg := fmt.Sprintf("[%s] %s: %s", m.Type, m.Title, m.Description)
-	l.emit(Event{Type: EventLog, Source: SourceAI, Message: "📝 " + msg})
+	l.emit(Event{Type: EventLog, Source: SourceAI, Message: "📝 " + msg, Memory: m})
 
 	// Memory Store に永続化
 	if l.memoryStore != nil {
@@ -455,16 +556,42 @@ func (l *Loop) callMCP(ctx context.Context, action *schema.Action) {
 		return
 	}
 
-	// 承認ゲートチェック
-	if l.mcpMgr.IsProposalRequired(action.MCPServer) {
-		desc := fmt.Sprintf("MCP call: %s.%s", action.MCPServer, action.MCPTool)
-		l.lastCommand = desc
-		if !l.handlePropose(ctx, desc, action.Thought) {
+	toolLabel := fmt.Sprintf("[MCP] %s.%s", action.MCPServer, action.MCPTool)
+
+	// 承認ゲートチェック（引数ルール → ツール単位 → サーバー単位）
+	decision, reason := l.mcpMgr.CheckCall(action.MCPServer, action.MCPTool, action.MCPArgs)
+	switch {
+	case decision == mcp.DecisionDeny:
+		l.lastCommand = toolLabel
+		l.emit(Event{Type: EventLog, Source: SourceSystem,
+			Message: fmt.Sprintf("MCP call denied: %s.%s — %s", action.MCPServer, action.MCPTool, reason)})
+		l.lastToolOutput = "Error: MCP call denied by policy: " + reason
+		l.lastExitCode = 1
+		return
+	case decision == mcp.DecisionApprove && l.runner.AutoApprove():
+		l.emit(Event{Type: EventLog, Source: SourceSystem,
+			Message: fmt.Sprintf("Auto-approved: %s", toolLabel)})
+	case decision == mcp.DecisionApprove:
+		desc := action.Thought
+		if desc == "" {
+			desc = reason
+		}
+		l.lastCommand = toolLabel
+		approved, ok := l.awaitApproval(ctx, &Proposal{
+			Description: desc,
+			Tool:        toolLabel,
+			MCPArgs:     mcp.FormatArgs(action.MCPArgs),
+		})
+		if !ok {
+			return
+		}
+		if !approved {
+			l.lastToolOutput = "User rejected: " + toolLabel
+			l.target.SetStatusSafe(StatusScanning)
 			return
 		}
 	}
 
-	toolLabel := fmt.Sprintf("[MCP] %s.%s", action.MCPServer, action.MCPTool)
 	l.lastCommand = toolLabel
 	l.cmdStartTime = time.Now()
 	l.emit(Event{Type: EventCmdStart, Message: toolLabel})
@@ -480,15 +607,7 @@ func (l *Loop) callMCP(ctx context.Context, action *schema.Action) {
 		return
 	}
 
-	// MCP 結果をテキストに変換
-	var sb strings.Builder
-	for _, block := range result.Content {
-		if block.Text != "" {
-			sb.WriteString(block.Text)
-			sb.WriteString("\n")
-		}
-	}
-	output := strings.TrimSpace(sb.String())
+	output := mcpResultText(result)
 
 	if result.IsError {
 		l.lastExitCode = 1
@@ -524,14 +643,23 @@ func (l *Loop) callMCP(ctx context.Context, action *schema.Action) {
 	}
 }
 
+// mcpResultText は MCP の結果のテキストブロックを連結する。
+func mcpResultText(result *mcp.CallResult) string {
+	var sb strings.Builder
+	for _, block := range result.Content {
+		if block.Text != "" {
+			sb.WriteString(block.Text)
+			sb.WriteString("\n")
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
 // waitForUserMsg はユーザーからのメッセージをブロッキングで待つ。
 // コンテキストがキャンセルされた場合は空文字を返す。
 func (l *Loop) waitForUserMsg(ctx context.Context) string {
 	select {
 	case msg := <-l.userMsg:
-		if l.skillsReg != nil {
-			return l.skillsReg.Expand(msg)
-		}
 		return msg
 	case <-ctx.Done():
 		return ""
@@ -563,28 +691,15 @@ func (l *Loop) evaluateResult(ctx context.Context) {
 		}
 	}
 
-	// ReconTree: ツール出力をパースして偵察状態を更新
-	if l.reconTree != nil && l.lastCommand != "" {
-		parseOutput := l.lastToolOutput
-
-		// ffuf -o <file> の場合、ファイルから JSON を読み取る（stdout には JSON が出ないため）
-		if ffufPath := ExtractFfufOutputPath(l.lastCommand); ffufPath != "" {
-			if data, err := os.ReadFile(ffufPath); err == nil {
-				parseOutput = string(data)
-			}
-		}
-
-		// nmap -oX <file> / -oN <file> / -oA <base> の場合、ファイルから読み取る
-		if nmapPath := ExtractNmapOutputFile(l.lastCommand); nmapPath != "" {
-			if data, err := os.ReadFile(nmapPath); err == nil {
-				parseOutput = string(data)
-			}
-		}
+	// パーサー: ReconTree / Entity / memory に反映（ReconTree 無効でも Entity と memory は更新する）
+	if l.lastCommand != "" {
+		l.applyParserPlugin()
+	}
 
-		if err := DetectAndParse(l.lastCommand, parseOutput, l.reconTree, l.target.Host); err != nil {
-			l.emit(Event{Type: EventLog, Source: SourceSystem,
-				Message: fmt.Sprintf("ReconTree parse warning: %v", err)})
-		}
+	// ReconTree: パース結果から CVE の突き合わせと偵察の自動起動を行う
+	if l.reconTree != nil && l.lastCommand != "" {
+		// 既知の CVE をバナーから突き合わせる
+		EnrichReconTree(l.reconTree, l.vulnDB)
 
 		// リアクティブ spawn: Pending な HTTP ポートがあれば SubAgent を自動起動
 		// 新規追加ポートと非HTTP→HTTP 更新ポートの両方を検出する
@@ -592,6 +707,12 @@ func (l *Loop) evaluateResult(ctx context.Context) {
 			for _, port := range l.reconTree.PendingHTTPPorts() {
 				l.reconRunner.SpawnWebReconForPort(ctx, port)
 			}
+			// SMB / FTP / LDAP 等のサービス別偵察も同様に起動
+			for _, port := range l.reconTree.PendingServicePorts() {
+				l.reconRunner.SpawnServiceReconForPort(ctx, port)
+			}
+			// トリガーに一致した YAML プレイブック（WordPress, Jenkins 等）
+			l.reconRunner.TriggerPlaybooks(ctx)
 		}
 
 		// Target にも反映（TUI から参照可能にする）
@@ -599,6 +720,41 @@ func (l *Loop) evaluateResult(ctx context.Context) {
 	}
 }
 
+// applyParserPlugin はコマンドに対応するパーサー（nmap / ffuf / ツール定義の parser: 等）で出力を解析し、
+// ReconTree と memory に反映する。
+func (l *Loop) applyParserPlugin() {
+	if l.parsers == nil {
+		return
+	}
+	output := l.lastRawOutput
+	if output == "" {
+		output = l.lastToolOutput
+	}
+	res, err := l.parsers.Run(l.lastCommand, output, l.target.Host, l.runner)
+	if err != nil {
+		l.emit(Event{Type: EventLog, Source: SourceSystem,
+			Message: fmt.Sprintf("Parser warning: %v", err)})
+		return
+	}
+	if res.IsEmpty() {
+		return
+	}
+	ApplyParseResult(res, l.reconTree, l.target.Host)
+	l.target.AddEntities(res.Entities())
+	for _, m := range res.Memories() {
+		// 同じターゲットへの再実行（hydra・nikto 等）で既に記録した発見物は追加しない
+		if l.recordedMemories[memoryKey(m)] {
+			continue
+		}
+		l.recordMemory(m)
+	}
+}
+
+// memoryKey は memory の重複判定キー（type・title・description）を返す。
+func memoryKey(m *schema.Memory) string {
+	return string(m.Type) + "\x00" + m.Title + "\x00" + m.Description
+}
+
 // buildCommandSummary はコマンド実行結果のサマリーを生成する。
 func buildCommandSummary(exitCode int, output string) string {
 	lines := 0
@@ -737,9 +893,11 @@ func (l *Loop) streamAndCollect(ctx context.Context, linesCh <-chan tools.Output
 		errMsg := fmt.Sprintf("Execution error: %v", result.Err)
 		l.emit(Event{Type: EventLog, Source: SourceSystem, Message: errMsg})
 		l.lastToolOutput = "Error: " + result.Err.Error()
+		l.lastRawOutput = "" // 前のコマンドの出力をこのコマンドの結果として扱わない
 	} else {
-		l.target.AddEntities(result.Entities)
+		l.target.AddEntities(EnrichEntities(result.Entities, l.vulnDB))
 		l.lastToolOutput = result.Truncated
+		l.lastRawOutput = rawOutputText(result)
 	}
 
 	// コマンド履歴を記録
@@ -766,22 +924,19 @@ func (l *Loop) streamAndCollect(ctx context.Context, linesCh <-chan tools.Output
 		Duration: time.Since(l.cmdStartTime),
 		Message:  buildCommandSummary(result.ExitCode, result.Truncated),
 	})
+	// 実行できなかったコマンドは ExitCode 0 でもスキルのステップを進めない
+	if result.Err == nil {
+		l.advanceSkill()
+	}
 
 	l.target.SetStatusSafe(StatusScanning)
 }
 
-// drainUserMsg はユーザーメッセージを取得し、スキル呼び出し（/skill-name）なら展開する。
+// drainUserMsg はユーザーメッセージをノンブロッキングで取得する。
+// スキル呼び出し（/skill-name）の展開は Run のターン開始時に applySkill が行う。
 func (l *Loop) drainUserMsg() string {
 	select {
 	case msg := <-l.userMsg:
-		if l.skillsReg != nil {
-			expanded := l.skillsReg.Expand(msg)
-			if expanded != msg {
-				l.emit(Event{Type: EventLog, Source: SourceSystem,
-					Message: fmt.Sprintf("Skill expanded: %s", msg)})
-			}
-			return expanded
-		}
 		return msg
 	default:
 		return ""
@@ -830,7 +985,7 @@ func (l *Loop) buildSnapshot() string {
 	entityMap := map[string][]string{}
 	for _, e := range entities {
 		t := string(e.Type)
-		entityMap[t] = append(entityMap[t], e.Value)
+		entityMap[t] = append(entityMap[t], describeEntity(e))
 	}
 	snapshot := map[string]any{
 		"host":     l.target.Host,
@@ -845,8 +1000,29 @@ func (l *Loop) buildSnapshot() string {
 	return string(b)
 }
 
+// describeEntity は Brain スナップショット用に Entity を1行で表す。
+// 例: "5f4dcc3b5aa765d61d8327deb882cf99 [md5] (hashdump, 40%)"
+func describeEntity(e tools.Entity) string {
+	s := e.Value
+	if e.Detail != "" {
+		s += " [" + e.Detail + "]"
+	}
+	var meta []string
+	if e.Source != "" {
+		meta = append(meta, e.Source)
+	}
+	if e.Confidence > 0 && e.Confidence < 1 {
+		meta = append(meta, fmt.Sprintf("%d%%", int(e.Confidence*100+0.5)))
+	}
+	if len(meta) > 0 {
+		s += " (" + strings.Join(meta, ", ") + ")"
+	}
+	return s
+}
+
 func (l *Loop) emit(e Event) {
 	e.TargetID = l.target.ID
+	e.Host = l.target.Host
 	select {
 	case l.events <- e:
 	default:
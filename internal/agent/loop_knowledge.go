@@ -1,10 +1,13 @@
-// Package agent — loop_knowledge.go は search_knowledge / read_knowledge アクションのハンドラを定義する。
+// Package agent — loop_knowledge.go は search_knowledge / read_knowledge アクションのハンドラと、
+// サービス・CVE 発見時の自動ナレッジ検索を定義する。
 package agent
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/0x6d61/pentecter/internal/tools"
 	"github.com/0x6d61/pentecter/pkg/schema"
 )
 
@@ -16,8 +19,9 @@ const (
 )
 
 // handleSearchKnowledge は knowledge_query でナレッジベースを検索し結果を lastToolOutput に格納する。
+// knowledge_source 指定時はそのソースのみ検索する。
 func (l *Loop) handleSearchKnowledge(action *schema.Action) {
-	if l.knowledgeStore == nil {
+	if l.knowledgeBase.Len() == 0 {
 		l.lastToolOutput = "Error: Knowledge base not configured. Clone HackTricks: git clone --depth 1 https://github.com/carlospolop/hacktricks.git"
 		return
 	}
@@ -28,10 +32,20 @@ func (l *Loop) handleSearchKnowledge(action *schema.Action) {
 		return
 	}
 
-	l.emit(Event{Type: EventLog, Source: SourceSystem,
-		Message: fmt.Sprintf("Searching knowledge base: %q", query)})
+	source := action.KnowledgeSource
+	if source != "" {
+		l.emit(Event{Type: EventLog, Source: SourceSystem,
+			Message: fmt.Sprintf("Searching knowledge base [%s]: %q", source, query)})
+	} else {
+		l.emit(Event{Type: EventLog, Source: SourceSystem,
+			Message: fmt.Sprintf("Searching knowledge base: %q", query)})
+	}
 
-	results := l.knowledgeStore.Search(query, knowledgeSearchMaxResults)
+	results, err := l.knowledgeBase.Search(query, source, knowledgeSearchMaxResults)
+	if err != nil {
+		l.lastToolOutput = fmt.Sprintf("Error: %v", err)
+		return
+	}
 	if len(results) == 0 {
 		l.lastToolOutput = fmt.Sprintf("No results found for: %q", query)
 		return
@@ -41,7 +55,7 @@ func (l *Loop) handleSearchKnowledge(action *schema.Action) {
 	var sb strings.Builder
 	fmt.Fprintf(&sb, "Found %d results for %q:\n\n", len(results), query)
 	for i, r := range results {
-		fmt.Fprintf(&sb, "[%d] %s\n", i+1, r.File)
+		fmt.Fprintf(&sb, "[%d] %s:%s\n", i+1, r.Source, r.File)
 		if r.Title != "" {
 			fmt.Fprintf(&sb, "    Title: %s\n", r.Title)
 		}
@@ -53,13 +67,15 @@ func (l *Loop) handleSearchKnowledge(action *schema.Action) {
 		}
 		fmt.Fprintf(&sb, "    Matches: %d\n\n", r.MatchCount)
 	}
-	sb.WriteString("Use read_knowledge with the file path to read full article.")
+	fmt.Fprintf(&sb, "Sources: %s (set knowledge_source to search only one)\n", strings.Join(l.knowledgeBase.Sources(), ", "))
+	sb.WriteString("Use read_knowledge with the source:path to read full article.")
 	l.lastToolOutput = sb.String()
 }
 
 // handleReadKnowledge は knowledge_path のファイルを読み取り lastToolOutput に格納する。
+// パスは "source:path" 形式（ソース省略時は登録順に探す）。
 func (l *Loop) handleReadKnowledge(action *schema.Action) {
-	if l.knowledgeStore == nil {
+	if l.knowledgeBase.Len() == 0 {
 		l.lastToolOutput = "Error: Knowledge base not configured. Clone HackTricks: git clone --depth 1 https://github.com/carlospolop/hacktricks.git"
 		return
 	}
@@ -73,7 +89,7 @@ func (l *Loop) handleReadKnowledge(action *schema.Action) {
 	l.emit(Event{Type: EventLog, Source: SourceSystem,
 		Message: fmt.Sprintf("Reading knowledge article: %s", path)})
 
-	content, err := l.knowledgeStore.ReadFile(path, knowledgeReadMaxBytes)
+	content, err := l.knowledgeBase.ReadFile(path, knowledgeReadMaxBytes)
 	if err != nil {
 		l.lastToolOutput = fmt.Sprintf("Error reading knowledge file: %v", err)
 		return
@@ -81,3 +97,142 @@ func (l *Loop) handleReadKnowledge(action *schema.Action) {
 
 	l.lastToolOutput = content
 }
+
+const (
+	// autoKnowledgeResultsPerTrigger はトリガー1件あたりに注入する記事数
+	autoKnowledgeResultsPerTrigger = 3
+	// autoKnowledgeMaxPerTurn は1ターンに注入する記事数の上限
+	autoKnowledgeMaxPerTurn = 6
+	// autoKnowledgeSnippetChars はスニペットの最大文字数
+	autoKnowledgeSnippetChars = 200
+)
+
+// knowledgeTrigger は自動ナレッジ検索のきっかけ（サービス・バナー / CVE）。
+type knowledgeTrigger struct {
+	key   string // 重複検索防止用
+	label string // 注入テキストでの表示（"21/ftp vsftpd 2.3.4" 等）
+	query string
+}
+
+// buildRelevantTechniques は新たに判明したサービス・バナーと CVE エンティティでナレッジベースを検索し、
+// 上位のセクションを "Relevant techniques" として返す。
+// 同じトリガーは一度だけ検索し、同じ記事は二度注入しない。
+func (l *Loop) buildRelevantTechniques() string {
+	if l.knowledgeBase.Len() == 0 {
+		return ""
+	}
+	if l.knowledgeLookedUp == nil {
+		l.knowledgeLookedUp = make(map[string]bool)
+		l.knowledgeInjected = make(map[string]bool)
+	}
+
+	var sb strings.Builder
+	injected := 0
+	for _, trig := range l.knowledgeTriggers() {
+		if injected >= autoKnowledgeMaxPerTurn {
+			break // 残りは次のターンで検索する
+		}
+		l.knowledgeLookedUp[trig.key] = true
+
+		results, err := l.knowledgeBase.Search(trig.query, "", knowledgeSearchMaxResults)
+		if err != nil {
+			continue
+		}
+		var found []string
+		for _, r := range results {
+			ref := r.Source + ":" + r.File
+			if l.knowledgeInjected[ref] {
+				continue
+			}
+			l.knowledgeInjected[ref] = true
+			found = append(found, ref)
+			fmt.Fprintf(&sb, "- [%s] %s — %s\n", trig.label, ref, sectionLabel(r.Title, r.Section))
+			if snippet := compactSnippet(r.Snippet); snippet != "" {
+				fmt.Fprintf(&sb, "  %s\n", snippet)
+			}
+			injected++
+			if len(found) >= autoKnowledgeResultsPerTrigger || injected >= autoKnowledgeMaxPerTurn {
+				break
+			}
+		}
+		if len(found) > 0 {
+			l.emit(Event{Type: EventLog, Source: SourceSystem,
+				Message: fmt.Sprintf("Knowledge lookup for %s: %s", trig.label, strings.Join(found, ", "))})
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	sb.WriteString("Use read_knowledge with the source:path for full details.")
+	return sb.String()
+}
+
+// knowledgeTriggers は未検索のトリガーを返す（ReconTree のサービス → CVE エンティティの順）。
+func (l *Loop) knowledgeTriggers() []knowledgeTrigger {
+	var triggers []knowledgeTrigger
+	add := func(trig knowledgeTrigger) {
+		if trig.query != "" && !l.knowledgeLookedUp[trig.key] {
+			triggers = append(triggers, trig)
+		}
+	}
+
+	if l.reconTree != nil {
+		for _, s := range l.reconTree.PlaybookSubjects() {
+			if s.Service == "" && s.Banner == "" {
+				continue
+			}
+			label := strings.TrimSpace(fmt.Sprintf("%d/%s %s", s.Port, s.Service, s.Banner))
+			add(knowledgeTrigger{
+				key:   fmt.Sprintf("svc:%d:%s:%s", s.Port, s.Service, s.Banner),
+				label: label,
+				query: serviceKnowledgeQuery(s.Service, s.Banner),
+			})
+		}
+	}
+	for _, e := range l.target.SnapshotEntities() {
+		if e.Type != tools.EntityCVE {
+			continue
+		}
+		add(knowledgeTrigger{key: "cve:" + strings.ToUpper(e.Value), label: strings.ToUpper(e.Value), query: e.Value})
+	}
+	return triggers
+}
+
+// serviceKnowledgeQuery はサービス名とバナーの製品名（バージョン以外の先頭2語）を OR で繋いだクエリを返す。
+// "ftp" + "vsftpd 2.3.4" → "vsftpd | ftp"。両方を含む記事が上位になる。
+func serviceKnowledgeQuery(service, banner string) string {
+	var terms []string
+	for _, w := range strings.Fields(strings.ToLower(banner)) {
+		if len(terms) >= 2 {
+			break
+		}
+		if strings.ContainsAny(w, "0123456789") || len(w) < 3 {
+			continue
+		}
+		terms = append(terms, strings.Trim(w, "()[],;"))
+	}
+	if service != "" && service != "unknown" && !slices.Contains(terms, service) {
+		terms = append(terms, service)
+	}
+	return strings.Join(terms, " | ")
+}
+
+func sectionLabel(title, section string) string {
+	switch {
+	case title != "" && section != "":
+		return title + " › " + section
+	case section != "":
+		return section
+	default:
+		return title
+	}
+}
+
+// compactSnippet はスニペットを1行に詰めて autoKnowledgeSnippetChars で切り詰める。
+func compactSnippet(snippet string) string {
+	s := strings.Join(strings.Fields(snippet), " ")
+	if r := []rune(s); len(r) > autoKnowledgeSnippetChars {
+		s = string(r[:autoKnowledgeSnippetChars]) + "..."
+	}
+	return s
+}
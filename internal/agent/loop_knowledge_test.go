@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/0x6d61/pentecter/internal/knowledge"
+	"github.com/0x6d61/pentecter/internal/tools"
 	"github.com/0x6d61/pentecter/pkg/schema"
 )
 
@@ -59,9 +60,9 @@ func TestHandleSearchKnowledge_Success(t *testing.T) {
 
 	events := make(chan Event, 64)
 	loop := &Loop{
-		target:         NewTarget(1, "test"),
-		events:         events,
-		knowledgeStore: ks,
+		target:        NewTarget(1, "test"),
+		events:        events,
+		knowledgeBase: knowledge.NewLibrary().Add("hacktricks", ks),
 	}
 
 	action := &schema.Action{
@@ -88,9 +89,9 @@ func TestHandleSearchKnowledge_NoResults(t *testing.T) {
 
 	events := make(chan Event, 64)
 	loop := &Loop{
-		target:         NewTarget(1, "test"),
-		events:         events,
-		knowledgeStore: ks,
+		target:        NewTarget(1, "test"),
+		events:        events,
+		knowledgeBase: knowledge.NewLibrary().Add("hacktricks", ks),
 	}
 
 	action := &schema.Action{
@@ -108,9 +109,9 @@ func TestHandleSearchKnowledge_NoResults(t *testing.T) {
 func TestHandleSearchKnowledge_NoStore(t *testing.T) {
 	events := make(chan Event, 64)
 	loop := &Loop{
-		target:         NewTarget(1, "test"),
-		events:         events,
-		knowledgeStore: nil,
+		target:        NewTarget(1, "test"),
+		events:        events,
+		knowledgeBase: nil,
 	}
 
 	action := &schema.Action{
@@ -125,15 +126,57 @@ func TestHandleSearchKnowledge_NoStore(t *testing.T) {
 	}
 }
 
+func TestHandleSearchKnowledge_SourceRouting(t *testing.T) {
+	runbooks := t.TempDir()
+	if err := os.WriteFile(filepath.Join(runbooks, "ftp.txt"), []byte("Team note: vsftpd on legacy hosts is out of scope\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lib := knowledge.NewLibrary().
+		Add("hacktricks", knowledge.NewStore(setupKnowledgeTestData(t))).
+		Add("runbooks", knowledge.NewStore(runbooks).WithFormat(knowledge.FormatText))
+
+	loop := &Loop{
+		target:        NewTarget(1, "test"),
+		events:        make(chan Event, 64),
+		knowledgeBase: lib,
+	}
+
+	// 全ソース: ソース名付きで両方ヒット
+	loop.handleSearchKnowledge(&schema.Action{Action: schema.ActionSearchKnowledge, KnowledgeQuery: "vsftpd"})
+	for _, want := range []string{"hacktricks:network-services-pentesting/ftp.md", "runbooks:ftp.txt", "Sources: hacktricks, runbooks"} {
+		if !strings.Contains(loop.lastToolOutput, want) {
+			t.Errorf("expected %q in output, got: %s", want, loop.lastToolOutput)
+		}
+	}
+
+	// knowledge_source 指定: そのソースのみ
+	loop.handleSearchKnowledge(&schema.Action{Action: schema.ActionSearchKnowledge, KnowledgeQuery: "vsftpd", KnowledgeSource: "runbooks"})
+	if strings.Contains(loop.lastToolOutput, "hacktricks:") || !strings.Contains(loop.lastToolOutput, "runbooks:ftp.txt") {
+		t.Errorf("expected runbooks-only results, got: %s", loop.lastToolOutput)
+	}
+
+	// 未知のソース
+	loop.handleSearchKnowledge(&schema.Action{Action: schema.ActionSearchKnowledge, KnowledgeQuery: "vsftpd", KnowledgeSource: "exploitdb"})
+	if !strings.Contains(loop.lastToolOutput, "unknown source") {
+		t.Errorf("expected unknown source error, got: %s", loop.lastToolOutput)
+	}
+
+	// read_knowledge は source:path を受け付ける
+	loop.handleReadKnowledge(&schema.Action{Action: schema.ActionReadKnowledge, KnowledgePath: "runbooks:ftp.txt"})
+	if !strings.Contains(loop.lastToolOutput, "out of scope") {
+		t.Errorf("expected runbook content, got: %s", loop.lastToolOutput)
+	}
+}
+
 func TestHandleSearchKnowledge_EmptyQuery(t *testing.T) {
 	dir := setupKnowledgeTestData(t)
 	ks := knowledge.NewStore(dir)
 
 	events := make(chan Event, 64)
 	loop := &Loop{
-		target:         NewTarget(1, "test"),
-		events:         events,
-		knowledgeStore: ks,
+		target:        NewTarget(1, "test"),
+		events:        events,
+		knowledgeBase: knowledge.NewLibrary().Add("hacktricks", ks),
 	}
 
 	action := &schema.Action{
@@ -154,9 +197,9 @@ func TestHandleReadKnowledge_Success(t *testing.T) {
 
 	events := make(chan Event, 64)
 	loop := &Loop{
-		target:         NewTarget(1, "test"),
-		events:         events,
-		knowledgeStore: ks,
+		target:        NewTarget(1, "test"),
+		events:        events,
+		knowledgeBase: knowledge.NewLibrary().Add("hacktricks", ks),
 	}
 
 	action := &schema.Action{
@@ -177,9 +220,9 @@ func TestHandleReadKnowledge_NotFound(t *testing.T) {
 
 	events := make(chan Event, 64)
 	loop := &Loop{
-		target:         NewTarget(1, "test"),
-		events:         events,
-		knowledgeStore: ks,
+		target:        NewTarget(1, "test"),
+		events:        events,
+		knowledgeBase: knowledge.NewLibrary().Add("hacktricks", ks),
 	}
 
 	action := &schema.Action{
@@ -197,9 +240,9 @@ func TestHandleReadKnowledge_NotFound(t *testing.T) {
 func TestHandleReadKnowledge_NoStore(t *testing.T) {
 	events := make(chan Event, 64)
 	loop := &Loop{
-		target:         NewTarget(1, "test"),
-		events:         events,
-		knowledgeStore: nil,
+		target:        NewTarget(1, "test"),
+		events:        events,
+		knowledgeBase: nil,
 	}
 
 	action := &schema.Action{
@@ -220,9 +263,9 @@ func TestHandleReadKnowledge_EmptyPath(t *testing.T) {
 
 	events := make(chan Event, 64)
 	loop := &Loop{
-		target:         NewTarget(1, "test"),
-		events:         events,
-		knowledgeStore: ks,
+		target:        NewTarget(1, "test"),
+		events:        events,
+		knowledgeBase: knowledge.NewLibrary().Add("hacktricks", ks),
 	}
 
 	action := &schema.Action{
@@ -236,3 +279,73 @@ func TestHandleReadKnowledge_EmptyPath(t *testing.T) {
 		t.Errorf("expected 'empty' error, got: %s", loop.lastToolOutput)
 	}
 }
+
+func TestBuildRelevantTechniques(t *testing.T) {
+	dir := setupKnowledgeTestData(t)
+	cveDir := filepath.Join(dir, "cves")
+	if err := os.MkdirAll(cveDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	apache := "# Apache\n## Path Traversal (CVE-2021-41773)\nApache 2.4.49 allows path traversal: curl --path-as-is /cgi-bin/.%2e/.%2e/etc/passwd\n"
+	if err := os.WriteFile(filepath.Join(cveDir, "apache.md"), []byte(apache), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(21, "ftp", "vsftpd 2.3.4")
+	loop := &Loop{
+		target:        NewTarget(1, "10.10.11.100"),
+		events:        make(chan Event, 64),
+		knowledgeBase: knowledge.NewLibrary().Add("hacktricks", knowledge.NewStore(dir)),
+		reconTree:     tree,
+	}
+
+	got := loop.buildRelevantTechniques()
+	for _, want := range []string{"[21/ftp vsftpd 2.3.4]", "hacktricks:network-services-pentesting/ftp.md", "vsftpd 2.3.4 Backdoor", "read_knowledge"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in techniques, got:\n%s", want, got)
+		}
+	}
+
+	// 同じトリガーは再検索しない
+	if got := loop.buildRelevantTechniques(); got != "" {
+		t.Errorf("second call should inject nothing, got:\n%s", got)
+	}
+
+	// CVE エンティティで検索。注入済みの記事は再注入しない
+	loop.target.AddEntities([]tools.Entity{{Type: tools.EntityCVE, Value: "CVE-2021-41773"}})
+	tree.AddPort(2121, "ftp", "vsftpd 3.0.3")
+	got = loop.buildRelevantTechniques()
+	if !strings.Contains(got, "[CVE-2021-41773] hacktricks:cves/apache.md") {
+		t.Errorf("expected CVE lookup result, got:\n%s", got)
+	}
+	if strings.Contains(got, "ftp.md") {
+		t.Errorf("already injected article should not be repeated, got:\n%s", got)
+	}
+}
+
+func TestBuildRelevantTechniques_NoKnowledgeBase(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(21, "ftp", "vsftpd 2.3.4")
+	loop := &Loop{target: NewTarget(1, "10.10.11.100"), events: make(chan Event, 64), reconTree: tree}
+	if got := loop.buildRelevantTechniques(); got != "" {
+		t.Errorf("expected empty without knowledge base, got %q", got)
+	}
+}
+
+func TestServiceKnowledgeQuery(t *testing.T) {
+	tests := []struct {
+		service, banner, want string
+	}{
+		{"ftp", "vsftpd 2.3.4", "vsftpd | ftp"},
+		{"http", "Microsoft IIS httpd 10.0", "microsoft | iis | http"},
+		{"ssh", "OpenSSH 8.2p1 Ubuntu 4ubuntu0.5", "openssh | ubuntu | ssh"},
+		{"mysql", "", "mysql"},
+		{"unknown", "", ""},
+	}
+	for _, tt := range tests {
+		if got := serviceKnowledgeQuery(tt.service, tt.banner); got != tt.want {
+			t.Errorf("serviceKnowledgeQuery(%q, %q) = %q, want %q", tt.service, tt.banner, got, tt.want)
+		}
+	}
+}
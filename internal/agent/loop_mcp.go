@@ -0,0 +1,149 @@
+// Package agent — loop_mcp.go は MCP のリソース・プロンプト系アクション
+// （list_mcp_resources / read_mcp_resource / get_mcp_prompt）のハンドラを定義する。
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/0x6d61/pentecter/internal/mcp"
+	"github.com/0x6d61/pentecter/pkg/schema"
+)
+
+// mcpResourceMaxBytes は Brain に渡すリソース内容の最大バイト数
+const mcpResourceMaxBytes = 30000
+
+// handleListMCPResources は MCP サーバーのリソースとプロンプトの一覧を lastToolOutput に格納する。
+// mcp_server 指定時はそのサーバーのみ対象にする。
+func (l *Loop) handleListMCPResources(ctx context.Context, action *schema.Action) {
+	if l.mcpMgr == nil {
+		l.lastToolOutput = "Error: MCP not configured"
+		return
+	}
+	resources, err := l.mcpMgr.ListResources(ctx, action.MCPServer)
+	if err != nil {
+		l.lastToolOutput = "Error: " + err.Error()
+		return
+	}
+	prompts, err := l.mcpMgr.ListPrompts(ctx, action.MCPServer)
+	if err != nil {
+		l.lastToolOutput = "Error: " + err.Error()
+		return
+	}
+	l.emit(Event{Type: EventLog, Source: SourceSystem,
+		Message: fmt.Sprintf("MCP: %d resources, %d prompts", len(resources), len(prompts))})
+	l.lastToolOutput = FormatMCPCatalog(resources, prompts) +
+		"\nUse read_mcp_resource (mcp_server, mcp_uri) or get_mcp_prompt (mcp_server, mcp_prompt, mcp_args)."
+}
+
+// handleReadMCPResource は mcp_uri のリソースを読み込み lastToolOutput に格納する。
+func (l *Loop) handleReadMCPResource(ctx context.Context, action *schema.Action) {
+	if l.mcpMgr == nil {
+		l.lastToolOutput = "Error: MCP not configured"
+		return
+	}
+	if action.MCPServer == "" || action.MCPURI == "" {
+		l.lastToolOutput = "Error: read_mcp_resource requires mcp_server and mcp_uri"
+		return
+	}
+	l.emit(Event{Type: EventLog, Source: SourceSystem,
+		Message: fmt.Sprintf("Reading MCP resource %s %s", action.MCPServer, action.MCPURI)})
+	contents, err := l.mcpMgr.ReadResource(ctx, action.MCPServer, action.MCPURI)
+	if err != nil {
+		l.lastToolOutput = "Error: " + err.Error()
+		return
+	}
+	l.lastToolOutput = FormatMCPContents(contents, mcpResourceMaxBytes)
+}
+
+// handleGetMCPPrompt は mcp_prompt のプロンプトを mcp_args で展開して lastToolOutput に格納する。
+func (l *Loop) handleGetMCPPrompt(ctx context.Context, action *schema.Action) {
+	if l.mcpMgr == nil {
+		l.lastToolOutput = "Error: MCP not configured"
+		return
+	}
+	if action.MCPServer == "" || action.MCPPrompt == "" {
+		l.lastToolOutput = "Error: get_mcp_prompt requires mcp_server and mcp_prompt"
+		return
+	}
+	args := make(map[string]string, len(action.MCPArgs))
+	for k, v := range action.MCPArgs {
+		args[k] = fmt.Sprint(v)
+	}
+	l.emit(Event{Type: EventLog, Source: SourceSystem,
+		Message: fmt.Sprintf("Loading MCP prompt %s/%s", action.MCPServer, action.MCPPrompt)})
+	prompt, err := l.mcpMgr.GetPrompt(ctx, action.MCPServer, action.MCPPrompt, args)
+	if err != nil {
+		l.lastToolOutput = "Error: " + err.Error()
+		return
+	}
+	l.lastToolOutput = fmt.Sprintf("MCP prompt %s/%s:\n\n%s", action.MCPServer, action.MCPPrompt, prompt.Text())
+}
+
+// FormatMCPCatalog はリソースとプロンプトの一覧をテキストにする（Brain と TUI の /mcp で共用）。
+func FormatMCPCatalog(resources []mcp.Resource, prompts []mcp.Prompt) string {
+	var sb strings.Builder
+	if len(resources) == 0 {
+		sb.WriteString("No MCP resources.\n")
+	} else {
+		sb.WriteString("MCP resources:\n")
+		for _, r := range resources {
+			fmt.Fprintf(&sb, "  [%s] %s — %s", r.Server, r.URI, r.Name)
+			if r.Description != "" {
+				fmt.Fprintf(&sb, ": %s", r.Description)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	if len(prompts) == 0 {
+		sb.WriteString("No MCP prompts.\n")
+	} else {
+		sb.WriteString("MCP prompts:\n")
+		for _, p := range prompts {
+			fmt.Fprintf(&sb, "  [%s] %s", p.Server, p.Name)
+			if p.Description != "" {
+				fmt.Fprintf(&sb, ": %s", p.Description)
+			}
+			var args []string
+			for _, a := range p.Arguments {
+				if a.Required {
+					args = append(args, a.Name+"=<required>")
+				} else {
+					args = append(args, "["+a.Name+"]")
+				}
+			}
+			if len(args) > 0 {
+				fmt.Fprintf(&sb, " (args: %s)", strings.Join(args, " "))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// FormatMCPContents はリソースの内容を maxBytes までのテキストにする。バイナリは概要のみ示す。
+func FormatMCPContents(contents []mcp.ResourceContent, maxBytes int) string {
+	if len(contents) == 0 {
+		return "(empty resource)"
+	}
+	var sb strings.Builder
+	for _, c := range contents {
+		if len(contents) > 1 {
+			fmt.Fprintf(&sb, "--- %s ---\n", c.URI)
+		}
+		if c.Text == "" && c.Blob != "" {
+			fmt.Fprintf(&sb, "[binary content: %s, %d bytes base64]\n", c.MimeType, len(c.Blob))
+			continue
+		}
+		sb.WriteString(c.Text)
+		if !strings.HasSuffix(c.Text, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	text := strings.TrimRight(sb.String(), "\n")
+	if len(text) > maxBytes {
+		text = text[:maxBytes] + fmt.Sprintf("\n... (truncated, %d bytes total)", len(text))
+	}
+	return text
+}
@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/pentecter/internal/mcp"
+	"github.com/0x6d61/pentecter/pkg/schema"
+)
+
+// newResourceMCPManager はリソース・プロンプトを公開する HTTP MCP サーバーに接続した MCPManager を返す。
+func newResourceMCPManager(t *testing.T) *mcp.MCPManager {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     *int64         `json:"id"`
+			Method string         `json:"method"`
+			Params map[string]any `json:"params"`
+		}
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&req) != nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if req.ID == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{"protocolVersion": "2024-11-05", "capabilities": map[string]any{}}
+		case "tools/list":
+			result = map[string]any{"tools": []any{}}
+		case "resources/list":
+			result = map[string]any{"resources": []map[string]any{
+				{"uri": "wordlist://common", "name": "common.txt", "description": "Common paths"},
+			}}
+		case "resources/read":
+			result = map[string]any{"contents": []map[string]any{
+				{"uri": req.Params["uri"], "text": "admin\nbackup\n"},
+			}}
+		case "prompts/list":
+			result = map[string]any{"prompts": []map[string]any{
+				{"name": "sqli", "arguments": []map[string]any{{"name": "url", "required": true}}},
+			}}
+		case "prompts/get":
+			args, _ := req.Params["arguments"].(map[string]any)
+			result = map[string]any{"messages": []map[string]any{
+				{"role": "user", "content": map[string]any{"type": "text", "text": fmt.Sprintf("Test %v for SQLi", args["url"])}},
+			}}
+		}
+		data, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": *req.ID, "result": result})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "mcp.yaml")
+	if err := os.WriteFile(path, []byte("servers:\n  - name: lists\n    url: "+srv.URL+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := mcp.NewManager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.StartAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = mgr.Close() })
+	return mgr
+}
+
+func TestHandleMCPResourcesAndPrompts(t *testing.T) {
+	loop := &Loop{
+		target: NewTarget(1, "10.0.0.5"),
+		events: make(chan Event, 64),
+		mcpMgr: newResourceMCPManager(t),
+	}
+	ctx := context.Background()
+
+	loop.handleListMCPResources(ctx, &schema.Action{Action: schema.ActionListMCPResources})
+	for _, want := range []string{"[lists] wordlist://common — common.txt: Common paths", "[lists] sqli (args: url=<required>)"} {
+		if !strings.Contains(loop.lastToolOutput, want) {
+			t.Errorf("catalog should contain %q, got:\n%s", want, loop.lastToolOutput)
+		}
+	}
+
+	loop.handleReadMCPResource(ctx, &schema.Action{MCPServer: "lists", MCPURI: "wordlist://common"})
+	if loop.lastToolOutput != "admin\nbackup" {
+		t.Errorf("resource content: got %q", loop.lastToolOutput)
+	}
+
+	loop.handleGetMCPPrompt(ctx, &schema.Action{MCPServer: "lists", MCPPrompt: "sqli",
+		MCPArgs: map[string]any{"url": "http://10.0.0.5/item?id=1"}})
+	if !strings.Contains(loop.lastToolOutput, "Test http://10.0.0.5/item?id=1 for SQLi") {
+		t.Errorf("prompt should be expanded with mcp_args, got: %s", loop.lastToolOutput)
+	}
+}
+
+func TestHandleMCPResources_Errors(t *testing.T) {
+	loop := &Loop{target: NewTarget(1, "test"), events: make(chan Event, 64)}
+	ctx := context.Background()
+
+	loop.handleReadMCPResource(ctx, &schema.Action{MCPServer: "lists", MCPURI: "x"})
+	if !strings.Contains(loop.lastToolOutput, "not configured") {
+		t.Errorf("expected 'not configured' error, got: %s", loop.lastToolOutput)
+	}
+
+	loop.mcpMgr = newResourceMCPManager(t)
+	loop.handleReadMCPResource(ctx, &schema.Action{MCPServer: "lists"})
+	if !strings.Contains(loop.lastToolOutput, "requires mcp_server and mcp_uri") {
+		t.Errorf("expected missing argument error, got: %s", loop.lastToolOutput)
+	}
+	loop.handleGetMCPPrompt(ctx, &schema.Action{MCPServer: "unknown", MCPPrompt: "sqli"})
+	if !strings.Contains(loop.lastToolOutput, "unknown server") {
+		t.Errorf("expected unknown server error, got: %s", loop.lastToolOutput)
+	}
+}
+
+func TestFormatMCPContents(t *testing.T) {
+	got := FormatMCPContents([]mcp.ResourceContent{
+		{URI: "a://1", Text: strings.Repeat("x", 50)},
+		{URI: "a://2", MimeType: "application/zip", Blob: "UEsDBA=="},
+	}, 40)
+	if !strings.HasPrefix(got, "--- a://1 ---\n") || !strings.Contains(got, "truncated") {
+		t.Errorf("multiple contents should be labelled and truncated: %q", got)
+	}
+	if got := FormatMCPContents([]mcp.ResourceContent{{Blob: "UEsDBA==", MimeType: "application/zip"}}, 100); !strings.Contains(got, "binary content: application/zip") {
+		t.Errorf("binary content should be summarised: %q", got)
+	}
+	if got := FormatMCPContents(nil, 100); got != "(empty resource)" {
+		t.Errorf("empty: %q", got)
+	}
+}
@@ -0,0 +1,175 @@
+// Package agent - loop_skills.go はスキル呼び出し（ユーザーの /skill-name と Brain の use_skill）の展開と進捗追跡を定義する。
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/0x6d61/pentecter/internal/skills"
+	"github.com/0x6d61/pentecter/pkg/schema"
+)
+
+// skillRun はメインの会話に注入したスキルのステップ進捗を保持する。
+type skillRun struct {
+	inv  *skills.Invocation
+	vars map[string]string
+	done int // 完了済みステップ数
+}
+
+// applySkill はユーザー入力がスキル呼び出しなら検証・展開する。
+// 通常の入力はそのまま返す。検証に失敗した場合はエラー内容を Brain に伝える文面を返す。
+func (l *Loop) applySkill(ctx context.Context, msg string) string {
+	if l.skillsReg == nil || msg == "" {
+		return msg
+	}
+	inv, err := l.skillsReg.Parse(msg)
+	if err != nil {
+		l.emit(Event{Type: EventLog, Source: SourceSystem,
+			Message: fmt.Sprintf("Skill error: %v", err)})
+		return fmt.Sprintf("The user invoked a skill but it could not start: %v\nExplain the problem and ask for corrected arguments.", err)
+	}
+	if inv == nil {
+		return msg
+	}
+
+	text, err := l.startSkill(ctx, inv, inv.Skill.SubAgent)
+	if err != nil {
+		return fmt.Sprintf("The user invoked /%s but it could not start: %v\nTell the user what is needed to run it.", inv.Skill.Name, err)
+	}
+	return text
+}
+
+// handleUseSkill は use_skill アクションを処理する。Brain が自らスキルを読み込む／SubTask として起動する。
+func (l *Loop) handleUseSkill(ctx context.Context, action *schema.Action) {
+	if l.skillsReg == nil {
+		l.lastToolOutput = "Error: no skills are configured"
+		return
+	}
+	args := make(map[string]string, len(action.SkillArgs))
+	for k, v := range action.SkillArgs {
+		args[k] = fmt.Sprint(v)
+	}
+	inv, err := l.skillsReg.Invoke(action.Skill, args)
+	if err != nil {
+		l.emit(Event{Type: EventLog, Source: SourceSystem,
+			Message: fmt.Sprintf("Skill error: %v", err)})
+		l.lastToolOutput = "Error: " + err.Error()
+		return
+	}
+
+	asTask := inv.Skill.SubAgent
+	switch action.SkillMode {
+	case "task":
+		asTask = true
+	case "inline":
+		asTask = false
+	}
+	text, err := l.startSkill(ctx, inv, asTask)
+	if err != nil {
+		l.lastToolOutput = "Error: " + err.Error()
+		return
+	}
+	l.lastToolOutput = text
+}
+
+// startSkill は検証済みのスキル呼び出しを開始し、Brain に渡すテキストを返す。
+// asTask が true かつ SmartSubAgent が利用可能なら SubTask として起動し、
+// それ以外はスキルのプロンプトを展開して返す（steps があれば進捗追跡を開始する）。
+func (l *Loop) startSkill(ctx context.Context, inv *skills.Invocation, asTask bool) (string, error) {
+	sk := inv.Skill
+	if l.runner != nil {
+		if missing := sk.MissingTools(l.runner.HasTool); len(missing) > 0 {
+			l.emit(Event{Type: EventLog, Source: SourceSystem,
+				Message: fmt.Sprintf("Skill /%s requires unavailable tools: %s", sk.Name, strings.Join(missing, ", "))})
+			return "", fmt.Errorf("/%s requires tools that are not available: %s", sk.Name, strings.Join(missing, ", "))
+		}
+	}
+
+	vars := map[string]string{"target": l.target.Host}
+
+	if asTask {
+		if l.taskMgr.CanSpawnSmart() {
+			return l.spawnSkillTask(ctx, inv, vars)
+		}
+		l.emit(Event{Type: EventLog, Source: SourceSystem,
+			Message: fmt.Sprintf("Skill /%s: sub-agent unavailable — running in main conversation", sk.Name)})
+	}
+
+	l.activeSkill = nil
+	if len(sk.Steps) > 0 {
+		l.activeSkill = &skillRun{inv: inv, vars: vars}
+	}
+	l.emit(Event{Type: EventLog, Source: SourceSystem,
+		Message: fmt.Sprintf("Skill loaded: /%s", sk.Name)})
+	return inv.Prompt(vars), nil
+}
+
+// spawnSkillTask はスキルを SmartSubAgent タスクとして起動し、Brain への通知文を返す。
+func (l *Loop) spawnSkillTask(ctx context.Context, inv *skills.Invocation, vars map[string]string) (string, error) {
+	sk := inv.Skill
+	goal := "Skill /" + sk.Name
+	if sk.Description != "" {
+		goal += ": " + sk.Description
+	}
+	req := SpawnTaskRequest{
+		Kind:       TaskKindSmart,
+		Goal:       goal,
+		Command:    inv.Prompt(vars),
+		TargetHost: l.target.Host,
+		TargetID:   l.target.ID,
+		MaxTurns:   sk.MaxTurns,
+		ReconTree:  l.reconTree,
+	}
+	taskID, err := l.taskMgr.SpawnTask(ctx, req)
+	if err != nil {
+		l.emit(Event{Type: EventLog, Source: SourceSystem,
+			Message: fmt.Sprintf("Failed to spawn skill task: %v", err)})
+		return "", fmt.Errorf("sub-agent task for /%s failed to start: %w", sk.Name, err)
+	}
+
+	l.emit(Event{Type: EventSubTaskStart, TaskID: taskID, Message: goal})
+	l.emit(Event{Type: EventLog, Source: SourceSystem,
+		Message: fmt.Sprintf("Skill /%s started as %s", sk.Name, taskID)})
+	return fmt.Sprintf("Skill /%s started as background task %s. Its result will be delivered when it completes; continue with other work meanwhile.",
+		sk.Name, taskID), nil
+}
+
+// buildSkillProgress は実行中スキルのステップ進捗を Brain 向けテキストで返す。
+func (l *Loop) buildSkillProgress() string {
+	run := l.activeSkill
+	if run == nil {
+		return ""
+	}
+	steps := run.inv.Skill.Steps
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Active skill /%s — step %d/%d. Work through the steps in order:\n",
+		run.inv.Skill.Name, run.done+1, len(steps))
+	sb.WriteString(run.inv.Checklist(run.done, run.vars))
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// advanceSkill は直前のコマンド結果で現在のステップが完了したかを判定し、進捗を進める。
+func (l *Loop) advanceSkill() {
+	run := l.activeSkill
+	if run == nil || l.lastCommand == "" {
+		return
+	}
+	output := l.lastRawOutput
+	if output == "" {
+		output = l.lastToolOutput
+	}
+	sk := run.inv.Skill
+	st := sk.Steps[run.done]
+	if !st.Done(l.lastCommand, output, l.lastExitCode) {
+		return
+	}
+	run.done++
+	l.emit(Event{Type: EventLog, Source: SourceSystem,
+		Message: fmt.Sprintf("Skill /%s: step %d/%d done (%s)", sk.Name, run.done, len(sk.Steps), st.Name)})
+	if run.done == len(sk.Steps) {
+		l.emit(Event{Type: EventLog, Source: SourceSystem,
+			Message: fmt.Sprintf("Skill /%s: all steps complete", sk.Name)})
+		l.activeSkill = nil
+	}
+}
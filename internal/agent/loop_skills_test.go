@@ -0,0 +1,217 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/pentecter/internal/skills"
+	"github.com/0x6d61/pentecter/internal/tools"
+	"github.com/0x6d61/pentecter/pkg/schema"
+)
+
+// newSkillTestLoop はスキルを1つ登録した Loop を構築する。
+func newSkillTestLoop(t *testing.T, skillMD string) *Loop {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "skill.md"), []byte(skillMD), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reg := skills.NewRegistry()
+	if err := reg.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(reg.All()) != 1 {
+		t.Fatalf("skill not loaded: %q", skillMD)
+	}
+
+	toolReg := tools.NewRegistry()
+	toolReg.Register(&tools.ToolDef{Name: "portscan"})
+	return &Loop{
+		target:    NewTarget(1, "10.0.0.5"),
+		events:    make(chan Event, 64),
+		runner:    tools.NewCommandRunner(toolReg, tools.NewBlacklist(nil), tools.NewLogStore()),
+		skillsReg: reg,
+	}
+}
+
+const probeSkill = `---
+name: probe
+description: Probe a web app
+params:
+  - name: url
+    required: true
+  - name: depth
+    type: int
+    default: 2
+requires: [portscan]
+steps:
+  - name: Port scan
+    tool: portscan
+    command: portscan {{target}}
+    success: "80/tcp\\s+open"
+  - name: Fetch
+    tool: curl
+    command: curl -ik {{url}}
+---
+
+Probe {{url}} on {{target}} to depth {{depth}}.
+`
+
+func TestApplySkill_InjectAndTrackSteps(t *testing.T) {
+	l := newSkillTestLoop(t, probeSkill)
+
+	got := l.applySkill(context.Background(), "/probe url=http://10.0.0.5/app")
+	if !strings.Contains(got, "Probe http://10.0.0.5/app on 10.0.0.5 to depth 2.") {
+		t.Errorf("prompt not expanded: %q", got)
+	}
+	if l.activeSkill == nil {
+		t.Fatal("expected active skill run")
+	}
+	if p := l.buildSkillProgress(); !strings.Contains(p, "step 1/2") || !strings.Contains(p, "portscan 10.0.0.5") {
+		t.Errorf("progress: %q", p)
+	}
+
+	// ツールは一致するが成功条件を満たさない → 進まない
+	l.lastCommand, l.lastRawOutput, l.lastExitCode = "portscan 10.0.0.5", "80/tcp closed", 0
+	l.advanceSkill()
+	if l.activeSkill.done != 0 {
+		t.Fatalf("step should not advance, done=%d", l.activeSkill.done)
+	}
+
+	l.lastRawOutput = "80/tcp   open  http"
+	l.advanceSkill()
+	if l.activeSkill.done != 1 {
+		t.Fatalf("step 1 should be done, done=%d", l.activeSkill.done)
+	}
+
+	// 別ツールのコマンドは現在のステップに数えない
+	l.lastCommand, l.lastRawOutput = "nmap 10.0.0.5", ""
+	l.advanceSkill()
+	if l.activeSkill.done != 1 {
+		t.Fatalf("other tool should not advance, done=%d", l.activeSkill.done)
+	}
+
+	// success 未指定のステップは exit code 0 で完了
+	l.lastCommand, l.lastExitCode = "curl -ik http://10.0.0.5/app", 0
+	l.advanceSkill()
+	if l.activeSkill != nil {
+		t.Error("skill run should finish after last step")
+	}
+	if l.buildSkillProgress() != "" {
+		t.Error("progress should be empty after completion")
+	}
+}
+
+func TestStreamAndCollect_ExecutionErrorDoesNotAdvanceSkill(t *testing.T) {
+	l := newSkillTestLoop(t, probeSkill)
+	l.applySkill(context.Background(), "/probe url=http://10.0.0.5/app")
+	// 前のコマンドの出力は成功条件を満たしている
+	l.lastCommand, l.lastRawOutput = "portscan 10.0.0.5", "80/tcp   open  http"
+
+	linesCh := make(chan tools.OutputLine)
+	close(linesCh)
+	resultCh := make(chan *tools.ToolResult, 1)
+	resultCh <- &tools.ToolResult{ExitCode: 0, Err: errors.New("shell not found")}
+	l.streamAndCollect(context.Background(), linesCh, resultCh)
+
+	if l.activeSkill == nil || l.activeSkill.done != 0 {
+		t.Errorf("failed execution should not advance the step: %+v", l.activeSkill)
+	}
+	if l.lastRawOutput != "" {
+		t.Errorf("lastRawOutput should be reset on execution error, got %q", l.lastRawOutput)
+	}
+}
+
+func TestApplySkill_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		skill string
+		input string
+		want  string
+	}{
+		{"missing param", probeSkill, "/probe", `missing required parameter "url"`},
+		{"bad int", probeSkill, "/probe url=http://x depth=deep", "depth must be an integer"},
+		{"missing tool", strings.Replace(probeSkill, "requires: [portscan]", "requires: [portscan, no-such-tool-xyz]", 1),
+			"/probe http://x", "no-such-tool-xyz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newSkillTestLoop(t, tt.skill)
+			got := l.applySkill(context.Background(), tt.input)
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("got %q, want substring %q", got, tt.want)
+			}
+			if l.activeSkill != nil {
+				t.Error("failed invocation should not start a skill run")
+			}
+		})
+	}
+}
+
+func TestApplySkill_PassThrough(t *testing.T) {
+	l := newSkillTestLoop(t, probeSkill)
+	for _, msg := range []string{"", "focus on port 445", "/unknown arg"} {
+		if got := l.applySkill(context.Background(), msg); got != msg {
+			t.Errorf("applySkill(%q) = %q, want unchanged", msg, got)
+		}
+	}
+}
+
+func TestApplySkill_SubAgentFallback(t *testing.T) {
+	// SubBrain がない場合はメインの会話に注入する
+	l := newSkillTestLoop(t, strings.Replace(probeSkill, "requires: [portscan]", "requires: [portscan]\nsubagent: true", 1))
+	got := l.applySkill(context.Background(), "/probe http://x")
+	if !strings.Contains(got, "Probe http://x") {
+		t.Errorf("expected inline prompt, got %q", got)
+	}
+	if l.activeSkill == nil {
+		t.Error("expected active skill run in fallback mode")
+	}
+}
+
+func TestHandleUseSkill(t *testing.T) {
+	l := newSkillTestLoop(t, probeSkill)
+
+	l.handleUseSkill(context.Background(), &schema.Action{
+		Action:    schema.ActionUseSkill,
+		Skill:     "probe",
+		SkillArgs: map[string]any{"url": "http://10.0.0.5/", "depth": 4},
+	})
+	if !strings.Contains(l.lastToolOutput, "Probe http://10.0.0.5/ on 10.0.0.5 to depth 4.") {
+		t.Errorf("lastToolOutput: %q", l.lastToolOutput)
+	}
+	if l.activeSkill == nil {
+		t.Error("inline use_skill should start step tracking")
+	}
+}
+
+func TestHandleUseSkill_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		action *schema.Action
+		want   string
+	}{
+		{"unknown skill", &schema.Action{Skill: "nope"}, `unknown skill "nope" (available: probe)`},
+		{"unknown arg", &schema.Action{Skill: "probe", SkillArgs: map[string]any{"url": "x", "port": 80}}, `unknown parameter "port"`},
+		{"missing arg", &schema.Action{Skill: "probe"}, `missing required parameter "url"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newSkillTestLoop(t, probeSkill)
+			l.handleUseSkill(context.Background(), tt.action)
+			if !strings.HasPrefix(l.lastToolOutput, "Error: ") || !strings.Contains(l.lastToolOutput, tt.want) {
+				t.Errorf("lastToolOutput: got %q, want error containing %q", l.lastToolOutput, tt.want)
+			}
+		})
+	}
+
+	l := &Loop{target: NewTarget(1, "10.0.0.5"), events: make(chan Event, 8)}
+	l.handleUseSkill(context.Background(), &schema.Action{Skill: "probe"})
+	if l.lastToolOutput != "Error: no skills are configured" {
+		t.Errorf("nil registry: got %q", l.lastToolOutput)
+	}
+}
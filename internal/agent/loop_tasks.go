@@ -158,16 +158,23 @@ func (l *Loop) buildTaskResult(task *SubTask) string {
 
 	// Entity をターゲットに追加
 	if len(task.Entities) > 0 {
-		l.target.AddEntities(task.Entities)
+		l.target.AddEntities(EnrichEntities(task.Entities, l.vulnDB))
 	}
 
-	// ReconTree 連携: web_recon SubTask 完了時にポートの全タスクを Complete にする
-	if l.reconTree != nil && task.Metadata.Phase == "web_recon" && task.Metadata.Port > 0 {
+	// ReconTree 連携: web_recon / service_recon SubTask 完了時にポートの全タスクを Complete にする
+	isReconPhase := task.Metadata.Phase == "web_recon" || task.Metadata.Phase == "service_recon"
+	if l.reconTree != nil && isReconPhase && task.Metadata.Port > 0 {
 		l.reconTree.CompleteAllPortTasks(task.Metadata.Port)
+		// SubAgent が検出した技術スタックも CVE 突き合わせに使う
+		EnrichReconTree(l.reconTree, l.vulnDB)
 
 		// Target に最新の ReconTree を反映
 		l.target.SetReconTree(l.reconTree)
 	}
+	if l.reconTree != nil && task.Metadata.Phase == "playbook" && task.Metadata.Port > 0 {
+		l.reconTree.CompletePlaybook(task.Metadata.Port, task.Metadata.Playbook)
+		l.target.SetReconTree(l.reconTree)
+	}
 
 	return sb.String()
 }
@@ -3,13 +3,17 @@ package agent_test
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/0x6d61/pentecter/internal/agent"
 	"github.com/0x6d61/pentecter/internal/brain"
+	"github.com/0x6d61/pentecter/internal/mcp"
 	"github.com/0x6d61/pentecter/internal/memory"
+	"github.com/0x6d61/pentecter/internal/skills"
 	"github.com/0x6d61/pentecter/internal/tools"
 	"github.com/0x6d61/pentecter/pkg/schema"
 )
@@ -439,6 +443,41 @@ func TestLoop_Run_BrainError_MaxRetries_Fails(t *testing.T) {
 	}
 }
 
+func TestLoop_Run_BrainError_AuthNotRetried(t *testing.T) {
+	target := agent.NewTarget(1, "10.0.0.1")
+	// 認証エラーは再試行せずにすぐ失敗する
+	mb := &mockBrain{
+		errors: []error{&brain.APIError{Provider: "anthropic", StatusCode: 401, Body: "invalid x-api-key"}},
+	}
+
+	loop, events, _, _ := newTestLoop(target, mb)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go loop.Run(ctx)
+
+	deadline := time.After(500 * time.Millisecond)
+	for {
+		select {
+		case e := <-events:
+			if e.Type == agent.EventLog && strings.Contains(e.Message, "retrying") {
+				t.Fatalf("auth error should not be retried: %s", e.Message)
+			}
+			if e.Type == agent.EventError {
+				if !strings.Contains(e.Message, "(auth)") {
+					t.Errorf("expected classified error, got %q", e.Message)
+				}
+				if len(mb.inputs) != 1 {
+					t.Errorf("expected 1 Think call, got %d", len(mb.inputs))
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatal("timeout: expected EventError without retries")
+		}
+	}
+}
+
 func TestLoop_Run_Stalled_WaitsForUser(t *testing.T) {
 	target := agent.NewTarget(1, "10.0.0.1")
 	// 3 consecutive commands that produce failure output (Signal A: exit code + Signal B: pattern),
@@ -831,6 +870,78 @@ func TestLoop_Run_CallMCP_NoManager(t *testing.T) {
 	}
 }
 
+func TestLoop_Run_CallMCP_Policy(t *testing.T) {
+	// スコープ外 URL は拒否、browser_click は引数付きの承認提案になる
+	path := filepath.Join(t.TempDir(), "mcp.yaml")
+	cfg := `servers:
+  - name: playwright
+    command: npx
+    tools:
+      browser_click:
+        proposal_required: true
+    rules:
+      - tool: browser_navigate
+        arg: url
+        not_match: '^http://10\.0\.0\.1/'
+        action: deny
+        reason: out of scope
+`
+	if err := os.WriteFile(path, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := mcp.NewManager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := agent.NewTarget(1, "10.0.0.1")
+	mb := &mockBrain{
+		actions: []*schema.Action{
+			{Thought: "open external site", Action: schema.ActionCallMCP,
+				MCPServer: "playwright", MCPTool: "browser_navigate",
+				MCPArgs: map[string]any{"url": "http://evil.example/"}},
+			{Thought: "submit login form", Action: schema.ActionCallMCP,
+				MCPServer: "playwright", MCPTool: "browser_click",
+				MCPArgs: map[string]any{"ref": "e12"}},
+		},
+	}
+	loop, events, approve, _ := newTestLoop(target, mb)
+	loop.WithMCP(mgr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go loop.Run(ctx)
+
+	deadline := time.After(4 * time.Second)
+	gotDenied := false
+	for {
+		select {
+		case e := <-events:
+			if e.Type == agent.EventLog && strings.Contains(e.Message, "MCP call denied") &&
+				strings.Contains(e.Message, "out of scope") {
+				gotDenied = true
+			}
+			if e.Type == agent.EventProposal {
+				if !gotDenied {
+					t.Error("out-of-scope navigate should be denied before the click proposal")
+				}
+				if e.Proposal.Tool != "[MCP] playwright.browser_click" || !strings.Contains(e.Proposal.MCPArgs, `"ref": "e12"`) {
+					t.Errorf("proposal should show the MCP tool and its arguments: %+v", e.Proposal)
+				}
+				approve <- false
+			}
+			if e.Type == agent.EventComplete {
+				if len(mb.inputs) < 3 || !strings.Contains(mb.inputs[2].ToolOutput, "User rejected") {
+					t.Errorf("rejection should be reported to the brain: %+v", mb.inputs)
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatal("timeout waiting for EventComplete")
+		}
+	}
+}
+
 // --- SubTask integration tests ---
 
 // newTestLoopWithTaskManager はテスト用の Loop + TaskManager を構築する。
@@ -915,6 +1026,57 @@ func TestLoop_Run_SpawnTask_Wait(t *testing.T) {
 	}
 }
 
+func TestLoop_Run_SkillAsSubAgent(t *testing.T) {
+	dir := t.TempDir()
+	md := "---\nname: bg-probe\ndescription: Background probe\nsubagent: true\nparams:\n  - name: port\n    type: int\n    required: true\n---\n\nProbe port {{port}} on {{target}}."
+	if err := os.WriteFile(filepath.Join(dir, "bg-probe.md"), []byte(md), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reg := skills.NewRegistry()
+	_ = reg.LoadDir(dir)
+
+	target := agent.NewTarget(1, "10.0.0.1")
+	mb := &mockBrain{
+		actions: []*schema.Action{
+			{Thought: "ack", Action: schema.ActionThink},
+			{Thought: "waiting for skill", Action: schema.ActionWait},
+		},
+	}
+	loop, taskMgr, events, _, userMsg := newTestLoopWithTaskManager(target, mb)
+	loop.WithSkills(reg)
+	userMsg <- "/bg-probe 8080"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go loop.Run(ctx)
+
+	deadline := time.After(8 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.Type != agent.EventComplete {
+				continue
+			}
+			if !strings.Contains(mb.inputs[0].UserMessage, "background task task-1") {
+				t.Errorf("Brain should be told about the background task, got: %s", mb.inputs[0].UserMessage)
+			}
+			task, ok := taskMgr.GetTask("task-1")
+			if !ok {
+				t.Fatal("task-1 not spawned")
+			}
+			if task.Goal != "Skill /bg-probe: Background probe" {
+				t.Errorf("Goal: got %q", task.Goal)
+			}
+			if !strings.Contains(task.Command, "Probe port 8080 on 10.0.0.1.") {
+				t.Errorf("Command should carry the expanded skill prompt, got %q", task.Command)
+			}
+			return
+		case <-deadline:
+			t.Fatal("timeout waiting for EventComplete")
+		}
+	}
+}
+
 func TestLoop_Run_KillTask(t *testing.T) {
 	target := agent.NewTarget(1, "10.0.0.1")
 	mb := &mockBrain{
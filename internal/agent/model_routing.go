@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/0x6d61/pentecter/internal/brain"
+)
+
+// ModelRoute は用途ごとに Brain を割り当てるルート。
+// メイン Loop（SetBrain）と SmartSubAgent（SetSubBrain）が既定で、
+// ルートに Brain を設定するとその用途だけ別のモデルを使う。
+type ModelRoute string
+
+const (
+	// RouteExploit は脆弱性を記録した後のメイン Loop の思考（エクスプロイトの判断）。
+	RouteExploit ModelRoute = "exploit"
+	// RouteRecon は偵察 SubAgent（ReconRunner・プレイブック・recon/enum フェーズの spawn_task）。
+	RouteRecon ModelRoute = "recon"
+	// RouteExtract は自然文からのターゲット抽出（Brain.ExtractTarget）。
+	RouteExtract ModelRoute = "extract"
+)
+
+// extractTimeout は ExtractTarget 1回の上限。
+const extractTimeout = 60 * time.Second
+
+// isReconPhase は偵察 SubAgent として扱うタスクのフェーズかを返す。
+func isReconPhase(phase string) bool {
+	switch phase {
+	case "recon", "enum", "web_recon", "service_recon", "playbook":
+		return true
+	}
+	return false
+}
+
+// loopsWithoutOverride はターゲット単位の上書きがない Loop を返す。t.mu を保持して呼ぶこと。
+func (t *Team) loopsWithoutOverride() []*Loop {
+	var loops []*Loop
+	for _, loop := range t.loops {
+		if _, ok := t.targetBrains[loop.target.Host]; !ok {
+			loops = append(loops, loop)
+		}
+	}
+	return loops
+}
+
+// SetExploitBrain は exploit ルートの Brain を差し替える（nil = メインの Brain）。
+// ターゲット単位で上書きしている Loop には反映しない。
+func (t *Team) SetExploitBrain(br brain.Brain) {
+	t.mu.Lock()
+	t.exploitBr = br
+	loops := t.loopsWithoutOverride()
+	t.mu.Unlock()
+
+	for _, loop := range loops {
+		loop.SetExploitBrain(br)
+	}
+}
+
+// SetReconBrain は recon ルート（偵察 SubAgent）の Brain を差し替える（nil = SubBrain）。
+func (t *Team) SetReconBrain(br brain.Brain) {
+	t.taskMgr.SetReconBrain(br)
+}
+
+// SetExtractBrain は extract ルート（ターゲット抽出）の Brain を差し替える（nil = メインの Brain）。
+func (t *Team) SetExtractBrain(br brain.Brain) {
+	t.mu.Lock()
+	t.extractBr = br
+	t.mu.Unlock()
+}
+
+// SetTargetBrain は host の Loop だけが使う Brain を設定する（nil = 上書きを解除）。
+// 上書き中はメインと exploit の両方をこの Brain で置き換える。
+// まだ追加されていないホストにも設定でき、AddTarget 時に反映される。
+func (t *Team) SetTargetBrain(host string, br brain.Brain) {
+	t.mu.Lock()
+	if br == nil {
+		delete(t.targetBrains, host)
+	} else {
+		if t.targetBrains == nil {
+			t.targetBrains = make(map[string]brain.Brain)
+		}
+		t.targetBrains[host] = br
+	}
+	main, exploit := t.br, t.exploitBr
+	var target *Loop
+	for _, loop := range t.loops {
+		if loop.target.Host == host {
+			target = loop
+			break
+		}
+	}
+	t.mu.Unlock()
+
+	if target == nil {
+		return
+	}
+	if br != nil {
+		main, exploit = br, nil
+	}
+	target.SetBrain(main)
+	target.SetExploitBrain(exploit)
+}
+
+// ExtractTarget はユーザーの自然文からターゲットを抽出する（正規表現で見つからないときのフォールバック）。
+// extract ルートの Brain（未設定ならメインの Brain）を別 goroutine で呼び、見つかれば
+// EventAddTarget（Message に残りの指示）を、見つからなければシステムログを送る。
+func (t *Team) ExtractTarget(text string) {
+	t.mu.Lock()
+	br := t.extractBr
+	if br == nil {
+		br = t.br
+	}
+	ctx := t.ctx
+	t.mu.Unlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	go func() {
+		ectx, cancel := context.WithTimeout(ctx, extractTimeout)
+		defer cancel()
+
+		e := Event{Type: EventLog, Source: SourceSystem}
+		host, instruction, err := br.ExtractTarget(ectx, text)
+		switch {
+		case err != nil:
+			e.Message = fmt.Sprintf("Target extraction failed: %v", err)
+		case host == "":
+			e.Message = "No target found in your message. Add one with /target <host>."
+		default:
+			e = Event{Type: EventAddTarget, NewHost: host, Message: instruction}
+		}
+		select {
+		case t.events <- e:
+		case <-ctx.Done():
+		}
+	}()
+}
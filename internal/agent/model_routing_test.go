@@ -0,0 +1,146 @@
+package agent_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+	"github.com/0x6d61/pentecter/pkg/schema"
+)
+
+// extractBrain は ExtractTarget の結果を固定で返す mockBrain。
+type extractBrain struct {
+	mockBrain
+	host, instruction string
+}
+
+func (b *extractBrain) ExtractTarget(_ context.Context, _ string) (string, string, error) {
+	return b.host, b.instruction, nil
+}
+
+// waitComplete は targetID の EventComplete を待つ。
+func waitComplete(t *testing.T, events <-chan agent.Event, targetIDs ...int) {
+	t.Helper()
+	pending := make(map[int]bool, len(targetIDs))
+	for _, id := range targetIDs {
+		pending[id] = true
+	}
+	deadline := time.After(4 * time.Second)
+	for len(pending) > 0 {
+		select {
+		case e := <-events:
+			if e.Type == agent.EventComplete {
+				delete(pending, e.TargetID)
+			}
+		case <-deadline:
+			t.Fatalf("timeout waiting for EventComplete (pending: %v)", pending)
+		}
+	}
+}
+
+func TestLoop_ExploitBrainAfterVulnerability(t *testing.T) {
+	target := agent.NewTarget(1, "10.0.0.1")
+	mainBrain := &mockBrain{
+		actions: []*schema.Action{
+			{Thought: "recon", Action: schema.ActionThink},
+			{Thought: "found it", Action: schema.ActionMemory, Memory: &schema.Memory{
+				Type: schema.MemoryVulnerability, Title: "vsftpd 2.3.4 backdoor", Severity: "critical"}},
+		},
+	}
+	exploitBrain := &mockBrain{}
+	loop, events, _, _ := newTestLoop(target, mainBrain)
+	loop.SetExploitBrain(exploitBrain)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go loop.Run(ctx)
+	waitComplete(t, events, target.ID)
+
+	if len(mainBrain.inputs) != 2 {
+		t.Errorf("main brain should think until the vulnerability is recorded, got %d calls", len(mainBrain.inputs))
+	}
+	if len(exploitBrain.inputs) != 1 {
+		t.Errorf("exploit brain should take over after the vulnerability, got %d calls", len(exploitBrain.inputs))
+	}
+}
+
+func TestTeam_TargetBrainOverride(t *testing.T) {
+	events := make(chan agent.Event, 128)
+	original := &mockBrain{}
+	override := &mockBrain{}
+	team := agent.NewTeam(agent.TeamConfig{Events: events, Brain: original, Runner: newTestRunner()})
+
+	// 追加前に設定した上書きは AddTarget で反映される
+	team.SetTargetBrain("10.0.0.7", override)
+	newMain := &mockBrain{}
+	team.SetBrain(newMain)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	team.Start(ctx)
+	pinned, _, _ := team.AddTarget("10.0.0.7")
+	other, _, _ := team.AddTarget("10.0.0.8")
+	waitComplete(t, events, pinned.ID, other.ID)
+
+	if len(override.inputs) != 1 || len(newMain.inputs) != 1 || len(original.inputs) != 0 {
+		t.Errorf("calls: override=%d newMain=%d original=%d, want 1/1/0",
+			len(override.inputs), len(newMain.inputs), len(original.inputs))
+	}
+}
+
+func TestTeam_ExtractTarget(t *testing.T) {
+	events := make(chan agent.Event, 8)
+	team := agent.NewTeam(agent.TeamConfig{Events: events, Brain: &mockBrain{}, Runner: newTestRunner()})
+
+	next := func() agent.Event {
+		t.Helper()
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for extraction result")
+		}
+		return agent.Event{}
+	}
+
+	// extract ルートがなければメインの Brain（ホストなし）
+	team.ExtractTarget("please look at the staging box")
+	if e := next(); e.Type != agent.EventLog || !strings.Contains(e.Message, "No target found") {
+		t.Errorf("main brain found no host, got %+v", e)
+	}
+
+	team.SetExtractBrain(&extractBrain{host: "staging.corp.local", instruction: "focus on the web app"})
+	team.ExtractTarget("please look at the staging box")
+	if e := next(); e.Type != agent.EventAddTarget || e.NewHost != "staging.corp.local" || e.Message != "focus on the web app" {
+		t.Errorf("extract brain should add the target, got %+v", e)
+	}
+}
+
+func TestTaskManager_ReconBrainForReconPhases(t *testing.T) {
+	events := make(chan agent.Event, 128)
+	sub := &mockBrain{}
+	recon := &mockBrain{}
+	tm := agent.NewTaskManager(newTestRunner(), nil, events, sub)
+	tm.SetReconBrain(recon)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, phase := range []string{"web_recon", "exploit"} {
+		if _, err := tm.SpawnTask(ctx, agent.SpawnTaskRequest{
+			Kind: agent.TaskKindSmart, Goal: phase, Command: "go", TargetHost: "10.0.0.1",
+			MaxTurns: 1, Metadata: agent.TaskMetadata{Phase: phase},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if tm.WaitAny(ctx) == "" {
+			t.Fatal("timeout waiting for the task")
+		}
+	}
+
+	if len(recon.inputs) != 1 || len(sub.inputs) != 1 {
+		t.Errorf("recon phase should use the recon brain and others the sub brain: recon=%d sub=%d",
+			len(recon.inputs), len(sub.inputs))
+	}
+}
@@ -0,0 +1,462 @@
+package agent
+
+import (
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// builtinParsers は組み込みパーサーの一覧。
+func builtinParsers() []OutputParser {
+	return []OutputParser{
+		nmapParser{},
+		ffufParser{},
+		curlParser{},
+		niktoParser{},
+		gobusterParser{},
+		feroxbusterParser{},
+		whatwebParser{},
+		enum4linuxParser{},
+		sslscanParser{},
+		hydraParser{},
+	}
+}
+
+// --- nmap ---
+
+type nmapParser struct{}
+
+func (nmapParser) Name() string { return "nmap" }
+
+// Parse は -oX / -oN / -oA で出力ファイルが指定されていればファイルを、なければ stdout を解析する。
+func (nmapParser) Parse(in ParseInput) (*ParseResult, error) {
+	output := readOutputFile(ExtractNmapOutputFile(in.Command), in.Output)
+	if !strings.Contains(output, "<nmaprun") {
+		// XML がない場合はテキストパーサーにフォールバック
+		return &ParseResult{Ports: parseNmapText(output)}, nil
+	}
+	ports, err := parseNmapXML(output)
+	if err != nil {
+		return nil, err
+	}
+	return &ParseResult{Ports: ports}, nil
+}
+
+// --- ffuf ---
+
+type ffufParser struct{}
+
+func (ffufParser) Name() string { return "ffuf" }
+
+// Parse は JSON 出力（-o <file> または stdout）を解析する。
+// -s 付きの stdout はパス一覧しか出ないため、JSON がなければ何もしない。
+func (ffufParser) Parse(in ParseInput) (*ParseResult, error) {
+	output := readOutputFile(ExtractFfufOutputPath(in.Command), in.Output)
+	if !strings.Contains(output, `"results"`) {
+		return &ParseResult{}, nil
+	}
+	port, parentPath, taskType := parseFfufCommand(in.Command)
+	return parseFfufJSON(output, port, parentPath, taskType)
+}
+
+// --- curl ---
+
+type curlParser struct{}
+
+func (curlParser) Name() string { return "curl" }
+
+// Parse は取得した URL のプロファイリングを完了にする。レスポンスの中身は LLM に任せる。
+func (curlParser) Parse(in ParseInput) (*ParseResult, error) {
+	res := &ParseResult{}
+	if port, curlPath := parseCurlCommand(in.Command); curlPath != "" {
+		res.Completed = append(res.Completed, ParsedTask{Port: port, Path: curlPath, Task: TaskProfiling})
+	}
+	return res, nil
+}
+
+// readOutputFile はツールが出力ファイルに書いた結果を読む。
+// ファイル指定がない・読めない場合は stdout を返す。
+func readOutputFile(path, stdout string) string {
+	if path == "" {
+		return stdout
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return stdout
+	}
+	return string(data)
+}
+
+// --- nikto ---
+
+type niktoParser struct{}
+
+var (
+	niktoTargetPortRe = regexp.MustCompile(`^\+ Target Port:\s+(\d+)`)
+	niktoServerRe     = regexp.MustCompile(`^\+ Server:\s+(.+)$`)
+	niktoPoweredByRe  = regexp.MustCompile(`(?i)x-powered-by header:\s*([^\s.]+(?:\.[0-9][^\s.]*)*)`)
+	// "+ /admin/: ..." / "+ OSVDB-3092: /admin/: ..." / "+ [999986] /admin/: ..."
+	niktoItemRe = regexp.MustCompile(`^\+ (?:(OSVDB-\d+|\[\d+\]):\s*)?(/\S*?):\s+(.+)$`)
+)
+
+func (niktoParser) Name() string { return "nikto" }
+
+func (niktoParser) Parse(in ParseInput) (*ParseResult, error) {
+	res := &ParseResult{}
+	port := portFromCommandURL(in.Command, 80)
+	if p := extractURLFromFlag(in.Command, "-p"); p != "" {
+		if n, err := strconv.Atoi(p); err == nil {
+			port = n
+		}
+	}
+	for _, line := range strings.Split(in.Output, "\n") {
+		line = strings.TrimSpace(line)
+		if m := niktoTargetPortRe.FindStringSubmatch(line); m != nil {
+			port, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if m := niktoServerRe.FindStringSubmatch(line); m != nil {
+			if server := strings.TrimSpace(m[1]); server != "" && !strings.HasPrefix(server, "No banner") {
+				res.Technologies = append(res.Technologies, ParsedTechnology{Port: port, Name: normalizeProduct(server)})
+			}
+			continue
+		}
+		m := niktoItemRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		ref, itemPath, msg := m[1], m[2], strings.TrimSpace(m[3])
+		if t := niktoPoweredByRe.FindStringSubmatch(msg); t != nil {
+			res.Technologies = append(res.Technologies, ParsedTechnology{Port: port, Name: normalizeProduct(t[1])})
+			continue
+		}
+		if itemPath != "/" {
+			res.Endpoints = append(res.Endpoints, ParsedEndpoint{Port: port, Path: itemPath})
+		}
+		title := "nikto"
+		if strings.HasPrefix(ref, "OSVDB-") {
+			title = strings.ToLower(ref)
+		}
+		res.Findings = append(res.Findings, ParsedFinding{
+			Port:     port,
+			Path:     itemPath,
+			Title:    title,
+			Evidence: msg,
+			Severity: niktoSeverity(msg),
+		})
+	}
+	return res, nil
+}
+
+// niktoSeverity はメッセージ内容から深刻度を推定する。
+func niktoSeverity(msg string) string {
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "cve-") || strings.Contains(lower, "remote code") ||
+		strings.Contains(lower, "command execution") || strings.Contains(lower, "sql injection"):
+		return "high"
+	case strings.Contains(lower, "directory indexing") || strings.Contains(lower, "default file") ||
+		strings.Contains(lower, "backup") || strings.Contains(lower, "phpinfo") ||
+		strings.Contains(lower, "admin") || strings.Contains(lower, "password"):
+		return "medium"
+	case strings.Contains(lower, "header is not") || strings.Contains(lower, "not defined") ||
+		strings.Contains(lower, "not present") || strings.Contains(lower, "not set"):
+		return "info"
+	default:
+		return "low"
+	}
+}
+
+// --- gobuster ---
+
+type gobusterParser struct{}
+
+var (
+	gobusterDirRe   = regexp.MustCompile(`^(/\S*)\s+\(Status:\s*(\d{3})\)`)
+	gobusterVhostRe = regexp.MustCompile(`^Found:\s+(\S+)(?:\s+Status:\s*(\d{3}))?`)
+)
+
+func (gobusterParser) Name() string { return "gobuster" }
+
+func (gobusterParser) Parse(in ParseInput) (*ParseResult, error) {
+	res := &ParseResult{}
+	port := portFromCommandURL(in.Command, 80)
+	for _, line := range strings.Split(in.Output, "\n") {
+		line = strings.TrimSpace(line)
+		if m := gobusterDirRe.FindStringSubmatch(line); m != nil {
+			status, _ := strconv.Atoi(m[2])
+			if status == 404 {
+				continue
+			}
+			res.Endpoints = append(res.Endpoints, ParsedEndpoint{Port: port, Path: m[1], Status: status})
+			continue
+		}
+		if m := gobusterVhostRe.FindStringSubmatch(line); m != nil {
+			res.Vhosts = append(res.Vhosts, ParsedVhost{Port: port, Name: m[1]})
+		}
+	}
+	return res, nil
+}
+
+// --- feroxbuster ---
+
+type feroxbusterParser struct{}
+
+// "200      GET       10l       20w      300c http://10.0.0.1/admin"
+var feroxLineRe = regexp.MustCompile(`^(\d{3})\s+[A-Z]+\s+\S+l\s+\S+w\s+\S+c\s+(https?://\S+)`)
+
+func (feroxbusterParser) Name() string { return "feroxbuster" }
+
+func (feroxbusterParser) Parse(in ParseInput) (*ParseResult, error) {
+	res := &ParseResult{}
+	for _, line := range strings.Split(in.Output, "\n") {
+		m := feroxLineRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		status, _ := strconv.Atoi(m[1])
+		if status == 404 {
+			continue
+		}
+		u, err := url.Parse(m[2])
+		if err != nil || u.Path == "" || u.Path == "/" {
+			continue
+		}
+		res.Endpoints = append(res.Endpoints, ParsedEndpoint{Port: portFromURL(u), Path: u.Path, Status: status})
+	}
+	return res, nil
+}
+
+// --- whatweb ---
+
+type whatwebParser struct{}
+
+var (
+	whatwebLineRe   = regexp.MustCompile(`^(https?://\S+)\s+\[\d{3}[^\]]*\]\s*(.*)$`)
+	whatwebPluginRe = regexp.MustCompile(`^([A-Za-z0-9][\w.\-]*)(?:\[([^\]]*)\])?`)
+)
+
+// whatwebSkip は技術スタックとして扱わない whatweb プラグイン。
+var whatwebSkip = map[string]bool{
+	"Country": true, "IP": true, "Title": true, "HTTPServer": true, "X-Powered-By": true,
+	"Email": true, "Script": true, "UncommonHeaders": true, "Cookies": true, "HttpOnly": true,
+	"RedirectLocation": true, "Frame": true, "PasswordField": true, "HTML5": true,
+	"X-Frame-Options": true, "X-XSS-Protection": true, "Strict-Transport-Security": true,
+	"Meta-Author": true, "MetaGenerator": true, "Access-Control-Allow-Methods": true,
+}
+
+func (whatwebParser) Name() string { return "whatweb" }
+
+func (whatwebParser) Parse(in ParseInput) (*ParseResult, error) {
+	res := &ParseResult{}
+	for _, line := range strings.Split(in.Output, "\n") {
+		m := whatwebLineRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		u, err := url.Parse(m[1])
+		if err != nil {
+			continue
+		}
+		port := portFromURL(u)
+		for _, plugin := range splitWhatwebPlugins(m[2]) {
+			pm := whatwebPluginRe.FindStringSubmatch(plugin)
+			if pm == nil || whatwebSkip[pm[1]] {
+				continue
+			}
+			name := pm[1]
+			if pm[2] != "" {
+				name += " " + pm[2]
+			}
+			res.Technologies = append(res.Technologies, ParsedTechnology{Port: port, Name: name})
+		}
+	}
+	return res, nil
+}
+
+// splitWhatwebPlugins は "A[1], B[x, y], C" を角括弧内のカンマを無視して分割する。
+func splitWhatwebPlugins(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				out = append(out, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" {
+		out = append(out, rest)
+	}
+	return out
+}
+
+// --- enum4linux ---
+
+type enum4linuxParser struct{}
+
+var (
+	enumUserRe      = regexp.MustCompile(`user:\[([^\]]+)\]\s+rid:\[`)
+	enumShareRe     = regexp.MustCompile(`^//[^/\s]+/(\S+)\s+Mapping:\s*(\w+),?\s*Listing:\s*(\S+)`)
+	enumNullSession = regexp.MustCompile(`(?i)allows sessions using username '', password ''`)
+)
+
+func (enum4linuxParser) Name() string { return "enum4linux" }
+
+func (enum4linuxParser) Parse(in ParseInput) (*ParseResult, error) {
+	res := &ParseResult{}
+	seenUser := make(map[string]bool)
+	for _, line := range strings.Split(in.Output, "\n") {
+		line = strings.TrimSpace(line)
+		if m := enumUserRe.FindStringSubmatch(line); m != nil {
+			if !seenUser[m[1]] {
+				seenUser[m[1]] = true
+				res.Users = append(res.Users, ParsedUser{Port: 445, Service: "smb", Name: m[1]})
+			}
+			continue
+		}
+		if m := enumShareRe.FindStringSubmatch(line); m != nil {
+			if !strings.EqualFold(m[2], "OK") {
+				continue
+			}
+			severity := "low"
+			if strings.EqualFold(m[3], "OK") {
+				severity = "medium"
+			}
+			res.Findings = append(res.Findings, ParsedFinding{
+				Port:     445,
+				Title:    "smb-share",
+				Evidence: "share " + m[1] + " accessible (Mapping: " + m[2] + ", Listing: " + m[3] + ")",
+				Severity: severity,
+			})
+			continue
+		}
+		if enumNullSession.MatchString(line) {
+			res.Findings = append(res.Findings, ParsedFinding{
+				Port:     445,
+				Title:    "smb-null-session",
+				Evidence: "null session allowed",
+				Severity: "medium",
+			})
+		}
+	}
+	if !res.IsEmpty() {
+		res.Ports = append(res.Ports, ParsedPort{Port: 445, Service: "microsoft-ds"})
+	}
+	return res, nil
+}
+
+// --- sslscan ---
+
+type sslscanParser struct{}
+
+var (
+	sslscanTargetRe   = regexp.MustCompile(`Testing SSL server \S+ on port (\d+)`)
+	sslscanProtocolRe = regexp.MustCompile(`^(SSLv2|SSLv3|TLSv1\.0|TLSv1\.1)\s+enabled`)
+	sslscanCipherRe   = regexp.MustCompile(`^(?:Accepted|Preferred)\s+(\S+)\s+\d+\s+bits\s+(\S+)`)
+	sslscanHeartRe    = regexp.MustCompile(`^(\S+) vulnerable to heartbleed`)
+	sslscanWeakCipher = regexp.MustCompile(`(?i)RC4|DES-CBC|NULL|EXP|anon`)
+)
+
+func (sslscanParser) Name() string { return "sslscan" }
+
+func (sslscanParser) Parse(in ParseInput) (*ParseResult, error) {
+	res := &ParseResult{}
+	port := 443
+	seenPort := false
+	for _, line := range strings.Split(in.Output, "\n") {
+		line = strings.TrimSpace(line)
+		if m := sslscanTargetRe.FindStringSubmatch(line); m != nil {
+			port, _ = strconv.Atoi(m[1])
+			if !seenPort {
+				res.Ports = append(res.Ports, ParsedPort{Port: port, Service: "https"})
+				seenPort = true
+			}
+			continue
+		}
+		if m := sslscanProtocolRe.FindStringSubmatch(line); m != nil {
+			severity := "low"
+			if m[1] == "SSLv2" || m[1] == "SSLv3" {
+				severity = "medium"
+			}
+			res.Findings = append(res.Findings, ParsedFinding{
+				Port: port, Title: "weak-tls-protocol", Evidence: m[1] + " enabled", Severity: severity,
+			})
+			continue
+		}
+		if m := sslscanCipherRe.FindStringSubmatch(line); m != nil {
+			if sslscanWeakCipher.MatchString(m[2]) {
+				res.Findings = append(res.Findings, ParsedFinding{
+					Port: port, Title: "weak-cipher", Evidence: m[1] + " " + m[2], Severity: "medium",
+				})
+			}
+			continue
+		}
+		if m := sslscanHeartRe.FindStringSubmatch(line); m != nil {
+			res.Findings = append(res.Findings, ParsedFinding{
+				Port: port, Title: "heartbleed", Evidence: m[1] + " vulnerable to heartbleed (CVE-2014-0160)", Severity: "high",
+			})
+		}
+	}
+	return res, nil
+}
+
+// --- hydra ---
+
+type hydraParser struct{}
+
+// "[22][ssh] host: 10.0.0.1   login: admin   password: secret"
+var hydraCredRe = regexp.MustCompile(`^\[(\d+)\]\[([\w\-]+)\]\s+host:\s+\S+\s+login:\s+(\S+)\s+password:\s*(.*)$`)
+
+func (hydraParser) Name() string { return "hydra" }
+
+func (hydraParser) Parse(in ParseInput) (*ParseResult, error) {
+	res := &ParseResult{}
+	seenPort := make(map[int]bool)
+	for _, line := range strings.Split(in.Output, "\n") {
+		m := hydraCredRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		port, _ := strconv.Atoi(m[1])
+		service := m[2]
+		if !seenPort[port] {
+			seenPort[port] = true
+			res.Ports = append(res.Ports, ParsedPort{Port: port, Service: hydraServiceName(service)})
+		}
+		res.Credentials = append(res.Credentials, ParsedCredential{
+			Port:     port,
+			Service:  service,
+			Username: m[3],
+			Password: strings.TrimSpace(m[4]),
+		})
+	}
+	return res, nil
+}
+
+// hydraServiceName は hydra のモジュール名を nmap 互換のサービス名に寄せる。
+func hydraServiceName(module string) string {
+	switch {
+	case strings.HasPrefix(module, "http-") || module == "http":
+		return "http"
+	case strings.HasPrefix(module, "https-") || module == "https":
+		return "https"
+	default:
+		return module
+	}
+}
+
+// normalizeProduct は "Apache/2.4.49 (Unix)" → "Apache 2.4.49 (Unix)" のように区切りを揃える。
+func normalizeProduct(s string) string {
+	return strings.TrimSpace(strings.Replace(s, "/", " ", 1))
+}
@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/pentecter/pkg/schema"
+)
+
+const testNiktoOutput = `- Nikto v2.5.0
+---------------------------------------------------------------------------
++ Target IP:          10.10.11.100
++ Target Hostname:    10.10.11.100
++ Target Port:        8080
++ Start Time:         2025-01-01 00:00:00 (GMT0)
+---------------------------------------------------------------------------
++ Server: Apache/2.4.49 (Unix)
++ /: Retrieved x-powered-by header: PHP/7.4.3.
++ /: The anti-clickjacking X-Frame-Options header is not present.
++ OSVDB-3268: /backup/: Directory indexing found.
++ /phpinfo.php: Output from the phpinfo() function was found.
++ 8102 requests: 0 error(s) and 4 item(s) reported on remote host`
+
+func TestNiktoParser(t *testing.T) {
+	res, err := niktoParser{}.Parse(ParseInput{Command: "nikto -h 10.10.11.100 -p 8080", Output: testNiktoOutput})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Technologies) != 2 {
+		t.Fatalf("Technologies = %+v, want 2", res.Technologies)
+	}
+	if res.Technologies[0].Name != "Apache 2.4.49 (Unix)" || res.Technologies[0].Port != 8080 {
+		t.Errorf("tech[0] = %+v", res.Technologies[0])
+	}
+	if res.Technologies[1].Name != "PHP 7.4.3" {
+		t.Errorf("tech[1] = %q, want 'PHP 7.4.3'", res.Technologies[1].Name)
+	}
+
+	// "/" の header 指摘 + /backup/ + /phpinfo.php
+	if len(res.Findings) != 3 {
+		t.Fatalf("Findings = %+v, want 3", res.Findings)
+	}
+	if res.Findings[0].Severity != "info" {
+		t.Errorf("header finding severity = %q, want info", res.Findings[0].Severity)
+	}
+	if res.Findings[1].Title != "osvdb-3268" || res.Findings[1].Path != "/backup/" {
+		t.Errorf("finding[1] = %+v", res.Findings[1])
+	}
+	if len(res.Endpoints) != 2 {
+		t.Errorf("Endpoints = %+v, want 2", res.Endpoints)
+	}
+}
+
+func TestGobusterParser_Dir(t *testing.T) {
+	output := `===============================================================
+/admin                (Status: 301) [Size: 314] [--> http://10.10.11.100:8000/admin/]
+/index.php            (Status: 200) [Size: 1520]
+/missing              (Status: 404) [Size: 0]
+===============================================================`
+	res, _ := gobusterParser{}.Parse(ParseInput{Command: "gobuster dir -u http://10.10.11.100:8000 -w words.txt", Output: output})
+
+	if len(res.Endpoints) != 2 {
+		t.Fatalf("Endpoints = %+v, want 2", res.Endpoints)
+	}
+	if res.Endpoints[0].Path != "/admin" || res.Endpoints[0].Port != 8000 || res.Endpoints[0].Status != 301 {
+		t.Errorf("endpoint[0] = %+v", res.Endpoints[0])
+	}
+}
+
+func TestGobusterParser_Vhost(t *testing.T) {
+	output := "Found: dev.example.htb Status: 200 [Size: 1234]\nFound: admin.example.htb Status: 403 [Size: 10]"
+	res, _ := gobusterParser{}.Parse(ParseInput{Command: "gobuster vhost -u http://example.htb -w subs.txt", Output: output})
+
+	if len(res.Vhosts) != 2 || res.Vhosts[0].Name != "dev.example.htb" || res.Vhosts[0].Port != 80 {
+		t.Errorf("Vhosts = %+v", res.Vhosts)
+	}
+}
+
+func TestFeroxbusterParser(t *testing.T) {
+	output := `200      GET       10l       20w      300c https://10.10.11.100/login
+301      GET        9l       28w      320c https://10.10.11.100/static => https://10.10.11.100/static/
+404      GET        1l        2w       10c https://10.10.11.100/nope`
+	res, _ := feroxbusterParser{}.Parse(ParseInput{Output: output})
+
+	if len(res.Endpoints) != 2 {
+		t.Fatalf("Endpoints = %+v, want 2", res.Endpoints)
+	}
+	if res.Endpoints[0].Port != 443 || res.Endpoints[0].Path != "/login" {
+		t.Errorf("endpoint[0] = %+v", res.Endpoints[0])
+	}
+}
+
+func TestWhatwebParser(t *testing.T) {
+	output := `http://10.10.11.100 [200 OK] Apache[2.4.49], Country[RESERVED][ZZ], HTTPServer[Unix][Apache/2.4.49 (Unix)], IP[10.10.11.100], JQuery[3.5.1], PHP[7.4.3], Title[Welcome, friend], WordPress`
+	res, _ := whatwebParser{}.Parse(ParseInput{Output: output})
+
+	var names []string
+	for _, tech := range res.Technologies {
+		names = append(names, tech.Name)
+	}
+	got := strings.Join(names, "|")
+	want := "Apache 2.4.49|JQuery 3.5.1|PHP 7.4.3|WordPress"
+	if got != want {
+		t.Errorf("technologies = %q, want %q", got, want)
+	}
+}
+
+func TestEnum4linuxParser(t *testing.T) {
+	output := `[+] Server 10.10.11.100 allows sessions using username '', password ''
+user:[administrator] rid:[0x1f4]
+user:[bob] rid:[0x3e8]
+//10.10.11.100/ADMIN$	Mapping: DENIED, Listing: N/A
+//10.10.11.100/public	Mapping: OK, Listing: OK`
+	res, _ := enum4linuxParser{}.Parse(ParseInput{Output: output})
+
+	if len(res.Users) != 2 || res.Users[1].Name != "bob" {
+		t.Errorf("Users = %+v", res.Users)
+	}
+	// ユーザー名だけではログインできないため認証情報の memory にはしない
+	for _, m := range res.Memories() {
+		if m.Type == schema.MemoryCredential {
+			t.Errorf("username-only credential memory recorded: %+v", m)
+		}
+	}
+	if len(res.Findings) != 2 {
+		t.Fatalf("Findings = %+v, want 2 (null session + public share)", res.Findings)
+	}
+	if res.Findings[1].Title != "smb-share" || !strings.Contains(res.Findings[1].Evidence, "public") {
+		t.Errorf("share finding = %+v", res.Findings[1])
+	}
+	if len(res.Ports) != 1 || res.Ports[0].Port != 445 {
+		t.Errorf("Ports = %+v, want 445", res.Ports)
+	}
+}
+
+func TestSslscanParser(t *testing.T) {
+	output := `Testing SSL server 10.10.11.100 on port 8443 using SNI name 10.10.11.100
+  SSLv3     enabled
+  TLSv1.2   enabled
+Accepted  TLSv1.2  128 bits  RC4-SHA
+Preferred TLSv1.2  256 bits  ECDHE-RSA-AES256-GCM-SHA384   Curve P-256 DHE 256
+TLSv1.2 vulnerable to heartbleed`
+	res, _ := sslscanParser{}.Parse(ParseInput{Output: output})
+
+	if len(res.Ports) != 1 || res.Ports[0].Port != 8443 {
+		t.Errorf("Ports = %+v, want 8443", res.Ports)
+	}
+	if len(res.Findings) != 3 {
+		t.Fatalf("Findings = %+v, want 3", res.Findings)
+	}
+	if res.Findings[2].Title != "heartbleed" || res.Findings[2].Severity != "high" {
+		t.Errorf("heartbleed finding = %+v", res.Findings[2])
+	}
+}
+
+func TestHydraParser(t *testing.T) {
+	output := `Hydra v9.5 (c) 2023 by van Hauser/THC
+[22][ssh] host: 10.10.11.100   login: admin   password: s3cret!
+[80][http-post-form] host: 10.10.11.100   login: bob   password: hunter2
+1 of 1 target successfully completed, 2 valid passwords found`
+	res, _ := hydraParser{}.Parse(ParseInput{Output: output})
+
+	if len(res.Credentials) != 2 {
+		t.Fatalf("Credentials = %+v, want 2", res.Credentials)
+	}
+	if res.Credentials[0].Username != "admin" || res.Credentials[0].Password != "s3cret!" {
+		t.Errorf("cred[0] = %+v", res.Credentials[0])
+	}
+	if res.Ports[1].Service != "http" {
+		t.Errorf("http-post-form port service = %q, want http", res.Ports[1].Service)
+	}
+}
+
+func TestApplyParserPlugin_SkipsRecordedMemories(t *testing.T) {
+	events := make(chan Event, 32)
+	l := NewLoop(NewTarget(1, "10.10.11.100"), nil, nil, events, nil, nil)
+	l.lastCommand = "hydra -l admin -P pass.txt ssh://10.10.11.100"
+	l.lastRawOutput = `[22][ssh] host: 10.10.11.100   login: admin   password: s3cret!`
+
+	countMemories := func() int {
+		n := 0
+		for len(events) > 0 {
+			if e := <-events; e.Memory != nil {
+				n++
+			}
+		}
+		return n
+	}
+
+	l.applyParserPlugin()
+	if n := countMemories(); n != 1 {
+		t.Fatalf("first run: memories = %d, want 1", n)
+	}
+	// 同じターゲットへの再実行では同じ認証情報を記録しない
+	l.applyParserPlugin()
+	if n := countMemories(); n != 0 {
+		t.Errorf("re-run: memories = %d, want 0", n)
+	}
+}
@@ -0,0 +1,341 @@
+package agent
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/0x6d61/pentecter/internal/tools"
+	"github.com/0x6d61/pentecter/pkg/schema"
+)
+
+// ParseInput はパーサープラグインへの入力。
+type ParseInput struct {
+	Command string // 実行したコマンド（-u 等のフラグからポートを推定するために使う）
+	Output  string // ツールの生出力
+	Host    string // ターゲットホスト
+}
+
+// ParsedPort はパーサーが検出したポート。
+type ParsedPort struct {
+	Port    int
+	Service string
+	Banner  string
+}
+
+// ParsedEndpoint はパーサーが検出したエンドポイント。
+type ParsedEndpoint struct {
+	Port   int
+	Path   string
+	Status int
+}
+
+// ParsedVhost はパーサーが検出した仮想ホスト。
+type ParsedVhost struct {
+	Port int
+	Name string
+}
+
+// ParsedTechnology はパーサーが検出した技術スタック。
+type ParsedTechnology struct {
+	Port int
+	Name string // "Apache 2.4.49", "PHP 7.4.3"
+}
+
+// ParsedUser はパーサーが列挙したユーザー名（パスワードは未判明）。
+type ParsedUser struct {
+	Port    int
+	Service string
+	Name    string
+}
+
+// ParsedCredential はパーサーが検出したログイン可能な認証情報。
+type ParsedCredential struct {
+	Port     int
+	Service  string
+	Username string
+	Password string
+}
+
+// ParsedFinding はパーサーが検出した脆弱性・設定不備。
+type ParsedFinding struct {
+	Port     int
+	Path     string // 空なら "/"
+	Title    string
+	Evidence string
+	Severity string // "high", "medium", "low", "info"
+}
+
+// ParsedTask はツールの実行で完了した偵察タスク（結果ゼロでも列挙自体は済んでいる）。
+type ParsedTask struct {
+	Port int
+	Path string // 空なら "/"
+	Task ReconTaskType
+}
+
+// ParseResult はパーサープラグインの構造化出力。
+type ParseResult struct {
+	Parser       string
+	Ports        []ParsedPort
+	Endpoints    []ParsedEndpoint
+	Vhosts       []ParsedVhost
+	Technologies []ParsedTechnology
+	Users        []ParsedUser
+	Credentials  []ParsedCredential
+	Findings     []ParsedFinding
+	Completed    []ParsedTask
+}
+
+// IsEmpty は何も検出されなかったかを返す。
+func (r *ParseResult) IsEmpty() bool {
+	return r == nil || (len(r.Ports) == 0 && len(r.Endpoints) == 0 && len(r.Vhosts) == 0 &&
+		len(r.Technologies) == 0 && len(r.Users) == 0 && len(r.Credentials) == 0 &&
+		len(r.Findings) == 0 && len(r.Completed) == 0)
+}
+
+// OutputParser はツール出力を構造化するパーサープラグイン。
+// ツール定義 YAML の parser: フィールドで名前により参照される。
+type OutputParser interface {
+	Name() string
+	Parse(in ParseInput) (*ParseResult, error)
+}
+
+// ParserRegistry は名前 → OutputParser のレジストリ。
+type ParserRegistry struct {
+	mu      sync.RWMutex
+	parsers map[string]OutputParser
+}
+
+// NewParserRegistry は空のレジストリを返す。
+func NewParserRegistry() *ParserRegistry {
+	return &ParserRegistry{parsers: make(map[string]OutputParser)}
+}
+
+// DefaultParsers は組み込みパーサーを登録済みのレジストリを返す。
+func DefaultParsers() *ParserRegistry {
+	r := NewParserRegistry()
+	for _, p := range builtinParsers() {
+		r.Register(p)
+	}
+	return r
+}
+
+// Register はパーサーを登録する。同名のパーサーは上書きされる。
+func (r *ParserRegistry) Register(p OutputParser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parsers[p.Name()] = p
+}
+
+// Get は名前でパーサーを取得する。
+func (r *ParserRegistry) Get(name string) (OutputParser, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.parsers[name]
+	return p, ok
+}
+
+// Names は登録済みパーサー名をソートして返す。
+func (r *ParserRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.parsers))
+	for name := range r.parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve はコマンドに対応するパーサーを返す。
+// ツール定義に parser: があればそれを優先し、なければバイナリ名と同名のパーサーを使う。
+func (r *ParserRegistry) Resolve(command string, runner *tools.CommandRunner) (OutputParser, bool) {
+	if r == nil {
+		return nil, false
+	}
+	bin := extractToolName(command)
+	if runner != nil {
+		if def, ok := runner.LookupTool(bin); ok && def.Parser != "" {
+			return r.Get(def.Parser)
+		}
+	}
+	return r.Get(bin)
+}
+
+// Run はコマンドに対応するパーサーで出力を解析する。パーサーがなければ nil を返す。
+func (r *ParserRegistry) Run(command, output, host string, runner *tools.CommandRunner) (*ParseResult, error) {
+	p, ok := r.Resolve(command, runner)
+	if !ok {
+		return nil, nil
+	}
+	res, err := p.Parse(ParseInput{Command: command, Output: output, Host: host})
+	if err != nil {
+		return nil, fmt.Errorf("%s parser: %w", p.Name(), err)
+	}
+	if res != nil && res.Parser == "" {
+		res.Parser = p.Name()
+	}
+	return res, nil
+}
+
+// ApplyParseResult はパース結果を ReconTree に反映する。
+func ApplyParseResult(res *ParseResult, tree *ReconTree, host string) {
+	if res == nil || tree == nil {
+		return
+	}
+	for _, p := range res.Ports {
+		tree.AddPort(p.Port, p.Service, p.Banner)
+	}
+	for _, ep := range res.Endpoints {
+		tree.EnsureEndpoint(host, ep.Port, ep.Path)
+	}
+	for _, v := range res.Vhosts {
+		tree.AddVhost(host, v.Port, v.Name)
+	}
+	for _, tech := range res.Technologies {
+		tree.AddTechnology(host, tech.Port, tech.Name)
+	}
+	for _, f := range res.Findings {
+		findingPath := normalizeEndpointPath(f.Path)
+		tree.EnsureEndpoint(host, f.Port, findingPath)
+		tree.AddFinding(host, f.Port, findingPath, Finding{
+			Category: f.Title,
+			Evidence: f.Evidence,
+			Severity: f.Severity,
+		})
+	}
+	for _, c := range res.Completed {
+		tree.CompleteTask(host, c.Port, normalizeEndpointPath(c.Path), c.Task)
+	}
+}
+
+// Memories はパース結果のうち永続化すべきもの（認証情報・medium 以上の finding）を Memory に変換する。
+func (r *ParseResult) Memories() []*schema.Memory {
+	if r == nil {
+		return nil
+	}
+	var out []*schema.Memory
+	for _, c := range r.Credentials {
+		title := fmt.Sprintf("%s login %s", c.Service, c.Username)
+		desc := fmt.Sprintf("username=%s password=%s", c.Username, c.Password)
+		if c.Port > 0 {
+			desc += fmt.Sprintf(" port=%d", c.Port)
+		}
+		out = append(out, &schema.Memory{
+			Type:        schema.MemoryCredential,
+			Title:       title,
+			Description: desc + " (" + r.Parser + ")",
+		})
+	}
+	for _, f := range r.Findings {
+		if f.Severity != "high" && f.Severity != "medium" {
+			continue
+		}
+		desc := f.Evidence
+		if f.Path != "" {
+			desc = fmt.Sprintf("%s: %s", f.Path, f.Evidence)
+		}
+		out = append(out, &schema.Memory{
+			Type:        schema.MemoryVulnerability,
+			Title:       f.Title,
+			Description: fmt.Sprintf("port %d %s (%s)", f.Port, desc, r.Parser),
+			Severity:    f.Severity,
+		})
+	}
+	return out
+}
+
+// Entities はパース結果を Target のエンティティに変換する。
+// パーサーは出力構造を理解しているため、汎用パターンより高い confidence を付ける。
+func (r *ParseResult) Entities() []tools.Entity {
+	if r == nil {
+		return nil
+	}
+	var out []tools.Entity
+	for _, u := range r.Users {
+		out = append(out, tools.Entity{
+			Type: tools.EntityUsername, Value: u.Name, Detail: u.Service,
+			Confidence: 0.95, Source: r.Parser,
+		})
+	}
+	for _, c := range r.Credentials {
+		out = append(out, tools.Entity{
+			Type: tools.EntityUsername, Value: c.Username, Detail: c.Service,
+			Confidence: 0.95, Source: r.Parser,
+		})
+	}
+	for _, tech := range r.Technologies {
+		out = append(out, tools.Entity{
+			Type: tools.EntitySoftware, Value: tech.Name,
+			Confidence: 0.9, Source: r.Parser,
+		})
+	}
+	for _, v := range r.Vhosts {
+		out = append(out, tools.Entity{
+			Type: tools.EntityDomain, Value: strings.ToLower(v.Name), Detail: "vhost",
+			Confidence: 0.9, Source: r.Parser,
+		})
+	}
+	return out
+}
+
+// Summaries はパース結果を SubTask.Findings 用の 1 行サマリーに変換する。
+func (r *ParseResult) Summaries() []string {
+	var out []string
+	for _, m := range r.Memories() {
+		out = append(out, fmt.Sprintf("[%s] %s: %s", m.Type, m.Title, m.Description))
+	}
+	return out
+}
+
+// portFromCommandURL はコマンドの URL 引数（-u / --url / 位置引数）からポートを推定する。
+// 見つからなければ def を返す。
+func portFromCommandURL(command string, def int) int {
+	raw := extractURLFromFlag(command, "-u")
+	if raw == "" {
+		raw = extractURLFromFlag(command, "--url")
+	}
+	if raw == "" {
+		raw = extractURLFromFlag(command, "-h")
+	}
+	if raw == "" {
+		for _, f := range strings.Fields(command) {
+			f = strings.Trim(f, `"'`)
+			if strings.HasPrefix(f, "http://") || strings.HasPrefix(f, "https://") {
+				raw = f
+				break
+			}
+		}
+	}
+	if raw == "" {
+		return def
+	}
+	if !strings.Contains(raw, "://") {
+		// nikto -h 10.0.0.1:8080 等
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return def
+	}
+	return portFromURL(u)
+}
+
+// rawOutputText は ToolResult の全行を結合する（パーサーは切り捨て前の出力を使う）。
+// RawLines が空なら Truncated を返す。
+func rawOutputText(result *tools.ToolResult) string {
+	if result == nil {
+		return ""
+	}
+	if len(result.RawLines) == 0 {
+		return result.Truncated
+	}
+	var sb strings.Builder
+	for _, line := range result.RawLines {
+		sb.WriteString(line.Content)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
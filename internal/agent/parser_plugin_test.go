@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/pentecter/internal/tools"
+	"github.com/0x6d61/pentecter/pkg/schema"
+)
+
+func TestDefaultParsers_Names(t *testing.T) {
+	got := strings.Join(DefaultParsers().Names(), ",")
+	want := "curl,enum4linux,feroxbuster,ffuf,gobuster,hydra,nikto,nmap,sslscan,whatweb"
+	if got != want {
+		t.Errorf("Names = %q, want %q", got, want)
+	}
+}
+
+func TestParserRegistry_ResolveByBinary(t *testing.T) {
+	reg := DefaultParsers()
+
+	tests := []struct {
+		command string
+		want    string
+		ok      bool
+	}{
+		{"gobuster dir -u http://10.0.0.1 -w w.txt", "gobuster", true},
+		{"sudo /usr/bin/hydra -l admin -P p.txt ssh://10.0.0.1", "hydra", true},
+		{"sudo nmap -sV 10.0.0.1", "nmap", true},
+		{"echo hello", "", false},
+	}
+	for _, tt := range tests {
+		p, ok := reg.Resolve(tt.command, nil)
+		if ok != tt.ok {
+			t.Errorf("Resolve(%q) ok = %v, want %v", tt.command, ok, tt.ok)
+			continue
+		}
+		if ok && p.Name() != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.command, p.Name(), tt.want)
+		}
+	}
+}
+
+func TestParserRegistry_NilSafe(t *testing.T) {
+	var reg *ParserRegistry
+	res, err := reg.Run("nikto -h x", "+ Server: Apache", "x", nil)
+	if err != nil || res != nil {
+		t.Errorf("nil registry: res=%v err=%v, want nil/nil", res, err)
+	}
+}
+
+func TestApplyParseResult(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(80, "http", "")
+
+	res := &ParseResult{
+		Parser:       "nikto",
+		Endpoints:    []ParsedEndpoint{{Port: 80, Path: "/admin/users"}},
+		Technologies: []ParsedTechnology{{Port: 80, Name: "PHP 7.4.3"}, {Port: 80, Name: "PHP 7.4.3"}},
+		Findings:     []ParsedFinding{{Port: 80, Path: "/backup/", Title: "osvdb-3268", Evidence: "Directory indexing found.", Severity: "medium"}},
+	}
+	ApplyParseResult(res, tree, "10.10.11.100")
+
+	root := tree.Ports[0]
+	// /admin → /admin/users の中間ノードが作られ、/backup が追加される
+	if len(root.Children) != 2 {
+		t.Fatalf("children = %d, want 2", len(root.Children))
+	}
+	if root.Children[0].Path != "/admin" || len(root.Children[0].Children) != 1 ||
+		root.Children[0].Children[0].Path != "/admin/users" {
+		t.Errorf("unexpected endpoint tree: %+v", root.Children[0])
+	}
+	if len(root.Technologies) != 1 {
+		t.Errorf("Technologies = %v, want deduplicated single entry", root.Technologies)
+	}
+	if tree.CountFindings() != 1 {
+		t.Errorf("CountFindings = %d, want 1", tree.CountFindings())
+	}
+
+	intel := tree.RenderIntel()
+	if !strings.Contains(intel, "[tech: PHP 7.4.3]") {
+		t.Errorf("RenderIntel missing technologies:\n%s", intel)
+	}
+	if !strings.Contains(intel, "osvdb-3268: Directory indexing found.") {
+		t.Errorf("RenderIntel missing parser finding:\n%s", intel)
+	}
+}
+
+func TestApplyParseResult_CreatesMissingPort(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	ApplyParseResult(&ParseResult{
+		Endpoints: []ParsedEndpoint{{Port: 443, Path: "/login"}},
+	}, tree, "10.10.11.100")
+
+	if len(tree.Ports) != 1 || tree.Ports[0].Service != "https" {
+		t.Fatalf("Ports = %+v, want 443/https", tree.Ports)
+	}
+	if len(tree.Ports[0].Children) != 1 {
+		t.Errorf("children = %d, want 1", len(tree.Ports[0].Children))
+	}
+}
+
+func TestParseResult_Memories(t *testing.T) {
+	res := &ParseResult{
+		Parser: "hydra",
+		Credentials: []ParsedCredential{
+			{Port: 22, Service: "ssh", Username: "admin", Password: "pw"},
+		},
+		Findings: []ParsedFinding{
+			{Port: 80, Title: "header", Evidence: "missing", Severity: "info"},
+			{Port: 80, Title: "heartbleed", Evidence: "vulnerable", Severity: "high"},
+		},
+	}
+	mems := res.Memories()
+	if len(mems) != 2 {
+		t.Fatalf("Memories = %d, want 2 (info is skipped)", len(mems))
+	}
+	if mems[0].Type != schema.MemoryCredential || !strings.Contains(mems[0].Description, "password=pw") {
+		t.Errorf("credential memory = %+v", mems[0])
+	}
+	if mems[1].Type != schema.MemoryVulnerability || mems[1].Severity != "high" {
+		t.Errorf("vulnerability memory = %+v", mems[1])
+	}
+}
+
+func TestParseResult_Entities(t *testing.T) {
+	res := &ParseResult{
+		Parser:       "enum4linux",
+		Users:        []ParsedUser{{Port: 445, Service: "smb", Name: "bob"}},
+		Technologies: []ParsedTechnology{{Port: 80, Name: "PHP 7.4.3"}},
+		Vhosts:       []ParsedVhost{{Port: 80, Name: "Dev.Example.htb"}},
+	}
+	ents := res.Entities()
+	if len(ents) != 3 {
+		t.Fatalf("Entities = %+v, want 3", ents)
+	}
+	if ents[0].Type != tools.EntityUsername || ents[0].Source != "enum4linux" {
+		t.Errorf("username entity = %+v", ents[0])
+	}
+	if ents[2].Type != tools.EntityDomain || ents[2].Value != "dev.example.htb" {
+		t.Errorf("vhost entity = %+v", ents[2])
+	}
+}
@@ -39,24 +39,25 @@ type nmapService struct {
 	Version string `xml:"version,attr"`
 }
 
-// ParseNmapXML は nmap XML 出力をパースし、open ポートを ReconTree に追加する。
-func ParseNmapXML(xmlData string, tree *ReconTree) error {
+// parseNmapXML は nmap XML 出力から open ポートを抽出する。
+func parseNmapXML(xmlData string) ([]ParsedPort, error) {
 	// XML 部分を抽出（前後にゴミがある場合）
 	start := strings.Index(xmlData, "<nmaprun")
 	if start < 0 {
-		return nil // nmap XML が見つからない
+		return nil, nil // nmap XML が見つからない
 	}
 	end := strings.Index(xmlData, "</nmaprun>")
 	if end < 0 {
-		return nil
+		return nil, nil
 	}
 	xmlData = xmlData[start : end+len("</nmaprun>")]
 
 	var run nmapRun
 	if err := xml.Unmarshal([]byte(xmlData), &run); err != nil {
-		return fmt.Errorf("nmap XML parse: %w", err)
+		return nil, fmt.Errorf("nmap XML parse: %w", err)
 	}
 
+	var ports []ParsedPort
 	for _, host := range run.Hosts {
 		for _, port := range host.Ports {
 			if port.State.State != "open" {
@@ -69,22 +70,23 @@ func ParseNmapXML(xmlData string, tree *ReconTree) error {
 				}
 				banner += port.Service.Version
 			}
-			tree.AddPort(port.PortID, port.Service.Name, banner)
+			ports = append(ports, ParsedPort{Port: port.PortID, Service: port.Service.Name, Banner: banner})
 		}
 	}
-	return nil
+	return ports, nil
 }
 
 // --- nmap テキストパーサー ---
 
-// ParseNmapText は nmap テキスト出力をパースし、open ポートを ReconTree に追加する。
+// parseNmapText は nmap テキスト出力から open ポートを抽出する。
 // XML パーサーのフォールバックとして使用。
-func ParseNmapText(output string, tree *ReconTree) error {
+func parseNmapText(output string) []ParsedPort {
 	// Regex: "22/tcp   open   ssh   OpenSSH 8.2p1..."
 	// Format: PORT/PROTO STATE SERVICE VERSION...
 	re := regexp.MustCompile(`(?m)^(\d+)/(tcp|udp)\s+(open)\s+(\S+)\s*(.*)$`)
 	matches := re.FindAllStringSubmatch(output, -1)
 
+	var ports []ParsedPort
 	for _, m := range matches {
 		portStr := m[1]
 		// state := m[3] // always "open" due to regex
@@ -95,9 +97,9 @@ func ParseNmapText(output string, tree *ReconTree) error {
 		if _, err := fmt.Sscanf(portStr, "%d", &port); err != nil {
 			continue
 		}
-		tree.AddPort(port, service, banner)
+		ports = append(ports, ParsedPort{Port: port, Service: service, Banner: banner})
 	}
-	return nil
+	return ports
 }
 
 // --- ffuf JSON パーサー ---
@@ -115,30 +117,27 @@ type ffufResult struct {
 	URL    string            `json:"url"`
 }
 
-// ParseFfufJSON は ffuf JSON 出力をパースし、結果を ReconTree に追加する。
-// taskType により追加方法が異なる:
+// parseFfufJSON は ffuf JSON 出力をパースし、ReconTree に反映する内容を返す。
+// taskType により結果の扱いが異なる:
 //   - TaskEndpointEnum: 各結果を endpoint として追加
 //   - TaskVhostDiscov: 各結果を vhost として追加
 //   - TaskParamFuzz: タスクを完了にするのみ
-func ParseFfufJSON(jsonData string, tree *ReconTree, host string, port int, parentPath string, taskType ReconTaskType) error {
+//
+// いずれの場合も parentPath の taskType は完了になる（結果ゼロでも列挙は済んでいる）。
+func parseFfufJSON(jsonData string, port int, parentPath string, taskType ReconTaskType) (*ParseResult, error) {
 	// JSON 部分を抽出（前後にゴミがある場合）
 	start := strings.Index(jsonData, "{")
 	if start < 0 {
-		return nil
+		return nil, nil
 	}
 	jsonData = jsonData[start:]
 
 	var output ffufOutput
 	if err := json.Unmarshal([]byte(jsonData), &output); err != nil {
-		return fmt.Errorf("ffuf JSON parse: %w", err)
-	}
-
-	// 結果が空ならタスクを完了にする
-	if len(output.Results) == 0 {
-		tree.CompleteTask(host, port, parentPath, taskType)
-		return nil
+		return nil, fmt.Errorf("ffuf JSON parse: %w", err)
 	}
 
+	res := &ParseResult{}
 	switch taskType {
 	case TaskEndpointEnum:
 		for _, r := range output.Results {
@@ -157,18 +156,9 @@ func ParseFfufJSON(jsonData string, tree *ReconTree, host string, port int, pare
 				}
 				newPath = path.Join(parentPath, fuzz)
 			}
-			if !strings.HasPrefix(newPath, "/") {
-				newPath = "/" + newPath
-			}
-			// 親パスは URL から算出（再帰 ffuf でも正しい親に配置される）
-			parent := path.Dir(newPath)
-			if parent == "." {
-				parent = "/"
-			}
-			tree.AddEndpoint(host, port, parent, newPath)
+			// 親ノードは EnsureEndpoint がパスから辿る（再帰 ffuf でも正しい親に配置される）
+			res.Endpoints = append(res.Endpoints, ParsedEndpoint{Port: port, Path: newPath, Status: r.Status})
 		}
-		// 親のenum完了（結果あり = 列挙できた）
-		tree.CompleteTask(host, port, parentPath, TaskEndpointEnum)
 
 	case TaskVhostDiscov:
 		// vhost 発見: コマンドラインからドメインを抽出
@@ -178,21 +168,12 @@ func ParseFfufJSON(jsonData string, tree *ReconTree, host string, port int, pare
 			if fuzz == "" {
 				continue
 			}
-			vhostName := fuzz + "." + domain
-			tree.AddVhost(host, port, vhostName)
+			res.Vhosts = append(res.Vhosts, ParsedVhost{Port: port, Name: fuzz + "." + domain})
 		}
-		// 親の vhost discovery 完了
-		tree.CompleteTask(host, port, parentPath, TaskVhostDiscov)
-
-	case TaskParamFuzz:
-		// パラメータ発見は情報のみ、タスクを完了にする
-		tree.CompleteTask(host, port, parentPath, TaskParamFuzz)
-
-	case TaskProfiling:
-		tree.CompleteTask(host, port, parentPath, TaskProfiling)
 	}
-
-	return nil
+	// 親のタスクを完了にする（パラメータ発見・プロファイリングは完了のみ）
+	res.Completed = append(res.Completed, ParsedTask{Port: port, Path: parentPath, Task: taskType})
+	return res, nil
 }
 
 // extractDomainFromFfufCmd は ffuf コマンドラインから "Host: FUZZ.<domain>" のドメイン部分を抽出する。
@@ -212,40 +193,6 @@ func extractDomainFromFfufCmd(cmd string) string {
 	return rest
 }
 
-// --- 統合検出パーサー ---
-
-// DetectAndParse はコマンドと出力からツールを判定し、適切なパーサーを呼ぶ。
-// パーサーが一致しない場合は nil を返す（エラーではない）。
-func DetectAndParse(command string, output string, tree *ReconTree, host string) error {
-	cmdLower := strings.ToLower(command)
-
-	// nmap 検出
-	if strings.Contains(cmdLower, "nmap") {
-		if strings.Contains(output, "<nmaprun") {
-			return ParseNmapXML(output, tree)
-		}
-		// XML がない場合はテキストパーサーにフォールバック
-		return ParseNmapText(output, tree)
-	}
-
-	// ffuf 検出
-	if strings.Contains(cmdLower, "ffuf") && strings.Contains(output, `"results"`) {
-		port, parentPath, taskType := parseFfufCommand(command)
-		return ParseFfufJSON(output, tree, host, port, parentPath, taskType)
-	}
-
-	// curl 検出 → profiling 完了
-	if strings.Contains(cmdLower, "curl") {
-		port, curlPath := parseCurlCommand(command)
-		if curlPath != "" {
-			tree.CompleteTask(host, port, curlPath, TaskProfiling)
-		}
-		return nil
-	}
-
-	return nil
-}
-
 // parseFfufCommand は ffuf コマンドからポート、パス、タスクタイプを抽出する。
 func parseFfufCommand(command string) (port int, parentPath string, taskType ReconTaskType) {
 	port = 80
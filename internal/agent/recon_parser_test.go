@@ -2,6 +2,8 @@ package agent
 
 import (
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -22,12 +24,19 @@ const testFfufEmptyJSON = `{"commandline":"ffuf -w wordlist -u http://10.10.11.1
 
 const testFfufVhostJSON = `{"commandline":"ffuf -w wordlist -u http://10.10.11.100 -H 'Host: FUZZ.example.com'","results":[{"input":{"FUZZ":"dev"},"status":200,"length":1234,"url":"http://10.10.11.100/"},{"input":{"FUZZ":"staging"},"status":200,"length":5678,"url":"http://10.10.11.100/"}]}`
 
-func TestParseNmapXML(t *testing.T) {
-	tree := NewReconTree("10.10.11.100", 2)
-	err := ParseNmapXML(testNmapXML, tree)
+// parseInto はパーサーの結果を ReconTree に反映する。
+func parseInto(t *testing.T, p OutputParser, command, output string, tree *ReconTree) {
+	t.Helper()
+	res, err := p.Parse(ParseInput{Command: command, Output: output, Host: tree.Host})
 	if err != nil {
 		t.Fatal(err)
 	}
+	ApplyParseResult(res, tree, tree.Host)
+}
+
+func TestParseNmapXML(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	parseInto(t, nmapParser{}, "nmap -sV -sC 10.10.11.100", testNmapXML, tree)
 
 	// open ポートのみ追加される（22, 80）、filtered(443) は除外
 	if len(tree.Ports) != 2 {
@@ -53,7 +62,7 @@ func TestParseNmapXML(t *testing.T) {
 
 func TestParseNmapXML_OnlyOpenPorts(t *testing.T) {
 	tree := NewReconTree("10.10.11.100", 2)
-	_ = ParseNmapXML(testNmapXML, tree)
+	parseInto(t, nmapParser{}, "nmap -sV -sC 10.10.11.100", testNmapXML, tree)
 	// 443 は filtered なので追加されない
 	for _, p := range tree.Ports {
 		if p.Port == 443 {
@@ -64,7 +73,7 @@ func TestParseNmapXML_OnlyOpenPorts(t *testing.T) {
 
 func TestParseNmapXML_HTTPGetsPending(t *testing.T) {
 	tree := NewReconTree("10.10.11.100", 2)
-	_ = ParseNmapXML(testNmapXML, tree)
+	parseInto(t, nmapParser{}, "nmap -sV -sC 10.10.11.100", testNmapXML, tree)
 
 	http := tree.Ports[1] // port 80
 	if http.EndpointEnum != StatusPending {
@@ -84,10 +93,7 @@ func TestParseFfufJSON_Endpoints(t *testing.T) {
 	tree := NewReconTree("10.10.11.100", 2)
 	tree.AddPort(80, "http", "Apache")
 
-	err := ParseFfufJSON(testFfufDirJSON, tree, "10.10.11.100", 80, "/", TaskEndpointEnum)
-	if err != nil {
-		t.Fatal(err)
-	}
+	parseInto(t, ffufParser{}, "ffuf -w wordlist -u http://10.10.11.100/FUZZ", testFfufDirJSON, tree)
 
 	// /api と /login が追加される
 	node := tree.Ports[0]
@@ -107,10 +113,7 @@ func TestParseFfufJSON_Empty(t *testing.T) {
 	tree.AddPort(80, "http", "Apache")
 	tree.AddEndpoint("10.10.11.100", 80, "/", "/api")
 
-	err := ParseFfufJSON(testFfufEmptyJSON, tree, "10.10.11.100", 80, "/api", TaskEndpointEnum)
-	if err != nil {
-		t.Fatal(err)
-	}
+	parseInto(t, ffufParser{}, "ffuf -w wordlist -u http://10.10.11.100/api/FUZZ", testFfufEmptyJSON, tree)
 
 	// 結果ゼロ → タスク完了
 	apiNode := tree.Ports[0].Children[0]
@@ -123,10 +126,7 @@ func TestParseFfufJSON_Vhost(t *testing.T) {
 	tree := NewReconTree("10.10.11.100", 2)
 	tree.AddPort(80, "http", "Apache")
 
-	err := ParseFfufJSON(testFfufVhostJSON, tree, "10.10.11.100", 80, "", TaskVhostDiscov)
-	if err != nil {
-		t.Fatal(err)
-	}
+	parseInto(t, ffufParser{}, "ffuf -w wordlist -u http://10.10.11.100 -H 'Host: FUZZ.example.com'", testFfufVhostJSON, tree)
 
 	if len(tree.Vhosts) != 2 {
 		t.Fatalf("Vhosts count = %d, want 2", len(tree.Vhosts))
@@ -147,10 +147,7 @@ func TestParseFfufJSON_RecursiveURL(t *testing.T) {
 	// /api ノードを事前に追加（親として必要）
 	tree.AddEndpoint("10.10.11.100", 80, "/", "/api")
 
-	err := ParseFfufJSON(testFfufRecursiveJSON, tree, "10.10.11.100", 80, "/api", TaskEndpointEnum)
-	if err != nil {
-		t.Fatal(err)
-	}
+	parseInto(t, ffufParser{}, "ffuf -w wordlist -u http://10.10.11.100/api/FUZZ -recursion", testFfufRecursiveJSON, tree)
 
 	// /api ノードの子に /api/v1 が追加される
 	apiNode := tree.Ports[0].Children[0] // /api
@@ -180,10 +177,7 @@ func TestParseFfufJSON_EndpointEnumChildrenStayPending(t *testing.T) {
 		{"input":{"FUZZ":"api"},"status":200,"length":5678,"url":"http://10.10.11.100/api"}
 	]}`
 
-	err := ParseFfufJSON(jsonData, tree, "10.10.11.100", 80, "/", TaskEndpointEnum)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
+	parseInto(t, ffufParser{}, "ffuf -u http://10.10.11.100/FUZZ -of json", jsonData, tree)
 
 	// Parent endpoint_enum should be complete
 	portNode := tree.Ports[0]
@@ -212,10 +206,7 @@ func TestParseFfufJSON_RecursiveChildrenStayPending(t *testing.T) {
 	tree.AddEndpoint("10.10.11.100", 80, "/", "/api")
 
 	// Recursive ffuf result: /api/v1 and /api/v1/users discovered
-	err := ParseFfufJSON(testFfufRecursiveJSON, tree, "10.10.11.100", 80, "/api", TaskEndpointEnum)
-	if err != nil {
-		t.Fatal(err)
-	}
+	parseInto(t, ffufParser{}, "ffuf -w wordlist -u http://10.10.11.100/api/FUZZ -recursion", testFfufRecursiveJSON, tree)
 
 	apiNode := tree.Ports[0].Children[0] // /api
 	// /api endpoint_enum should be complete (parent)
@@ -246,47 +237,74 @@ func TestParseFfufJSON_RecursiveChildrenStayPending(t *testing.T) {
 	}
 }
 
-func TestDetectAndParse_Nmap(t *testing.T) {
+func TestDefaultParsers_Nmap(t *testing.T) {
 	tree := NewReconTree("10.10.11.100", 2)
-	err := DetectAndParse("nmap -sV -sC 10.10.11.100", testNmapXML, tree, "10.10.11.100")
+	res, err := DefaultParsers().Run("sudo nmap -sV -sC 10.10.11.100", testNmapXML, tree.Host, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
+	ApplyParseResult(res, tree, tree.Host)
+	if res.Parser != "nmap" || len(tree.Ports) != 2 {
+		t.Errorf("parser = %q, Ports count = %d, want nmap / 2", res.Parser, len(tree.Ports))
+	}
+}
+
+func TestNmapParser_ReadsOutputFile(t *testing.T) {
+	xmlPath := filepath.Join(t.TempDir(), "scan.xml")
+	if err := os.WriteFile(xmlPath, []byte(testNmapXML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewReconTree("10.10.11.100", 2)
+	// -oX のときは stdout ではなくファイルを読む
+	parseInto(t, nmapParser{}, "nmap -sV -oX "+xmlPath+" 10.10.11.100", "Starting Nmap 7.94SVN", tree)
 	if len(tree.Ports) != 2 {
 		t.Errorf("Ports count = %d, want 2", len(tree.Ports))
 	}
 }
 
-func TestDetectAndParse_Ffuf(t *testing.T) {
+func TestDefaultParsers_Ffuf(t *testing.T) {
 	tree := NewReconTree("10.10.11.100", 2)
 	tree.AddPort(80, "http", "Apache")
 
-	err := DetectAndParse(
-		"ffuf -w /usr/share/wordlists/dirb/common.txt -u http://10.10.11.100/FUZZ",
-		testFfufDirJSON,
-		tree, "10.10.11.100",
+	jsonPath := filepath.Join(t.TempDir(), "ffuf.json")
+	if err := os.WriteFile(jsonPath, []byte(testFfufDirJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err := DefaultParsers().Run(
+		"ffuf -s -w /usr/share/wordlists/dirb/common.txt -u http://10.10.11.100/FUZZ -o "+jsonPath,
+		"api\nlogin\n",
+		tree.Host, nil,
 	)
 	if err != nil {
 		t.Fatal(err)
 	}
+	ApplyParseResult(res, tree, tree.Host)
 	if len(tree.Ports[0].Children) != 2 {
 		t.Errorf("Children = %d, want 2", len(tree.Ports[0].Children))
 	}
 }
 
-func TestDetectAndParse_Curl(t *testing.T) {
+func TestFfufParser_NoJSON(t *testing.T) {
+	res, err := ffufParser{}.Parse(ParseInput{Command: "ffuf -s -u http://10.10.11.100/FUZZ", Output: "api\nlogin\n"})
+	if err != nil || !res.IsEmpty() {
+		t.Errorf("res = %+v, err = %v, want empty", res, err)
+	}
+}
+
+func TestDefaultParsers_Curl(t *testing.T) {
 	tree := NewReconTree("10.10.11.100", 2)
 	tree.AddPort(80, "http", "Apache")
 	tree.AddEndpoint("10.10.11.100", 80, "/", "/login")
 
-	err := DetectAndParse(
+	res, err := DefaultParsers().Run(
 		"curl -ik http://10.10.11.100/login",
 		"HTTP/1.1 200 OK\r\nContent-Type: text/html",
-		tree, "10.10.11.100",
+		tree.Host, nil,
 	)
 	if err != nil {
 		t.Fatal(err)
 	}
+	ApplyParseResult(res, tree, tree.Host)
 	loginNode := tree.Ports[0].Children[0]
 	if loginNode.Profiling != StatusComplete {
 		t.Errorf("Profiling = %d, want complete", loginNode.Profiling)
@@ -309,10 +327,7 @@ Nmap done: 1 IP address (1 host up) scanned in 25.43 seconds`
 
 func TestParseNmapText(t *testing.T) {
 	tree := NewReconTree("10.10.11.100", 2)
-	err := ParseNmapText(testNmapText, tree)
-	if err != nil {
-		t.Fatal(err)
-	}
+	parseInto(t, nmapParser{}, "nmap -sV -sC 10.10.11.100", testNmapText, tree)
 
 	// open ポートのみ追加（22, 80, 3306）。closed(443), filtered(8080) は除外
 	if len(tree.Ports) != 3 {
@@ -343,7 +358,7 @@ func TestParseNmapText(t *testing.T) {
 
 func TestParseNmapText_HTTPGetsPending(t *testing.T) {
 	tree := NewReconTree("10.10.11.100", 2)
-	_ = ParseNmapText(testNmapText, tree)
+	parseInto(t, nmapParser{}, "nmap -sV -sC 10.10.11.100", testNmapText, tree)
 
 	http := tree.Ports[1] // port 80
 	if http.EndpointEnum != StatusPending {
@@ -359,23 +374,10 @@ func TestParseNmapText_HTTPGetsPending(t *testing.T) {
 	}
 }
 
-func TestDetectAndParse_NmapText(t *testing.T) {
-	tree := NewReconTree("10.10.11.100", 2)
-	// nmap command without XML output
-	err := DetectAndParse("nmap -sV -sC 10.10.11.100", testNmapText, tree, "10.10.11.100")
-	if err != nil {
-		t.Fatal(err)
-	}
-	if len(tree.Ports) != 3 {
-		t.Errorf("Ports count = %d, want 3", len(tree.Ports))
-	}
-}
-
-func TestDetectAndParse_Unknown(t *testing.T) {
-	tree := NewReconTree("10.10.11.100", 2)
-	err := DetectAndParse("echo hello", "hello", tree, "10.10.11.100")
-	if err != nil {
-		t.Errorf("unknown command should not error, got: %v", err)
+func TestDefaultParsers_Unknown(t *testing.T) {
+	res, err := DefaultParsers().Run("echo hello", "hello", "10.10.11.100", nil)
+	if err != nil || res != nil {
+		t.Errorf("unknown command should not be parsed, got: %+v, %v", res, err)
 	}
 }
 
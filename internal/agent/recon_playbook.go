@@ -0,0 +1,235 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0x6d61/pentecter/internal/playbook"
+)
+
+// PlaybookTask は pending な追加プレイブック1件。
+type PlaybookTask struct {
+	Port int
+	Name string
+}
+
+// playbooksIn はステータスが st の追加プレイブック名を名前順で返す。呼び出し側でロックを保持すること。
+func (n *ReconNode) playbooksIn(st ReconStatus) []string {
+	var names []string
+	for name, s := range n.Playbooks {
+		if s == st {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PlaybookSubject はポートのトリガー判定用スナップショットを返す。
+// 子孫の endpoint パスも含める（"/wp-login.php" 等のパストリガー用）。
+func (t *ReconTree) PlaybookSubject(port int) (playbook.Subject, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, node := range t.Ports {
+		if node.Port == port {
+			return subjectOf(node), true
+		}
+	}
+	return playbook.Subject{}, false
+}
+
+// PlaybookSubjects は全ポートのトリガー判定用スナップショットを返す。
+func (t *ReconTree) PlaybookSubjects() []playbook.Subject {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	subjects := make([]playbook.Subject, 0, len(t.Ports))
+	for _, node := range t.Ports {
+		subjects = append(subjects, subjectOf(node))
+	}
+	return subjects
+}
+
+func subjectOf(node *ReconNode) playbook.Subject {
+	s := playbook.Subject{
+		Host:         node.Host,
+		Port:         node.Port,
+		Service:      node.Service,
+		Banner:       node.Banner,
+		Technologies: append([]string(nil), node.Technologies...),
+	}
+	var children []*ReconNode
+	collectAllChildren(&children, node)
+	for _, child := range children {
+		s.Paths = append(s.Paths, child.Path)
+	}
+	return s
+}
+
+// AddPlaybookTask はポートに追加プレイブックを pending で登録する。
+// 既に登録済み（実行中・完了含む）なら false を返す。
+func (t *ReconTree) AddPlaybookTask(port int, name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, node := range t.Ports {
+		if node.Port != port {
+			continue
+		}
+		if _, ok := node.Playbooks[name]; ok {
+			return false
+		}
+		if node.Playbooks == nil {
+			node.Playbooks = make(map[string]ReconStatus)
+		}
+		node.Playbooks[name] = StatusPending
+		return true
+	}
+	return false
+}
+
+// PendingPlaybookTasks は pending な追加プレイブックをポート順・名前順で返す。
+func (t *ReconTree) PendingPlaybookTasks() []PlaybookTask {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var result []PlaybookTask
+	for _, node := range t.Ports {
+		for _, name := range node.playbooksIn(StatusPending) {
+			result = append(result, PlaybookTask{Port: node.Port, Name: name})
+		}
+	}
+	return result
+}
+
+// StartPlaybook は max_parallel チェック + Pending → InProgress を原子的に行う。
+func (t *ReconTree) StartPlaybook(port int, name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.active >= t.MaxParallel {
+		return false
+	}
+	for _, node := range t.Ports {
+		if node.Port == port && node.Playbooks[name] == StatusPending {
+			node.Playbooks[name] = StatusInProgress
+			t.active++
+			return true
+		}
+	}
+	return false
+}
+
+// CompletePlaybook は実行中の追加プレイブックを Complete にし、active を -1 する。
+func (t *ReconTree) CompletePlaybook(port int, name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, node := range t.Ports {
+		if node.Port == port && node.Playbooks[name] == StatusInProgress {
+			node.Playbooks[name] = StatusComplete
+			if t.active > 0 {
+				t.active--
+			}
+			return
+		}
+	}
+}
+
+// webReconPlaybook は HTTP ポートに一致する workflow: web のプレイブックを返す（なければ nil）。
+func (rr *ReconRunner) webReconPlaybook(port int) *playbook.Playbook {
+	if rr.playbooks == nil {
+		return nil
+	}
+	subject, ok := rr.tree.PlaybookSubject(port)
+	if !ok {
+		return nil
+	}
+	if matched := rr.playbooks.Match(playbook.WorkflowWeb, subject); len(matched) > 0 {
+		return matched[0]
+	}
+	return nil
+}
+
+// TriggerPlaybooks はトリガーに一致した追加プレイブックを登録し、pending なものを spawn する。
+// サービス名・バナー・検出技術・発見パスが変わるたびに呼ぶ（登録済みのものは再登録しない）。
+func (rr *ReconRunner) TriggerPlaybooks(ctx context.Context) {
+	if rr.playbooks == nil {
+		return
+	}
+	for _, subject := range rr.tree.PlaybookSubjects() {
+		for _, pb := range rr.playbooks.Match("", subject) {
+			if rr.tree.AddPlaybookTask(subject.Port, pb.Name) {
+				rr.emitLog(fmt.Sprintf("[RECON] Playbook %q triggered on port %d", pb.Name, subject.Port))
+			}
+		}
+	}
+	for _, task := range rr.tree.PendingPlaybookTasks() {
+		pb, ok := rr.playbooks.Get(task.Name)
+		if !ok {
+			continue
+		}
+		rr.spawnPlaybook(ctx, task.Port, pb)
+	}
+}
+
+// spawnPlaybook は追加プレイブックの SubAgent を spawn する。
+func (rr *ReconRunner) spawnPlaybook(ctx context.Context, port int, pb *playbook.Playbook) {
+	if rr.taskMgr == nil {
+		rr.emitLog("[RECON] TaskManager not configured — skipping playbook SubAgent")
+		return
+	}
+	select {
+	case <-ctx.Done():
+		return
+	default:
+	}
+
+	subject, ok := rr.tree.PlaybookSubject(port)
+	if !ok {
+		return
+	}
+	if !rr.tree.StartPlaybook(port, pb.Name) {
+		rr.emitLog(fmt.Sprintf("[RECON] Max parallel reached — deferring playbook %q on port %d", pb.Name, port))
+		return
+	}
+
+	rr.emitLog(fmt.Sprintf("[RECON] Spawning playbook %q SubAgent for %s:%d", pb.Name, rr.targetHost, port))
+	_, err := rr.taskMgr.SpawnTask(ctx, SpawnTaskRequest{
+		Kind:       TaskKindSmart,
+		Goal:       fmt.Sprintf("Playbook %s on %s:%d", pb.Name, rr.targetHost, port),
+		Command:    pb.Render(playbookVars(rr.targetHost, subject)),
+		TargetHost: rr.targetHost,
+		TargetID:   rr.targetID,
+		MaxTurns:   pb.TurnLimit(30),
+		ReconTree:  rr.tree,
+		Playbook:   pb,
+		Metadata: TaskMetadata{
+			Port:     port,
+			Service:  subject.Service,
+			Phase:    "playbook",
+			Playbook: pb.Name,
+		},
+	})
+	if err != nil {
+		rr.emitLog(fmt.Sprintf("[RECON] SubAgent spawn error for :%d: %v", port, err))
+	}
+}
+
+// playbookVars はプレイブックのプレースホルダー値を返す。
+func playbookVars(host string, s playbook.Subject) map[string]string {
+	scheme, url := webURL(host, s.Port)
+	var cats strings.Builder
+	for i, cat := range MinFuzzCategories {
+		if i > 0 {
+			cats.WriteString("\n")
+		}
+		fmt.Fprintf(&cats, "  - %s: %s", cat.Name, cat.Description)
+	}
+	return map[string]string{
+		"host":            host,
+		"port":            strconv.Itoa(s.Port),
+		"service":         s.Service,
+		"scheme":          scheme,
+		"url":             url,
+		"fuzz_categories": cats.String(),
+	}
+}
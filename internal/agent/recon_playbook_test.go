@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/pentecter/internal/playbook"
+)
+
+func newTestPlaybooks(t *testing.T) *playbook.Registry {
+	t.Helper()
+	reg := playbook.NewRegistry()
+	for _, pb := range []*playbook.Playbook{
+		{
+			Name:     "web-recon",
+			Workflow: playbook.WorkflowWeb,
+			Triggers: []playbook.Trigger{{Service: []string{"http", "https"}}},
+			Phases:   []playbook.Phase{{Name: "enumerate", Commands: []string{"ffuf -u {{url}}/FUZZ"}}},
+			MaxTurns: 40,
+		},
+		{
+			Name:     "wordpress",
+			Triggers: []playbook.Trigger{{Banner: `(?i)wordpress`}, {Path: `^/wp-login\.php$`}},
+			Phases:   []playbook.Phase{{Name: "wpscan", Commands: []string{"wpscan --url {{url}}"}}},
+		},
+	} {
+		if err := reg.Register(pb); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return reg
+}
+
+func TestReconTree_PlaybookTasks(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 1)
+	tree.AddPort(80, "http", "Apache")
+	tree.CompleteTask("10.10.11.100", 80, "", TaskEndpointEnum)
+	tree.CompleteTask("10.10.11.100", 80, "", TaskVhostDiscov)
+
+	if !tree.AddPlaybookTask(80, "wordpress") {
+		t.Fatal("AddPlaybookTask should register a new playbook")
+	}
+	if tree.AddPlaybookTask(80, "wordpress") {
+		t.Error("duplicate AddPlaybookTask should return false")
+	}
+	if tree.AddPlaybookTask(8080, "wordpress") {
+		t.Error("unknown port should return false")
+	}
+	if got := tree.CountPending(); got != 1 {
+		t.Errorf("CountPending = %d, want 1", got)
+	}
+
+	pending := tree.PendingPlaybookTasks()
+	if len(pending) != 1 || pending[0].Port != 80 || pending[0].Name != "wordpress" {
+		t.Fatalf("PendingPlaybookTasks = %v", pending)
+	}
+	if !tree.StartPlaybook(80, "wordpress") {
+		t.Fatal("StartPlaybook should succeed")
+	}
+	if tree.StartPlaybook(80, "wordpress") {
+		t.Error("StartPlaybook on in-progress playbook should fail")
+	}
+	if !strings.Contains(tree.RenderIntel(), "Playbook SubAgent active: wordpress on 80") {
+		t.Errorf("intel should show active playbook\n%s", tree.RenderIntel())
+	}
+	if !strings.Contains(tree.RenderTree(), "playbook:wordpress") {
+		t.Errorf("tree should show playbook\n%s", tree.RenderTree())
+	}
+
+	tree.CompletePlaybook(80, "wordpress")
+	if tree.Active() != 0 {
+		t.Errorf("active = %d, want 0", tree.Active())
+	}
+	if !strings.Contains(tree.RenderIntel(), "80/http Apache — recon complete") {
+		t.Errorf("port should be recon complete\n%s", tree.RenderIntel())
+	}
+}
+
+func TestReconTree_PlaybookSubject_Paths(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(80, "http", "Apache")
+	tree.EnsureEndpoint("10.10.11.100", 80, "/blog/wp-login.php")
+	tree.AddTechnology("10.10.11.100", 80, "WordPress 5.8")
+
+	s, ok := tree.PlaybookSubject(80)
+	if !ok {
+		t.Fatal("subject not found")
+	}
+	if len(s.Paths) != 2 || s.Paths[1] != "/blog/wp-login.php" {
+		t.Errorf("Paths = %v", s.Paths)
+	}
+	if len(s.Technologies) != 1 {
+		t.Errorf("Technologies = %v", s.Technologies)
+	}
+}
+
+func TestReconRunner_TriggerPlaybooks(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 1)
+	tree.AddPort(80, "http", "Apache")
+	tree.AddPort(22, "ssh", "OpenSSH")
+	tree.SetActiveForTest(1) // spawn は保留される
+	events := make(chan Event, 100)
+
+	rr := NewReconRunner(ReconRunnerConfig{
+		Tree:       tree,
+		TaskMgr:    &TaskManager{},
+		Events:     events,
+		TargetHost: "10.10.11.100",
+		Playbooks:  newTestPlaybooks(t),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// トリガー未達
+	rr.TriggerPlaybooks(ctx)
+	if len(tree.PendingPlaybookTasks()) != 0 {
+		t.Fatal("no playbook should be triggered yet")
+	}
+
+	// パストリガー
+	tree.EnsureEndpoint("10.10.11.100", 80, "/wp-login.php")
+	rr.TriggerPlaybooks(ctx)
+	pending := tree.PendingPlaybookTasks()
+	if len(pending) != 1 || pending[0].Name != "wordpress" {
+		t.Fatalf("PendingPlaybookTasks = %v, want wordpress", pending)
+	}
+	// workflow: web のプレイブックは追加タスクにならない
+	for _, p := range pending {
+		if p.Name == "web-recon" {
+			t.Error("web workflow playbook should not be an additional task")
+		}
+	}
+
+	found := false
+	for len(events) > 0 {
+		if e := <-events; strings.Contains(e.Message, `Playbook "wordpress" triggered on port 80`) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("should emit trigger log")
+	}
+}
+
+func TestReconRunner_WebReconPlaybook(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(8443, "https", "nginx")
+
+	rr := NewReconRunner(ReconRunnerConfig{Tree: tree, TargetHost: "10.10.11.100", Playbooks: newTestPlaybooks(t)})
+	pb := rr.webReconPlaybook(8443)
+	if pb == nil || pb.Name != "web-recon" {
+		t.Fatalf("webReconPlaybook = %v, want web-recon", pb)
+	}
+	s, _ := tree.PlaybookSubject(8443)
+	prompt := pb.Render(playbookVars("10.10.11.100", s))
+	if !strings.Contains(prompt, "ffuf -u https://10.10.11.100:8443/FUZZ") {
+		t.Errorf("prompt should expand url\n%s", prompt)
+	}
+
+	// プレイブックなしなら nil（組み込みワークフローにフォールバック）
+	rr = NewReconRunner(ReconRunnerConfig{Tree: tree, TargetHost: "10.10.11.100"})
+	if rr.webReconPlaybook(8443) != nil {
+		t.Error("no registry should return nil")
+	}
+}
+
+func TestPlaybookVars_FuzzCategories(t *testing.T) {
+	vars := playbookVars("10.10.11.100", playbook.Subject{Port: 80, Service: "http"})
+	if vars["url"] != "http://10.10.11.100" || vars["scheme"] != "http" || vars["port"] != "80" {
+		t.Errorf("vars = %v", vars)
+	}
+	for _, cat := range MinFuzzCategories {
+		if !strings.Contains(vars["fuzz_categories"], cat.Name) {
+			t.Errorf("fuzz_categories missing %q", cat.Name)
+		}
+	}
+}
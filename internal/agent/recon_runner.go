@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"strings"
+
+	"github.com/0x6d61/pentecter/internal/playbook"
 )
 
 // ReconRunnerConfig は ReconRunner の構築パラメーター。
@@ -12,12 +14,15 @@ type ReconRunnerConfig struct {
 	TaskMgr    *TaskManager // SubAgent 用（nil = SubAgent 無効）
 	Events     chan<- Event
 	TargetHost string
-	TargetID   int    // TUI イベント用
-	MemDir     string // raw output 保存先（空 = 保存しない）
+	TargetID   int                // TUI イベント用
+	MemDir     string             // raw output 保存先（空 = 保存しない）
+	Playbooks  *playbook.Registry // YAML プレイブック（nil = 組み込みワークフローのみ）
 }
 
 // ReconRunner は自動偵察を実行するオーケストレーター。
-// リアクティブモデル: evaluateResult が新 HTTP ポートを検出するたびに SpawnWebReconForPort を呼ぶ。
+// リアクティブモデル: evaluateResult が新 HTTP ポートを検出するたびに SpawnWebReconForPort を、
+// SMB/FTP 等のテンプレート対象ポートには SpawnServiceReconForPort を呼ぶ。
+// プレイブックのトリガーに一致したポートには TriggerPlaybooks が追加の SubAgent を起動する。
 type ReconRunner struct {
 	tree       *ReconTree
 	taskMgr    *TaskManager
@@ -25,6 +30,7 @@ type ReconRunner struct {
 	targetHost string
 	targetID   int
 	memDir     string
+	playbooks  *playbook.Registry
 }
 
 // NewReconRunner は ReconRunner を構築する。
@@ -36,6 +42,7 @@ func NewReconRunner(cfg ReconRunnerConfig) *ReconRunner {
 		targetHost: cfg.TargetHost,
 		targetID:   cfg.TargetID,
 		memDir:     cfg.MemDir,
+		playbooks:  cfg.Playbooks,
 	}
 }
 
@@ -61,8 +68,18 @@ func (rr *ReconRunner) SpawnWebReconForPort(ctx context.Context, port *ReconNode
 		return
 	}
 
+	// workflow: web のプレイブックがあればそれを、なければ組み込みのワークフローを使う
 	prompt := buildWebReconPrompt(rr.targetHost, port.Port)
-	rr.emitLog(fmt.Sprintf("[RECON] Spawning web recon SubAgent for %s:%d", rr.targetHost, port.Port))
+	maxTurns := 50
+	pb := rr.webReconPlaybook(port.Port)
+	if pb != nil {
+		subject, _ := rr.tree.PlaybookSubject(port.Port)
+		prompt = pb.Render(playbookVars(rr.targetHost, subject))
+		maxTurns = pb.TurnLimit(maxTurns)
+		rr.emitLog(fmt.Sprintf("[RECON] Spawning web recon SubAgent for %s:%d (playbook %q)", rr.targetHost, port.Port, pb.Name))
+	} else {
+		rr.emitLog(fmt.Sprintf("[RECON] Spawning web recon SubAgent for %s:%d", rr.targetHost, port.Port))
+	}
 
 	_, err := rr.taskMgr.SpawnTask(ctx, SpawnTaskRequest{
 		Kind:       TaskKindSmart,
@@ -70,8 +87,9 @@ func (rr *ReconRunner) SpawnWebReconForPort(ctx context.Context, port *ReconNode
 		Command:    prompt,
 		TargetHost: rr.targetHost,
 		TargetID:   rr.targetID,
-		MaxTurns:   50,
+		MaxTurns:   maxTurns,
 		ReconTree:  rr.tree,
+		Playbook:   pb,
 		Metadata: TaskMetadata{
 			Port:    port.Port,
 			Service: port.Service,
@@ -83,6 +101,52 @@ func (rr *ReconRunner) SpawnWebReconForPort(ctx context.Context, port *ReconNode
 	}
 }
 
+// SpawnServiceReconForPort は非 HTTP ポートのサービス別偵察 SubAgent を spawn する。
+// SpawnWebReconForPort と同じく max_parallel を超える場合は次の evaluateResult で再試行する。
+func (rr *ReconRunner) SpawnServiceReconForPort(ctx context.Context, port *ReconNode) {
+	if rr.taskMgr == nil {
+		rr.emitLog("[RECON] TaskManager not configured — skipping service recon SubAgent")
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+		return
+	default:
+	}
+
+	tmpl, ok := matchServiceTemplate(port)
+	if !ok {
+		return
+	}
+
+	if !rr.tree.StartPortRecon(port) {
+		rr.emitLog(fmt.Sprintf("[RECON] Max parallel reached — deferring port %d", port.Port))
+		return
+	}
+
+	prompt := buildServiceReconPrompt(rr.targetHost, port, tmpl)
+	rr.emitLog(fmt.Sprintf("[RECON] Spawning %s SubAgent for %s:%d", tmpl.Task, rr.targetHost, port.Port))
+
+	_, err := rr.taskMgr.SpawnTask(ctx, SpawnTaskRequest{
+		Kind:       TaskKindSmart,
+		Goal:       fmt.Sprintf("%s on %s:%d", tmpl.Label, rr.targetHost, port.Port),
+		Command:    prompt,
+		TargetHost: rr.targetHost,
+		TargetID:   rr.targetID,
+		MaxTurns:   30,
+		ReconTree:  rr.tree,
+		Metadata: TaskMetadata{
+			Port:    port.Port,
+			Service: port.Service,
+			Phase:   "service_recon",
+		},
+	})
+	if err != nil {
+		rr.emitLog(fmt.Sprintf("[RECON] SubAgent spawn error for :%d: %v", port.Port, err))
+	}
+}
+
 // findHTTPPorts は ReconTree から HTTP ポートを返す。
 func (rr *ReconRunner) findHTTPPorts() []*ReconNode {
 	var httpPorts []*ReconNode
@@ -107,13 +171,12 @@ func (rr *ReconRunner) emitLog(msg string) {
 	}
 }
 
-// buildWebReconPrompt は HTTP ポート用の web recon SubAgent プロンプトを生成する。
-func buildWebReconPrompt(host string, port int) string {
-	scheme := "http"
+// webURL はポートからスキームとベース URL を返す（80/443 はポート番号を省略）。
+func webURL(host string, port int) (scheme, url string) {
+	scheme = "http"
 	if port == 443 || port == 8443 {
 		scheme = "https"
 	}
-	var url string
 	switch port {
 	case 80:
 		url = fmt.Sprintf("http://%s", host)
@@ -122,6 +185,13 @@ func buildWebReconPrompt(host string, port int) string {
 	default:
 		url = fmt.Sprintf("%s://%s:%d", scheme, host, port)
 	}
+	return scheme, url
+}
+
+// buildWebReconPrompt は HTTP ポート用の web recon SubAgent プロンプトを生成する。
+// playbooks/ に workflow: web のプレイブックがない場合のフォールバック。
+func buildWebReconPrompt(host string, port int) string {
+	_, url := webURL(host, port)
 
 	// カテゴリリストを動的に構築
 	var catList strings.Builder
@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// serviceReconTemplate は非 HTTP サービス向けの偵察タスクテンプレート。
+// AddPort がサービス名またはポート番号で照合し、Task を pending にする。
+type serviceReconTemplate struct {
+	Task     ReconTaskType
+	Label    string   // Goal / ログ表示用（"SMB enumeration"）
+	Services []string // nmap のサービス名
+	Ports    []int    // サービス名が不明・未知の場合のフォールバック
+	Steps    string   // SubAgent に渡す手順（%[1]s = host, %[2]d = port）
+}
+
+// serviceReconTemplates はサービス別偵察タスクの定義。
+var serviceReconTemplates = []serviceReconTemplate{
+	{
+		Task:     TaskSMBEnum,
+		Label:    "SMB share enumeration",
+		Services: []string{"microsoft-ds", "netbios-ssn", "smb"},
+		Ports:    []int{445, 139},
+		Steps: `1. NULL SESSION / SHARE LISTING:
+   smbclient -N -L //%[1]s -p %[2]d
+   smbmap -H %[1]s -P %[2]d
+2. USERS, GROUPS AND PASSWORD POLICY:
+   enum4linux -a %[1]s
+3. For each readable share, list contents recursively:
+   smbclient -N //%[1]s/<share> -c 'recurse ON; ls'
+4. Check SMB signing and known vulnerabilities:
+   nmap -p %[2]d --script smb2-security-mode,smb-vuln* %[1]s`,
+	},
+	{
+		Task:     TaskFTPAnon,
+		Label:    "FTP anonymous access check",
+		Services: []string{"ftp"},
+		Ports:    []int{21},
+		Steps: `1. ANONYMOUS LOGIN:
+   nmap -p %[2]d --script ftp-anon,ftp-syst %[1]s
+2. If anonymous login is allowed, list all files recursively:
+   curl -s --list-only ftp://anonymous:anonymous@%[1]s:%[2]d/
+3. Download interesting files (configs, backups, credentials) and check whether the directory is writable.`,
+	},
+	{
+		Task:     TaskLDAPDump,
+		Label:    "LDAP base dump",
+		Services: []string{"ldap", "ldaps", "globalcatLDAP"},
+		Ports:    []int{389, 636, 3268},
+		Steps: `1. ROOT DSE (naming contexts, domain name):
+   ldapsearch -x -H ldap://%[1]s:%[2]d -s base namingcontexts
+2. ANONYMOUS BASE DUMP:
+   ldapsearch -x -H ldap://%[1]s:%[2]d -b "<naming-context>"
+3. Extract users, groups, descriptions (passwords in description fields are common) and computer names.`,
+	},
+	{
+		Task:     TaskSNMPWalk,
+		Label:    "SNMP community walk",
+		Services: []string{"snmp"},
+		Ports:    []int{161},
+		Steps: `1. COMMUNITY STRING BRUTEFORCE:
+   onesixtyone -c /usr/share/seclists/Discovery/SNMP/common-snmp-community-strings.txt %[1]s
+2. WALK with each valid community (start with "public"):
+   snmpwalk -v2c -c public %[1]s
+3. Look for running processes with arguments, installed software, user accounts and network interfaces.`,
+	},
+	{
+		Task:     TaskDBDefaultCreds,
+		Label:    "Database default credential check",
+		Services: []string{"mysql", "postgresql", "ms-sql-s", "oracle-tns", "mongodb", "redis"},
+		Ports:    []int{3306, 5432, 1433, 1521, 27017, 6379},
+		Steps: `1. FINGERPRINT the database:
+   nmap -sV -p %[2]d --script "banner,*-info" %[1]s
+2. UNAUTHENTICATED ACCESS (redis / mongodb often allow it):
+   redis-cli -h %[1]s -p %[2]d INFO
+   mongosh --host %[1]s --port %[2]d --eval 'db.adminCommand({listDatabases:1})'
+3. DEFAULT CREDENTIALS (root:<empty>, root:root, sa:<empty>, postgres:postgres, etc.):
+   hydra -C /usr/share/seclists/Passwords/Default-Credentials/<db>-betterdefaultpasslist.txt -s %[2]d %[1]s <service>
+4. If login succeeds, list databases and tables but do NOT modify data.`,
+	},
+	{
+		Task:     TaskSSHEnum,
+		Label:    "SSH enumeration",
+		Services: []string{"ssh"},
+		Ports:    []int{22},
+		Steps: `1. VERSION AND ALGORITHMS:
+   nmap -p %[2]d --script ssh2-enum-algos,ssh-hostkey,ssh-auth-methods %[1]s
+2. Check whether password authentication is enabled and the version is affected by user enumeration (CVE-2018-15473).
+3. Do NOT brute-force passwords unless credentials were found elsewhere — try discovered credentials only.`,
+	},
+	{
+		Task:     TaskRDPEnum,
+		Label:    "RDP enumeration",
+		Services: []string{"ms-wbt-server", "rdp"},
+		Ports:    []int{3389},
+		Steps: `1. NLA, ENCRYPTION AND HOST INFO:
+   nmap -p %[2]d --script rdp-enum-encryption,rdp-ntlm-info %[1]s
+2. Record the NetBIOS / DNS domain and computer name from rdp-ntlm-info.
+3. Check for known vulnerabilities (BlueKeep CVE-2019-0708) only with non-destructive checks.`,
+	},
+}
+
+// serviceTaskOrder はサービスタスクの優先順（テンプレート定義順）。
+var serviceTaskOrder = func() []ReconTaskType {
+	order := make([]ReconTaskType, 0, len(serviceReconTemplates))
+	for _, tmpl := range serviceReconTemplates {
+		order = append(order, tmpl.Task)
+	}
+	return order
+}()
+
+// matchServiceTemplate はサービス名（優先）またはポート番号でテンプレートを探す。
+// HTTP 系ポートは web recon が担当するため対象外。
+func matchServiceTemplate(node *ReconNode) (*serviceReconTemplate, bool) {
+	if node.isHTTP() {
+		return nil, false
+	}
+	service := strings.ToLower(strings.TrimSuffix(node.Service, "?"))
+	if service != "" && service != "unknown" {
+		for i := range serviceReconTemplates {
+			for _, s := range serviceReconTemplates[i].Services {
+				if strings.EqualFold(s, service) {
+					return &serviceReconTemplates[i], true
+				}
+			}
+		}
+	}
+	for i := range serviceReconTemplates {
+		for _, p := range serviceReconTemplates[i].Ports {
+			if p == node.Port {
+				return &serviceReconTemplates[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// addServiceTasks はテンプレートに一致するサービスタスクを pending にする（未登録の場合のみ）。
+// 呼び出し側でロックを保持していること。
+func addServiceTasks(node *ReconNode) {
+	tmpl, ok := matchServiceTemplate(node)
+	if !ok {
+		return
+	}
+	if node.getReconStatus(tmpl.Task) == StatusNone {
+		node.setReconStatus(tmpl.Task, StatusPending)
+	}
+}
+
+// buildServiceReconPrompt はサービス別偵察 SubAgent のプロンプトを生成する。
+func buildServiceReconPrompt(host string, port *ReconNode, tmpl *serviceReconTemplate) string {
+	banner := port.Banner
+	if banner == "" {
+		banner = "unknown"
+	}
+	return fmt.Sprintf(`You are a service reconnaissance agent for %s port %d/%s (banner: %s).
+Task: %s.
+Your ReconTree is automatically updated as you run commands.
+
+WORKFLOW:
+%s
+
+RULES:
+- Stay on %s:%d — do NOT scan other ports or hosts
+- Do NOT run destructive or denial-of-service checks
+- Report credentials, readable shares/files, users and misconfigurations with "memory" action
+- When the workflow is complete, use "complete" action to finish with a short summary
+`, host, port.Port, port.Service, banner, tmpl.Label, fmt.Sprintf(tmpl.Steps, host, port.Port), host, port.Port)
+}
@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchServiceTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		port    int
+		service string
+		want    ReconTaskType
+		ok      bool
+	}{
+		{"smb by service", 445, "microsoft-ds", TaskSMBEnum, true},
+		{"netbios", 139, "netbios-ssn", TaskSMBEnum, true},
+		{"ftp", 21, "ftp", TaskFTPAnon, true},
+		{"ldap", 389, "ldap", TaskLDAPDump, true},
+		{"snmp", 161, "snmp", TaskSNMPWalk, true},
+		{"mysql", 3306, "mysql", TaskDBDefaultCreds, true},
+		{"redis non-standard port", 16379, "redis", TaskDBDefaultCreds, true},
+		{"ssh", 22, "ssh", TaskSSHEnum, true},
+		{"rdp", 3389, "ms-wbt-server", TaskRDPEnum, true},
+		{"unknown service falls back to port", 2121, "ftp?", TaskFTPAnon, true},
+		{"port fallback", 5432, "", TaskDBDefaultCreds, true},
+		{"smtp has no template", 25, "smtp", 0, false},
+		{"http is handled by web recon", 80, "http", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, ok := matchServiceTemplate(&ReconNode{Port: tt.port, Service: tt.service})
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && tmpl.Task != tt.want {
+				t.Errorf("task = %s, want %s", tmpl.Task, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddPort_ServiceTaskPending(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(445, "microsoft-ds", "Samba smbd 4.6.2")
+	tree.AddPort(25, "smtp", "Postfix smtpd")
+
+	smb := tree.Ports[0]
+	if got := smb.getReconStatus(TaskSMBEnum); got != StatusPending {
+		t.Errorf("smb_enum = %d, want pending", got)
+	}
+	if smb.EndpointEnum != StatusNone {
+		t.Errorf("EndpointEnum = %d, want none for SMB", smb.EndpointEnum)
+	}
+	if len(tree.Ports[1].ServiceTasks) != 0 {
+		t.Errorf("smtp should have no service tasks, got %v", tree.Ports[1].ServiceTasks)
+	}
+	if got := tree.CountPending(); got != 1 {
+		t.Errorf("CountPending = %d, want 1", got)
+	}
+
+	// 再検出しても状態は上書きしない
+	tree.CompleteTask("10.10.11.100", 445, "", TaskSMBEnum)
+	tree.AddPort(445, "microsoft-ds", "Samba smbd 4.6.2-Ubuntu")
+	if got := smb.getReconStatus(TaskSMBEnum); got != StatusComplete {
+		t.Errorf("smb_enum = %d, want complete after re-detection", got)
+	}
+}
+
+func TestPendingServicePorts(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(21, "ftp", "vsftpd 3.0.3")
+	tree.AddPort(80, "http", "Apache")
+	tree.AddPort(25, "smtp", "")
+
+	ports := tree.PendingServicePorts()
+	if len(ports) != 1 || ports[0].Port != 21 {
+		t.Fatalf("PendingServicePorts = %v, want [21]", ports)
+	}
+
+	if !tree.StartPortRecon(ports[0]) {
+		t.Fatal("StartPortRecon should succeed")
+	}
+	if got := ports[0].getReconStatus(TaskFTPAnon); got != StatusInProgress {
+		t.Errorf("ftp_anon = %d, want in-progress", got)
+	}
+	if len(tree.PendingServicePorts()) != 0 {
+		t.Error("in-progress port should not be pending")
+	}
+
+	tree.CompleteAllPortTasks(21)
+	if got := ports[0].getReconStatus(TaskFTPAnon); got != StatusComplete {
+		t.Errorf("ftp_anon = %d, want complete", got)
+	}
+	if tree.Active() != 0 {
+		t.Errorf("active = %d, want 0", tree.Active())
+	}
+}
+
+func TestNextBatch_ServiceTasksAfterWeb(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 3)
+	tree.AddPort(22, "ssh", "OpenSSH 8.2")
+	tree.AddPort(80, "http", "Apache")
+
+	batch := tree.NextBatch()
+	if len(batch) != 3 {
+		t.Fatalf("batch size = %d, want 3", len(batch))
+	}
+	if batch[0].Type != TaskEndpointEnum || batch[1].Type != TaskVhostDiscov || batch[2].Type != TaskSSHEnum {
+		t.Errorf("order = %s, %s, %s; want endpoint_enum, vhost_discovery, ssh_enum",
+			batch[0].Type, batch[1].Type, batch[2].Type)
+	}
+}
+
+func TestRenderTree_ServiceTasks(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(445, "microsoft-ds", "Samba")
+	output := tree.RenderTree()
+	if !strings.Contains(output, "smb_enum") {
+		t.Errorf("tree should show smb_enum task\noutput:\n%s", output)
+	}
+}
+
+func TestRenderIntel_ServiceRecon(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(389, "ldap", "OpenLDAP")
+	output := tree.RenderIntel()
+	if !strings.Contains(output, "389/ldap OpenLDAP — recon pending") {
+		t.Errorf("ldap should show recon pending\noutput:\n%s", output)
+	}
+
+	tree.StartPortRecon(tree.Ports[0])
+	output = tree.RenderIntel()
+	if !strings.Contains(output, "SubAgent active") {
+		t.Errorf("ldap should show SubAgent active\noutput:\n%s", output)
+	}
+	if !strings.Contains(output, "[BACKGROUND] Service recon SubAgent active on: 389/ldap") {
+		t.Errorf("should show background service recon\noutput:\n%s", output)
+	}
+}
+
+func TestBuildServiceReconPrompt(t *testing.T) {
+	node := &ReconNode{Port: 161, Service: "snmp"}
+	tmpl, ok := matchServiceTemplate(node)
+	if !ok {
+		t.Fatal("snmp template not found")
+	}
+	prompt := buildServiceReconPrompt("10.10.11.100", node, tmpl)
+	for _, want := range []string{
+		"10.10.11.100 port 161/snmp",
+		"banner: unknown",
+		"snmpwalk -v2c -c public 10.10.11.100",
+		`"complete" action`,
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q\nprompt:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "%!") {
+		t.Errorf("prompt has formatting errors:\n%s", prompt)
+	}
+}
+
+func TestBuildServiceReconPrompt_AllTemplatesFormat(t *testing.T) {
+	for _, tmpl := range serviceReconTemplates {
+		node := &ReconNode{Port: tmpl.Ports[0], Service: tmpl.Services[0]}
+		prompt := buildServiceReconPrompt("10.10.11.100", node, &tmpl)
+		if strings.Contains(prompt, "%!") {
+			t.Errorf("%s prompt has formatting errors:\n%s", tmpl.Task, prompt)
+		}
+	}
+}
+
+func TestReconRunner_SpawnServiceReconForPort_MaxParallel(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 1)
+	tree.AddPort(445, "microsoft-ds", "Samba")
+	tree.SetActiveForTest(1)
+	events := make(chan Event, 100)
+
+	rr := NewReconRunner(ReconRunnerConfig{
+		Tree:       tree,
+		TaskMgr:    &TaskManager{},
+		Events:     events,
+		TargetHost: "10.10.11.100",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rr.SpawnServiceReconForPort(ctx, tree.Ports[0])
+
+	if got := tree.Ports[0].getReconStatus(TaskSMBEnum); got != StatusPending {
+		t.Errorf("smb_enum = %d, want pending (deferred)", got)
+	}
+	if tree.Active() != 1 {
+		t.Errorf("active = %d, want 1", tree.Active())
+	}
+}
@@ -2,8 +2,11 @@ package agent
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+
+	"github.com/0x6d61/pentecter/internal/vulndb"
 )
 
 // ReconStatus は偵察タスクの状態
@@ -28,8 +31,20 @@ const (
 	TaskParamFuzz
 	TaskProfiling
 	TaskVhostDiscov
+
+	// サービス別偵察タスク（非 HTTP ポート。テンプレートは recon_service.go）
+	TaskSMBEnum
+	TaskFTPAnon
+	TaskLDAPDump
+	TaskSNMPWalk
+	TaskDBDefaultCreds
+	TaskSSHEnum
+	TaskRDPEnum
 )
 
+// webTaskTypes は HTTP ポート/エンドポイントの偵察タスク（優先順）
+var webTaskTypes = []ReconTaskType{TaskEndpointEnum, TaskParamFuzz, TaskProfiling, TaskVhostDiscov}
+
 func (t ReconTaskType) String() string {
 	switch t {
 	case TaskEndpointEnum:
@@ -40,11 +55,30 @@ func (t ReconTaskType) String() string {
 		return "profiling"
 	case TaskVhostDiscov:
 		return "vhost_discovery"
+	case TaskSMBEnum:
+		return "smb_enum"
+	case TaskFTPAnon:
+		return "ftp_anon"
+	case TaskLDAPDump:
+		return "ldap_dump"
+	case TaskSNMPWalk:
+		return "snmp_walk"
+	case TaskDBDefaultCreds:
+		return "db_default_creds"
+	case TaskSSHEnum:
+		return "ssh_enum"
+	case TaskRDPEnum:
+		return "rdp_enum"
 	default:
 		return "unknown"
 	}
 }
 
+// isServiceTask はサービス別偵察タスクかどうか
+func (t ReconTaskType) isServiceTask() bool {
+	return t >= TaskSMBEnum && t <= TaskRDPEnum
+}
+
 // Finding はバリューファジングで発見された脆弱性の疑い
 type Finding struct {
 	Param    string // パラメーター名 (e.g. "id")
@@ -67,8 +101,21 @@ type ReconNode struct {
 	Profiling    ReconStatus
 	VhostDiscov  ReconStatus
 
+	// ServiceTasks は非 HTTP ポートのサービス別偵察タスク（nil = なし）
+	ServiceTasks map[ReconTaskType]ReconStatus
+	// Playbooks はトリガーに一致した追加プレイブック名 → ステータス（nil = なし）
+	Playbooks map[string]ReconStatus
+
 	Findings []Finding
 
+	// Technologies はパーサーが検出した技術スタック（例: "Apache 2.4.49", "PHP 7.4.3"）
+	Technologies []string
+
+	// CVEs はバナー・技術スタックからオフライン DB で突き合わせた既知の脆弱性（CVSS 降順）
+	CVEs []*vulndb.CVE
+	// CPEs は突き合わせに使った CPE（"cpe:2.3:a:apache:http_server:2.4.49:..."）
+	CPEs []string
+
 	Children []*ReconNode
 }
 
@@ -90,7 +137,7 @@ func (n *ReconNode) getReconStatus(taskType ReconTaskType) ReconStatus {
 	case TaskVhostDiscov:
 		return n.VhostDiscov
 	default:
-		return StatusNone
+		return n.ServiceTasks[taskType]
 	}
 }
 
@@ -105,12 +152,40 @@ func (n *ReconNode) setReconStatus(taskType ReconTaskType, status ReconStatus) {
 		n.Profiling = status
 	case TaskVhostDiscov:
 		n.VhostDiscov = status
+	default:
+		if taskType.isServiceTask() {
+			if n.ServiceTasks == nil {
+				n.ServiceTasks = make(map[ReconTaskType]ReconStatus)
+			}
+			n.ServiceTasks[taskType] = status
+		}
+	}
+}
+
+// taskTypes はノードが持ちうる全タスクタイプを返す（web タスク + サービスタスク）。
+func (n *ReconNode) taskTypes() []ReconTaskType {
+	if len(n.ServiceTasks) == 0 {
+		return webTaskTypes
+	}
+	types := append([]ReconTaskType{}, webTaskTypes...)
+	for _, tt := range serviceTaskOrder {
+		if _, ok := n.ServiceTasks[tt]; ok {
+			types = append(types, tt)
+		}
 	}
+	return types
 }
 
 // countTasks はノードとその子孫の pending/complete/total を再帰的に数える
 func (n *ReconNode) countTasks() (pending, complete, total int) {
-	for _, st := range []ReconStatus{n.EndpointEnum, n.ParamFuzz, n.Profiling, n.VhostDiscov} {
+	statuses := make([]ReconStatus, 0, len(webTaskTypes)+len(n.Playbooks))
+	for _, tt := range n.taskTypes() {
+		statuses = append(statuses, n.getReconStatus(tt))
+	}
+	for _, st := range n.Playbooks {
+		statuses = append(statuses, st)
+	}
+	for _, st := range statuses {
 		switch st {
 		case StatusPending:
 			pending++
@@ -165,6 +240,7 @@ func NewReconTree(host string, maxParallel int) *ReconTree {
 
 // AddPort は nmap で発見したポートをツリーに追加する。
 // HTTP 系なら EndpointEnum + VhostDiscov を pending にする。
+// 非 HTTP でサービステンプレート（SMB, FTP 等）があれば、そのタスクを pending にする。
 // 同じポート番号が既に存在する場合は banner/service を更新し、重複追加しない。
 func (t *ReconTree) AddPort(port int, service, banner string) {
 	t.mu.Lock()
@@ -184,6 +260,7 @@ func (t *ReconTree) AddPort(port int, service, banner string) {
 				existing.EndpointEnum = StatusPending
 				existing.VhostDiscov = StatusPending
 			}
+			addServiceTasks(existing)
 			return
 		}
 	}
@@ -197,6 +274,7 @@ func (t *ReconTree) AddPort(port int, service, banner string) {
 		node.EndpointEnum = StatusPending
 		node.VhostDiscov = StatusPending
 	}
+	addServiceTasks(node)
 	t.Ports = append(t.Ports, node)
 }
 
@@ -235,6 +313,118 @@ func (t *ReconTree) AddVhost(parentHost string, port int, vhostName string) {
 	t.Vhosts = append(t.Vhosts, node)
 }
 
+// EnsureEndpoint は endpoint を重複なしで追加する。中間ディレクトリが未登録なら併せて作成する。
+// ポートノードが存在しない場合は HTTP ポートとして追加する（gobuster 等を nmap より先に実行したケース）。
+// 新規作成したノードは AddEndpoint と同様に EndpointEnum + ParamFuzz + Profiling を pending にする。
+func (t *ReconTree) EnsureEndpoint(host string, port int, endpointPath string) {
+	endpointPath = normalizeEndpointPath(endpointPath)
+	if port <= 0 || endpointPath == "/" {
+		return
+	}
+
+	t.mu.Lock()
+	if t.findNode(host, port, "/") == nil && host == t.Host {
+		service := "http"
+		if port == 443 || port == 8443 {
+			service = "https"
+		}
+		t.mu.Unlock()
+		t.AddPort(port, service, "")
+		t.mu.Lock()
+	}
+	defer t.mu.Unlock()
+
+	parent := t.findNode(host, port, "/")
+	if parent == nil {
+		return
+	}
+	current := ""
+	for _, seg := range strings.Split(strings.TrimPrefix(endpointPath, "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		current += "/" + seg
+		var next *ReconNode
+		for _, child := range parent.Children {
+			if child.Path == current {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			next = &ReconNode{
+				Host:         host,
+				Port:         port,
+				Path:         current,
+				EndpointEnum: StatusPending,
+				ParamFuzz:    StatusPending,
+				Profiling:    StatusPending,
+			}
+			parent.Children = append(parent.Children, next)
+		}
+		parent = next
+	}
+}
+
+// normalizeEndpointPath は "admin/" → "/admin" のように先頭スラッシュ付き・末尾スラッシュなしに揃える。
+func normalizeEndpointPath(p string) string {
+	p = strings.TrimSuffix(p, "/")
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
+
+// AddTechnology はポートノードに検出技術を重複なしで追加する。
+// ノードが見つからない場合は何もしない。
+func (t *ReconTree) AddTechnology(host string, port int, tech string) {
+	if tech == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	node := t.findNode(host, port, "/")
+	if node == nil {
+		return
+	}
+	for _, existing := range node.Technologies {
+		if strings.EqualFold(existing, tech) {
+			return
+		}
+	}
+	node.Technologies = append(node.Technologies, tech)
+}
+
+// ProductStrings はポートごとのバナーと検出技術を返す（CVE 突き合わせ用のスナップショット）。
+func (t *ReconTree) ProductStrings() map[int][]string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make(map[int][]string, len(t.Ports))
+	for _, node := range t.Ports {
+		var products []string
+		if node.Banner != "" {
+			products = append(products, node.Banner)
+		}
+		products = append(products, node.Technologies...)
+		if len(products) > 0 {
+			out[node.Port] = products
+		}
+	}
+	return out
+}
+
+// SetVulns はポートノードの既知脆弱性を置き換える。ノードが見つからない場合は何もしない。
+func (t *ReconTree) SetVulns(port int, cpes []string, cves []*vulndb.CVE) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	node := t.findNode(t.Host, port, "/")
+	if node == nil {
+		return
+	}
+	node.CPEs = cpes
+	node.CVEs = cves
+}
+
 // CompleteTask は指定タスクを完了にする。
 // path が空文字列の場合はポートレベルノードを対象とする。
 func (t *ReconTree) CompleteTask(host string, port int, path string, taskType ReconTaskType) {
@@ -333,7 +523,7 @@ func (t *ReconTree) Unlock() {
 }
 
 // NextBatch は MaxParallel - active 個の pending タスクを優先順で返す。
-// 優先順: endpoint_enum > param_fuzz > profiling > vhost_discovery
+// 優先順: endpoint_enum > param_fuzz > profiling > vhost_discovery > サービス別タスク
 func (t *ReconTree) NextBatch() []*ReconTask {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
@@ -344,7 +534,7 @@ func (t *ReconTree) NextBatch() []*ReconTask {
 
 	var tasks []*ReconTask
 	// 優先順にタスクを収集
-	for _, taskType := range []ReconTaskType{TaskEndpointEnum, TaskParamFuzz, TaskProfiling, TaskVhostDiscov} {
+	for _, taskType := range append(append([]ReconTaskType{}, webTaskTypes...), serviceTaskOrder...) {
 		t.collectPending(&tasks, taskType, available)
 		if len(tasks) >= available {
 			break
@@ -421,7 +611,7 @@ func (t *ReconTree) CompleteAllPortTasks(port int) {
 	found := false
 	for _, node := range t.Ports {
 		if node.Port == port {
-			for _, tt := range []ReconTaskType{TaskEndpointEnum, TaskParamFuzz, TaskProfiling, TaskVhostDiscov} {
+			for _, tt := range node.taskTypes() {
 				if node.getReconStatus(tt) == StatusInProgress {
 					node.setReconStatus(tt, StatusComplete)
 					found = true
@@ -574,6 +764,7 @@ func renderNode(sb *strings.Builder, node *ReconNode, prefix, childPrefix string
 		if node.isHTTP() {
 			// vhost + endpoint ステータス表示
 			sb.WriteString("\n")
+			renderPlaybookLines(sb, node, childPrefix, false)
 			hasChildren := len(node.Children) > 0
 			vhostPrefix := childPrefix + "|-- "
 			if !hasChildren {
@@ -608,6 +799,16 @@ func renderNode(sb *strings.Builder, node *ReconNode, prefix, childPrefix string
 			}
 		} else {
 			sb.WriteString("\n")
+			// サービス別タスクのステータス
+			types := node.taskTypes()[len(webTaskTypes):]
+			renderPlaybookLines(sb, node, childPrefix, len(types) == 0)
+			for j, tt := range types {
+				cp := childPrefix + "|-- "
+				if j == len(types)-1 {
+					cp = childPrefix + "+-- "
+				}
+				fmt.Fprintf(sb, "%s%s %s\n", cp, tt, statusIcon(node.getReconStatus(tt)))
+			}
 		}
 	} else {
 		// endpoint ノード
@@ -615,6 +816,23 @@ func renderNode(sb *strings.Builder, node *ReconNode, prefix, childPrefix string
 	}
 }
 
+// renderPlaybookLines はポートノードの追加プレイブックのステータスを名前順で描画する。
+// last が true なら最後の行を "+-- " にする（後続の行がない場合）。
+func renderPlaybookLines(sb *strings.Builder, node *ReconNode, childPrefix string, last bool) {
+	names := make([]string, 0, len(node.Playbooks))
+	for name := range node.Playbooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for j, name := range names {
+		cp := childPrefix + "|-- "
+		if last && j == len(names)-1 {
+			cp = childPrefix + "+-- "
+		}
+		fmt.Fprintf(sb, "%splaybook:%s %s\n", cp, name, statusIcon(node.Playbooks[name]))
+	}
+}
+
 func renderEndpointNode(sb *strings.Builder, node *ReconNode, prefix, childPrefix string) {
 	status := fmt.Sprintf("%s%s%s",
 		statusIcon(node.EndpointEnum),
@@ -633,7 +851,11 @@ func renderEndpointNode(sb *strings.Builder, node *ReconNode, prefix, childPrefi
 		if isLast {
 			fp = childPrefix + "+-- "
 		}
-		fmt.Fprintf(sb, "%sfinding: param \"%s\" \u2014 %s (%s)\n", fp, f.Param, f.Category, f.Evidence)
+		if f.Param == "" {
+			fmt.Fprintf(sb, "%sfinding: %s \u2014 %s\n", fp, f.Category, f.Evidence)
+		} else {
+			fmt.Fprintf(sb, "%sfinding: param \"%s\" \u2014 %s (%s)\n", fp, f.Param, f.Category, f.Evidence)
+		}
 	}
 
 	for _, child := range node.Children {
@@ -662,14 +884,19 @@ func (t *ReconTree) RenderIntel() string {
 	sb.WriteString("=== RECON INTEL ===\n")
 
 	// [BACKGROUND]: InProgress タスクがあるポートを表示
-	var activePorts []string
+	var activePorts, activeServices, activePlaybooks []string
 	for _, node := range t.Ports {
-		if node.isHTTP() {
-			for _, tt := range []ReconTaskType{TaskEndpointEnum, TaskParamFuzz, TaskProfiling, TaskVhostDiscov} {
-				if node.getReconStatus(tt) == StatusInProgress {
+		for _, name := range node.playbooksIn(StatusInProgress) {
+			activePlaybooks = append(activePlaybooks, fmt.Sprintf("%s on %d", name, node.Port))
+		}
+		for _, tt := range node.taskTypes() {
+			if node.getReconStatus(tt) == StatusInProgress {
+				if node.isHTTP() {
 					activePorts = append(activePorts, fmt.Sprintf("%d", node.Port))
-					break
+				} else {
+					activeServices = append(activeServices, fmt.Sprintf("%d/%s", node.Port, node.Service))
 				}
+				break
 			}
 		}
 	}
@@ -677,6 +904,14 @@ func (t *ReconTree) RenderIntel() string {
 		fmt.Fprintf(&sb, "[BACKGROUND] HTTPAgent active on ports: %s — do NOT run ffuf/dirb yourself\n\n",
 			strings.Join(activePorts, ", "))
 	}
+	if len(activeServices) > 0 {
+		fmt.Fprintf(&sb, "[BACKGROUND] Service recon SubAgent active on: %s — do NOT repeat its enumeration yourself\n\n",
+			strings.Join(activeServices, ", "))
+	}
+	if len(activePlaybooks) > 0 {
+		fmt.Fprintf(&sb, "[BACKGROUND] Playbook SubAgent active: %s — do NOT repeat its steps yourself\n\n",
+			strings.Join(activePlaybooks, ", "))
+	}
 
 	// [FINDINGS]: 全ノードの findings を表示
 	hasFindings := false
@@ -690,18 +925,27 @@ func (t *ReconTree) RenderIntel() string {
 		sb.WriteString("\n")
 	}
 
+	// [KNOWN VULNS]: バナーから突き合わせた CVE（ポートごとに CVSS 上位のみ）
+	t.renderKnownVulns(&sb)
+
 	// [ATTACK SURFACE]: 全ポート + ステータス
 	sb.WriteString("[ATTACK SURFACE]\n")
 	for _, node := range t.Ports {
 		status := "not tested"
-		if node.isHTTP() {
+		if node.isHTTP() || len(node.ServiceTasks) > 0 || len(node.Playbooks) > 0 {
 			// Check if any task is InProgress
-			for _, tt := range []ReconTaskType{TaskEndpointEnum, TaskParamFuzz, TaskProfiling, TaskVhostDiscov} {
+			for _, tt := range node.taskTypes() {
 				if node.getReconStatus(tt) == StatusInProgress {
 					status = "HTTPAgent active"
+					if !node.isHTTP() {
+						status = "SubAgent active"
+					}
 					break
 				}
 			}
+			if status == "not tested" && len(node.playbooksIn(StatusInProgress)) > 0 {
+				status = "SubAgent active"
+			}
 			if status == "not tested" {
 				anyTask, allComplete := nodeTreeTaskStatus(node)
 				if anyTask && allComplete {
@@ -715,19 +959,74 @@ func (t *ReconTree) RenderIntel() string {
 		if banner != "" {
 			banner = " " + banner
 		}
-		fmt.Fprintf(&sb, "  %d/%s%s — %s\n", node.Port, node.Service, banner, status)
+		tech := ""
+		if len(node.Technologies) > 0 {
+			tech = " [tech: " + strings.Join(node.Technologies, ", ") + "]"
+		}
+		fmt.Fprintf(&sb, "  %d/%s%s%s — %s\n", node.Port, node.Service, banner, tech, status)
 	}
 
 	return sb.String()
 }
 
+// maxVulnsPerPort は RenderIntel でポートごとに表示する CVE の上限
+const maxVulnsPerPort = 5
+
+// renderKnownVulns は [KNOWN VULNS] セクションをレンダリングする。
+func (t *ReconTree) renderKnownVulns(sb *strings.Builder) {
+	header := false
+	for _, node := range t.Ports {
+		if len(node.CVEs) == 0 {
+			continue
+		}
+		if !header {
+			sb.WriteString("[KNOWN VULNS]\n")
+			header = true
+		}
+		for i, c := range node.CVEs {
+			if i == maxVulnsPerPort {
+				fmt.Fprintf(sb, "  Port %d: +%d more\n", node.Port, len(node.CVEs)-maxVulnsPerPort)
+				break
+			}
+			exploit := ""
+			if c.HasExploit() {
+				refs := make([]string, 0, len(c.Exploits))
+				for _, e := range c.Exploits {
+					refs = append(refs, e.Ref())
+				}
+				exploit = " [exploit: " + strings.Join(refs, ", ") + "]"
+			}
+			fmt.Fprintf(sb, "  Port %d: %s CVSS %.1f%s — %s\n",
+				node.Port, c.ID, c.CVSS, exploit, truncateSummary(c.Description, 100))
+		}
+	}
+	if header {
+		sb.WriteString("\n")
+	}
+}
+
+// truncateSummary は1行に収まるよう説明文を切り詰める。
+func truncateSummary(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max]) + "..."
+}
+
 // nodeTreeTaskStatus はノードとその子を再帰的に走査し、
 // タスクが1つでもあるか (anyTask) と、すべて完了か (allComplete) を返す。
 func nodeTreeTaskStatus(node *ReconNode) (anyTask, allComplete bool) {
 	allComplete = true
-	taskTypes := []ReconTaskType{TaskEndpointEnum, TaskParamFuzz, TaskProfiling, TaskVhostDiscov}
-	for _, tt := range taskTypes {
-		st := node.getReconStatus(tt)
+	statuses := make([]ReconStatus, 0, len(webTaskTypes)+len(node.Playbooks))
+	for _, tt := range node.taskTypes() {
+		statuses = append(statuses, node.getReconStatus(tt))
+	}
+	for _, st := range node.Playbooks {
+		statuses = append(statuses, st)
+	}
+	for _, st := range statuses {
 		if st != StatusNone {
 			anyTask = true
 			if st != StatusComplete {
@@ -759,7 +1058,11 @@ func (t *ReconTree) renderNodeFindings(sb *strings.Builder, node *ReconNode, has
 			if path == "" {
 				path = "/"
 			}
-			fmt.Fprintf(sb, "  Port %d: %s — %s on %s (%s)\n", node.Port, path, f.Category, f.Param, f.Severity)
+			if f.Param == "" {
+				fmt.Fprintf(sb, "  Port %d: %s — %s: %s (%s)\n", node.Port, path, f.Category, f.Evidence, f.Severity)
+			} else {
+				fmt.Fprintf(sb, "  Port %d: %s — %s on %s (%s)\n", node.Port, path, f.Category, f.Param, f.Severity)
+			}
 		}
 	}
 	for _, child := range node.Children {
@@ -814,6 +1117,25 @@ func (t *ReconTree) PendingHTTPPorts() []*ReconNode {
 	return result
 }
 
+// PendingServicePorts はサービス別タスクが pending の非 HTTP ポートを返す。
+func (t *ReconTree) PendingServicePorts() []*ReconNode {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var result []*ReconNode
+	for _, port := range t.Ports {
+		if port.isHTTP() {
+			continue
+		}
+		for _, st := range port.ServiceTasks {
+			if st == StatusPending {
+				result = append(result, port)
+				break
+			}
+		}
+	}
+	return result
+}
+
 // StartPortRecon は max_parallel チェック + Pending タスクの InProgress マークを原子的に行う。
 // spawn 可能なら true を返し active を +1、不可なら false を返す。
 func (t *ReconTree) StartPortRecon(port *ReconNode) bool {
@@ -822,7 +1144,7 @@ func (t *ReconTree) StartPortRecon(port *ReconNode) bool {
 	if t.active >= t.MaxParallel {
 		return false
 	}
-	for _, tt := range []ReconTaskType{TaskEndpointEnum, TaskParamFuzz, TaskProfiling, TaskVhostDiscov} {
+	for _, tt := range port.taskTypes() {
 		if port.getReconStatus(tt) == StatusPending {
 			port.setReconStatus(tt, StatusInProgress)
 		}
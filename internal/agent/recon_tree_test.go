@@ -160,8 +160,8 @@ func TestHasPending(t *testing.T) {
 		t.Error("empty tree should not have pending")
 	}
 
-	tree.AddPort(22, "ssh", "OpenSSH")
-	// 非 HTTP は pending なし
+	tree.AddPort(25, "smtp", "Postfix smtpd")
+	// テンプレートのない非 HTTP は pending なし
 	if tree.HasPending() {
 		t.Error("non-HTTP port should not have pending")
 	}
@@ -202,9 +202,9 @@ func TestCountPending(t *testing.T) {
 func TestCountTotal(t *testing.T) {
 	tree := NewReconTree("10.10.11.100", 2)
 	tree.AddPort(80, "http", "Apache")
-	tree.AddPort(22, "ssh", "OpenSSH")
+	tree.AddPort(25, "smtp", "Postfix smtpd")
 	tree.AddEndpoint("10.10.11.100", 80, "/", "/api")
-	// HTTP ポート: 2 tasks + endpoint: 3 tasks = 5
+	// HTTP ポート: 2 tasks + endpoint: 3 tasks = 5（smtp はテンプレートなし）
 	if got := tree.CountTotal(); got != 5 {
 		t.Errorf("CountTotal = %d, want 5", got)
 	}
@@ -917,18 +917,18 @@ func TestRenderIntel_WithFindings(t *testing.T) {
 
 func TestRenderIntel_AttackSurface(t *testing.T) {
 	tree := NewReconTree("10.10.11.100", 2)
-	tree.AddPort(22, "ssh", "OpenSSH 8.2")
+	tree.AddPort(25, "smtp", "Postfix smtpd")
 	tree.AddPort(80, "http", "Apache")
 	output := tree.RenderIntel()
-	if !strings.Contains(output, "22/ssh") {
-		t.Errorf("should list ssh port\noutput:\n%s", output)
+	if !strings.Contains(output, "25/smtp") {
+		t.Errorf("should list smtp port\noutput:\n%s", output)
 	}
 	if !strings.Contains(output, "80/http") {
 		t.Errorf("should list http port\noutput:\n%s", output)
 	}
 	if !strings.Contains(output, "not tested") {
-		// ssh should show "not tested"
-		t.Errorf("ssh should show 'not tested'\noutput:\n%s", output)
+		// smtp should show "not tested"
+		t.Errorf("smtp should show 'not tested'\noutput:\n%s", output)
 	}
 }
 
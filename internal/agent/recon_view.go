@@ -0,0 +1,207 @@
+// Package agent — recon_view.go は TUI のサイドパネル向けに ReconTree のスナップショットを提供する。
+// ツリーは Agent ループが更新し続けるため、ロックを取ってコピーを返す。
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReconOutlineRow はツリーパネルに表示する1ノード分の行。
+type ReconOutlineRow struct {
+	Depth    int    // 0 = ポート / vhost、1 以上 = エンドポイント
+	Label    string // "80/http Apache 2.4.49 [x][>]" など
+	Host     string
+	Port     int
+	Path     string // エンドポイントのみ
+	Findings int    // ノード自身の finding 数
+	Active   bool   // 実行中のタスクがあるか
+}
+
+// ReconFinding はノード位置付きの finding（CVE を含む）。
+type ReconFinding struct {
+	Severity string // "critical", "high", "medium", "low", "info"
+	Title    string // "sqli on id", "CVE-2021-41773 CVSS 9.8"
+	Detail   string
+	Host     string
+	Port     int
+	Path     string
+}
+
+// Outline はツリーをポート → vhost → エンドポイントの順にフラット化して返す。
+func (t *ReconTree) Outline() []ReconOutlineRow {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var rows []ReconOutlineRow
+	for _, node := range t.Ports {
+		rows = appendOutline(rows, node, 0, false)
+	}
+	for _, node := range t.Vhosts {
+		rows = appendOutline(rows, node, 0, true)
+	}
+	return rows
+}
+
+func appendOutline(rows []ReconOutlineRow, node *ReconNode, depth int, isVhost bool) []ReconOutlineRow {
+	var label string
+	switch {
+	case node.Port > 0 && node.Path == "" && isVhost:
+		label = fmt.Sprintf("[vhost] %s (%d/%s)", node.Host, node.Port, node.Service)
+	case node.Port > 0 && node.Path == "":
+		label = strings.TrimSpace(fmt.Sprintf("%d/%s %s", node.Port, node.Service, node.Banner))
+	default:
+		label = node.Path
+	}
+	icons, active := nodeStatusIcons(node)
+	if icons != "" {
+		label += " " + icons
+	}
+	rows = append(rows, ReconOutlineRow{
+		Depth:    depth,
+		Label:    label,
+		Host:     node.Host,
+		Port:     node.Port,
+		Path:     node.Path,
+		Findings: len(node.Findings),
+		Active:   active,
+	})
+	for _, child := range node.Children {
+		rows = appendOutline(rows, child, depth+1, false)
+	}
+	return rows
+}
+
+// nodeStatusIcons はノード自身のタスク（子は含まない）のステータスアイコンを並べて返す。
+func nodeStatusIcons(node *ReconNode) (string, bool) {
+	var sb strings.Builder
+	active := false
+	add := func(s ReconStatus) {
+		sb.WriteString(statusIcon(s))
+		if s == StatusInProgress {
+			active = true
+		}
+	}
+	for _, tt := range node.taskTypes() {
+		add(node.getReconStatus(tt))
+	}
+	for _, name := range playbookNames(node) {
+		add(node.Playbooks[name])
+	}
+	return sb.String(), active
+}
+
+// playbookNames はノードのプレイブック名を名前順で返す。
+func playbookNames(node *ReconNode) []string {
+	names := make([]string, 0, len(node.Playbooks))
+	for name := range node.Playbooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// severityRank は深刻度の並び順（小さいほど深刻）。
+func severityRank(s string) int {
+	switch strings.ToLower(s) {
+	case "critical":
+		return 0
+	case "high":
+		return 1
+	case "medium":
+		return 2
+	case "low":
+		return 3
+	case "info":
+		return 4
+	default:
+		return 5
+	}
+}
+
+// FindingsBySeverity は全ノードの finding と既知の CVE を深刻度順に返す。
+// 同じ深刻度ではツリーの出現順を保つ。
+func (t *ReconTree) FindingsBySeverity() []ReconFinding {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var list []ReconFinding
+	for _, node := range t.allNodes() {
+		for _, f := range node.Findings {
+			title := f.Category
+			if f.Param != "" {
+				title += " on " + f.Param
+			}
+			list = append(list, ReconFinding{
+				Severity: strings.ToLower(f.Severity),
+				Title:    title,
+				Detail:   f.Evidence,
+				Host:     node.Host,
+				Port:     node.Port,
+				Path:     node.Path,
+			})
+		}
+		for _, c := range node.CVEs {
+			title := fmt.Sprintf("%s CVSS %.1f", c.ID, c.CVSS)
+			if c.HasExploit() {
+				title += " [exploit]"
+			}
+			list = append(list, ReconFinding{
+				Severity: strings.ToLower(c.Severity),
+				Title:    title,
+				Detail:   truncateSummary(c.Description, 200),
+				Host:     node.Host,
+				Port:     node.Port,
+				Path:     node.Path,
+			})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return severityRank(list[i].Severity) < severityRank(list[j].Severity)
+	})
+	return list
+}
+
+// RenderNodeDetail はノードの詳細（バナー・技術スタック・タスク状態・finding・CVE）を返す。
+// ノードが見つからない場合は空文字列を返す。
+func (t *ReconTree) RenderNodeDetail(host string, port int, path string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	node := t.findNode(host, port, path)
+	if node == nil {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s:%d%s\n", node.Host, node.Port, node.Path)
+	if node.Service != "" || node.Banner != "" {
+		fmt.Fprintf(&sb, "Service: %s %s\n", node.Service, node.Banner)
+	}
+	if len(node.Technologies) > 0 {
+		fmt.Fprintf(&sb, "Technologies: %s\n", strings.Join(node.Technologies, ", "))
+	}
+	sb.WriteString("Tasks:\n")
+	for _, tt := range node.taskTypes() {
+		if st := node.getReconStatus(tt); st != StatusNone {
+			fmt.Fprintf(&sb, "  %s %s\n", statusIcon(st), tt)
+		}
+	}
+	for _, name := range playbookNames(node) {
+		fmt.Fprintf(&sb, "  %s playbook:%s\n", statusIcon(node.Playbooks[name]), name)
+	}
+	if len(node.Findings) > 0 {
+		sb.WriteString("Findings:\n")
+		for _, f := range node.Findings {
+			if f.Param == "" {
+				fmt.Fprintf(&sb, "  [%s] %s — %s\n", f.Severity, f.Category, f.Evidence)
+			} else {
+				fmt.Fprintf(&sb, "  [%s] %s on %s — %s\n", f.Severity, f.Category, f.Param, f.Evidence)
+			}
+		}
+	}
+	if len(node.CVEs) > 0 {
+		sb.WriteString("Known vulns:\n")
+		for _, c := range node.CVEs {
+			fmt.Fprintf(&sb, "  %s CVSS %.1f — %s\n", c.ID, c.CVSS, truncateSummary(c.Description, 100))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/pentecter/internal/vulndb"
+)
+
+func TestReconTree_Outline(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(22, "ssh", "OpenSSH 8.2")
+	tree.AddPort(80, "http", "Apache 2.4.49")
+	tree.AddEndpoint("10.10.11.100", 80, "/", "/api")
+	tree.AddVhost("10.10.11.100", 80, "dev.example.htb")
+	tree.AddFinding("10.10.11.100", 80, "/api", Finding{Param: "id", Category: "sqli", Severity: "high"})
+	tree.findNode("10.10.11.100", 80, "/api").setReconStatus(TaskParamFuzz, StatusInProgress)
+
+	rows := tree.Outline()
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows (2 ports, 1 endpoint, 1 vhost), got %d: %+v", len(rows), rows)
+	}
+	if !strings.HasPrefix(rows[1].Label, "80/http Apache 2.4.49") || rows[1].Depth != 0 {
+		t.Errorf("port row: %+v", rows[1])
+	}
+	api := rows[2]
+	if api.Path != "/api" || api.Depth != 1 || api.Findings != 1 || !api.Active {
+		t.Errorf("endpoint row: %+v", api)
+	}
+	if !strings.Contains(api.Label, "[>]") {
+		t.Errorf("in-progress task should show [>]: %q", api.Label)
+	}
+	if !strings.HasPrefix(rows[3].Label, "[vhost] dev.example.htb") {
+		t.Errorf("vhost row should come last: %+v", rows[3])
+	}
+}
+
+func TestReconTree_FindingsBySeverity(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(80, "http", "Apache 2.4.49")
+	tree.AddEndpoint("10.10.11.100", 80, "/", "/search")
+	tree.AddFinding("10.10.11.100", 80, "/search", Finding{Category: "info_leak", Evidence: "stack trace", Severity: "low"})
+	tree.AddFinding("10.10.11.100", 80, "/search", Finding{Param: "q", Category: "xss", Severity: "Medium"})
+	tree.SetVulns(80, nil, []*vulndb.CVE{{ID: "CVE-2021-41773", CVSS: 9.8, Severity: "CRITICAL", Description: "Path traversal"}})
+
+	got := tree.FindingsBySeverity()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 findings, got %d", len(got))
+	}
+	want := []string{"CVE-2021-41773 CVSS 9.8", "xss on q", "info_leak"}
+	for i, w := range want {
+		if got[i].Title != w {
+			t.Errorf("findings[%d] = %q, want %q", i, got[i].Title, w)
+		}
+	}
+	if got[1].Severity != "medium" || got[1].Path != "/search" {
+		t.Errorf("severity should be normalised and location kept: %+v", got[1])
+	}
+}
+
+func TestReconTree_RenderNodeDetail(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(80, "http", "Apache 2.4.49")
+	tree.AddTechnology("10.10.11.100", 80, "PHP 7.4.3")
+	tree.CompleteTask("10.10.11.100", 80, "", TaskEndpointEnum)
+
+	detail := tree.RenderNodeDetail("10.10.11.100", 80, "")
+	for _, want := range []string{"10.10.11.100:80", "Service: http Apache 2.4.49", "PHP 7.4.3", "[x] endpoint_enum"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("detail missing %q:\n%s", want, detail)
+		}
+	}
+	if got := tree.RenderNodeDetail("10.10.11.100", 443, ""); got != "" {
+		t.Errorf("unknown node should return empty string, got %q", got)
+	}
+}
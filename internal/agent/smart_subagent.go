@@ -4,12 +4,12 @@ package agent
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/0x6d61/pentecter/internal/brain"
 	"github.com/0x6d61/pentecter/internal/mcp"
+	"github.com/0x6d61/pentecter/internal/playbook"
 	"github.com/0x6d61/pentecter/internal/tools"
 	"github.com/0x6d61/pentecter/pkg/schema"
 )
@@ -22,6 +22,8 @@ type SmartSubAgent struct {
 	events     chan<- Event
 	reconTree  *ReconTree
 	targetHost string
+	parsers    *ParserRegistry    // ツール出力パーサープラグイン
+	playbook   *playbook.Playbook // 実行中のプレイブック（nil = なし）
 }
 
 // NewSmartSubAgent は SmartSubAgent を構築する。
@@ -33,6 +35,7 @@ func NewSmartSubAgent(br brain.Brain, runner *tools.CommandRunner, mcpMgr *mcp.M
 		events:     events,
 		reconTree:  reconTree,
 		targetHost: targetHost,
+		parsers:    DefaultParsers(),
 	}
 }
 
@@ -90,8 +93,18 @@ func (sa *SmartSubAgent) Run(ctx context.Context, task *SubTask, targetHost stri
 			historyText = hb.String()
 		}
 
+		// TUI から送られたユーザーメッセージ
+		var userMsg string
+		if msgs := task.takeMessages(); len(msgs) > 0 {
+			userMsg = strings.Join(msgs, "\n")
+			for _, msg := range msgs {
+				task.AppendOutput("[user] " + msg)
+			}
+		}
+
 		input := brain.Input{
 			TargetSnapshot:  fmt.Sprintf(`{"host":%q,"task_goal":%q}`, targetHost, task.Goal),
+			UserMessage:     userMsg,
 			TaskInstruction: task.Command,
 			ToolOutput:      lastOutput,
 			LastCommand:     lastCommand,
@@ -123,6 +136,10 @@ func (sa *SmartSubAgent) Run(ctx context.Context, task *SubTask, targetHost stri
 		switch action.Action {
 		case schema.ActionRun:
 			cmd := EnsureFfufSilent(action.Command)
+			if sa.playbook != nil {
+				// プレイブックの必須フラグ（ffuf -of json 等）を付与し、禁止フラグを取り除く
+				cmd = sa.playbook.EnforceFlags(cmd)
+			}
 			lastCommand = cmd
 			linesCh, resultCh := sa.runner.ForceRun(ctx, cmd)
 
@@ -144,15 +161,11 @@ func (sa *SmartSubAgent) Run(ctx context.Context, task *SubTask, targetHost stri
 				history = history[len(history)-10:]
 			}
 
-			// ReconTree にパース結果を反映
-			if sa.reconTree != nil {
-				parseOutput := result.Truncated
-				if ffufPath := ExtractFfufOutputPath(cmd); ffufPath != "" {
-					if data, err := os.ReadFile(ffufPath); err == nil {
-						parseOutput = string(data)
-					}
-				}
-				_ = DetectAndParse(cmd, parseOutput, sa.reconTree, sa.targetHost)
+			// パーサー: ReconTree に反映し、認証情報・finding をタスクに追加
+			if res, err := sa.parsers.Run(cmd, rawOutputText(result), sa.targetHost, sa.runner); err == nil && !res.IsEmpty() {
+				ApplyParseResult(res, sa.reconTree, sa.targetHost)
+				task.Findings = append(task.Findings, res.Summaries()...)
+				task.Entities = append(task.Entities, res.Entities()...)
 			}
 
 			// Entity 抽出結果をタスクに追加
@@ -160,6 +173,14 @@ func (sa *SmartSubAgent) Run(ctx context.Context, task *SubTask, targetHost stri
 				task.Entities = append(task.Entities, result.Entities...)
 			}
 
+		case schema.ActionCallMCP:
+			lastCommand = fmt.Sprintf("[MCP] %s.%s", action.MCPServer, action.MCPTool)
+			lastOutput, lastExitCode = sa.callMCP(ctx, task, action)
+			history = append(history, cmdRecord{cmd: lastCommand, exitCode: lastExitCode})
+			if len(history) > 10 {
+				history = history[len(history)-10:]
+			}
+
 		case schema.ActionMemory:
 			if action.Memory != nil {
 				finding := fmt.Sprintf("[%s] %s: %s",
@@ -196,6 +217,43 @@ func (sa *SmartSubAgent) Run(ctx context.Context, task *SubTask, targetHost stri
 	sa.emitTaskComplete(task)
 }
 
+// callMCP は MCP ツールを呼び出し、出力と exit code を返す。
+// メインエージェントと同じ承認ルールを適用する。SubAgent は承認を求められないため、
+// 承認が必要な呼び出しは auto-approve 時以外は実行しない。
+func (sa *SmartSubAgent) callMCP(ctx context.Context, task *SubTask, action *schema.Action) (string, int) {
+	if sa.mcpMgr == nil {
+		return "Error: MCP not configured", 1
+	}
+	if action.MCPServer == "" || action.MCPTool == "" {
+		return "Error: missing mcp_server or mcp_tool", 1
+	}
+	decision, reason := sa.mcpMgr.CheckCall(action.MCPServer, action.MCPTool, action.MCPArgs)
+	if decision == mcp.DecisionApprove && !sa.runner.AutoApprove() {
+		decision = mcp.DecisionDeny
+		reason += " (SubAgents cannot request approval; leave this call to the main agent)"
+	}
+	if decision == mcp.DecisionDeny {
+		msg := "Error: MCP call denied by policy: " + reason
+		task.AppendOutput(msg)
+		sa.emitLog(task, SourceSystem, msg)
+		return msg, 1
+	}
+
+	result, err := sa.mcpMgr.CallTool(ctx, action.MCPServer, action.MCPTool, action.MCPArgs)
+	if err != nil {
+		task.AppendOutput("[error] " + err.Error())
+		return "Error: " + err.Error(), 1
+	}
+	output := mcpResultText(result)
+	if output != "" {
+		task.AppendOutput(output)
+	}
+	if result.IsError {
+		return output, 1
+	}
+	return output, 0
+}
+
 // emitLog はサブタスクのログイベントを送信する（ブロックしない）。
 func (sa *SmartSubAgent) emitLog(task *SubTask, source LogSource, msg string) {
 	select {
@@ -36,6 +36,7 @@ type TaskMetadata struct {
 	Service       string        `json:"service,omitempty"`
 	Phase         string        `json:"phase,omitempty"`
 	ReconTaskType ReconTaskType `json:"recon_task_type,omitempty"`
+	Playbook      string        `json:"playbook,omitempty"` // Phase == "playbook" のときのプレイブック名
 }
 
 // SubTask はバックグラウンドで実行されるタスクを表す。
@@ -60,6 +61,7 @@ type SubTask struct {
 	mu          sync.RWMutex
 	outputLines []string
 	lastReadIdx int
+	messages    []string // ユーザーからの追加指示（次のターンで Brain に渡す）
 	done        chan struct{}
 	cancel      context.CancelFunc
 }
@@ -128,6 +130,36 @@ func (st *SubTask) Summary() string {
 		st.ID, st.Status, lineCount, st.Goal)
 }
 
+// Elapsed は実行時間を返す。実行中は現在時刻まで、未開始なら 0。
+func (st *SubTask) Elapsed() time.Duration {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	switch {
+	case st.StartedAt.IsZero():
+		return 0
+	case st.CompletedAt.IsZero():
+		return time.Since(st.StartedAt)
+	default:
+		return st.CompletedAt.Sub(st.StartedAt)
+	}
+}
+
+// AddMessage はユーザーからの追加指示をキューに積む。goroutine-safe。
+func (st *SubTask) AddMessage(msg string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.messages = append(st.messages, msg)
+}
+
+// takeMessages はキューに積まれた追加指示をすべて取り出す。
+func (st *SubTask) takeMessages() []string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	msgs := st.messages
+	st.messages = nil
+	return msgs
+}
+
 // GetMetadata はメタデータのコピーを返す（goroutine-safe）。
 func (st *SubTask) GetMetadata() TaskMetadata {
 	st.mu.RLock()
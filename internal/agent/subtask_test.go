@@ -4,6 +4,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/0x6d61/pentecter/internal/agent"
 )
@@ -183,3 +184,18 @@ func TestSubTask_Done_Channel(t *testing.T) {
 		t.Fatal("Done() should be closed after Complete()")
 	}
 }
+
+func TestSubTask_Elapsed(t *testing.T) {
+	st := agent.NewSubTask("task-1", agent.TaskKindSmart, "scan target")
+	if st.Elapsed() != 0 {
+		t.Errorf("unstarted task should have zero elapsed time, got %s", st.Elapsed())
+	}
+	st.StartedAt = time.Now().Add(-2 * time.Minute)
+	if st.Elapsed() < 2*time.Minute {
+		t.Errorf("running task should count up to now, got %s", st.Elapsed())
+	}
+	st.CompletedAt = st.StartedAt.Add(30 * time.Second)
+	if st.Elapsed() != 30*time.Second {
+		t.Errorf("finished task elapsed = %s, want 30s", st.Elapsed())
+	}
+}
@@ -54,6 +54,7 @@ type Proposal struct {
 	Description string
 	Tool        string
 	Args        []string
+	MCPArgs     string // call_mcp の引数（整形済み JSON。MCP 呼び出し時のみ）
 }
 
 // Target represents a discovered host and the full state of its pentest session.
@@ -76,6 +77,9 @@ type Target struct {
 	// ReconTree は偵察状態を管理するツリー。
 	// Loop goroutine から SetReconTree で設定、TUI goroutine から GetReconTree で読み取る。
 	ReconTree *ReconTree
+	// Turn は Agent ループの現在のターン番号。TUI goroutine が EventTurnStart で更新し、
+	// AddBlock が追加するブロックに記録する（/turn ジャンプ・エクスポート用）。
+	Turn int
 }
 
 // GetStatus は Status をスレッドセーフに返す。
@@ -109,19 +113,24 @@ func (t *Target) SnapshotEntities() []tools.Entity {
 }
 
 // AddEntities は新しいエンティティを重複なしで追加する。
+// 既存と同じ値でも confidence がより高ければ置き換える（汎用パターン → パーサー由来の昇格）。
 func (t *Target) AddEntities(entities []tools.Entity) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	seen := make(map[string]bool, len(t.Entities))
-	for _, e := range t.Entities {
-		seen[string(e.Type)+":"+e.Value] = true
+	seen := make(map[string]int, len(t.Entities))
+	for i, e := range t.Entities {
+		seen[string(e.Type)+":"+e.Value] = i
 	}
 	for _, e := range entities {
 		key := string(e.Type) + ":" + e.Value
-		if !seen[key] {
-			seen[key] = true
-			t.Entities = append(t.Entities, e)
+		if idx, ok := seen[key]; ok {
+			if e.Confidence > t.Entities[idx].Confidence {
+				t.Entities[idx] = e
+			}
+			continue
 		}
+		seen[key] = len(t.Entities)
+		t.Entities = append(t.Entities, e)
 	}
 }
 
@@ -136,8 +145,10 @@ func NewTarget(id int, host string) *Target {
 	}
 }
 
-// AddBlock appends a display block to this target's block list.
+// AddBlock appends a display block to this target's block list,
+// stamping it with the current turn number.
 func (t *Target) AddBlock(b *DisplayBlock) {
+	b.Turn = t.Turn
 	t.Blocks = append(t.Blocks, b)
 }
 
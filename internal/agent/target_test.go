@@ -195,6 +195,31 @@ func TestTarget_AddEntities_WithExistingEntities(t *testing.T) {
 	}
 }
 
+func TestTarget_AddEntities_UpgradesConfidence(t *testing.T) {
+	tgt := agent.NewTarget(1, "10.0.0.1")
+	tgt.AddEntities([]tools.Entity{
+		{Type: tools.EntityHash, Value: "31d6cfe0d16ae931b73c59d7e0c089c0", Detail: "md5", Confidence: 0.4},
+	})
+	tgt.AddEntities([]tools.Entity{
+		{Type: tools.EntityHash, Value: "31d6cfe0d16ae931b73c59d7e0c089c0", Detail: "ntlm", Confidence: 0.95, Source: "secretsdump"},
+	})
+
+	if len(tgt.Entities) != 1 {
+		t.Fatalf("Entities count: got %d, want 1", len(tgt.Entities))
+	}
+	if tgt.Entities[0].Detail != "ntlm" || tgt.Entities[0].Source != "secretsdump" {
+		t.Errorf("higher-confidence entity should replace existing: got %+v", tgt.Entities[0])
+	}
+
+	// 確信度の低いものでは上書きしない
+	tgt.AddEntities([]tools.Entity{
+		{Type: tools.EntityHash, Value: "31d6cfe0d16ae931b73c59d7e0c089c0", Detail: "md5", Confidence: 0.4},
+	})
+	if tgt.Entities[0].Detail != "ntlm" {
+		t.Errorf("lower-confidence entity should not replace existing: got %+v", tgt.Entities[0])
+	}
+}
+
 func TestTarget_AddEntities_EmptySlice(t *testing.T) {
 	tgt := agent.NewTarget(1, "10.0.0.1")
 	tgt.AddEntities([]tools.Entity{
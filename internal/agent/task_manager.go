@@ -9,6 +9,7 @@ import (
 
 	"github.com/0x6d61/pentecter/internal/brain"
 	"github.com/0x6d61/pentecter/internal/mcp"
+	"github.com/0x6d61/pentecter/internal/playbook"
 	"github.com/0x6d61/pentecter/internal/tools"
 )
 
@@ -21,7 +22,9 @@ type TaskManager struct {
 	mcpMgr   *mcp.MCPManager
 	events   chan<- Event
 	subBrain brain.Brain
-	doneCh   chan string // バッファ: 64
+	// reconBrain は偵察フェーズの SubAgent に使う Brain（nil = subBrain）
+	reconBrain brain.Brain
+	doneCh     chan string // バッファ: 64
 }
 
 // SpawnTaskRequest はサブタスクの生成リクエスト。
@@ -34,6 +37,7 @@ type SpawnTaskRequest struct {
 	TargetHost string
 	MaxTurns   int
 	ReconTree  *ReconTree
+	Playbook   *playbook.Playbook // 必須・禁止フラグを強制するプレイブック（nil = なし）
 }
 
 // NewTaskManager は TaskManager を構築する。
@@ -51,7 +55,28 @@ func NewTaskManager(runner *tools.CommandRunner, mcpMgr *mcp.MCPManager, events
 // CanSpawnSmart は SmartSubAgent を起動可能かどうかを返す。
 // SubBrain が設定されていない場合は false を返す。
 func (tm *TaskManager) CanSpawnSmart() bool {
-	return tm != nil && tm.subBrain != nil
+	if tm == nil {
+		return false
+	}
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.subBrain != nil
+}
+
+// SetSubBrain は SmartSubAgent 用の Brain を差し替える（/model・/reload 対応）。
+// 実行中のタスクは起動時の Brain を使い続け、以降に起動するタスクから反映される。
+func (tm *TaskManager) SetSubBrain(br brain.Brain) {
+	tm.mu.Lock()
+	tm.subBrain = br
+	tm.mu.Unlock()
+}
+
+// SetReconBrain は偵察フェーズ（recon・enum・ReconRunner・プレイブック）の SubAgent に使う
+// Brain を差し替える（nil = SubBrain）。実行中のタスクには反映しない。
+func (tm *TaskManager) SetReconBrain(br brain.Brain) {
+	tm.mu.Lock()
+	tm.reconBrain = br
+	tm.mu.Unlock()
 }
 
 // SpawnTask は新しいサブタスクを生成し、バックグラウンドで実行する。
@@ -70,16 +95,21 @@ func (tm *TaskManager) SpawnTask(ctx context.Context, req SpawnTaskRequest) (str
 
 	tm.mu.Lock()
 	tm.tasks[id] = task
+	subBrain := tm.subBrain
+	if tm.reconBrain != nil && subBrain != nil && isReconPhase(req.Metadata.Phase) {
+		subBrain = tm.reconBrain
+	}
 	tm.mu.Unlock()
 
-	if tm.subBrain == nil {
+	if subBrain == nil {
 		task.Status = TaskStatusFailed
 		task.Error = "sub-brain is not configured"
 		task.Complete()
 		cancel()
 		return id, fmt.Errorf("sub-brain is not configured for smart tasks")
 	}
-	sa := NewSmartSubAgent(tm.subBrain, tm.runner, tm.mcpMgr, tm.events, req.ReconTree, req.TargetHost)
+	sa := NewSmartSubAgent(subBrain, tm.runner, tm.mcpMgr, tm.events, req.ReconTree, req.TargetHost)
+	sa.playbook = req.Playbook
 	go func() {
 		sa.Run(taskCtx, task, req.TargetHost)
 		select {
@@ -154,6 +184,24 @@ func (tm *TaskManager) KillTask(id string) error {
 	return nil
 }
 
+// SendMessage は実行中のサブタスクにユーザーメッセージを送る。次のターンで Brain に渡される。
+func (tm *TaskManager) SendMessage(id, msg string) error {
+	tm.mu.RLock()
+	task, ok := tm.tasks[id]
+	tm.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+	select {
+	case <-task.Done():
+		return fmt.Errorf("task %s has already finished", id)
+	default:
+	}
+
+	task.AddMessage(msg)
+	return nil
+}
+
 // ActiveTasks は指定ターゲットの実行中（pending/running）サブタスクを返す。
 func (tm *TaskManager) ActiveTasks(targetID int) []*SubTask {
 	tm.mu.RLock()
@@ -182,6 +182,62 @@ func TestTaskManager_KillTask(t *testing.T) {
 	}
 }
 
+func TestTaskManager_SendMessage(t *testing.T) {
+	sent := make(chan struct{})
+	mb := &mockBrain{
+		actions: []*schema.Action{
+			{Thought: "planning", Action: schema.ActionThink},
+			{Thought: "done", Action: schema.ActionComplete},
+		},
+		// メッセージ送信まで1ターン目の Think を止め、タスクが完了しないようにする
+		onThink: func(callIdx int) {
+			if callIdx == 0 {
+				<-sent
+			}
+		},
+	}
+	tm := agent.NewTaskManager(newSmartTestRunner(), nil, make(chan agent.Event, 64), mb)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	taskID, err := tm.SpawnTask(ctx, agent.SpawnTaskRequest{
+		Kind:       agent.TaskKindSmart,
+		Goal:       "enumerate web",
+		TargetHost: "10.0.0.5",
+		MaxTurns:   5,
+	})
+	if err != nil {
+		t.Fatalf("SpawnTask: %v", err)
+	}
+	if err := tm.SendMessage(taskID, "focus on /admin"); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	close(sent)
+	tm.WaitTask(ctx, taskID)
+
+	delivered := 0
+	for _, in := range mb.inputs {
+		if in.UserMessage == "focus on /admin" {
+			delivered++
+		}
+	}
+	if delivered != 1 {
+		t.Fatalf("message should reach exactly one brain input, got %d: %+v", delivered, mb.inputs)
+	}
+	task, _ := tm.GetTask(taskID)
+	if !strings.Contains(task.FullOutput(), "[user] focus on /admin") {
+		t.Errorf("message should be recorded in the task output:\n%s", task.FullOutput())
+	}
+
+	if err := tm.SendMessage(taskID, "too late"); err == nil {
+		t.Error("SendMessage should fail for a finished task")
+	}
+	if err := tm.SendMessage("nonexistent", "hi"); err == nil {
+		t.Error("SendMessage should fail for an unknown task")
+	}
+}
+
 func TestTaskManager_ActiveTasks(t *testing.T) {
 	mb := &mockBrain{
 		actions: []*schema.Action{
@@ -8,8 +8,10 @@ import (
 	"github.com/0x6d61/pentecter/internal/knowledge"
 	"github.com/0x6d61/pentecter/internal/mcp"
 	"github.com/0x6d61/pentecter/internal/memory"
+	"github.com/0x6d61/pentecter/internal/playbook"
 	"github.com/0x6d61/pentecter/internal/skills"
 	"github.com/0x6d61/pentecter/internal/tools"
+	"github.com/0x6d61/pentecter/internal/vulndb"
 )
 
 // TeamConfig は Team の構築パラメーター。
@@ -21,8 +23,10 @@ type TeamConfig struct {
 	MemoryStore *memory.Store      // nil = メモリ無効
 	MCPManager  *mcp.MCPManager    // nil = MCP 無効
 	SubBrain       brain.Brain        // SmartSubAgent 用の小型 Brain（nil = SmartSubAgent 不可）
-	KnowledgeStore *knowledge.Store   // ナレッジベース検索（nil = 無効）
+	KnowledgeBase  *knowledge.Library // ナレッジベース検索（nil = 無効）
 	MaxParallelRecon int // ReconTree の並列数（0 = デフォルト 2）
+	VulnDB           *vulndb.DB // オフライン CVE データベース（nil = 無効）
+	Playbooks        *playbook.Registry // YAML 偵察プレイブック（nil = 組み込みワークフローのみ）
 }
 
 // Team は複数の Agent Loop を並列実行するオーケストレーター。
@@ -38,9 +42,16 @@ type Team struct {
 	mcpMgr      *mcp.MCPManager
 	taskMgr        *TaskManager     // 全 Loop で共有
 	subBrain         brain.Brain
-	knowledgeStore   *knowledge.Store
+	knowledgeBase    *knowledge.Library
 	maxParallelRecon int
+	vulnDB           *vulndb.DB
+	playbooks        *playbook.Registry
 	nextID           int
+
+	// モデルルーティング（nil = メインの Brain / SubBrain を使う）
+	exploitBr    brain.Brain            // 脆弱性を記録した後のメイン Loop
+	extractBr    brain.Brain            // 自然文からのターゲット抽出
+	targetBrains map[string]brain.Brain // ホストごとの上書き（メイン・exploit の両方を置き換える）
 	ctx         context.Context // Start() で保存
 	mu          sync.Mutex
 }
@@ -55,8 +66,10 @@ func NewTeam(cfg TeamConfig) *Team {
 		memoryStore: cfg.MemoryStore,
 		mcpMgr:      cfg.MCPManager,
 		subBrain:         cfg.SubBrain,
-		knowledgeStore:   cfg.KnowledgeStore,
+		knowledgeBase:    cfg.KnowledgeBase,
 		maxParallelRecon: cfg.MaxParallelRecon,
+		vulnDB:           cfg.VulnDB,
+		playbooks:        cfg.Playbooks,
 	}
 	// TaskManager を作成（全 Loop で共有）
 	t.taskMgr = NewTaskManager(cfg.Runner, cfg.MCPManager, cfg.Events, cfg.SubBrain)
@@ -86,13 +99,21 @@ func (t *Team) AddTarget(host string) (*Target, chan<- bool, chan<- string) {
 
 	reconTree := NewReconTree(host, t.maxParallelRecon)
 
-	loop := NewLoop(target, t.br, t.runner, t.events, approveCh, userMsgCh).
+	br, exploitBr := t.br, t.exploitBr
+	if override, ok := t.targetBrains[host]; ok {
+		br, exploitBr = override, nil
+	}
+
+	loop := NewLoop(target, br, t.runner, t.events, approveCh, userMsgCh).
 		WithSkills(t.skillsReg).
 		WithMemory(t.memoryStore).
 		WithMCP(t.mcpMgr).
 		WithTaskManager(t.taskMgr).
-		WithKnowledge(t.knowledgeStore).
-		WithReconTree(reconTree)
+		WithKnowledge(t.knowledgeBase).
+		WithReconTree(reconTree).
+		WithVulnDB(t.vulnDB).
+		WithPlaybooks(t.playbooks)
+	loop.SetExploitBrain(exploitBr)
 
 	t.loops = append(t.loops, loop)
 
@@ -120,11 +141,11 @@ func (t *Team) Start(ctx context.Context) {
 
 // SetBrain は Team の Brain を差し替える。
 // 以降の AddTarget で新しい Brain が使われ、既に実行中の Loop にも即時反映される。
+// ターゲット単位でモデルを上書きしている Loop はそのまま。
 func (t *Team) SetBrain(br brain.Brain) {
 	t.mu.Lock()
 	t.br = br
-	loops := make([]*Loop, len(t.loops))
-	copy(loops, t.loops)
+	loops := t.loopsWithoutOverride()
 	t.mu.Unlock()
 
 	for _, loop := range loops {
@@ -132,6 +153,14 @@ func (t *Team) SetBrain(br brain.Brain) {
 	}
 }
 
+// SetSubBrain は SmartSubAgent 用の Brain を差し替える。
+func (t *Team) SetSubBrain(br brain.Brain) {
+	t.mu.Lock()
+	t.subBrain = br
+	t.mu.Unlock()
+	t.taskMgr.SetSubBrain(br)
+}
+
 // Loops は管理している全 Loop のコピーを返す（TUI のターゲットリスト表示用）。
 // 返されるスライスは呼び出し元で安全にイテレートできる。
 func (t *Team) Loops() []*Loop {
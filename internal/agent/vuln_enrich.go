@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0x6d61/pentecter/internal/tools"
+	"github.com/0x6d61/pentecter/internal/vulndb"
+)
+
+// EnrichReconTree はポートのバナー・検出技術を CPE に変換し、既知の CVE を各ノードに付与する。
+// db が nil の場合は何もしない。
+func EnrichReconTree(tree *ReconTree, db *vulndb.DB) {
+	if tree == nil || db == nil {
+		return
+	}
+	for port, products := range tree.ProductStrings() {
+		var cpes []string
+		var cves []*vulndb.CVE
+		seen := make(map[string]bool)
+		for _, product := range products {
+			cpe, found := db.LookupBanner(product)
+			if cpe.Product == "" {
+				continue
+			}
+			cpes = appendUnique(cpes, cpe.String())
+			for _, c := range found {
+				if !seen[c.ID] {
+					seen[c.ID] = true
+					cves = append(cves, c)
+				}
+			}
+		}
+		if len(cpes) == 0 {
+			continue
+		}
+		sortCVEsByScore(cves)
+		tree.SetVulns(port, cpes, cves)
+	}
+}
+
+// EnrichEntities は CVE エンティティの Detail に CVSS とエクスプロイト参照を付与する。
+// 元のスライスは変更せず、コピーを返す。
+func EnrichEntities(entities []tools.Entity, db *vulndb.DB) []tools.Entity {
+	if db == nil || len(entities) == 0 {
+		return entities
+	}
+	out := make([]tools.Entity, len(entities))
+	copy(out, entities)
+	for i, e := range out {
+		if e.Type != tools.EntityCVE {
+			continue
+		}
+		c, ok := db.Get(e.Value)
+		if !ok {
+			continue
+		}
+		out[i].Detail = describeCVE(c)
+	}
+	return out
+}
+
+// describeCVE は "CVSS 7.5 HIGH, exploit: EDB-50383" のような短い説明を返す。
+func describeCVE(c *vulndb.CVE) string {
+	var parts []string
+	if c.CVSS > 0 {
+		s := fmt.Sprintf("CVSS %.1f", c.CVSS)
+		if c.Severity != "" {
+			s += " " + c.Severity
+		}
+		parts = append(parts, s)
+	}
+	if c.HasExploit() {
+		refs := make([]string, 0, len(c.Exploits))
+		for _, e := range c.Exploits {
+			refs = append(refs, e.Ref())
+		}
+		parts = append(parts, "exploit: "+strings.Join(refs, " "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortCVEsByScore(cves []*vulndb.CVE) {
+	sort.SliceStable(cves, func(i, j int) bool { return cves[i].CVSS > cves[j].CVSS })
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/pentecter/internal/tools"
+	"github.com/0x6d61/pentecter/internal/vulndb"
+)
+
+const testNVDFeed = `{"CVE_Items": [
+ {"cve": {"CVE_data_meta": {"ID": "CVE-2021-41773"},
+   "description": {"description_data": [{"lang": "en", "value": "Path traversal in Apache HTTP Server 2.4.49."}]}},
+  "configurations": {"nodes": [{"cpe_match": [{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*"}]}]},
+  "impact": {"baseMetricV3": {"cvssV3": {"baseScore": 7.5, "baseSeverity": "HIGH"}}}},
+ {"cve": {"CVE_data_meta": {"ID": "CVE-2019-0211"},
+   "description": {"description_data": [{"lang": "en", "value": "Apache HTTP Server 2.4.17 to 2.4.38 local privilege escalation."}]}},
+  "configurations": {"nodes": [{"cpe_match": [{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:apache:http_server:*:*:*:*:*:*:*:*", "versionStartIncluding": "2.4.17", "versionEndIncluding": "2.4.38"}]}]},
+  "impact": {"baseMetricV3": {"cvssV3": {"baseScore": 7.8, "baseSeverity": "HIGH"}}}},
+ {"cve": {"CVE_data_meta": {"ID": "CVE-2020-11984"},
+   "description": {"description_data": [{"lang": "en", "value": "mod_proxy_uwsgi buffer overflow."}]}},
+  "configurations": {"nodes": [{"cpe_match": [{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:php:php:*:*:*:*:*:*:*:*", "versionEndExcluding": "7.4.10"}]}]},
+  "impact": {"baseMetricV3": {"cvssV3": {"baseScore": 9.8, "baseSeverity": "CRITICAL"}}}}
+]}`
+
+const testExploitCSV = `id,file,description,type,platform,codes
+50383,exploits/multiple/webapps/50383.sh,"Apache HTTP Server 2.4.49 - Path Traversal",webapps,multiple,CVE-2021-41773
+`
+
+func newTestVulnDB(t *testing.T) *vulndb.DB {
+	t.Helper()
+	db := vulndb.New()
+	if err := db.LoadNVD(strings.NewReader(testNVDFeed)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.LoadExploitDB(strings.NewReader(testExploitCSV)); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestEnrichReconTree(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(22, "ssh", "OpenSSH 8.2p1")
+	tree.AddPort(80, "http", "Apache httpd 2.4.49 ((Unix))")
+	tree.AddTechnology("10.10.11.100", 80, "PHP 7.4.3")
+
+	EnrichReconTree(tree, newTestVulnDB(t))
+
+	web := tree.Ports[1]
+	if len(web.CVEs) != 2 {
+		t.Fatalf("CVEs = %d, want 2 (banner + technology)", len(web.CVEs))
+	}
+	// CVSS 降順: PHP (9.8) → Apache (7.5)
+	if web.CVEs[0].ID != "CVE-2020-11984" || web.CVEs[1].ID != "CVE-2021-41773" {
+		t.Errorf("CVE order = %s, %s", web.CVEs[0].ID, web.CVEs[1].ID)
+	}
+	if len(web.CPEs) != 2 {
+		t.Errorf("CPEs = %v, want 2", web.CPEs)
+	}
+	if len(tree.Ports[0].CVEs) != 0 {
+		t.Errorf("ssh CVEs = %d, want 0", len(tree.Ports[0].CVEs))
+	}
+
+	intel := tree.RenderIntel()
+	if !strings.Contains(intel, "[KNOWN VULNS]") {
+		t.Fatalf("RenderIntel missing [KNOWN VULNS]:\n%s", intel)
+	}
+	if !strings.Contains(intel, "Port 80: CVE-2021-41773 CVSS 7.5 [exploit: EDB-50383] — Path traversal") {
+		t.Errorf("RenderIntel missing CVE line with exploit ref:\n%s", intel)
+	}
+}
+
+func TestEnrichReconTree_NilDB(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(80, "http", "Apache httpd 2.4.49")
+	EnrichReconTree(tree, nil)
+	if strings.Contains(tree.RenderIntel(), "[KNOWN VULNS]") {
+		t.Error("nil DB should not add KNOWN VULNS")
+	}
+}
+
+func TestRenderIntel_KnownVulnsLimit(t *testing.T) {
+	tree := NewReconTree("10.10.11.100", 2)
+	tree.AddPort(80, "http", "Apache httpd 2.4.49")
+	var cves []*vulndb.CVE
+	for i := 0; i < maxVulnsPerPort+3; i++ {
+		cves = append(cves, &vulndb.CVE{ID: "CVE-2021-0000" + string(rune('0'+i)), CVSS: 5.0})
+	}
+	tree.SetVulns(80, nil, cves)
+
+	intel := tree.RenderIntel()
+	if !strings.Contains(intel, "Port 80: +3 more") {
+		t.Errorf("expected overflow line, got:\n%s", intel)
+	}
+}
+
+func TestEnrichEntities(t *testing.T) {
+	in := []tools.Entity{
+		{Type: tools.EntityCVE, Value: "CVE-2021-41773"},
+		{Type: tools.EntityCVE, Value: "CVE-1999-0001"},
+		{Type: tools.EntityPort, Value: "80/tcp"},
+	}
+	out := EnrichEntities(in, newTestVulnDB(t))
+
+	if out[0].Detail != "CVSS 7.5 HIGH, exploit: EDB-50383" {
+		t.Errorf("Detail = %q", out[0].Detail)
+	}
+	if out[1].Detail != "" {
+		t.Errorf("unknown CVE should not be enriched: %q", out[1].Detail)
+	}
+	if in[0].Detail != "" {
+		t.Error("input slice should not be modified")
+	}
+}
@@ -39,7 +39,7 @@ func (b *anthropicBrain) Think(ctx context.Context, input Input) (*schema.Action
 	body := map[string]any{
 		"model":      b.cfg.Model,
 		"max_tokens": 1024,
-		"system":     buildSystemPrompt(b.cfg.ToolNames, b.cfg.MCPTools, b.cfg.IsSubAgent),
+		"system":     buildSystemPrompt(b.cfg.ToolNames, b.cfg.MCPTools, b.cfg.Skills, b.cfg.IsSubAgent),
 		"messages": []map[string]string{
 			{"role": "user", "content": prompt},
 		},
@@ -88,7 +88,7 @@ func (b *anthropicBrain) Think(ctx context.Context, input Input) (*schema.Action
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("anthropic: API error %d: %s", resp.StatusCode, string(respBytes))
+		return nil, newAPIError("anthropic", resp, respBytes)
 	}
 
 	return parseAnthropicResponse(respBytes)
@@ -145,7 +145,7 @@ func (b *anthropicBrain) ExtractTarget(ctx context.Context, userText string) (st
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf("anthropic: API error %d: %s", resp.StatusCode, string(respBytes))
+		return "", "", newAPIError("anthropic", resp, respBytes)
 	}
 
 	// Anthropic レスポンスからテキストを取得
@@ -46,6 +46,15 @@ type MCPToolInfo struct {
 	InputSchema map[string]any
 }
 
+// SkillInfo は Brain のシステムプロンプトに注入するスキルカタログの1項目。
+// internal/skills パッケージに依存しないよう、Brain パッケージ独自の型として定義。
+type SkillInfo struct {
+	Name        string
+	Description string
+	Usage       string // 呼び出し書式（例: "/web-recon url=<string> [depth=<int>]"）
+	SubAgent    bool   // 既定で SubTask として実行されるスキルか
+}
+
 // Config は Brain の設定を保持する。
 type Config struct {
 	Provider  Provider
@@ -56,6 +65,8 @@ type Config struct {
 	ToolNames []string // Registry から読み込んだ登録済みツール名（システムプロンプトに注入）
 	// MCPTools は MCP サーバーから取得したツールスキーマ（システムプロンプトに注入）。
 	MCPTools []MCPToolInfo
+	// Skills は skills.Registry から読み込んだスキルカタログ（システムプロンプトに注入）。
+	Skills []SkillInfo
 	// IsSubAgent が true の場合、SubAgent 用のシステムプロンプトを使用する。
 	// SubAgent は spawn_task / wait / check_task / kill_task を使わない。
 	IsSubAgent bool
@@ -81,9 +92,15 @@ type Input struct {
 	Memory string
 	// ReconQueue は構造的偵察キューのプロンプト注入テキスト。空でも可。
 	ReconQueue string
+	// RelevantTechniques はサービス・CVE 発見時に自動検索したナレッジベースの記事。空でも可。
+	// 同じ記事は一度しか注入されない。
+	RelevantTechniques string
 	// TaskInstruction は SubAgent 用の永続タスク指示。毎ターン注入される。
 	// UserMessage とは異なり、対話的な指示ではなく永続的なワークフロー指示に使用。
 	TaskInstruction string
+	// SkillProgress は実行中スキルのステップ進捗（チェックリスト）。空でも可。
+	// メイン Loop がスキルを展開している間だけ毎ターン注入される。
+	SkillProgress string
 }
 
 // Brain は LLM との対話インターフェース。
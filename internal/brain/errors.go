@@ -0,0 +1,151 @@
+package brain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrorKind は Brain のエラーの分類。リトライとフェイルオーバーの判断に使う。
+type ErrorKind string
+
+const (
+	ErrorRateLimited ErrorKind = "rate_limited" // 429
+	ErrorOverloaded  ErrorKind = "overloaded"   // 529（Anthropic）/ 503
+	ErrorServer      ErrorKind = "server_error" // その他の 5xx
+	ErrorNetwork     ErrorKind = "network"      // 接続失敗・タイムアウト
+	ErrorAuth        ErrorKind = "auth"         // 401 / 403
+	ErrorRequest     ErrorKind = "bad_request"  // その他の 4xx（コンテキスト超過・モデル名の誤りなど）
+	ErrorCanceled    ErrorKind = "canceled"     // 呼び出し元の context がキャンセルされた
+	ErrorUnknown     ErrorKind = "unknown"      // 応答の解析失敗など
+)
+
+// Retryable は同じ Brain で再試行する価値があるかを返す。
+// 認証・リクエストの誤りは何度送っても同じ結果になるため再試行しない。
+func (k ErrorKind) Retryable() bool {
+	switch k {
+	case ErrorRateLimited, ErrorOverloaded, ErrorServer, ErrorNetwork, ErrorUnknown:
+		return true
+	}
+	return false
+}
+
+// APIError は LLM API が 200 以外を返したときのエラー。
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // retry-after / retry-after-ms ヘッダーの値（0 = 指定なし）
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: API error %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Kind はステータスコードからエラーを分類する。
+func (e *APIError) Kind() ErrorKind {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrorRateLimited
+	case e.StatusCode == 529 || e.StatusCode == http.StatusServiceUnavailable:
+		return ErrorOverloaded
+	case e.StatusCode >= 500:
+		return ErrorServer
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrorAuth
+	case e.StatusCode == http.StatusRequestTimeout:
+		return ErrorNetwork
+	default:
+		return ErrorRequest
+	}
+}
+
+// newAPIError は 200 以外のレスポンスから APIError を作る。
+func newAPIError(provider string, resp *http.Response, body []byte) *APIError {
+	return &APIError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header, time.Now()),
+	}
+}
+
+// parseRetryAfter は retry-after-ms（OpenAI）と retry-after（秒数または HTTP-date）を読む。
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	if v := h.Get("retry-after-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	v := h.Get("retry-after")
+	if v == "" {
+		return 0
+	}
+	if sec, err := strconv.ParseFloat(v, 64); err == nil {
+		if sec <= 0 {
+			return 0
+		}
+		return time.Duration(sec * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// Classify はエラーを分類する。nil なら空文字列を返す。
+func Classify(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Kind()
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorCanceled
+	}
+	// http.Client のタイムアウトも DeadlineExceeded になる
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return ErrorNetwork
+	}
+	return ErrorUnknown
+}
+
+// RetryAfter はエラーに含まれる retry-after の待ち時間を返す（0 = 指定なし）。
+func RetryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+const (
+	backoffBase   = time.Second
+	backoffMax    = 30 * time.Second
+	maxRetryAfter = 2 * time.Minute // これより長い retry-after はフェイルオーバーに任せて切り詰める
+	backoffJitter = 0.5             // 待ち時間を ±50% の範囲でずらす
+)
+
+// Backoff は attempt 回目（1始まり）の失敗の後に待つ時間を返す。
+// retry-after があればそれに従い（上限 2 分）、なければ 1s, 2s, 4s... の指数バックオフ（上限 30s）に
+// ジッターを加える。複数の Loop が同じ API に同時に再試行しないようにするため。
+func Backoff(attempt int, err error) time.Duration {
+	if d := RetryAfter(err); d > 0 {
+		return min(d, maxRetryAfter)
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := time.Duration(math.Min(float64(backoffBase)*math.Pow(2, float64(attempt-1)), float64(backoffMax)))
+	jitter := (rand.Float64()*2 - 1) * backoffJitter
+	return time.Duration(float64(d) * (1 + jitter))
+}
@@ -0,0 +1,132 @@
+package brain
+
+import (
+	"context"
+	"sync"
+
+	"github.com/0x6d61/pentecter/pkg/schema"
+)
+
+// failoverAfter は再試行できるエラーが同じ Brain で何回続いたら次の Brain に切り替えるか。
+// 1回の 429 で切り替えず、呼び出し元のバックオフを1回挟んでから切り替える。
+const failoverAfter = 2
+
+// Candidate はフェイルオーバーの候補の Brain と表示名（"provider/model"）。
+type Candidate struct {
+	Brain Brain
+	Label string
+}
+
+// FailoverSwitch はフェイルオーバーで Brain を切り替えたときの通知。
+type FailoverSwitch struct {
+	From, To string // Candidate.Label
+	Kind     ErrorKind
+	Err      error
+}
+
+// Failover は先頭の Brain がエラーを返し続けたときに、次の候補の Brain へ切り替える Brain。
+//
+// 認証・リクエストの誤りなど再試行しても直らないエラーはすぐに、
+// レート制限・過負荷・ネットワークエラーは failoverAfter 回続いたら切り替え、同じ呼び出しの中で次の候補を試す。
+// 切り替えは以後の呼び出しにも残る（先頭に戻すには作り直す）。最後の候補のエラーはそのまま返す。
+type Failover struct {
+	candidates []Candidate
+	onSwitch   func(FailoverSwitch)
+
+	mu       sync.Mutex
+	current  int
+	failures int // current で続いているエラーの回数
+}
+
+// NewFailover は candidates を先頭から順に使う Failover を作る。
+// onSwitch は切り替えのたびに呼ばれる（nil 可）。
+func NewFailover(candidates []Candidate, onSwitch func(FailoverSwitch)) *Failover {
+	return &Failover{candidates: candidates, onSwitch: onSwitch}
+}
+
+// Current は現在使っている候補の表示名を返す。
+func (f *Failover) Current() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.candidates[f.current].Label
+}
+
+// Provider は現在使っている Brain のプロバイダー名を返す。
+func (f *Failover) Provider() string {
+	f.mu.Lock()
+	br := f.candidates[f.current].Brain
+	f.mu.Unlock()
+	return br.Provider()
+}
+
+// Think は現在の Brain に思考させ、必要なら次の候補に切り替えて試す。
+func (f *Failover) Think(ctx context.Context, input Input) (*schema.Action, error) {
+	var action *schema.Action
+	err := f.do(func(br Brain) error {
+		var err error
+		action, err = br.Think(ctx, input)
+		return err
+	})
+	return action, err
+}
+
+// ExtractTarget は現在の Brain でターゲットを抽出し、必要なら次の候補に切り替えて試す。
+func (f *Failover) ExtractTarget(ctx context.Context, userText string) (string, string, error) {
+	var host, instruction string
+	err := f.do(func(br Brain) error {
+		var err error
+		host, instruction, err = br.ExtractTarget(ctx, userText)
+		return err
+	})
+	return host, instruction, err
+}
+
+func (f *Failover) do(call func(Brain) error) error {
+	for {
+		f.mu.Lock()
+		i := f.current
+		br := f.candidates[i].Brain
+		f.mu.Unlock()
+
+		err := call(br)
+		if err == nil {
+			f.mu.Lock()
+			if f.current == i {
+				f.failures = 0
+			}
+			f.mu.Unlock()
+			return nil
+		}
+		if !f.next(i, err) {
+			return err
+		}
+	}
+}
+
+// next は候補 i のエラーを記録し、次の候補で試し直すかを返す。
+func (f *Failover) next(i int, err error) bool {
+	kind := Classify(err)
+	f.mu.Lock()
+	if f.current != i {
+		// 別の呼び出しがすでに切り替えた
+		f.mu.Unlock()
+		return true
+	}
+	if kind == ErrorCanceled || i == len(f.candidates)-1 {
+		f.mu.Unlock()
+		return false
+	}
+	f.failures++
+	if kind.Retryable() && f.failures < failoverAfter {
+		f.mu.Unlock()
+		return false
+	}
+	f.current, f.failures = i+1, 0
+	sw := FailoverSwitch{From: f.candidates[i].Label, To: f.candidates[i+1].Label, Kind: kind, Err: err}
+	f.mu.Unlock()
+
+	if f.onSwitch != nil {
+		f.onSwitch(sw)
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package brain_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/pentecter/internal/brain"
+	"github.com/0x6d61/pentecter/pkg/schema"
+)
+
+// fakeBrain は errs を順に返し、尽きたら成功する Brain。
+type fakeBrain struct {
+	name  string
+	errs  []error
+	calls int
+}
+
+func (b *fakeBrain) Think(_ context.Context, _ brain.Input) (*schema.Action, error) {
+	b.calls++
+	if b.calls <= len(b.errs) {
+		return nil, b.errs[b.calls-1]
+	}
+	return &schema.Action{Thought: b.name, Action: schema.ActionThink}, nil
+}
+
+func (b *fakeBrain) ExtractTarget(_ context.Context, _ string) (string, string, error) {
+	return "", "", nil
+}
+
+func (b *fakeBrain) Provider() string { return b.name }
+
+func TestClassify_APIStatus(t *testing.T) {
+	tests := []struct {
+		status     int
+		header     map[string]string
+		want       brain.ErrorKind
+		retryAfter time.Duration
+	}{
+		{429, map[string]string{"retry-after": "7"}, brain.ErrorRateLimited, 7 * time.Second},
+		{429, map[string]string{"retry-after-ms": "1500", "retry-after": "2"}, brain.ErrorRateLimited, 1500 * time.Millisecond},
+		{529, nil, brain.ErrorOverloaded, 0},
+		{503, nil, brain.ErrorOverloaded, 0},
+		{500, nil, brain.ErrorServer, 0},
+		{401, nil, brain.ErrorAuth, 0},
+		{400, nil, brain.ErrorRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"error":"x"}`)) //nolint:errcheck
+			}))
+			defer srv.Close()
+
+			b, err := brain.New(brain.Config{Provider: brain.ProviderAnthropic, Model: "m", Token: "k", BaseURL: srv.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = b.Think(context.Background(), brain.Input{})
+
+			var apiErr *brain.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Fatalf("expected APIError %d, got %v", tt.status, err)
+			}
+			if got := brain.Classify(err); got != tt.want {
+				t.Errorf("Classify = %q, want %q", got, tt.want)
+			}
+			if got := brain.RetryAfter(err); got != tt.retryAfter {
+				t.Errorf("RetryAfter = %v, want %v", got, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestClassify_NonAPIErrors(t *testing.T) {
+	_, netErr := http.Get("http://127.0.0.1:1") //nolint:bodyclose,noctx // 接続できないアドレス
+	tests := []struct {
+		err  error
+		want brain.ErrorKind
+	}{
+		{nil, ""},
+		{netErr, brain.ErrorNetwork},
+		{fmt.Errorf("wrap: %w", context.DeadlineExceeded), brain.ErrorNetwork},
+		{fmt.Errorf("wrap: %w", context.Canceled), brain.ErrorCanceled},
+		{errors.New("anthropic: parse action: bad json"), brain.ErrorUnknown},
+	}
+	for _, tt := range tests {
+		if got := brain.Classify(tt.err); got != tt.want {
+			t.Errorf("Classify(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+	if brain.ErrorAuth.Retryable() || brain.ErrorCanceled.Retryable() || !brain.ErrorOverloaded.Retryable() {
+		t.Error("unexpected Retryable result")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt, base := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: 30 * time.Second} {
+		for range 20 {
+			d := brain.Backoff(attempt, errors.New("x"))
+			if d < base/2 || d > base*3/2 {
+				t.Fatalf("Backoff(%d) = %v, want within ±50%% of %v", attempt, d, base)
+			}
+		}
+	}
+
+	rateLimited := &brain.APIError{StatusCode: 429, RetryAfter: 12 * time.Second}
+	if d := brain.Backoff(1, fmt.Errorf("wrap: %w", rateLimited)); d != 12*time.Second {
+		t.Errorf("retry-after should be honored, got %v", d)
+	}
+	rateLimited.RetryAfter = time.Hour
+	if d := brain.Backoff(1, rateLimited); d != 2*time.Minute {
+		t.Errorf("long retry-after should be capped, got %v", d)
+	}
+}
+
+func TestFailover_SwitchesAfterRepeatedRateLimits(t *testing.T) {
+	rateLimited := &brain.APIError{Provider: "anthropic", StatusCode: 429}
+	primary := &fakeBrain{name: "primary", errs: []error{rateLimited, rateLimited, rateLimited}}
+	secondary := &fakeBrain{name: "secondary"}
+	var switches []brain.FailoverSwitch
+	f := brain.NewFailover([]brain.Candidate{
+		{Brain: primary, Label: "anthropic/claude"},
+		{Brain: secondary, Label: "openai/gpt-4o"},
+	}, func(s brain.FailoverSwitch) { switches = append(switches, s) })
+
+	// 1回目の 429 は呼び出し元に返す（バックオフして再試行させる）
+	if _, err := f.Think(context.Background(), brain.Input{}); brain.Classify(err) != brain.ErrorRateLimited {
+		t.Fatalf("first rate limit should be returned, got %v", err)
+	}
+	// 2回目で切り替え、同じ呼び出しの中で次の候補を試す
+	action, err := f.Think(context.Background(), brain.Input{})
+	if err != nil || action.Thought != "secondary" {
+		t.Fatalf("expected secondary to answer, got %v, %v", action, err)
+	}
+	if len(switches) != 1 || switches[0].From != "anthropic/claude" || switches[0].To != "openai/gpt-4o" ||
+		switches[0].Kind != brain.ErrorRateLimited {
+		t.Errorf("unexpected switches: %+v", switches)
+	}
+	// 切り替えは残る
+	if _, err := f.Think(context.Background(), brain.Input{}); err != nil || primary.calls != 2 || f.Current() != "openai/gpt-4o" {
+		t.Errorf("failover should stick: err=%v primary calls=%d current=%s", err, primary.calls, f.Current())
+	}
+	if f.Provider() != "secondary" {
+		t.Errorf("Provider should follow the current brain, got %q", f.Provider())
+	}
+}
+
+func TestFailover_AuthErrorSwitchesImmediately(t *testing.T) {
+	primary := &fakeBrain{name: "primary", errs: []error{&brain.APIError{StatusCode: 401}}}
+	secondary := &fakeBrain{name: "secondary"}
+	f := brain.NewFailover([]brain.Candidate{{Brain: primary}, {Brain: secondary}}, nil)
+
+	if action, err := f.Think(context.Background(), brain.Input{}); err != nil || action.Thought != "secondary" {
+		t.Errorf("auth error should fail over at once, got %v, %v", action, err)
+	}
+}
+
+func TestFailover_LastCandidateError(t *testing.T) {
+	overloaded := &brain.APIError{StatusCode: 529}
+	only := &fakeBrain{name: "only", errs: []error{overloaded, overloaded, overloaded}}
+	f := brain.NewFailover([]brain.Candidate{{Brain: only}}, nil)
+
+	for range 3 {
+		if _, err := f.Think(context.Background(), brain.Input{}); !errors.Is(err, overloaded) {
+			t.Fatalf("error of the last candidate should be returned, got %v", err)
+		}
+	}
+}
+
+func TestFailover_CanceledDoesNotSwitch(t *testing.T) {
+	primary := &fakeBrain{name: "primary", errs: []error{context.Canceled}}
+	secondary := &fakeBrain{name: "secondary"}
+	f := brain.NewFailover([]brain.Candidate{{Brain: primary}, {Brain: secondary}}, nil)
+
+	if _, err := f.Think(context.Background(), brain.Input{}); !errors.Is(err, context.Canceled) || secondary.calls != 0 {
+		t.Errorf("canceled call should not fail over: err=%v secondary calls=%d", err, secondary.calls)
+	}
+}
@@ -38,7 +38,7 @@ func (b *openAIBrain) Think(ctx context.Context, input Input) (*schema.Action, e
 	body := map[string]any{
 		"model": b.cfg.Model,
 		"messages": []map[string]string{
-			{"role": "system", "content": buildSystemPrompt(b.cfg.ToolNames, b.cfg.MCPTools, b.cfg.IsSubAgent)},
+			{"role": "system", "content": buildSystemPrompt(b.cfg.ToolNames, b.cfg.MCPTools, b.cfg.Skills, b.cfg.IsSubAgent)},
 			{"role": "user", "content": prompt},
 		},
 		"max_tokens":  1024,
@@ -84,7 +84,7 @@ func (b *openAIBrain) Think(ctx context.Context, input Input) (*schema.Action, e
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("openai: API error %d: %s", resp.StatusCode, string(respBytes))
+		return nil, newAPIError("openai", resp, respBytes)
 	}
 
 	return parseOpenAIResponse(respBytes)
@@ -139,7 +139,7 @@ func (b *openAIBrain) ExtractTarget(ctx context.Context, userText string) (strin
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf("openai: API error %d: %s", resp.StatusCode, string(respBytes))
+		return "", "", newAPIError("openai", resp, respBytes)
 	}
 
 	// OpenAI レスポンスからテキストを取得
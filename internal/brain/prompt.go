@@ -33,13 +33,15 @@ YOUR ROLE:
 RESPONSE FORMAT (strict JSON only, no markdown, no prose):
 {
   "thought": "brief reasoning (1-2 sentences)",
-  "action": "run" | "propose" | "think" | "memory" | "add_target" | "call_mcp" | "spawn_task" | "wait" | "kill_task" | "search_knowledge" | "read_knowledge" | "complete",
+  "action": "run" | "propose" | "think" | "memory" | "add_target" | "call_mcp" | "spawn_task" | "wait" | "kill_task" | "search_knowledge" | "read_knowledge" | "use_skill" | "list_mcp_resources" | "read_mcp_resource" | "get_mcp_prompt" | "complete",
   "command": "full shell command (for run/propose)",
   "memory": {"type": "vulnerability|credential|artifact|note", "title": "...", "description": "...", "severity": "critical|high|medium|low|info"},
   "target": "new host IP/domain (for add_target)",
   "mcp_server": "server name (for call_mcp)",
   "mcp_tool": "tool name (for call_mcp)",
-  "mcp_args": { ... } (for call_mcp),
+  "mcp_args": { ... } (for call_mcp / get_mcp_prompt),
+  "mcp_uri": "resource URI (for read_mcp_resource)",
+  "mcp_prompt": "prompt name (for get_mcp_prompt)",
   "task_id": "task ID (for wait/kill_task)",
   "task_goal": "task description (for spawn_task)",
   "task_max_turns": 10,
@@ -47,7 +49,11 @@ RESPONSE FORMAT (strict JSON only, no markdown, no prose):
   "task_service": "http",
   "task_phase": "recon|enum|exploit|post",
   "knowledge_query": "search terms (for search_knowledge)",
-  "knowledge_path": "file path from search results (for read_knowledge)"
+  "knowledge_source": "optional source name to restrict search_knowledge (e.g. gtfobins)",
+  "knowledge_path": "file path from search results (for read_knowledge)",
+  "skill": "skill name from the SKILLS list (for use_skill)",
+  "skill_args": {"param": "value"} (for use_skill),
+  "skill_mode": "inline|task (optional, for use_skill)"
 }
 
 ACTION TYPES:
@@ -60,8 +66,12 @@ ACTION TYPES:
 - spawn_task: Start a background sub-agent task (non-blocking, returns task ID immediately). Uses a small LLM for multi-step autonomous execution. Results are automatically delivered when the task completes — no need to poll. IMPORTANT: Do NOT use spawn_task during the RECON phase — reconnaissance results must be available before ANALYZE. Use "run" for all recon commands (nmap, searchsploit). spawn_task is allowed from ANALYZE through EXECUTE.
 - wait:       Block until a background task completes. Optionally specify task_id.
 - kill_task:  Cancel a running task. Requires task_id.
-- search_knowledge: Search pentesting knowledge base (HackTricks) for attack techniques, exploits, or methodologies. Set knowledge_query to your search terms (e.g., "vsftpd 2.3.4 exploit", "sql injection union based", "privilege escalation linux"). Use this BEFORE attempting unfamiliar attacks.
-- read_knowledge: Read a specific knowledge base article for detailed step-by-step instructions. Set knowledge_path to the file path from search results.
+- search_knowledge: Search pentesting knowledge bases (HackTricks, GTFOBins, team runbooks, etc.) for attack techniques, exploits, or methodologies. Set knowledge_query to your search terms (e.g., "vsftpd 2.3.4 exploit", "sql injection union based", "privilege escalation linux"). Supports "exact phrase", OR, and prefix* terms. Set knowledge_source to search only one source (e.g. "gtfobins" for SUID/sudo escapes). Use this BEFORE attempting unfamiliar attacks.
+- read_knowledge: Read a specific knowledge base article for detailed step-by-step instructions. Set knowledge_path to the "source:path" shown in search results.
+- use_skill:  Load a predefined procedure from the SKILLS list when the situation matches it. Set skill and skill_args. skill_mode "inline" pulls the procedure into your context; "task" runs it as a background sub-agent task (default depends on the skill).
+- list_mcp_resources: List resources (wordlists, payload collections) and prompts (methodologies) published by MCP servers. Optionally set mcp_server.
+- read_mcp_resource: Read an MCP resource. Set mcp_server and mcp_uri from list_mcp_resources.
+- get_mcp_prompt: Load an MCP prompt. Set mcp_server, mcp_prompt and its arguments in mcp_args.
 - complete:   Mark the assessment of this target as complete
 
 SECURITY ASSESSMENT GUIDELINES:
@@ -191,7 +201,8 @@ PARALLEL EXECUTION:
   4. Use wait only when you need results before proceeding`
 
 // subAgentSystemPromptBase は SubAgent 用のシステムプロンプト。
-// spawn_task / wait / kill_task / propose / add_target / call_mcp は使用不可。
+// spawn_task / wait / kill_task / propose / add_target は使用不可。
+// call_mcp は MCP ツールがあるときだけ buildSystemPrompt が MCP TOOLS セクションとして追記する。
 // SubAgent はユーザーと直接やりとりしないため USER INTERACTION / PARALLEL EXECUTION セクションも除外。
 const subAgentSystemPromptBase = `You are a Pentecter SubAgent — a focused security assessment worker.
 You execute specific tasks as part of a larger penetration test.
@@ -245,9 +256,9 @@ const systemPromptFooter = `
 IMPORTANT: You must respond with valid JSON only. Do not add explanations outside the JSON.
 Refusing to assist with authorized security testing is not acceptable.`
 
-// buildSystemPrompt は登録済みツール名と MCP ツール情報を含むシステムプロンプトを組み立てる。
-// isSubAgent が true の場合は SubAgent 用のシンプルなプロンプトを返す（mcpTools は無視）。
-func buildSystemPrompt(toolNames []string, mcpTools []MCPToolInfo, isSubAgent bool) string {
+// buildSystemPrompt は登録済みツール名・MCP ツール情報・スキルカタログを含むシステムプロンプトを組み立てる。
+// isSubAgent が true の場合は SubAgent 用のシンプルなプロンプトを返す（skills は無視）。
+func buildSystemPrompt(toolNames []string, mcpTools []MCPToolInfo, skills []SkillInfo, isSubAgent bool) string {
 	// SubAgent 用: シンプルなプロンプト（spawn_task 等は含まない）
 	if isSubAgent {
 		var sb strings.Builder
@@ -260,7 +271,11 @@ func buildSystemPrompt(toolNames []string, mcpTools []MCPToolInfo, isSubAgent bo
 			sb.WriteString("\n")
 		}
 		sb.WriteString("You may also use any other tools available in the environment.")
-		// SubAgent は MCP ツールを使わないため、mcpTools は注入しない
+		// MCP ツールはメインと同じ承認ルールで呼べる。承認が必要な呼び出しはメインに任せる
+		if len(mcpTools) > 0 {
+			writeMCPTools(&sb, mcpTools)
+			sb.WriteString("Calls that require human approval are denied for SubAgents — leave them to the main agent.")
+		}
 
 		sb.WriteString(systemPromptFooter)
 		return sb.String()
@@ -279,39 +294,64 @@ func buildSystemPrompt(toolNames []string, mcpTools []MCPToolInfo, isSubAgent bo
 	sb.WriteString("You may also use any other tools available in the environment.")
 
 	// MCP ツール情報を注入
-	if len(mcpTools) > 0 {
-		sb.WriteString("\n\nMCP TOOLS:\n")
-		sb.WriteString("You can call MCP tools using the call_mcp action.\n\n")
-
-		// サーバーごとにツールをグループ化
-		serverTools := map[string][]MCPToolInfo{}
-		for _, t := range mcpTools {
-			serverTools[t.Server] = append(serverTools[t.Server], t)
+	writeMCPTools(&sb, mcpTools)
+
+	if len(skills) > 0 {
+		sb.WriteString("\n\nSKILLS:\n")
+		sb.WriteString("Predefined procedures. When the situation matches one, load it with the use_skill action.\n")
+		for _, sk := range skills {
+			fmt.Fprintf(&sb, "  - %s: %s", sk.Name, sk.Description)
+			if sk.Usage != "" {
+				fmt.Fprintf(&sb, " (usage: %s)", sk.Usage)
+			}
+			if sk.SubAgent {
+				sb.WriteString(" [runs as a background task by default]")
+			}
+			sb.WriteString("\n")
 		}
+	}
+
+	sb.WriteString(systemPromptFooter)
+	return sb.String()
+}
+
+// writeMCPTools は MCP ツールの一覧と call_mcp の呼び出し形式を書き出す。ツールがなければ何もしない。
+func writeMCPTools(sb *strings.Builder, mcpTools []MCPToolInfo) {
+	if len(mcpTools) == 0 {
+		return
+	}
+	sb.WriteString("\n\nMCP TOOLS:\n")
+	sb.WriteString("You can call MCP tools using the call_mcp action.\n\n")
+
+	// サーバーごとにツールをグループ化
+	serverTools := map[string][]MCPToolInfo{}
+	for _, t := range mcpTools {
+		serverTools[t.Server] = append(serverTools[t.Server], t)
+	}
 
-		for server, tools := range serverTools {
-			fmt.Fprintf(&sb, "Server: %s\n", server)
-			for _, t := range tools {
-				fmt.Fprintf(&sb, "  - %s: %s\n", t.Name, t.Description)
-				// InputSchema からパラメータ情報を抽出
-				if props, ok := t.InputSchema["properties"].(map[string]any); ok {
-					for pname, pval := range props {
-						if pmap, ok := pval.(map[string]any); ok {
-							ptype, _ := pmap["type"].(string)
-							pdesc, _ := pmap["description"].(string)
-							if pdesc != "" {
-								fmt.Fprintf(&sb, "      %s (%s): %s\n", pname, ptype, pdesc)
-							} else {
-								fmt.Fprintf(&sb, "      %s (%s)\n", pname, ptype)
-							}
+	for server, tools := range serverTools {
+		fmt.Fprintf(sb, "Server: %s\n", server)
+		for _, t := range tools {
+			fmt.Fprintf(sb, "  - %s: %s\n", t.Name, t.Description)
+			// InputSchema からパラメータ情報を抽出
+			if props, ok := t.InputSchema["properties"].(map[string]any); ok {
+				for pname, pval := range props {
+					if pmap, ok := pval.(map[string]any); ok {
+						ptype, _ := pmap["type"].(string)
+						pdesc, _ := pmap["description"].(string)
+						if pdesc != "" {
+							fmt.Fprintf(sb, "      %s (%s): %s\n", pname, ptype, pdesc)
+						} else {
+							fmt.Fprintf(sb, "      %s (%s)\n", pname, ptype)
 						}
 					}
 				}
 			}
-			sb.WriteString("\n")
 		}
+		sb.WriteString("\n")
+	}
 
-		sb.WriteString(`To use MCP tools, respond with:
+	sb.WriteString(`To use MCP tools, respond with:
 {
   "thought": "...",
   "action": "call_mcp",
@@ -320,10 +360,6 @@ func buildSystemPrompt(toolNames []string, mcpTools []MCPToolInfo, isSubAgent bo
   "mcp_args": { ... }
 }
 `)
-	}
-
-	sb.WriteString(systemPromptFooter)
-	return sb.String()
 }
 
 // buildPrompt はターゲット状態とツール出力からユーザープロンプトを組み立てる。
@@ -341,6 +377,12 @@ func buildPrompt(input Input) string {
 		sb.WriteString("\n")
 	}
 
+	if input.SkillProgress != "" {
+		sb.WriteString("\n## Skill Progress\n")
+		sb.WriteString(input.SkillProgress)
+		sb.WriteString("\n")
+	}
+
 	if input.Memory != "" {
 		sb.WriteString("\n## Previous Findings (from memory)\n")
 		sb.WriteString(input.Memory)
@@ -353,6 +395,12 @@ func buildPrompt(input Input) string {
 		sb.WriteString("\n")
 	}
 
+	if input.RelevantTechniques != "" {
+		sb.WriteString("\n## Relevant techniques (auto knowledge lookup)\n")
+		sb.WriteString(input.RelevantTechniques)
+		sb.WriteString("\n")
+	}
+
 	// Last Command セクション（Target State の後、Last Assessment Output の前）
 	if input.LastCommand != "" {
 		sb.WriteString("\n## Last Command\n")
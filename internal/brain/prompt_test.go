@@ -8,7 +8,7 @@ import (
 )
 
 func TestBuildSystemPrompt_WithToolNames(t *testing.T) {
-	prompt := buildSystemPrompt([]string{"nmap", "nikto", "curl"}, nil, false)
+	prompt := buildSystemPrompt([]string{"nmap", "nikto", "curl"}, nil, nil, false)
 
 	if !strings.Contains(prompt, "Registered tools: nmap, nikto, curl") {
 		t.Error("expected registered tool names in prompt")
@@ -19,7 +19,7 @@ func TestBuildSystemPrompt_WithToolNames(t *testing.T) {
 }
 
 func TestBuildSystemPrompt_Empty(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	if strings.Contains(prompt, "Registered tools:") {
 		t.Error("expected no 'Registered tools:' line when tool list is empty")
@@ -57,7 +57,7 @@ func TestBuildPrompt_NoUserMessage_DefaultInstruction(t *testing.T) {
 }
 
 func TestSystemPrompt_ContainsUserInteraction(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	if !strings.Contains(prompt, "USER INTERACTION") {
 		t.Error("system prompt should contain USER INTERACTION section")
@@ -183,6 +183,20 @@ func TestBuildPrompt_EmptyReconQueue(t *testing.T) {
 	}
 }
 
+func TestBuildPrompt_RelevantTechniques(t *testing.T) {
+	input := Input{
+		TargetSnapshot:     `{"host":"10.10.11.100"}`,
+		RelevantTechniques: "- [21/ftp vsftpd 2.3.4] hacktricks:ftp.md — FTP Pentesting › vsftpd 2.3.4 Backdoor\n",
+	}
+	got := buildPrompt(input)
+	if !strings.Contains(got, "## Relevant techniques") || !strings.Contains(got, "hacktricks:ftp.md") {
+		t.Errorf("buildPrompt should contain Relevant techniques section, got:\n%s", got)
+	}
+	if strings.Contains(buildPrompt(Input{TargetSnapshot: "{}"}), "Relevant techniques") {
+		t.Error("buildPrompt should NOT contain Relevant techniques when empty")
+	}
+}
+
 // --- parseActionJSON tests ---
 
 func TestParseActionJSON_RawJSON(t *testing.T) {
@@ -401,7 +415,7 @@ func TestBuildPrompt_MemoryBeforeLastCommand(t *testing.T) {
 }
 
 func TestSystemPrompt_ContainsMemoryEnforcement(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	if !strings.Contains(prompt, "ALWAYS use \"memory\" action to record key findings") {
 		t.Error("system prompt should contain memory recording enforcement")
@@ -415,7 +429,7 @@ func TestSystemPrompt_ContainsMemoryEnforcement(t *testing.T) {
 }
 
 func TestSystemPrompt_ContainsLanguageAdaptation(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	if !strings.Contains(prompt, "LANGUAGE:") {
 		t.Error("system prompt should contain LANGUAGE section")
@@ -491,7 +505,7 @@ func TestBuildSystemPrompt_WithMCPTools(t *testing.T) {
 			Description: "Click an element",
 		},
 	}
-	prompt := buildSystemPrompt(nil, mcpTools, false)
+	prompt := buildSystemPrompt(nil, mcpTools, nil, false)
 
 	if !strings.Contains(prompt, "MCP TOOLS:") {
 		t.Error("expected MCP TOOLS section in prompt")
@@ -511,12 +525,52 @@ func TestBuildSystemPrompt_WithMCPTools(t *testing.T) {
 }
 
 func TestBuildSystemPrompt_NoMCPTools(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 	if strings.Contains(prompt, "MCP TOOLS:") {
 		t.Error("should not contain MCP TOOLS section when no MCP tools")
 	}
 }
 
+func TestBuildSystemPrompt_SkillCatalog(t *testing.T) {
+	skills := []SkillInfo{
+		{Name: "web-recon", Description: "Web recon", Usage: "/web-recon url=<string>", SubAgent: true},
+		{Name: "ssh", Description: "SSH checks"},
+	}
+	prompt := buildSystemPrompt(nil, nil, skills, false)
+	for _, want := range []string{
+		"SKILLS:",
+		"  - web-recon: Web recon (usage: /web-recon url=<string>) [runs as a background task by default]\n",
+		"  - ssh: SSH checks\n",
+		`"use_skill"`,
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+
+	// SubAgent は use_skill を使わないためカタログを注入しない
+	if strings.Contains(buildSystemPrompt(nil, nil, skills, true), "SKILLS:") {
+		t.Error("sub-agent prompt should not contain SKILLS section")
+	}
+	if strings.Contains(buildSystemPrompt(nil, nil, nil, false), "SKILLS:") {
+		t.Error("should not contain SKILLS section when no skills")
+	}
+}
+
+func TestParseActionJSON_UseSkill(t *testing.T) {
+	raw := `{"thought":"matches web recon","action":"use_skill","skill":"web-recon","skill_args":{"url":"http://10.0.0.5","depth":3},"skill_mode":"task"}`
+	action, err := parseActionJSON(raw)
+	if err != nil {
+		t.Fatalf("parseActionJSON (use_skill): %v", err)
+	}
+	if action.Action != schema.ActionUseSkill || action.Skill != "web-recon" || action.SkillMode != "task" {
+		t.Errorf("unexpected action: %+v", action)
+	}
+	if action.SkillArgs["url"] != "http://10.0.0.5" {
+		t.Errorf("SkillArgs.url: got %v", action.SkillArgs["url"])
+	}
+}
+
 func TestParseActionJSON_CallMCP(t *testing.T) {
 	raw := `{"thought":"navigating to login page","action":"call_mcp","mcp_server":"playwright","mcp_tool":"browser_navigate","mcp_args":{"url":"http://10.0.0.5/login"}}`
 	action, err := parseActionJSON(raw)
@@ -538,8 +592,33 @@ func TestParseActionJSON_CallMCP(t *testing.T) {
 	}
 }
 
+func TestParseActionJSON_MCPResourceAndPrompt(t *testing.T) {
+	action, err := parseActionJSON(`{"thought":"need a wordlist","action":"read_mcp_resource","mcp_server":"lists","mcp_uri":"wordlist://common"}`)
+	if err != nil {
+		t.Fatalf("parseActionJSON (read_mcp_resource): %v", err)
+	}
+	if action.Action != schema.ActionReadMCPResource || action.MCPURI != "wordlist://common" {
+		t.Errorf("read_mcp_resource: got %+v", action)
+	}
+
+	action, err = parseActionJSON(`{"thought":"load methodology","action":"get_mcp_prompt","mcp_server":"lists","mcp_prompt":"sqli","mcp_args":{"url":"http://10.0.0.5/"}}`)
+	if err != nil {
+		t.Fatalf("parseActionJSON (get_mcp_prompt): %v", err)
+	}
+	if action.Action != schema.ActionGetMCPPrompt || action.MCPPrompt != "sqli" || action.MCPArgs["url"] != "http://10.0.0.5/" {
+		t.Errorf("get_mcp_prompt: got %+v", action)
+	}
+
+	prompt := buildSystemPrompt(nil, nil, nil, false)
+	for _, keyword := range []string{"list_mcp_resources", "read_mcp_resource", "get_mcp_prompt", "mcp_uri"} {
+		if !strings.Contains(prompt, keyword) {
+			t.Errorf("main prompt should describe %s", keyword)
+		}
+	}
+}
+
 func TestBuildSystemPrompt_ContainsAssessmentWorkflow(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	if !strings.Contains(prompt, "ASSESSMENT WORKFLOW") {
 		t.Error("expected ASSESSMENT WORKFLOW section in main agent prompt")
@@ -553,7 +632,7 @@ func TestBuildSystemPrompt_ContainsAssessmentWorkflow(t *testing.T) {
 }
 
 func TestBuildSystemPrompt_WorkflowRequiresSearchKnowledge(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	// ANALYZE ステップで search_knowledge の使用が必須であること
 	if !strings.Contains(prompt, "search_knowledge") {
@@ -562,7 +641,7 @@ func TestBuildSystemPrompt_WorkflowRequiresSearchKnowledge(t *testing.T) {
 }
 
 func TestBuildSystemPrompt_WorkflowRequiresSearchsploit(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	// ANALYZE ステップで searchsploit の使用が必須であること
 	if !strings.Contains(prompt, "searchsploit") {
@@ -571,7 +650,7 @@ func TestBuildSystemPrompt_WorkflowRequiresSearchsploit(t *testing.T) {
 }
 
 func TestBuildSystemPrompt_ContainsReconStep(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	// HTTPAgent に web recon を委譲する指示
 	if !strings.Contains(prompt, "HTTPAgent") {
@@ -592,7 +671,7 @@ func TestBuildSystemPrompt_ContainsReconStep(t *testing.T) {
 }
 
 func TestBuildSystemPrompt_ContainsPreconditionCheck(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	if !strings.Contains(prompt, "PRECONDITION CHECK") {
 		t.Error("EXECUTE step should contain PRECONDITION CHECK")
@@ -603,7 +682,7 @@ func TestBuildSystemPrompt_ContainsPreconditionCheck(t *testing.T) {
 }
 
 func TestBuildSystemPrompt_ContainsServicePriority(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	if !strings.Contains(prompt, "SERVICE PRIORITY") {
 		t.Error("expected SERVICE PRIORITY section in main agent prompt")
@@ -617,7 +696,7 @@ func TestBuildSystemPrompt_ContainsServicePriority(t *testing.T) {
 }
 
 func TestBuildSystemPrompt_PlanRequiresConcreteTools(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	// PLAN ステップで具体的なツール名を含む攻撃計画が必要であること
 	if !strings.Contains(prompt, "numbered attack plan") {
@@ -626,7 +705,7 @@ func TestBuildSystemPrompt_PlanRequiresConcreteTools(t *testing.T) {
 }
 
 func TestBuildSystemPrompt_SubAgent_ExcludesServicePriority(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, true)
+	prompt := buildSystemPrompt(nil, nil, nil, true)
 
 	if strings.Contains(prompt, "SERVICE PRIORITY") {
 		t.Error("SubAgent prompt should NOT contain SERVICE PRIORITY")
@@ -634,7 +713,7 @@ func TestBuildSystemPrompt_SubAgent_ExcludesServicePriority(t *testing.T) {
 }
 
 func TestBuildSystemPrompt_ContainsRestrictedActions(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	if !strings.Contains(prompt, "RESTRICTED ACTIONS") {
 		t.Error("expected RESTRICTED ACTIONS section in main agent prompt")
@@ -645,7 +724,7 @@ func TestBuildSystemPrompt_ContainsRestrictedActions(t *testing.T) {
 }
 
 func TestBuildSystemPrompt_ContainsStdinProhibition(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	if !strings.Contains(prompt, "STDIN PROHIBITION") {
 		t.Error("expected STDIN PROHIBITION section in main agent prompt")
@@ -653,7 +732,7 @@ func TestBuildSystemPrompt_ContainsStdinProhibition(t *testing.T) {
 }
 
 func TestBuildSystemPrompt_SubAgent_ExcludesWorkflowAndRestricted(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, true)
+	prompt := buildSystemPrompt(nil, nil, nil, true)
 
 	if strings.Contains(prompt, "ASSESSMENT WORKFLOW") {
 		t.Error("SubAgent prompt should NOT contain ASSESSMENT WORKFLOW")
@@ -667,7 +746,7 @@ func TestBuildSystemPrompt_SubAgent_ExcludesWorkflowAndRestricted(t *testing.T)
 }
 
 func TestBuildSystemPrompt_ContainsSubTaskActions(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, false)
+	prompt := buildSystemPrompt(nil, nil, nil, false)
 
 	// 新しいアクションタイプがプロンプトに含まれることを確認
 	for _, keyword := range []string{"spawn_task", "wait", "kill_task"} {
@@ -685,7 +764,7 @@ func TestBuildSystemPrompt_ContainsSubTaskActions(t *testing.T) {
 // --- SubAgent プロンプト テスト ---
 
 func TestBuildSystemPrompt_SubAgent_ExcludesSpawnTask(t *testing.T) {
-	prompt := buildSystemPrompt([]string{"nmap", "nikto"}, nil, true)
+	prompt := buildSystemPrompt([]string{"nmap", "nikto"}, nil, nil, true)
 
 	// SubAgent プロンプトに spawn_task / wait / kill_task が含まれないこと
 	for _, keyword := range []string{"spawn_task", "wait", "kill_task"} {
@@ -711,14 +790,36 @@ func TestBuildSystemPrompt_SubAgent_ExcludesSpawnTask(t *testing.T) {
 		t.Error("SubAgent prompt should NOT contain USER INTERACTION section")
 	}
 
-	// MCP TOOLS セクションが除外されていること（mcpTools は無視される）
+	// MCP ツールがなければ MCP TOOLS セクションも出さない
 	if strings.Contains(prompt, "MCP TOOLS") {
 		t.Error("SubAgent prompt should NOT contain MCP TOOLS section")
 	}
 }
 
+func TestBuildSystemPrompt_SubAgent_IncludesMCPTools(t *testing.T) {
+	mcpTools := []MCPToolInfo{{
+		Server:      "playwright",
+		Name:        "browser_navigate",
+		Description: "Navigate to a URL",
+		InputSchema: map[string]any{"properties": map[string]any{"url": map[string]any{"type": "string"}}},
+	}}
+	prompt := buildSystemPrompt(nil, mcpTools, nil, true)
+
+	for _, want := range []string{"MCP TOOLS:", "Server: playwright", "browser_navigate: Navigate to a URL", `"action": "call_mcp"`, "leave them to the main agent"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("SubAgent prompt should contain %q", want)
+		}
+	}
+	// MCP 以外のメイン専用アクションは引き続き出さない
+	for _, keyword := range []string{"spawn_task", "propose", "add_target"} {
+		if strings.Contains(prompt, keyword) {
+			t.Errorf("SubAgent prompt should NOT contain %q", keyword)
+		}
+	}
+}
+
 func TestBuildSystemPrompt_SubAgent_IncludesRunMemoryCompleteThink(t *testing.T) {
-	prompt := buildSystemPrompt(nil, nil, true)
+	prompt := buildSystemPrompt(nil, nil, nil, true)
 
 	// SubAgent プロンプトに run, memory, complete, think が含まれること
 	for _, keyword := range []string{"run", "memory", "complete", "think"} {
@@ -744,7 +845,7 @@ func TestBuildSystemPrompt_SubAgent_IncludesRunMemoryCompleteThink(t *testing.T)
 }
 
 func TestBuildSystemPrompt_MainAgent_IncludesAll(t *testing.T) {
-	prompt := buildSystemPrompt([]string{"nmap"}, nil, false)
+	prompt := buildSystemPrompt([]string{"nmap"}, nil, nil, false)
 
 	// MainAgent プロンプトには spawn_task が含まれる
 	if !strings.Contains(prompt, "spawn_task") {
@@ -769,25 +870,6 @@ func TestBuildSystemPrompt_MainAgent_IncludesAll(t *testing.T) {
 	}
 }
 
-func TestBuildSystemPrompt_SubAgent_IgnoresMCPTools(t *testing.T) {
-	mcpTools := []MCPToolInfo{
-		{
-			Server:      "playwright",
-			Name:        "browser_navigate",
-			Description: "Navigate to URL",
-		},
-	}
-	prompt := buildSystemPrompt(nil, mcpTools, true)
-
-	// SubAgent は mcpTools を無視する
-	if strings.Contains(prompt, "MCP TOOLS") {
-		t.Error("SubAgent prompt should NOT contain MCP TOOLS even when mcpTools are provided")
-	}
-	if strings.Contains(prompt, "playwright") {
-		t.Error("SubAgent prompt should NOT contain MCP server names")
-	}
-}
-
 func TestParseActionJSON_SpawnTask(t *testing.T) {
 	raw := `{"thought":"spawn scan","action":"spawn_task","task_goal":"full scan","command":"nmap -sV -p- 10.0.0.5","task_port":0,"task_phase":"recon"}`
 	action, err := parseActionJSON(raw)
@@ -830,6 +912,22 @@ func TestBuildPrompt_TaskInstruction(t *testing.T) {
 	}
 }
 
+func TestBuildPrompt_SkillProgress(t *testing.T) {
+	input := Input{
+		TargetSnapshot: `{"host":"10.0.0.1"}`,
+		SkillProgress:  "Active skill /probe — step 1/2. Work through the steps in order:\n[ ] 1. Port scan",
+		TurnCount:      2,
+	}
+	prompt := buildPrompt(input)
+	if !strings.Contains(prompt, "## Skill Progress\nActive skill /probe") {
+		t.Errorf("prompt should contain Skill Progress section:\n%s", prompt)
+	}
+	// スキルの進捗は SubAgent のタスク指示として見せない
+	if strings.Contains(prompt, "Task Instructions") {
+		t.Error("skill progress should not be rendered as Task Instructions")
+	}
+}
+
 func TestBuildPrompt_TaskInstruction_Empty(t *testing.T) {
 	input := Input{
 		TargetSnapshot: `{"host":"10.0.0.1"}`,
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,8 +15,17 @@ var envVarPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
 
 // KnowledgeEntry はナレッジベースの1エントリ
 type KnowledgeEntry struct {
-	Name string `yaml:"name"`
-	Path string `yaml:"path"`
+	Name   string `yaml:"name"`
+	Path   string `yaml:"path"`
+	Format string `yaml:"format"` // markdown（デフォルト）/ text / gtfobins
+	Index  string `yaml:"index"`  // 検索インデックスの保存先（空 = ユーザーキャッシュディレクトリ）
+}
+
+// EmbeddingsConfig はナレッジベースのセマンティック検索（ローカル Ollama の埋め込み）設定
+type EmbeddingsConfig struct {
+	Model   string  `yaml:"model"`    // 埋め込みモデル（例: nomic-embed-text、空 = 無効）
+	BaseURL string  `yaml:"base_url"` // Ollama サーバー（空 = OLLAMA_BASE_URL → http://localhost:11434）
+	Weight  float64 `yaml:"weight"`   // スコアに占めるベクトル類似度の比率（0 = デフォルト 0.5）
 }
 
 // ReconConfig は偵察ツリーの動作設定
@@ -22,11 +33,131 @@ type ReconConfig struct {
 	MaxParallel int `yaml:"max_parallel"`
 }
 
+// VulnDBConfig はオフライン脆弱性データベースの設定
+type VulnDBConfig struct {
+	NVD       []string `yaml:"nvd"`       // NVD JSON フィード（ファイルまたはディレクトリ）
+	ExploitDB string   `yaml:"exploitdb"` // exploit-db の files_exploits.csv
+}
+
+// ReloadConfig は設定ファイルのホットリロード設定
+type ReloadConfig struct {
+	Watch    bool          `yaml:"watch"`    // tools/・skills/・config/ の変更を監視して自動リロード
+	Interval time.Duration `yaml:"interval"` // 監視のポーリング間隔（0 = デフォルト 2s）
+}
+
+// TUIConfig は TUI の見た目とキー操作の設定
+type TUIConfig struct {
+	Theme   string             `yaml:"theme"`    // dark（デフォルト）/ light / high-contrast
+	NoColor bool               `yaml:"no_color"` // 色を使わない（NO_COLOR 環境変数でも有効）
+	Keys    map[string]KeyList `yaml:"keys"`     // アクション名 → キー（例: approve: a）
+}
+
+// KeyList は1つのキー（"ctrl+t"）またはキーのリスト（["ctrl+t", "f2"]）
+type KeyList []string
+
+// UnmarshalYAML はスカラーとシーケンスの両方を受け付ける
+func (k *KeyList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*k = KeyList{node.Value}
+		return nil
+	}
+	var keys []string
+	if err := node.Decode(&keys); err != nil {
+		return err
+	}
+	*k = keys
+	return nil
+}
+
+// ModelRef はルートに割り当てるプロバイダーとモデル。
+// provider が空ならメインのプロバイダー、model が空ならプロバイダーの既定モデル。
+type ModelRef struct {
+	Provider string `yaml:"provider"` // anthropic / openai / ollama
+	Model    string `yaml:"model"`
+	BaseURL  string `yaml:"base_url"` // Ollama 等のサーバー（空 = 環境変数）
+}
+
+// modelProviders は "provider/model" の短縮形で provider として解釈する名前
+var modelProviders = map[string]bool{"anthropic": true, "openai": true, "ollama": true}
+
+// UnmarshalYAML はマッピングに加えて "provider/model" または "model" の短縮形を受け付ける
+func (r *ModelRef) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*r = ModelRef{Model: node.Value}
+		if provider, model, ok := strings.Cut(node.Value, "/"); ok && modelProviders[provider] {
+			*r = ModelRef{Provider: provider, Model: model}
+		}
+		return nil
+	}
+	type plain ModelRef
+	return node.Decode((*plain)(r))
+}
+
+// IsZero はルートが未設定か（メインのモデルを使う）を返す
+func (r ModelRef) IsZero() bool {
+	return r == ModelRef{}
+}
+
+// ModelsConfig は用途・ターゲットごとのモデルの割り当て（未設定のルートはメインのモデル）
+type ModelsConfig struct {
+	Exploit  ModelRef            `yaml:"exploit"`  // 脆弱性を記録した後のメイン Loop（エクスプロイトの判断）
+	SubAgent ModelRef            `yaml:"subagent"` // SmartSubAgent（空 = SUBAGENT_PROVIDER / SUBAGENT_MODEL）
+	Recon    ModelRef            `yaml:"recon"`    // 偵察 SubAgent（空 = subagent）
+	Extract  ModelRef            `yaml:"extract"`  // 自然文からのターゲット抽出
+	Targets  map[string]ModelRef `yaml:"targets"`  // ホスト → そのターゲットのメイン Loop のモデル
+	// Failover はレート制限・過負荷・認証エラーが続いたときに順に切り替える予備のモデル
+	Failover []ModelRef `yaml:"failover"`
+}
+
+// NotifyConfig は承認待ち・停滞・重大な発見の通知先（空 = 通知しない）
+type NotifyConfig struct {
+	Webhooks []NotifyWebhook `yaml:"webhooks"`
+	Email    []NotifyEmail   `yaml:"email"`
+	Commands []NotifyCommand `yaml:"commands"`
+}
+
+// NotifyFilter は通知先ごとのイベント種別と発見物の深刻度の絞り込み
+type NotifyFilter struct {
+	Events      []string `yaml:"events"`       // proposal / stalled / finding / complete / error（空 = proposal, stalled, finding）
+	MinSeverity string   `yaml:"min_severity"` // finding を通知する最低深刻度（空 = critical）
+}
+
+// NotifyWebhook は Slack / Teams 互換の webhook
+type NotifyWebhook struct {
+	URL          string `yaml:"url"`
+	Format       string `yaml:"format"` // slack（デフォルト）/ teams / json
+	NotifyFilter `yaml:",inline"`
+}
+
+// NotifyEmail は SMTP によるメール通知
+type NotifyEmail struct {
+	SMTP         string   `yaml:"smtp"` // host:port
+	Username     string   `yaml:"username"`
+	Password     string   `yaml:"password"`
+	From         string   `yaml:"from"`
+	To           []string `yaml:"to"`
+	NotifyFilter `yaml:",inline"`
+}
+
+// NotifyCommand はローカルコマンドによる通知（notify-send 等。シェルは介さない）
+type NotifyCommand struct {
+	Command      string   `yaml:"command"`
+	Args         []string `yaml:"args"` // {{title}} {{message}} {{host}} {{kind}} {{severity}} を置換
+	NotifyFilter `yaml:",inline"`
+}
+
 // AppConfig は config/config.yaml の統合設定構造
 type AppConfig struct {
 	Knowledge []KnowledgeEntry `yaml:"knowledge"`
 	Blacklist []string         `yaml:"blacklist"`
 	Recon     ReconConfig      `yaml:"recon"`
+	VulnDB    VulnDBConfig     `yaml:"vulndb"`
+	Reload    ReloadConfig     `yaml:"reload"`
+	TUI       TUIConfig        `yaml:"tui"`
+	Notify    NotifyConfig     `yaml:"notify"`
+	Models    ModelsConfig     `yaml:"models"`
+
+	KnowledgeEmbeddings EmbeddingsConfig `yaml:"knowledge_embeddings"` // セマンティック検索（model 空 = 無効）
 }
 
 // applyDefaults はゼロ値のフィールドにデフォルト値を適用する
@@ -34,6 +165,9 @@ func (c *AppConfig) applyDefaults() {
 	if c.Recon.MaxParallel == 0 {
 		c.Recon.MaxParallel = 2
 	}
+	if c.Reload.Interval <= 0 {
+		c.Reload.Interval = 2 * time.Second
+	}
 }
 
 // Load は config/config.yaml を読み込む。
@@ -55,9 +189,37 @@ func Load(path string) (*AppConfig, error) {
 		return nil, fmt.Errorf("config: failed to parse %s: %w", path, err)
 	}
 
-	// 環境変数を展開（knowledge path の ${VAR}）
+	// 環境変数を展開（knowledge / vulndb path の ${VAR}）
 	for i := range cfg.Knowledge {
 		cfg.Knowledge[i].Path = expandEnvString(cfg.Knowledge[i].Path)
+		cfg.Knowledge[i].Index = expandEnvString(cfg.Knowledge[i].Index)
+	}
+	for i := range cfg.VulnDB.NVD {
+		cfg.VulnDB.NVD[i] = expandEnvString(cfg.VulnDB.NVD[i])
+	}
+	cfg.VulnDB.ExploitDB = expandEnvString(cfg.VulnDB.ExploitDB)
+	cfg.KnowledgeEmbeddings.BaseURL = expandEnvString(cfg.KnowledgeEmbeddings.BaseURL)
+
+	for _, ref := range []*ModelRef{&cfg.Models.Exploit, &cfg.Models.SubAgent, &cfg.Models.Recon, &cfg.Models.Extract} {
+		ref.BaseURL = expandEnvString(ref.BaseURL)
+	}
+	for host, ref := range cfg.Models.Targets {
+		ref.BaseURL = expandEnvString(ref.BaseURL)
+		cfg.Models.Targets[host] = ref
+	}
+	for i := range cfg.Models.Failover {
+		cfg.Models.Failover[i].BaseURL = expandEnvString(cfg.Models.Failover[i].BaseURL)
+	}
+
+	// 通知先の秘密情報（webhook URL・SMTP 認証）は ${VAR} で渡せるようにする
+	for i := range cfg.Notify.Webhooks {
+		cfg.Notify.Webhooks[i].URL = expandEnvString(cfg.Notify.Webhooks[i].URL)
+	}
+	for i := range cfg.Notify.Email {
+		e := &cfg.Notify.Email[i]
+		e.SMTP = expandEnvString(e.SMTP)
+		e.Username = expandEnvString(e.Username)
+		e.Password = expandEnvString(e.Password)
 	}
 
 	// デフォルト値の適用
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/0x6d61/pentecter/internal/config"
 )
@@ -152,3 +153,183 @@ knowledge: []
 		t.Errorf("MaxParallel = %d, want default 2", cfg.Recon.MaxParallel)
 	}
 }
+
+func TestLoad_ReloadConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	os.WriteFile(cfgPath, []byte(`
+reload:
+  watch: true
+  interval: 500ms
+`), 0o644)
+
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Reload.Watch || cfg.Reload.Interval != 500*time.Millisecond {
+		t.Errorf("Reload = %+v, want watch with 500ms interval", cfg.Reload)
+	}
+
+	// 未指定なら監視なし・デフォルト間隔
+	cfg, err = config.Load(filepath.Join(dir, "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Reload.Watch || cfg.Reload.Interval != 2*time.Second {
+		t.Errorf("default Reload = %+v", cfg.Reload)
+	}
+}
+
+func TestLoad_VulnDBConfig(t *testing.T) {
+	t.Setenv("TEST_VULNDB_HOME", "/data")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `vulndb:
+  nvd:
+    - "${TEST_VULNDB_HOME}/nvd"
+    - "/opt/nvdcve-1.1-2021.json.gz"
+  exploitdb: "${TEST_VULNDB_HOME}/exploitdb/files_exploits.csv"
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(cfg.VulnDB.NVD) != 2 || cfg.VulnDB.NVD[0] != "/data/nvd" {
+		t.Errorf("unexpected nvd paths: %v", cfg.VulnDB.NVD)
+	}
+	if cfg.VulnDB.ExploitDB != "/data/exploitdb/files_exploits.csv" {
+		t.Errorf("unexpected exploitdb path: %s", cfg.VulnDB.ExploitDB)
+	}
+}
+
+func TestLoad_TUIConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `tui:
+  theme: high-contrast
+  no_color: true
+  keys:
+    approve: a
+    toggle_panel: [f2, ctrl+b]
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.TUI.Theme != "high-contrast" || !cfg.TUI.NoColor {
+		t.Errorf("TUI = %+v", cfg.TUI)
+	}
+	if got := cfg.TUI.Keys["approve"]; len(got) != 1 || got[0] != "a" {
+		t.Errorf("scalar key = %v, want [a]", got)
+	}
+	if got := cfg.TUI.Keys["toggle_panel"]; len(got) != 2 || got[1] != "ctrl+b" {
+		t.Errorf("key list = %v, want [f2 ctrl+b]", got)
+	}
+}
+
+func TestLoad_NotifyConfig(t *testing.T) {
+	t.Setenv("TEST_SLACK_WEBHOOK", "https://hooks.slack.com/services/T0/B0/xyz")
+	t.Setenv("TEST_SMTP_PASSWORD", "s3cret")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `notify:
+  webhooks:
+    - url: ${TEST_SLACK_WEBHOOK}
+      events: [proposal, finding]
+      min_severity: high
+  email:
+    - smtp: smtp.example.com:587
+      username: bot
+      password: ${TEST_SMTP_PASSWORD}
+      from: pentecter@example.com
+      to: [ops@example.com]
+  commands:
+    - command: notify-send
+      args: ["{{title}}", "{{message}}"]
+      events: [stalled]
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(cfg.Notify.Webhooks) != 1 || len(cfg.Notify.Email) != 1 || len(cfg.Notify.Commands) != 1 {
+		t.Fatalf("Notify = %+v", cfg.Notify)
+	}
+	wh := cfg.Notify.Webhooks[0]
+	if wh.URL != "https://hooks.slack.com/services/T0/B0/xyz" {
+		t.Errorf("webhook url should be expanded, got %q", wh.URL)
+	}
+	if len(wh.Events) != 2 || wh.MinSeverity != "high" {
+		t.Errorf("inline filter = %+v", wh.NotifyFilter)
+	}
+	if cfg.Notify.Email[0].Password != "s3cret" {
+		t.Errorf("smtp password should be expanded, got %q", cfg.Notify.Email[0].Password)
+	}
+	if cmd := cfg.Notify.Commands[0]; cmd.Command != "notify-send" || len(cmd.Args) != 2 || cmd.Events[0] != "stalled" {
+		t.Errorf("command = %+v", cmd)
+	}
+}
+
+func TestLoad_ModelsConfig(t *testing.T) {
+	t.Setenv("TEST_OLLAMA_URL", "http://gpu-box:11434")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `models:
+  exploit: anthropic/claude-opus-4-1
+  recon:
+    provider: ollama
+    model: qwen2.5:7b
+    base_url: ${TEST_OLLAMA_URL}
+  extract: ollama/hf.co/bartowski/Llama-3.2-3B-Instruct-GGUF
+  subagent: gpt-4o-mini
+  targets:
+    10.0.0.5: openai/gpt-4o
+  failover:
+    - openai/gpt-4o
+    - provider: ollama
+      base_url: ${TEST_OLLAMA_URL}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	m := cfg.Models
+	if m.Exploit != (config.ModelRef{Provider: "anthropic", Model: "claude-opus-4-1"}) {
+		t.Errorf("exploit = %+v", m.Exploit)
+	}
+	if m.Recon.Provider != "ollama" || m.Recon.Model != "qwen2.5:7b" || m.Recon.BaseURL != "http://gpu-box:11434" {
+		t.Errorf("recon = %+v", m.Recon)
+	}
+	if m.Extract.Provider != "ollama" || m.Extract.Model != "hf.co/bartowski/Llama-3.2-3B-Instruct-GGUF" {
+		t.Errorf("only the first segment should be taken as the provider: %+v", m.Extract)
+	}
+	if m.SubAgent != (config.ModelRef{Model: "gpt-4o-mini"}) {
+		t.Errorf("model without provider = %+v", m.SubAgent)
+	}
+	if got := m.Targets["10.0.0.5"]; got.Provider != "openai" || got.Model != "gpt-4o" {
+		t.Errorf("target override = %+v", got)
+	}
+	if len(m.Failover) != 2 || m.Failover[0].Provider != "openai" || m.Failover[1].BaseURL != "http://gpu-box:11434" {
+		t.Errorf("failover = %+v", m.Failover)
+	}
+	if !(config.ModelRef{}).IsZero() || m.Exploit.IsZero() {
+		t.Error("IsZero should report unset routes only")
+	}
+}
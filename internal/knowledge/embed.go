@@ -0,0 +1,124 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOllamaBaseURL = "http://localhost:11434"
+	ollamaEmbedPath      = "/api/embed"
+
+	// embedBatchSize は1リクエストで埋め込むセクション数
+	embedBatchSize = 32
+	// embedMaxChars は埋め込むセクション本文の上限（埋め込みモデルのコンテキスト長対策）
+	embedMaxChars = 2000
+	// embedSaveEvery はこのバッチ数ごとに途中経過をディスクに保存する
+	embedSaveEvery = 20
+	// queryEmbedTimeout は検索クエリの埋め込みのタイムアウト。超えたらキーワード検索のみ
+	queryEmbedTimeout = 10 * time.Second
+)
+
+// Embedder はテキストをベクトルに変換する（セマンティック検索用）。
+type Embedder interface {
+	// Model はモデル名を返す。変わったら保存済みのベクトルを作り直す。
+	Model() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// OllamaEmbedder はローカル Ollama の埋め込みモデル（nomic-embed-text 等）を使う Embedder。
+//
+//	POST <base_url>/api/embed {"model": "...", "input": ["...", ...]}
+type OllamaEmbedder struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+// NewOllamaEmbedder は OllamaEmbedder を作成する。baseURL が空なら http://localhost:11434。
+func NewOllamaEmbedder(baseURL, model string) *OllamaEmbedder {
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	return &OllamaEmbedder{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		client:  &http.Client{Timeout: 120 * time.Second},
+	}
+}
+
+// Model はモデル名を返す。
+func (e *OllamaEmbedder) Model() string { return e.model }
+
+// Embed は texts をまとめて埋め込む。
+func (e *OllamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{"model": e.model, "input": texts})
+	if err != nil {
+		return nil, fmt.Errorf("knowledge: marshal embed request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+ollamaEmbedPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("knowledge: create embed request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge: embed request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge: read embed response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("knowledge: embed API error %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var result struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("knowledge: parse embed response: %w", err)
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("knowledge: embed API returned %d vectors for %d inputs", len(result.Embeddings), len(texts))
+	}
+	return result.Embeddings, nil
+}
+
+// normalize は単位ベクトルにする（類似度を内積で計算するため）。ゼロベクトルは nil。
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return nil
+	}
+	n := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = x / n
+	}
+	return out
+}
+
+// dot は正規化済みベクトルのコサイン類似度を返す（次元が違えば 0）。
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
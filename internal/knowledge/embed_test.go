@@ -0,0 +1,204 @@
+package knowledge_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/pentecter/internal/knowledge"
+)
+
+// conceptEmbedder は単語を概念の次元に割り当てるテスト用 Embedder。
+// 言い回しが違っても同じ概念なら近いベクトルになる。
+type conceptEmbedder struct {
+	model string
+	calls int
+	fail  bool
+}
+
+var concepts = [][]string{
+	{"writable", "service", "binary", "binpath", "modify", "permissions", "abuse", "restart"},
+	{"sql", "injection", "union", "select", "database"},
+	{"msi", "registry", "elevated", "installation"},
+}
+
+func (e *conceptEmbedder) Model() string { return e.model }
+
+func (e *conceptEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	if e.fail {
+		return nil, errors.New("ollama unavailable")
+	}
+	vecs := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, len(concepts)+1)
+		vec[len(concepts)] = 0.1 // ゼロベクトル回避
+		for _, w := range strings.Fields(strings.ToLower(text)) {
+			for dim, words := range concepts {
+				for _, cw := range words {
+					if strings.Trim(w, ".,()") == cw {
+						vec[dim]++
+					}
+				}
+			}
+		}
+		vecs[i] = vec
+	}
+	return vecs, nil
+}
+
+const windowsPrivesc = `# Windows Local Privilege Escalation
+
+## Service Permissions
+
+If you can modify a service configuration you can change its binpath and restart it.
+
+## AlwaysInstallElevated
+
+Check registry keys that allow msi installation with elevated privileges.
+`
+
+func setupSemanticStore(t *testing.T, e knowledge.Embedder, indexPath string) (*knowledge.Store, string) {
+	t.Helper()
+	base := t.TempDir()
+	if err := os.WriteFile(filepath.Join(base, "windows.md"), []byte(windowsPrivesc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "sqli.md"), []byte("# SQL Injection\n\n## Union Based\n\nUNION SELECT from the database.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return knowledge.NewStore(base).WithIndexPath(indexPath).WithEmbedder(e, 0.5), base
+}
+
+func TestSemanticSearch_FindsDifferentWording(t *testing.T) {
+	e := &conceptEmbedder{model: "concept-v1"}
+	store, _ := setupSemanticStore(t, e, filepath.Join(t.TempDir(), "kb.gob"))
+
+	// 埋め込み未計算ならキーワード検索のみ（AND で一致なし）
+	if results := store.Search("abuse writable service binary", 5); len(results) != 0 {
+		t.Fatalf("keyword-only search should miss, got %v", results)
+	}
+
+	stats, err := store.Index(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if stats.Embedded != stats.Sections || stats.Vectors != stats.Sections {
+		t.Errorf("stats = %+v, want all sections embedded", stats)
+	}
+
+	results := store.Search("abuse writable service binary", 5)
+	if len(results) == 0 {
+		t.Fatal("semantic search should find the service permissions section")
+	}
+	if results[0].Section != "Service Permissions" || results[0].MatchCount != 0 {
+		t.Errorf("top hit = %s / %q (matches %d), want Service Permissions by similarity only",
+			results[0].File, results[0].Section, results[0].MatchCount)
+	}
+	for _, r := range results {
+		if r.File == "sqli.md" {
+			t.Errorf("unrelated section should not be added: %+v", r)
+		}
+	}
+
+	// キーワード一致は引き続き上位
+	results = store.Search("union select", 5)
+	if len(results) == 0 || results[0].File != "sqli.md" {
+		t.Errorf("keyword hit should stay on top, got %v", results)
+	}
+}
+
+func TestSemanticSearch_VectorsPersisted(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "kb.gob")
+	e := &conceptEmbedder{model: "concept-v1"}
+	store, base := setupSemanticStore(t, e, indexPath)
+	if _, err := store.Index(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同じモデルなら再計算しない
+	reopened := knowledge.NewStore(base).WithIndexPath(indexPath).WithEmbedder(e, 0.5)
+	stats, err := reopened.Index(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Embedded != 0 || stats.Vectors == 0 {
+		t.Errorf("stats = %+v, want vectors loaded from disk", stats)
+	}
+
+	// モデルが変わったら全て作り直す
+	v2 := &conceptEmbedder{model: "concept-v2"}
+	reopened = knowledge.NewStore(base).WithIndexPath(indexPath).WithEmbedder(v2, 0.5)
+	stats, err = reopened.Index(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Embedded != stats.Sections {
+		t.Errorf("stats = %+v, want all sections re-embedded for new model", stats)
+	}
+}
+
+func TestSemanticSearch_EmbedFailureFallsBack(t *testing.T) {
+	e := &conceptEmbedder{model: "concept-v1"}
+	store, _ := setupSemanticStore(t, e, "")
+	if _, err := store.Index(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	e.fail = true
+	results := store.Search("union select", 5)
+	if len(results) == 0 || results[0].File != "sqli.md" {
+		t.Errorf("keyword search should still work when embedding fails, got %v", results)
+	}
+	if _, err := store.Index(context.Background(), true); err == nil {
+		t.Error("Index should report embedding errors")
+	}
+}
+
+func TestOllamaEmbedder_Embed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("path = %s, want /api/embed", r.URL.Path)
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Model != "nomic-embed-text" || len(req.Input) != 2 {
+			t.Errorf("request = %+v", req)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"model":"nomic-embed-text","embeddings":[[0.1,0.2],[0.3,0.4]]}`)) //nolint:errcheck // テスト専用 httptest サーバー
+	}))
+	defer srv.Close()
+
+	e := knowledge.NewOllamaEmbedder(srv.URL+"/", "nomic-embed-text")
+	vecs, err := e.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(vecs) != 2 || vecs[1][1] != 0.4 {
+		t.Errorf("vecs = %v", vecs)
+	}
+}
+
+func TestOllamaEmbedder_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	_, err := knowledge.NewOllamaEmbedder(srv.URL, "missing").Embed(context.Background(), []string{"a"})
+	if err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Errorf("err = %v, want API error", err)
+	}
+}
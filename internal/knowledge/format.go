@@ -0,0 +1,194 @@
+package knowledge
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Format はナレッジソースのファイル形式
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown" // HackTricks / PayloadsAllTheThings 等（H2/H3 でセクション分割）
+	FormatText     Format = "text"     // プレーンテキストの手順書（段落単位でセクション分割）
+	FormatGTFOBins Format = "gtfobins" // GTFOBins の _gtfobins/*.md（YAML front matter の functions ごとに分割）
+)
+
+// textChunkLines はプレーンテキストを分割する目安の行数
+const textChunkLines = 20
+
+var (
+	// gtfobinsFunction は functions 直下のキー（"  sudo:" 等）
+	gtfobinsFunction = regexp.MustCompile(`^  ([A-Za-z][\w-]*):\s*$`)
+	// gtfobinsField は値の前の YAML キー（"- code: |" 等）。インデックスには値だけ入れる
+	gtfobinsField = regexp.MustCompile(`^\s*-?\s*(code|description|contexts?)\s*:\s*[|>]?-?\s*`)
+)
+
+// ParseFormat は設定値を Format に変換する（空 = markdown）。
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "", FormatMarkdown:
+		return FormatMarkdown, nil
+	case FormatText, FormatGTFOBins:
+		return f, nil
+	default:
+		return "", fmt.Errorf("knowledge: unknown format %q (want markdown, text or gtfobins)", s)
+	}
+}
+
+// matches はファイル名がこの形式のインデックス対象か判定する。
+func (f Format) matches(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	switch f {
+	case FormatText:
+		return ext == ".txt" || ext == ".text"
+	case FormatGTFOBins:
+		return ext == ".md" || ext == ".yml" || ext == ".yaml"
+	default:
+		return ext == ".md"
+	}
+}
+
+// sectionBuilder はファイルをセクションに分割しながら用語を数える。
+// タイトルの用語は全セクションに、ヘッダーの用語は2倍の重みで加える。
+type sectionBuilder struct {
+	fe         *fileEntry
+	titleTerms []string
+	cur        *section
+}
+
+func newSectionBuilder(title string) *sectionBuilder {
+	return &sectionBuilder{fe: &fileEntry{Title: title}, titleTerms: tokenize(title)}
+}
+
+// start は行 i から新しいセクションを始める。
+func (b *sectionBuilder) start(i int, heading string) {
+	b.cur = &section{Heading: heading, Start: i, Terms: make(map[string]int)}
+	b.add(b.fe.Title, 1)
+	b.add(heading, 2)
+}
+
+func (b *sectionBuilder) add(text string, weight int) {
+	for _, t := range tokenize(text) {
+		b.cur.Terms[t] += weight
+		b.cur.Length += weight
+	}
+}
+
+// flush は現在のセクションを行 end（含まない）で閉じる。
+func (b *sectionBuilder) flush(end int) {
+	if b.cur == nil {
+		return
+	}
+	b.cur.End = end
+	if b.cur.Length > 0 {
+		b.fe.Sections = append(b.fe.Sections, *b.cur)
+	}
+	b.cur = nil
+}
+
+// parseFile はファイルを形式に応じてセクションに分割してインデックス化する。
+func parseFile(path string, format Format) (*fileEntry, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	switch format {
+	case FormatText:
+		return parseText(name, lines), nil
+	case FormatGTFOBins:
+		return parseGTFOBins(name, lines), nil
+	default:
+		return parseMarkdown(lines), nil
+	}
+}
+
+// parseMarkdown は H2/H3 でセクションを区切る。H1 はファイルのタイトル。
+func parseMarkdown(lines []string) *fileEntry {
+	title := ""
+	for _, line := range lines {
+		if isH1(line) {
+			title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+			break
+		}
+	}
+	b := newSectionBuilder(title)
+	for i, line := range lines {
+		if heading, ok := sectionHeading(line); ok {
+			b.flush(i)
+			b.start(i, heading)
+			continue
+		}
+		if b.cur == nil {
+			b.start(i, "")
+		}
+		if isH1(line) {
+			continue // タイトルは加算済み
+		}
+		b.add(line, 1)
+	}
+	b.flush(len(lines))
+	return b.fe
+}
+
+// parseText は textChunkLines 行を超えたら次の空行で区切る。タイトルはファイル名。
+func parseText(name string, lines []string) *fileEntry {
+	b := newSectionBuilder(name)
+	for i, line := range lines {
+		if b.cur == nil {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			b.start(i, "")
+		}
+		b.add(line, 1)
+		if strings.TrimSpace(line) == "" && i-b.cur.Start >= textChunkLines {
+			b.flush(i + 1)
+		}
+	}
+	b.flush(len(lines))
+	return b.fe
+}
+
+// parseGTFOBins は front matter の functions（shell / sudo / suid ...）ごとにセクションを作る。
+// タイトルはバイナリ名（ファイル名）。
+func parseGTFOBins(name string, lines []string) *fileEntry {
+	b := newSectionBuilder(name)
+	inFunctions := false
+	for i, line := range lines {
+		if i > 0 && strings.TrimSpace(line) == "---" {
+			break // front matter の終わり
+		}
+		if !strings.HasPrefix(line, " ") && strings.TrimSpace(line) != "" {
+			b.flush(i)
+			inFunctions = strings.HasPrefix(line, "functions:")
+			continue
+		}
+		if !inFunctions {
+			continue
+		}
+		if m := gtfobinsFunction.FindStringSubmatch(line); m != nil {
+			b.flush(i)
+			b.start(i, m[1])
+			continue
+		}
+		if b.cur != nil {
+			b.add(gtfobinsField.ReplaceAllString(line, ""), 1)
+		}
+	}
+	b.flush(endOfFrontMatter(lines))
+	return b.fe
+}
+
+// endOfFrontMatter は front matter を閉じる "---" の行番号を返す（なければ末尾）。
+func endOfFrontMatter(lines []string) int {
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return i
+		}
+	}
+	return len(lines)
+}
@@ -0,0 +1,432 @@
+package knowledge
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// indexVersion はインデックスファイルの形式バージョン。トークナイザ等を変えたら上げる。
+const indexVersion = 3
+
+// refreshInterval は検索時に mtime を再確認する最小間隔
+const refreshInterval = 30 * time.Second
+
+// section はインデックスの検索単位（H1/H2/H3 で区切ったセクション）。
+type section struct {
+	Heading string         // H2/H3 ヘッダー（冒頭部分は空）
+	Start   int            // 開始行（0-origin、ヘッダー行を含む）
+	End     int            // 終了行（含まない）
+	Terms   map[string]int // 用語 → 出現回数（タイトル・ヘッダーの重み込み）
+	Length  int            // 用語数（BM25 の文書長）
+}
+
+// fileEntry はファイル1件分のインデックス。
+type fileEntry struct {
+	ModTime  int64
+	Size     int64
+	Title    string
+	Sections []section
+	Vectors  [][]float32 // セクションごとの埋め込み（正規化済み、未計算は nil）
+}
+
+// indexData はディスクに保存するインデックス本体。
+type indexData struct {
+	Version    int
+	Format     Format
+	EmbedModel string                // Vectors を計算した埋め込みモデル
+	Files      map[string]*fileEntry // 相対パス → エントリ
+}
+
+// posting は転置インデックスの1エントリ。
+type posting struct {
+	file    *fileEntry
+	path    string
+	section int
+	tf      int
+}
+
+// vecRef はセマンティック検索対象のセクション1件。
+type vecRef struct {
+	file    *fileEntry
+	path    string
+	section int
+	vec     []float32
+}
+
+// index はメモリ上の転置インデックス。indexData から再構築する。
+type index struct {
+	data       *indexData
+	postings   map[string][]posting
+	vectors    []vecRef
+	embedModel string
+	docCount   int
+	avgLength  float64
+	checkedAt  time.Time
+}
+
+// IndexStats はインデックス構築・更新の結果。
+type IndexStats struct {
+	Files    int // インデックス済みファイル数
+	Sections int // セクション数
+	Terms    int // ユニーク用語数
+	Updated  int // 今回（再）解析したファイル数
+	Removed  int // 削除されたファイル数
+	Embedded int // 今回埋め込みを計算したセクション数
+	Vectors  int // 埋め込み済みセクション数
+}
+
+// DefaultIndexPath はナレッジベースごとのインデックス保存先（ユーザーキャッシュディレクトリ配下）を返す。
+// キャッシュディレクトリが取得できない場合は空文字列（メモリ上のみ）を返す。
+func DefaultIndexPath(basePath string) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	abs, err := filepath.Abs(basePath)
+	if err != nil {
+		abs = basePath
+	}
+	sum := sha1.Sum([]byte(abs))
+	return filepath.Join(cacheDir, "pentecter", "knowledge", hex.EncodeToString(sum[:6])+".gob")
+}
+
+// WithIndexPath はインデックスの保存先を設定する（空 = メモリ上のみ）。
+func (s *Store) WithIndexPath(path string) *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.indexPath = path
+	s.idx = nil
+	return s
+}
+
+// IndexPath はインデックスの保存先を返す。
+func (s *Store) IndexPath() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.indexPath
+}
+
+// Index はインデックスを構築・更新して保存する（pentecter kb index 用）。
+// force が true なら既存のインデックスを破棄して全ファイルを再解析する。
+// Embedder が設定されていれば、未計算のセクションの埋め込みも計算する。
+func (s *Store) Index(ctx context.Context, force bool) (IndexStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if force || s.idx == nil {
+		s.loadIndexLocked(force)
+	}
+	stats, err := s.refreshLocked(true)
+	if err != nil || s.embedder == nil {
+		return stats, err
+	}
+	err = s.embedLocked(ctx, &stats)
+	stats.Vectors = len(s.idx.vectors)
+	return stats, err
+}
+
+// embedLocked は埋め込み未計算のセクションを embedBatchSize 件ずつ埋め込む。
+// モデルが変わっていれば全て作り直す。途中で失敗しても計算済みの分は保存する。
+func (s *Store) embedLocked(ctx context.Context, stats *IndexStats) error {
+	data := s.idx.data
+	model := s.embedder.Model()
+	if data.EmbedModel != model {
+		for _, fe := range data.Files {
+			fe.Vectors = nil
+		}
+		data.EmbedModel = model
+	}
+
+	type target struct {
+		path    string
+		file    *fileEntry
+		section int
+	}
+	paths := make([]string, 0, len(data.Files))
+	for relPath := range data.Files {
+		paths = append(paths, relPath)
+	}
+	sort.Strings(paths)
+	var todo []target
+	for _, relPath := range paths {
+		fe := data.Files[relPath]
+		if len(fe.Vectors) != len(fe.Sections) {
+			fe.Vectors = make([][]float32, len(fe.Sections))
+		}
+		for i := range fe.Sections {
+			if fe.Vectors[i] == nil {
+				todo = append(todo, target{path: relPath, file: fe, section: i})
+			}
+		}
+	}
+
+	finish := func(err error) error {
+		checked := s.idx.checkedAt
+		s.idx = newIndex(data)
+		s.idx.checkedAt = checked
+		if s.indexPath != "" {
+			if saveErr := saveIndex(s.indexPath, data); saveErr != nil && err == nil {
+				err = saveErr
+			}
+		}
+		return err
+	}
+
+	for batch := 0; len(todo) > 0; batch++ {
+		n := min(embedBatchSize, len(todo))
+		lines := make(lineCache)
+		texts := make([]string, n)
+		for i, t := range todo[:n] {
+			texts[i] = sectionText(lines.get(s.basePath, t.path), t.file, t.file.Sections[t.section])
+		}
+		vecs, err := s.embedder.Embed(ctx, texts)
+		if err != nil {
+			return finish(err)
+		}
+		for i, t := range todo[:n] {
+			t.file.Vectors[t.section] = normalize(vecs[i])
+		}
+		stats.Embedded += n
+		todo = todo[n:]
+		if (batch+1)%embedSaveEvery == 0 && s.indexPath != "" {
+			if err := saveIndex(s.indexPath, data); err != nil {
+				return finish(err)
+			}
+		}
+	}
+	return finish(nil)
+}
+
+// sectionText は埋め込み用のテキスト（タイトル + 本文、embedMaxChars まで）を返す。
+func sectionText(lines []string, fe *fileEntry, sec section) string {
+	var sb strings.Builder
+	sb.WriteString(fe.Title)
+	for _, line := range sectionLines(lines, sec) {
+		if sb.Len() >= embedMaxChars {
+			break
+		}
+		sb.WriteString("\n")
+		sb.WriteString(line)
+	}
+	text := sb.String()
+	if len(text) > embedMaxChars {
+		text = strings.ToValidUTF8(text[:embedMaxChars], "")
+	}
+	return text
+}
+
+// ensureIndex は検索前にインデックスを用意する。
+// 初回はディスクから読み込み、以降は refreshInterval ごとに mtime を確認して差分更新する。
+func (s *Store) ensureIndex() *index {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.idx == nil {
+		s.loadIndexLocked(false)
+	}
+	if time.Since(s.idx.checkedAt) >= refreshInterval {
+		_, _ = s.refreshLocked(false)
+	}
+	return s.idx
+}
+
+// loadIndexLocked はディスクからインデックスを読み込む。読めない・形式違いの場合は空で初期化する。
+func (s *Store) loadIndexLocked(empty bool) {
+	data := &indexData{Version: indexVersion, Format: s.format, Files: make(map[string]*fileEntry)}
+	if !empty && s.indexPath != "" {
+		if f, err := os.Open(s.indexPath); err == nil {
+			var loaded indexData
+			if gob.NewDecoder(bufio.NewReader(f)).Decode(&loaded) == nil &&
+				loaded.Version == indexVersion && loaded.Format == s.format && loaded.Files != nil {
+				data = &loaded
+			}
+			_ = f.Close()
+		}
+	}
+	s.idx = newIndex(data)
+}
+
+// refreshLocked は mtime / サイズが変わったファイルだけ再解析し、削除されたファイルを取り除く。
+// 変更があれば（または save が true なら）ディスクに保存する。
+func (s *Store) refreshLocked(save bool) (IndexStats, error) {
+	var stats IndexStats
+	data := s.idx.data
+	seen := make(map[string]bool, len(data.Files))
+
+	_ = filepath.WalkDir(s.basePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != s.basePath && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !s.format.matches(d.Name()) {
+			return nil
+		}
+		relPath, err := filepath.Rel(s.basePath, path)
+		if err != nil {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+		seen[relPath] = true
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if old, ok := data.Files[relPath]; ok && old.ModTime == info.ModTime().UnixNano() && old.Size == info.Size() {
+			return nil
+		}
+		entry, err := parseFile(path, s.format)
+		if err != nil {
+			return nil
+		}
+		entry.ModTime = info.ModTime().UnixNano()
+		entry.Size = info.Size()
+		data.Files[relPath] = entry
+		stats.Updated++
+		return nil
+	})
+
+	for relPath := range data.Files {
+		if !seen[relPath] {
+			delete(data.Files, relPath)
+			stats.Removed++
+		}
+	}
+
+	changed := stats.Updated > 0 || stats.Removed > 0
+	if changed {
+		s.idx = newIndex(data)
+	}
+	s.idx.checkedAt = time.Now()
+
+	stats.Files = len(data.Files)
+	stats.Sections = s.idx.docCount
+	stats.Terms = len(s.idx.postings)
+
+	if (changed || save) && s.indexPath != "" {
+		if err := saveIndex(s.indexPath, data); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// saveIndex は一時ファイルに書いてから rename する（書き込み途中のファイルを読ませない）。
+func saveIndex(path string, data *indexData) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("knowledge: failed to create index dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".index-*.tmp")
+	if err != nil {
+		return fmt.Errorf("knowledge: failed to write index: %w", err)
+	}
+	w := bufio.NewWriter(tmp)
+	if err := gob.NewEncoder(w).Encode(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("knowledge: failed to encode index: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("knowledge: failed to write index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("knowledge: failed to write index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("knowledge: failed to write index: %w", err)
+	}
+	return nil
+}
+
+// newIndex は indexData から転置インデックスを構築する。
+func newIndex(data *indexData) *index {
+	idx := &index{data: data, postings: make(map[string][]posting), embedModel: data.EmbedModel}
+	total := 0
+	for relPath, fe := range data.Files {
+		for si, sec := range fe.Sections {
+			idx.docCount++
+			total += sec.Length
+			for term, tf := range sec.Terms {
+				idx.postings[term] = append(idx.postings[term], posting{file: fe, path: relPath, section: si, tf: tf})
+			}
+			if si < len(fe.Vectors) && fe.Vectors[si] != nil {
+				idx.vectors = append(idx.vectors, vecRef{file: fe, path: relPath, section: si, vec: fe.Vectors[si]})
+			}
+		}
+	}
+	if idx.docCount > 0 {
+		idx.avgLength = float64(total) / float64(idx.docCount)
+	}
+	return idx
+}
+
+// sectionHeading は H2/H3 ヘッダー行ならヘッダー文字列を返す。
+func sectionHeading(line string) (string, bool) {
+	if strings.HasPrefix(line, "## ") || strings.HasPrefix(line, "### ") {
+		return strings.TrimSpace(strings.TrimLeft(line, "#")), true
+	}
+	return "", false
+}
+
+func isH1(line string) bool {
+	return strings.HasPrefix(line, "# ")
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// tokenize は小文字化して用語に分割する。
+// "ms-sql-info" や "2.3.4" のような複合語はそのままの形と構成要素の両方を返す。
+func tokenize(text string) []string {
+	var terms []string
+	for _, word := range words(text) {
+		terms = append(terms, word)
+		if strings.ContainsAny(word, "-.") {
+			terms = append(terms, strings.FieldsFunc(word, func(r rune) bool { return r == '-' || r == '.' })...)
+		}
+	}
+	return terms
+}
+
+// words は小文字化した語の列を返す（複合語は分解しない）。
+func words(text string) []string {
+	var result []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.'
+	}) {
+		if word = strings.Trim(word, "-."); word != "" {
+			result = append(result, word)
+		}
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package knowledge
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Library は複数のナレッジソース（HackTricks / GTFOBins / 社内手順書 等）をまとめて検索する。
+type Library struct {
+	names  []string // 登録順
+	stores map[string]*Store
+}
+
+// NewLibrary は空の Library を作成する。
+func NewLibrary() *Library {
+	return &Library{stores: make(map[string]*Store)}
+}
+
+// Add はソースを登録する（メソッドチェーン用）。nil の Store は無視する。
+// 同名のソースは後から登録したもので置き換える。
+func (lib *Library) Add(name string, s *Store) *Library {
+	if s == nil {
+		return lib
+	}
+	if _, ok := lib.stores[name]; !ok {
+		lib.names = append(lib.names, name)
+	}
+	lib.stores[name] = s
+	return lib
+}
+
+// Len は登録済みソース数を返す（nil-safe）。
+func (lib *Library) Len() int {
+	if lib == nil {
+		return 0
+	}
+	return len(lib.names)
+}
+
+// Sources は登録順のソース名一覧を返す。
+func (lib *Library) Sources() []string {
+	if lib == nil {
+		return nil
+	}
+	return append([]string(nil), lib.names...)
+}
+
+// Search は全ソース（source 指定時はそのソースのみ）を検索し、スコア順にマージして返す。
+// BM25 スコアはコーパスの大きさで尺度が変わるため、ソースごとの最高スコアで正規化してから並べる
+// （大きなソースが小さな社内手順書を上位から押し出さないように）。同点はソース内の順位で交互に並べる。
+// Score は元の値のまま返す。
+// 結果の Source にソース名を設定する。未知のソース名はエラー。
+func (lib *Library) Search(query, source string, maxResults int) ([]SearchResult, error) {
+	names := lib.names
+	if source != "" {
+		if _, ok := lib.stores[source]; !ok {
+			return nil, fmt.Errorf("knowledge: unknown source %q (available: %s)", source, strings.Join(lib.names, ", "))
+		}
+		names = []string{source}
+	}
+
+	type ranked struct {
+		result SearchResult
+		norm   float64
+		rank   int // ソース内の順位
+	}
+	var merged []ranked
+	for _, name := range names {
+		hits := lib.stores[name].Search(query, maxResults)
+		top := 0.0
+		for _, r := range hits {
+			top = math.Max(top, r.Score)
+		}
+		for i, r := range hits {
+			r.Source = name
+			norm := 0.0
+			if top > 0 {
+				norm = r.Score / top
+			}
+			merged = append(merged, ranked{result: r, norm: norm, rank: i})
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].norm != merged[j].norm {
+			return merged[i].norm > merged[j].norm
+		}
+		return merged[i].rank < merged[j].rank
+	})
+	if maxResults > 0 && len(merged) > maxResults {
+		merged = merged[:maxResults]
+	}
+	results := make([]SearchResult, 0, len(merged))
+	for _, m := range merged {
+		results = append(results, m.result)
+	}
+	return results, nil
+}
+
+// ReadFile は "source:path" 形式のパスでソースを指定して読む。
+// ソース指定がなければ登録順に探し、最初に読めたものを返す。
+func (lib *Library) ReadFile(path string, maxBytes int) (string, error) {
+	if name, rel, ok := strings.Cut(path, ":"); ok {
+		if s, found := lib.stores[name]; found {
+			return s.ReadFile(rel, maxBytes)
+		}
+	}
+	if len(lib.names) == 0 {
+		return "", fmt.Errorf("knowledge: no sources configured")
+	}
+	var firstErr error
+	for _, name := range lib.names {
+		content, err := lib.stores[name].ReadFile(path, maxBytes)
+		if err == nil {
+			return content, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return "", firstErr
+}
+
+// CompletePaths は入力途中の "source:path" に続く候補を返す（TUI の補完用）。
+// ":" がなければソース名を "name:" の形で返し、あればそのソースの該当ディレクトリを列挙する。
+func (lib *Library) CompletePaths(prefix string) []string {
+	if lib == nil {
+		return nil
+	}
+	name, rel, ok := strings.Cut(prefix, ":")
+	if !ok {
+		var out []string
+		for _, n := range lib.names {
+			if strings.HasPrefix(n, name) {
+				out = append(out, n+":")
+			}
+		}
+		return out
+	}
+	s, found := lib.stores[name]
+	if !found {
+		return nil
+	}
+	dir, base := "", rel
+	if i := strings.LastIndex(rel, "/"); i >= 0 {
+		dir, base = rel[:i+1], rel[i+1:]
+	}
+	var out []string
+	for _, entry := range s.ListDir(dir) {
+		if strings.HasPrefix(entry, base) {
+			out = append(out, name+":"+dir+entry)
+		}
+	}
+	return out
+}
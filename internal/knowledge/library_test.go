@@ -0,0 +1,198 @@
+package knowledge_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/pentecter/internal/knowledge"
+)
+
+const vimGTFOBins = `---
+description: vim can be used to break out from restricted environments.
+functions:
+  shell:
+    - code: vim -c ':!/bin/sh'
+  sudo:
+    - description: Spawn a root shell via sudo.
+      code: sudo vim -c ':!/bin/sh'
+  suid:
+    - code: ./vim -c ':py3 import os; os.execl("/bin/sh", "sh", "-pc", "reset; exec sh -p")'
+---
+`
+
+const runbookText = `Domain controller triage
+
+Check replication with repadmin /replsummary before touching anything.
+
+Kerberoasting
+
+Request service tickets with GetUserSPNs.py and crack them offline.
+`
+
+// setupLibrary は markdown / gtfobins / text の3ソースを持つ Library を作成する。
+func setupLibrary(t *testing.T) *knowledge.Library {
+	t.Helper()
+	gtfo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(gtfo, "vim.md"), []byte(vimGTFOBins), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runbooks := t.TempDir()
+	if err := os.WriteFile(filepath.Join(runbooks, "ad.txt"), []byte(runbookText), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return knowledge.NewLibrary().
+		Add("hacktricks", knowledge.NewStore(setupTestData(t))).
+		Add("gtfobins", knowledge.NewStore(gtfo).WithFormat(knowledge.FormatGTFOBins)).
+		Add("runbooks", knowledge.NewStore(runbooks).WithFormat(knowledge.FormatText)).
+		Add("missing", knowledge.NewStore(filepath.Join(gtfo, "nope")))
+}
+
+func TestLibrary_Sources(t *testing.T) {
+	lib := setupLibrary(t)
+	if got := strings.Join(lib.Sources(), ","); got != "hacktricks,gtfobins,runbooks" {
+		t.Errorf("Sources = %s (nil stores should be skipped)", got)
+	}
+	var nilLib *knowledge.Library
+	if nilLib.Len() != 0 {
+		t.Error("nil Library should have no sources")
+	}
+}
+
+func TestLibrary_Search(t *testing.T) {
+	lib := setupLibrary(t)
+
+	tests := []struct {
+		name        string
+		query       string
+		source      string
+		wantSource  string
+		wantFile    string
+		wantSection string
+	}{
+		{"gtfobins function section", "vim sudo", "", "gtfobins", "vim.md", "sudo"},
+		{"text chunk", "kerberoasting", "", "runbooks", "ad.txt", ""},
+		{"markdown", "vsftpd backdoor", "", "hacktricks", "ftp.md", "vsftpd 2.3.4 Backdoor"},
+		{"restricted to source", "shell", "gtfobins", "gtfobins", "vim.md", "shell"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := lib.Search(tt.query, tt.source, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) == 0 {
+				t.Fatalf("Search(%q) returned no results", tt.query)
+			}
+			r := results[0]
+			if r.Source != tt.wantSource || !strings.HasSuffix(r.File, tt.wantFile) || r.Section != tt.wantSection {
+				t.Errorf("top hit = %s:%s / %q, want %s:%s / %q", r.Source, r.File, r.Section, tt.wantSource, tt.wantFile, tt.wantSection)
+			}
+			if tt.source != "" {
+				for _, r := range results {
+					if r.Source != tt.source {
+						t.Errorf("result from %s leaked into %s-only search", r.Source, tt.source)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLibrary_Search_UnknownSource(t *testing.T) {
+	lib := setupLibrary(t)
+	_, err := lib.Search("sql", "exploitdb", 10)
+	if err == nil || !strings.Contains(err.Error(), "hacktricks, gtfobins, runbooks") {
+		t.Errorf("err = %v, want unknown source with available list", err)
+	}
+}
+
+func TestLibrary_Search_NormalizesPerSource(t *testing.T) {
+	// 大きなソース: "kerberoast" を含む文書が少数、無関係な文書が多数（IDF が高くなる）
+	big := t.TempDir()
+	for i := range 20 {
+		body := fmt.Sprintf("# Note %d\n\nUnrelated enumeration notes about smb shares %d.\n", i, i)
+		if i < 5 {
+			body = fmt.Sprintf("# Kerberoast %d\n\nKerberoast service accounts variant %d.\n", i, i)
+		}
+		if err := os.WriteFile(filepath.Join(big, fmt.Sprintf("note%02d.md", i)), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 小さなソース: 1文書だけ（生の BM25 スコアは低くなる）
+	small := t.TempDir()
+	if err := os.WriteFile(filepath.Join(small, "ad.txt"), []byte("Kerberoast the svc accounts first.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lib := knowledge.NewLibrary().
+		Add("hacktricks", knowledge.NewStore(big)).
+		Add("runbooks", knowledge.NewStore(small).WithFormat(knowledge.FormatText))
+
+	results, err := lib.Search("kerberoast", "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, r := range results {
+		found = found || r.Source == "runbooks"
+	}
+	if !found {
+		t.Errorf("small source crowded out of top results: %+v", results)
+	}
+}
+
+func TestLibrary_ReadFile(t *testing.T) {
+	lib := setupLibrary(t)
+
+	content, err := lib.ReadFile("gtfobins:vim.md", 0)
+	if err != nil || !strings.Contains(content, "sudo vim") {
+		t.Errorf("ReadFile(source:path) = %q, %v", content, err)
+	}
+	// ソース省略時は登録順に探す
+	content, err = lib.ReadFile("ad.txt", 0)
+	if err != nil || !strings.Contains(content, "Kerberoasting") {
+		t.Errorf("ReadFile(path) = %q, %v", content, err)
+	}
+	if _, err := lib.ReadFile("gtfobins:../../etc/passwd", 0); err == nil {
+		t.Error("path traversal should be rejected")
+	}
+}
+
+func TestLibrary_CompletePaths(t *testing.T) {
+	lib := setupLibrary(t)
+
+	if got := strings.Join(lib.CompletePaths("g"), ","); got != "gtfobins:" {
+		t.Errorf("source completion = %s", got)
+	}
+	if got := strings.Join(lib.CompletePaths("gtfobins:v"), ","); got != "gtfobins:vim.md" {
+		t.Errorf("file completion = %s", got)
+	}
+	// 対象形式でないファイルは候補にしない
+	if got := lib.CompletePaths("runbooks:"); len(got) != 1 || got[0] != "runbooks:ad.txt" {
+		t.Errorf("text source completion = %v", got)
+	}
+	if got := lib.CompletePaths("gtfobins:../"); len(got) != 0 {
+		t.Errorf("completion must not escape the base directory: %v", got)
+	}
+	if got := lib.CompletePaths("nope:x"); got != nil {
+		t.Errorf("unknown source should have no candidates: %v", got)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]knowledge.Format{
+		"":         knowledge.FormatMarkdown,
+		"Markdown": knowledge.FormatMarkdown,
+		"text":     knowledge.FormatText,
+		"gtfobins": knowledge.FormatGTFOBins,
+	} {
+		if got, err := knowledge.ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := knowledge.ParseFormat("pdf"); err == nil {
+		t.Error("unknown format should be an error")
+	}
+}
@@ -0,0 +1,384 @@
+package knowledge
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BM25 パラメータ
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+const (
+	// defaultSemanticWeight はスコアに占めるベクトル類似度のデフォルト比率
+	defaultSemanticWeight = 0.5
+	// semanticMinSimilarity はキーワードに一致しないセクションを結果に加える類似度の下限
+	semanticMinSimilarity = 0.5
+)
+
+// semanticQuery はクエリの埋め込みとブレンド比率。
+type semanticQuery struct {
+	vec    []float32
+	weight float64
+}
+
+// queryAlt はクエリ中の1項目（用語・前方一致・フレーズのいずれか）。
+type queryAlt struct {
+	terms  []string // 用語（フレーズなら2語以上）
+	prefix bool     // 末尾 * による前方一致
+}
+
+// queryGroup は OR で繋がれた項目の集まり。グループ同士は AND。
+type queryGroup []queryAlt
+
+// parseQuery はクエリ文字列を解析する。
+// `"union select"` はフレーズ、`smb OR cifs` / `smb|cifs` はいずれか、`kerb*` は前方一致。
+func parseQuery(query string) []queryGroup {
+	var (
+		groups []queryGroup
+		or     bool
+	)
+	add := func(alt queryAlt) {
+		if or && len(groups) > 0 {
+			groups[len(groups)-1] = append(groups[len(groups)-1], alt)
+		} else {
+			groups = append(groups, queryGroup{alt})
+		}
+		or = false
+	}
+
+	for _, item := range splitQuery(query) {
+		if item.text == "|" || (!item.quoted && item.text == "OR") {
+			or = true
+			continue
+		}
+		text := item.text
+		prefix := !item.quoted && strings.HasSuffix(text, "*")
+		ws := words(strings.TrimSuffix(text, "*"))
+		if len(ws) == 0 {
+			continue
+		}
+		add(queryAlt{terms: ws, prefix: prefix && len(ws) == 1})
+	}
+	return groups
+}
+
+type queryItem struct {
+	text   string
+	quoted bool
+}
+
+// splitQuery はクエリを空白・ダブルクォート・"|" で項目に分割する。
+func splitQuery(query string) []queryItem {
+	var (
+		items []queryItem
+		cur   strings.Builder
+	)
+	flush := func() {
+		if cur.Len() > 0 {
+			items = append(items, queryItem{text: cur.String()})
+			cur.Reset()
+		}
+	}
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '"':
+			flush()
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			items = append(items, queryItem{text: string(runes[i+1 : min(end, len(runes))]), quoted: true})
+			i = end
+		case r == '|':
+			flush()
+			items = append(items, queryItem{text: "|"})
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return items
+}
+
+type docKey struct {
+	path    string
+	section int
+}
+
+type docHit struct {
+	file  *fileEntry
+	score float64
+	count int
+}
+
+// semanticQuery はクエリを埋め込む。Embedder 未設定・埋め込み未計算・失敗時は nil（キーワード検索のみ）。
+func (s *Store) semanticQuery(idx *index, query string) *semanticQuery {
+	s.mu.Lock()
+	embedder, weight := s.embedder, s.semanticWeight
+	s.mu.Unlock()
+	if embedder == nil || len(idx.vectors) == 0 || idx.embedModel != embedder.Model() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), queryEmbedTimeout)
+	defer cancel()
+	vecs, err := embedder.Embed(ctx, []string{query})
+	if err != nil || len(vecs) != 1 {
+		return nil
+	}
+	vec := normalize(vecs[0])
+	if vec == nil {
+		return nil
+	}
+	return &semanticQuery{vec: vec, weight: weight}
+}
+
+// search はクエリに一致するセクションを BM25 スコア順に返す。
+// sq が nil でなければベクトル類似度とブレンドする。
+func (idx *index) search(basePath string, groups []queryGroup, maxResults int, sq *semanticQuery) []SearchResult {
+	lines := make(lineCache)
+	var hits map[docKey]*docHit
+	for gi, group := range groups {
+		gh := make(map[docKey]*docHit)
+		for _, alt := range group {
+			for key, h := range idx.altHits(basePath, alt, lines) {
+				mergeHit(gh, key, h)
+			}
+		}
+		if gi == 0 {
+			hits = gh
+			continue
+		}
+		// AND: 全グループに一致したセクションだけ残す
+		for key, h := range hits {
+			other, ok := gh[key]
+			if !ok {
+				delete(hits, key)
+				continue
+			}
+			h.score += other.score
+			h.count += other.count
+		}
+	}
+
+	idx.blend(hits, sq)
+
+	keys := make([]docKey, 0, len(hits))
+	for key := range hits {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := hits[keys[i]], hits[keys[j]]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if keys[i].path != keys[j].path {
+			return keys[i].path < keys[j].path
+		}
+		return keys[i].section < keys[j].section
+	})
+	if maxResults > 0 && len(keys) > maxResults {
+		keys = keys[:maxResults]
+	}
+
+	needles := queryNeedles(groups)
+	results := make([]SearchResult, 0, len(keys))
+	for _, key := range keys {
+		h := hits[key]
+		sec := h.file.Sections[key.section]
+		results = append(results, SearchResult{
+			File:       key.path,
+			Title:      h.file.Title,
+			Section:    sec.Heading,
+			Snippet:    sectionSnippet(lines.get(basePath, key.path), sec, needles),
+			MatchCount: h.count,
+			Score:      h.score,
+		})
+	}
+	return results
+}
+
+// blend は BM25 スコアを最大値で 0〜1 に正規化し、ベクトル類似度と重み付きで合算する。
+// キーワードに一致しなくても類似度が semanticMinSimilarity 以上のセクションは結果に加える。
+func (idx *index) blend(hits map[docKey]*docHit, sq *semanticQuery) {
+	if sq == nil {
+		return
+	}
+	maxScore := 0.0
+	for _, h := range hits {
+		maxScore = math.Max(maxScore, h.score)
+	}
+	for _, h := range hits {
+		if maxScore > 0 {
+			h.score = (1 - sq.weight) * h.score / maxScore
+		}
+	}
+	for _, v := range idx.vectors {
+		sim := dot(sq.vec, v.vec)
+		key := docKey{path: v.path, section: v.section}
+		if h, ok := hits[key]; ok {
+			h.score += sq.weight * sim
+		} else if sim >= semanticMinSimilarity {
+			hits[key] = &docHit{file: v.file, score: sq.weight * sim}
+		}
+	}
+}
+
+// altHits は1項目に一致するセクションとスコアを返す。
+func (idx *index) altHits(basePath string, alt queryAlt, lines lineCache) map[docKey]*docHit {
+	if len(alt.terms) == 1 {
+		hits := make(map[docKey]*docHit)
+		for _, term := range idx.expand(alt.terms[0], alt.prefix) {
+			for key, h := range idx.termHits(term) {
+				mergeHit(hits, key, h)
+			}
+		}
+		return hits
+	}
+
+	// フレーズ: 全用語を含むセクションに絞ってから本文で語順を確認する
+	hits := idx.termHits(alt.terms[0])
+	for _, term := range alt.terms[1:] {
+		th := idx.termHits(term)
+		for key, h := range hits {
+			other, ok := th[key]
+			if !ok {
+				delete(hits, key)
+				continue
+			}
+			h.score += other.score
+			h.count += other.count
+		}
+	}
+	for key, h := range hits {
+		if !containsPhrase(lines.get(basePath, key.path), h.file.Sections[key.section], alt.terms) {
+			delete(hits, key)
+		}
+	}
+	return hits
+}
+
+// expand は前方一致の用語を展開する。
+func (idx *index) expand(term string, prefix bool) []string {
+	if !prefix {
+		return []string{term}
+	}
+	var terms []string
+	for t := range idx.postings {
+		if strings.HasPrefix(t, term) {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// termHits は用語を含むセクションと BM25 スコアを返す。
+func (idx *index) termHits(term string) map[docKey]*docHit {
+	postings := idx.postings[term]
+	hits := make(map[docKey]*docHit, len(postings))
+	if len(postings) == 0 {
+		return hits
+	}
+	df := float64(len(postings))
+	idf := math.Log(1 + (float64(idx.docCount)-df+0.5)/(df+0.5))
+	for _, p := range postings {
+		length := float64(p.file.Sections[p.section].Length)
+		tf := float64(p.tf)
+		score := idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*length/idx.avgLength))
+		hits[docKey{path: p.path, section: p.section}] = &docHit{file: p.file, score: score, count: p.tf}
+	}
+	return hits
+}
+
+func mergeHit(hits map[docKey]*docHit, key docKey, h *docHit) {
+	if existing, ok := hits[key]; ok {
+		existing.score += h.score
+		existing.count += h.count
+		return
+	}
+	hits[key] = &docHit{file: h.file, score: h.score, count: h.count}
+}
+
+// queryNeedles はスニペット行の選択に使う小文字の文字列を返す。
+func queryNeedles(groups []queryGroup) []string {
+	var needles []string
+	for _, group := range groups {
+		for _, alt := range group {
+			needles = append(needles, alt.terms...)
+		}
+	}
+	return needles
+}
+
+// sectionLines はセクションの行を返す（インデックス後にファイルが縮んでいても範囲外を読まない）。
+func sectionLines(lines []string, sec section) []string {
+	start, end := min(sec.Start, len(lines)), min(sec.End, len(lines))
+	return lines[start:end]
+}
+
+// sectionSnippet はセクション内でクエリ用語が最初に出現した行の前後をスニペットにする。
+// ヘッダー行より本文の行を優先する。
+func sectionSnippet(lines []string, sec section, needles []string) string {
+	body := sectionLines(lines, sec)
+	if len(body) == 0 {
+		return ""
+	}
+	first := -1
+	for i, line := range body {
+		lower := strings.ToLower(line)
+		for _, n := range needles {
+			if strings.Contains(lower, n) {
+				if i > 0 || sec.Heading == "" {
+					return buildSnippet(body, []int{i}, 3)
+				}
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+	if first < 0 {
+		// 類似度だけで選ばれたセクションはヘッダーの次の行から
+		first = min(1, len(body)-1)
+	}
+	return buildSnippet(body, []int{first}, 3)
+}
+
+// containsPhrase はセクション本文に用語列がこの順で連続して出現するか確認する。
+func containsPhrase(lines []string, sec section, phrase []string) bool {
+	ws := words(strings.Join(sectionLines(lines, sec), "\n"))
+	for i := 0; i+len(phrase) <= len(ws); i++ {
+		match := true
+		for j, term := range phrase {
+			if ws[i+j] != term {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// lineCache は1回の検索中に読んだファイルの行を保持する。
+type lineCache map[string][]string
+
+func (c lineCache) get(basePath, relPath string) []string {
+	if lines, ok := c[relPath]; ok {
+		return lines
+	}
+	lines, _ := readLines(filepath.Join(basePath, filepath.FromSlash(relPath)))
+	c[relPath] = lines
+	return lines
+}
@@ -3,27 +3,37 @@
 package knowledge
 
 import (
-	"bufio"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 )
 
 // Store はナレッジベースへの検索・読み取りインターフェース
 type Store struct {
 	basePath string // clone 済みリポジトリの src/ ディレクトリ
+	format   Format // ファイル形式（デフォルト markdown）
+
+	embedder       Embedder // セマンティック検索（nil = キーワード検索のみ）
+	semanticWeight float64  // スコアに占めるベクトル類似度の比率（0〜1）
+
+	mu        sync.Mutex
+	indexPath string // 転置インデックスの保存先（空 = メモリ上のみ）
+	idx       *index // 初回検索時に構築
 }
 
 // SearchResult は検索結果1件を表す
 type SearchResult struct {
-	File       string // 相対パス（例: "pentesting-web/sql-injection/README.md"）
-	Title      string // 最初の H1 ヘッダー
-	Section    string // マッチしたセクションの H2/H3 ヘッダー
-	Snippet    string // マッチ行の前後コンテキスト（3行程度）
-	MatchCount int    // マッチ数
+	Source     string  // ソース名（Library 経由の検索時のみ。例: "hacktricks"）
+	File       string  // 相対パス（例: "pentesting-web/sql-injection/README.md"）
+	Title      string  // 最初の H1 ヘッダー
+	Section    string  // マッチしたセクションの H2/H3 ヘッダー
+	Snippet    string  // マッチ行の前後コンテキスト（3行程度）
+	MatchCount int     // セクション内のクエリ用語の出現数
+	Score      float64 // BM25 スコア（降順で並ぶ）
 }
 
 // Category はナレッジベースのカテゴリ
@@ -43,168 +53,45 @@ func NewStore(basePath string) *Store {
 	if err != nil || !info.IsDir() {
 		return nil
 	}
-	return &Store{basePath: basePath}
+	return &Store{basePath: basePath, format: FormatMarkdown}
 }
 
-// Search はクエリに一致するファイル・セクションを返す。
-// - クエリをスペースで分割 → 全キーワードがファイル内のどこかに存在すればマッチ（file-level AND, case-insensitive）
-// - スニペットは最初にキーワードが出現した行の前後コンテキスト
-// - マッチ行の近くの H2/H3 ヘッダーをセクション名として抽出
-// - ファイルごとにグルーピング → マッチ数で降順ソート
-// - maxResults で結果数を制限
-func (s *Store) Search(query string, maxResults int) []SearchResult {
-	query = strings.TrimSpace(query)
-	if query == "" {
-		return nil
-	}
-
-	// クエリをスペースで分割し、小文字に変換
-	keywords := strings.Fields(strings.ToLower(query))
-	if len(keywords) == 0 {
-		return nil
-	}
-
-	// ファイルごとの検索結果を格納する map
-	type fileMatch struct {
-		relPath    string
-		title      string   // 最初の H1 ヘッダー
-		section    string   // 最後にマッチしたセクション
-		lines      []string // ファイルの全行（スニペット用）
-		matchLines []int    // マッチした行番号
-		matchCount int
-	}
-
-	var results []fileMatch
-
-	// basePath 以下の全 .md ファイルを走査
-	_ = filepath.WalkDir(s.basePath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil // エラーのあるディレクトリはスキップ
-		}
-		if d.IsDir() {
-			return nil
-		}
-		if !strings.HasSuffix(strings.ToLower(d.Name()), ".md") {
-			return nil
-		}
-
-		// ファイルを読み込み
-		f, err := os.Open(path)
-		if err != nil {
-			return nil
-		}
-		defer func() { _ = f.Close() }()
-
-		relPath, err := filepath.Rel(s.basePath, path)
-		if err != nil {
-			return nil
-		}
-		// Windows パス区切り文字をスラッシュに変換
-		relPath = filepath.ToSlash(relPath)
-
-		var (
-			lines          []string
-			title          string
-			currentSection string
-			matchSection   string
-			firstMatchLine = -1
-			matchCount     int
-		)
-
-		// キーワードごとのファイル内出現フラグ
-		keywordFound := make([]bool, len(keywords))
-
-		scanner := bufio.NewScanner(f)
-		lineNum := 0
-		for scanner.Scan() {
-			line := scanner.Text()
-			lines = append(lines, line)
-
-			// H1 ヘッダーを抽出（最初の1つだけ）
-			if title == "" && strings.HasPrefix(line, "# ") && !strings.HasPrefix(line, "## ") {
-				title = strings.TrimPrefix(line, "# ")
-				title = strings.TrimSpace(title)
-			}
-
-			// H2/H3 ヘッダーを追跡
-			if strings.HasPrefix(line, "## ") || strings.HasPrefix(line, "### ") {
-				trimmed := strings.TrimLeft(line, "#")
-				currentSection = strings.TrimSpace(trimmed)
-			}
-
-			// 各キーワードの出現をチェック（file-level AND）
-			lowerLine := strings.ToLower(line)
-			lineHasMatch := false
-			for ki, kw := range keywords {
-				if strings.Contains(lowerLine, kw) {
-					keywordFound[ki] = true
-					matchCount++
-					lineHasMatch = true
-				}
-			}
-
-			// 最初のマッチ行を記録（スニペット用）
-			if lineHasMatch && firstMatchLine < 0 {
-				firstMatchLine = lineNum
-				if currentSection != "" {
-					matchSection = currentSection
-				}
-			}
-
-			lineNum++
-		}
-
-		// 全キーワードがファイル内に存在する場合のみマッチ
-		allFound := true
-		for _, found := range keywordFound {
-			if !found {
-				allFound = false
-				break
-			}
-		}
+// WithEmbedder はセマンティック検索を有効にする（メソッドチェーン用）。
+// weight はスコアに占めるベクトル類似度の比率（0 以下 / 1 超ならデフォルト 0.5）。
+// 埋め込みは Index（pentecter kb index）で計算され、計算済みのセクションだけが対象になる。
+func (s *Store) WithEmbedder(e Embedder, weight float64) *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if weight <= 0 || weight > 1 {
+		weight = defaultSemanticWeight
+	}
+	s.embedder = e
+	s.semanticWeight = weight
+	return s
+}
 
-		if allFound && matchCount > 0 {
-			matchLines := []int{}
-			if firstMatchLine >= 0 {
-				matchLines = append(matchLines, firstMatchLine)
-			}
-			results = append(results, fileMatch{
-				relPath:    relPath,
-				title:      title,
-				section:    matchSection,
-				lines:      lines,
-				matchLines: matchLines,
-				matchCount: matchCount,
-			})
-		}
+// WithFormat はファイル形式を設定する（メソッドチェーン用）。
+func (s *Store) WithFormat(format Format) *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.format = format
+	s.idx = nil
+	return s
+}
 
+// Search はクエリに一致するセクションを BM25 スコア順に返す。
+// - インデックスはセクション（H2/H3 区切り）単位。初回検索時に構築し、以降は mtime の変化だけ差分更新する
+// - Embedder 設定時は、キーワードと言い回しが違っても意味の近いセクションをベクトル類似度で加える
+// - スペース区切りの用語は AND、"OR" / "|" で繋いだ用語はいずれか、"..." はフレーズ、末尾 * は前方一致
+// - スニペットはセクション内で最初にクエリ用語が出現した行の前後コンテキスト
+// - maxResults で結果数を制限
+func (s *Store) Search(query string, maxResults int) []SearchResult {
+	q := parseQuery(query)
+	if len(q) == 0 {
 		return nil
-	})
-
-	// マッチ数で降順ソート
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].matchCount > results[j].matchCount
-	})
-
-	// maxResults で制限
-	if maxResults > 0 && len(results) > maxResults {
-		results = results[:maxResults]
 	}
-
-	// SearchResult に変換
-	searchResults := make([]SearchResult, len(results))
-	for i, fm := range results {
-		snippet := buildSnippet(fm.lines, fm.matchLines, 3)
-		searchResults[i] = SearchResult{
-			File:       fm.relPath,
-			Title:      fm.title,
-			Section:    fm.section,
-			Snippet:    snippet,
-			MatchCount: fm.matchCount,
-		}
-	}
-
-	return searchResults
+	idx := s.ensureIndex()
+	return idx.search(s.basePath, q, maxResults, s.semanticQuery(idx, query))
 }
 
 // buildSnippet はマッチ行の前後 contextLines 行をスニペットとして結合する。
@@ -278,6 +165,32 @@ func (s *Store) ReadFile(path string, maxBytes int) (string, error) {
 	return string(data), nil
 }
 
+// ListDir は dir（basePath からの相対パス）直下のサブディレクトリ（末尾に "/"）と
+// 対象形式のファイルを名前順に返す。隠しエントリと basePath 外のディレクトリは返さない。
+func (s *Store) ListDir(dir string) []string {
+	clean := filepath.Clean(filepath.FromSlash(dir))
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) || filepath.IsAbs(clean) {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Join(s.basePath, clean))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case strings.HasPrefix(name, "."):
+		case entry.IsDir():
+			names = append(names, name+"/")
+		case s.format.matches(name):
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // ListCategories はトップレベルカテゴリ一覧を返す。
 // トップレベルディレクトリをカテゴリとして扱い、配下の .md ファイル数をカウントする。
 func (s *Store) ListCategories() []Category {
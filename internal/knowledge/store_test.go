@@ -1,6 +1,7 @@
 package knowledge_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -273,7 +274,7 @@ func TestSearch_ResultFields(t *testing.T) {
 	}
 }
 
-func TestSearch_SortedByMatchCount(t *testing.T) {
+func TestSearch_SortedByScore(t *testing.T) {
 	base := setupTestData(t)
 	store := knowledge.NewStore(base)
 
@@ -283,11 +284,11 @@ func TestSearch_SortedByMatchCount(t *testing.T) {
 		t.Skipf("Need at least 2 results to test sorting, got %d", len(results))
 	}
 
-	// 結果がマッチ数の降順でソートされていること
+	// 結果が BM25 スコアの降順でソートされていること
 	for i := 1; i < len(results); i++ {
-		if results[i].MatchCount > results[i-1].MatchCount {
-			t.Errorf("Results should be sorted by MatchCount descending: result[%d].MatchCount=%d > result[%d].MatchCount=%d",
-				i, results[i].MatchCount, i-1, results[i-1].MatchCount)
+		if results[i].Score > results[i-1].Score {
+			t.Errorf("Results should be sorted by Score descending: result[%d].Score=%f > result[%d].Score=%f",
+				i, results[i].Score, i-1, results[i-1].Score)
 		}
 	}
 }
@@ -475,3 +476,141 @@ func TestSearch_KeywordsAcrossLines_DefaultCredentials(t *testing.T) {
 		t.Error("Expected mssql.md in results for 'mssql default credentials'")
 	}
 }
+
+// --- Index / クエリ構文テスト ---
+
+func TestSearch_SectionLevelHit(t *testing.T) {
+	base := setupTestData(t)
+	store := knowledge.NewStore(base)
+
+	results := store.Search("mssql default credentials", 10)
+	if len(results) == 0 {
+		t.Fatal("expected results")
+	}
+	r := results[0]
+	if !strings.Contains(r.File, "mssql") || r.Section != "Default Credentials" {
+		t.Errorf("top hit = %s / %q, want mssql.md / Default Credentials", r.File, r.Section)
+	}
+	if !strings.Contains(r.Snippet, "sa / sa") {
+		t.Errorf("snippet should come from the matched section, got:\n%s", r.Snippet)
+	}
+	if strings.Contains(r.Snippet, "xp_cmdshell") {
+		t.Errorf("snippet should not cross into the next section, got:\n%s", r.Snippet)
+	}
+}
+
+func TestSearch_QuerySyntax(t *testing.T) {
+	base := setupTestData(t)
+	store := knowledge.NewStore(base)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantFiles []string // 結果に含まれるべきファイル（部分一致）
+		wantNone  bool
+	}{
+		{"phrase", `"union select"`, []string{"sql-injection"}, false},
+		{"phrase wrong order", `"select union"`, nil, true},
+		{"or keyword", "stored OR bounce", []string{"xss.md", "ftp.md"}, false},
+		{"or pipe", "stored|bounce", []string{"xss.md", "ftp.md"}, false},
+		{"prefix", "impack*", []string{"mssql.md"}, false},
+		{"compound token part", "ms-sql-info", []string{"mssql.md"}, false},
+		{"dotted version", "2.3.4", []string{"ftp.md"}, false},
+		{"and across groups", "vsftpd xss", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := store.Search(tt.query, 20)
+			if tt.wantNone {
+				if len(results) != 0 {
+					t.Errorf("Search(%q) = %d results, want none: %v", tt.query, len(results), results)
+				}
+				return
+			}
+			for _, want := range tt.wantFiles {
+				found := false
+				for _, r := range results {
+					if strings.Contains(r.File, want) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("Search(%q) missing %s, got: %v", tt.query, want, results)
+				}
+			}
+		})
+	}
+}
+
+func TestIndex_PersistAndRefresh(t *testing.T) {
+	base := setupTestData(t)
+	indexPath := filepath.Join(t.TempDir(), "kb.gob")
+
+	store := knowledge.NewStore(base).WithIndexPath(indexPath)
+	stats, err := store.Index(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if stats.Files != 4 || stats.Updated != 4 || stats.Sections == 0 || stats.Terms == 0 {
+		t.Errorf("initial stats = %+v", stats)
+	}
+	if _, err := os.Stat(indexPath); err != nil {
+		t.Fatalf("index file should be written: %v", err)
+	}
+
+	// 別の Store から読み込むと再解析は不要
+	reopened := knowledge.NewStore(base).WithIndexPath(indexPath)
+	stats, err = reopened.Index(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Updated != 0 || stats.Files != 4 {
+		t.Errorf("reopened stats = %+v, want no updates", stats)
+	}
+
+	// 変更・追加・削除されたファイルだけ反映される
+	xss := filepath.Join(base, "pentesting-web", "xss.md")
+	if err := os.WriteFile(xss, []byte("# XSS\n\n## Mutation XSS\n\nmXSS abuses sanitizer parsing quirks.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(base, "network-services-pentesting", "ftp.md")); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = reopened.Index(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Updated != 1 || stats.Removed != 1 || stats.Files != 3 {
+		t.Errorf("refresh stats = %+v, want 1 updated / 1 removed / 3 files", stats)
+	}
+	if results := reopened.Search("sanitizer", 10); len(results) != 1 || results[0].Section != "Mutation XSS" {
+		t.Errorf("updated content should be searchable, got %v", results)
+	}
+	if results := reopened.Search("vsftpd", 10); len(results) != 0 {
+		t.Errorf("removed file should not be searchable, got %v", results)
+	}
+
+	// force は全ファイルを再解析する
+	stats, err = reopened.Index(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Updated != 3 {
+		t.Errorf("forced stats = %+v, want 3 updated", stats)
+	}
+}
+
+func TestDefaultIndexPath(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	a := knowledge.DefaultIndexPath("/opt/hacktricks/src")
+	b := knowledge.DefaultIndexPath("/opt/other/src")
+	if a == "" || a == b {
+		t.Errorf("paths should be non-empty and distinct per knowledge base: %q, %q", a, b)
+	}
+	if !strings.Contains(filepath.ToSlash(a), "pentecter/knowledge/") {
+		t.Errorf("path should be under pentecter/knowledge: %s", a)
+	}
+}
@@ -28,21 +28,204 @@ type jsonRPCResponse struct {
 	Error   *jsonRPCError   `json:"error,omitempty"`
 }
 
+// jsonRPCEnvelope はサーバーからのメッセージ種別の判定に使う。
+// method があればサーバーからの通知・リクエスト、なければレスポンス。
+type jsonRPCEnvelope struct {
+	Method string          `json:"method"`
+	ID     json.RawMessage `json:"id"`
+}
+
 type jsonRPCError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 }
 
-// MCPClient は MCP サーバーとの JSON-RPC 2.0 over stdio 通信を管理する
+// NotificationHandler はサーバーからの通知（notifications/tools/list_changed 等）の method を受け取る。
+// 受信処理の中から呼ばれるため、同じクライアントへのリクエストは別 goroutine で行うこと。
+type NotificationHandler func(method string)
+
+// Client は MCP サーバーとの通信を抽象化する。
+// stdio サブプロセス（MCPClient）と HTTP 経由のリモートサーバー（HTTPClient）が実装する。
+type Client interface {
+	Initialize(ctx context.Context) error
+	ListTools(ctx context.Context) ([]ToolSchema, error)
+	CallTool(ctx context.Context, name string, args map[string]any) (*CallResult, error)
+	ListResources(ctx context.Context) ([]Resource, error)
+	ReadResource(ctx context.Context, uri string) ([]ResourceContent, error)
+	ListPrompts(ctx context.Context) ([]Prompt, error)
+	GetPrompt(ctx context.Context, name string, args map[string]string) (*PromptResult, error)
+	// Ping はサーバーが応答するかを確認する（ヘルスチェック用）。
+	Ping(ctx context.Context) error
+	// OnNotification はサーバーからの通知を受け取るハンドラを設定する。
+	OnNotification(h NotificationHandler)
+	Close() error
+}
+
+// rpcConn はトランスポートごとの JSON-RPC 送受信。
+// MCP のメソッド（initialize / tools / resources / prompts）はこの上に共通で実装する。
+type rpcConn interface {
+	sendRequest(ctx context.Context, method string, params any) (json.RawMessage, error)
+	sendNotification(method string) error
+}
+
+// initialize は initialize リクエストと notifications/initialized 通知を送る。
+func initialize(ctx context.Context, c rpcConn) error {
+	// initialize リクエスト送信
+	result, err := c.sendRequest(ctx, "initialize", map[string]any{
+		"protocolVersion": "2024-11-05",
+		"capabilities":    map[string]any{},
+		"clientInfo": map[string]any{
+			"name":    "pentecter",
+			"version": "0.1.0",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("mcp: initialize failed: %w", err)
+	}
+	_ = result // サーバーの capabilities は現時点では使わない
+
+	// notifications/initialized 通知を送信（id なし）
+	if err := c.sendNotification("notifications/initialized"); err != nil {
+		return fmt.Errorf("mcp: failed to send initialized notification: %w", err)
+	}
+
+	return nil
+}
+
+// listTools は tools/list を呼び出してツール一覧を返す。
+func listTools(ctx context.Context, c rpcConn) ([]ToolSchema, error) {
+	result, err := c.sendRequest(ctx, "tools/list", nil)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: tools/list failed: %w", err)
+	}
+
+	// レスポンスの tools フィールドをパース
+	var resp struct {
+		Tools []ToolSchema `json:"tools"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return nil, fmt.Errorf("mcp: failed to parse tools/list response: %w", err)
+	}
+
+	return resp.Tools, nil
+}
+
+// ping は MCP の ping リクエストを送る。
+func ping(ctx context.Context, c rpcConn) error {
+	if _, err := c.sendRequest(ctx, "ping", nil); err != nil {
+		return fmt.Errorf("mcp: ping failed: %w", err)
+	}
+	return nil
+}
+
+// callTool は tools/call でツールを呼び出す。
+func callTool(ctx context.Context, c rpcConn, name string, args map[string]any) (*CallResult, error) {
+	params := map[string]any{
+		"name": name,
+	}
+	if args != nil {
+		params["arguments"] = args
+	}
+
+	result, err := c.sendRequest(ctx, "tools/call", params)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: tools/call failed: %w", err)
+	}
+
+	var callResult CallResult
+	if err := json.Unmarshal(result, &callResult); err != nil {
+		return nil, fmt.Errorf("mcp: failed to parse tools/call response: %w", err)
+	}
+
+	return &callResult, nil
+}
+
+// listResources は resources/list を呼び出してリソース一覧を返す。
+func listResources(ctx context.Context, c rpcConn) ([]Resource, error) {
+	result, err := c.sendRequest(ctx, "resources/list", nil)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: resources/list failed: %w", err)
+	}
+	var resp struct {
+		Resources []Resource `json:"resources"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return nil, fmt.Errorf("mcp: failed to parse resources/list response: %w", err)
+	}
+	return resp.Resources, nil
+}
+
+// readResource は resources/read でリソースの内容を取得する。
+func readResource(ctx context.Context, c rpcConn, uri string) ([]ResourceContent, error) {
+	result, err := c.sendRequest(ctx, "resources/read", map[string]any{"uri": uri})
+	if err != nil {
+		return nil, fmt.Errorf("mcp: resources/read failed: %w", err)
+	}
+	var resp struct {
+		Contents []ResourceContent `json:"contents"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return nil, fmt.Errorf("mcp: failed to parse resources/read response: %w", err)
+	}
+	return resp.Contents, nil
+}
+
+// listPrompts は prompts/list を呼び出してプロンプト一覧を返す。
+func listPrompts(ctx context.Context, c rpcConn) ([]Prompt, error) {
+	result, err := c.sendRequest(ctx, "prompts/list", nil)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: prompts/list failed: %w", err)
+	}
+	var resp struct {
+		Prompts []Prompt `json:"prompts"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return nil, fmt.Errorf("mcp: failed to parse prompts/list response: %w", err)
+	}
+	return resp.Prompts, nil
+}
+
+// getPrompt は prompts/get で引数を展開したプロンプトを取得する。
+func getPrompt(ctx context.Context, c rpcConn, name string, args map[string]string) (*PromptResult, error) {
+	params := map[string]any{"name": name}
+	if len(args) > 0 {
+		params["arguments"] = args
+	}
+	result, err := c.sendRequest(ctx, "prompts/get", params)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: prompts/get failed: %w", err)
+	}
+	var prompt PromptResult
+	if err := json.Unmarshal(result, &prompt); err != nil {
+		return nil, fmt.Errorf("mcp: failed to parse prompts/get response: %w", err)
+	}
+	return &prompt, nil
+}
+
+// MCPClient は MCP サーバーとの JSON-RPC 2.0 over stdio 通信を管理する。
+// stdout は1つの goroutine が読み続け、レスポンスを ID で待機中のリクエストへ振り分ける。
+// そのため複数のリクエストを並行して送れ、タイムアウトしたリクエストの遅れたレスポンスは破棄される。
 type MCPClient struct {
 	stdin   io.WriteCloser
 	stdout  io.ReadCloser
 	scanner *bufio.Scanner
 	cmd     *exec.Cmd // サブプロセスモード時のみ非 nil
 
-	mu     sync.Mutex // stdin/stdout 操作の排他制御
+	mu     sync.Mutex // stdin への書き込みの排他制御
 	nextID atomic.Int64
 	closed atomic.Bool
+
+	readOnce sync.Once
+	pmu      sync.Mutex
+	pending  map[int64]chan scanResult // リクエスト ID → レスポンス待ち
+	readErr  error                     // stdout が閉じた後のエラー
+	notify   NotificationHandler
+}
+
+// scanResult は stdout から読み取ったレスポンスまたは読み取りエラー。
+type scanResult struct {
+	resp jsonRPCResponse
+	err  error
 }
 
 // NewStdioClient は MCP サーバーをサブプロセスとして起動し、クライアントを返す。
@@ -68,12 +251,8 @@ func NewStdioClient(command string, args []string, env []string) (*MCPClient, er
 		return nil, fmt.Errorf("mcp: failed to start server %s: %w", command, err)
 	}
 
-	c := &MCPClient{
-		stdin:   stdin,
-		stdout:  stdout,
-		scanner: bufio.NewScanner(stdout),
-		cmd:     cmd,
-	}
+	c := newClientFromPipes(stdin, stdout)
+	c.cmd = cmd
 	return c, nil
 }
 
@@ -83,6 +262,7 @@ func newClientFromPipes(stdin io.WriteCloser, stdout io.ReadCloser) *MCPClient {
 		stdin:   stdin,
 		stdout:  stdout,
 		scanner: bufio.NewScanner(stdout),
+		pending: make(map[int64]chan scanResult),
 	}
 }
 
@@ -92,27 +272,7 @@ func (c *MCPClient) Initialize(ctx context.Context) error {
 	if c.closed.Load() {
 		return fmt.Errorf("mcp: client is closed")
 	}
-
-	// initialize リクエスト送信
-	result, err := c.sendRequest(ctx, "initialize", map[string]any{
-		"protocolVersion": "2024-11-05",
-		"capabilities":    map[string]any{},
-		"clientInfo": map[string]any{
-			"name":    "pentecter",
-			"version": "0.1.0",
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("mcp: initialize failed: %w", err)
-	}
-	_ = result // サーバーの capabilities は現時点では使わない
-
-	// notifications/initialized 通知を送信（id なし）
-	if err := c.sendNotification("notifications/initialized"); err != nil {
-		return fmt.Errorf("mcp: failed to send initialized notification: %w", err)
-	}
-
-	return nil
+	return initialize(ctx, c)
 }
 
 // ListTools は MCP サーバーからツール一覧を取得する
@@ -120,47 +280,62 @@ func (c *MCPClient) ListTools(ctx context.Context) ([]ToolSchema, error) {
 	if c.closed.Load() {
 		return nil, fmt.Errorf("mcp: client is closed")
 	}
+	return listTools(ctx, c)
+}
 
-	result, err := c.sendRequest(ctx, "tools/list", nil)
-	if err != nil {
-		return nil, fmt.Errorf("mcp: tools/list failed: %w", err)
+// CallTool は MCP サーバーのツールを呼び出す
+func (c *MCPClient) CallTool(ctx context.Context, name string, args map[string]any) (*CallResult, error) {
+	if c.closed.Load() {
+		return nil, fmt.Errorf("mcp: client is closed")
 	}
+	return callTool(ctx, c, name, args)
+}
 
-	// レスポンスの tools フィールドをパース
-	var resp struct {
-		Tools []ToolSchema `json:"tools"`
-	}
-	if err := json.Unmarshal(result, &resp); err != nil {
-		return nil, fmt.Errorf("mcp: failed to parse tools/list response: %w", err)
+// ListResources は MCP サーバーが公開するリソース一覧を取得する
+func (c *MCPClient) ListResources(ctx context.Context) ([]Resource, error) {
+	if c.closed.Load() {
+		return nil, fmt.Errorf("mcp: client is closed")
 	}
-
-	return resp.Tools, nil
+	return listResources(ctx, c)
 }
 
-// CallTool は MCP サーバーのツールを呼び出す
-func (c *MCPClient) CallTool(ctx context.Context, name string, args map[string]any) (*CallResult, error) {
+// ReadResource はリソースの内容を取得する
+func (c *MCPClient) ReadResource(ctx context.Context, uri string) ([]ResourceContent, error) {
 	if c.closed.Load() {
 		return nil, fmt.Errorf("mcp: client is closed")
 	}
+	return readResource(ctx, c, uri)
+}
 
-	params := map[string]any{
-		"name": name,
-	}
-	if args != nil {
-		params["arguments"] = args
+// ListPrompts は MCP サーバーが公開するプロンプト一覧を取得する
+func (c *MCPClient) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	if c.closed.Load() {
+		return nil, fmt.Errorf("mcp: client is closed")
 	}
+	return listPrompts(ctx, c)
+}
 
-	result, err := c.sendRequest(ctx, "tools/call", params)
-	if err != nil {
-		return nil, fmt.Errorf("mcp: tools/call failed: %w", err)
+// GetPrompt は引数を展開したプロンプトを取得する
+func (c *MCPClient) GetPrompt(ctx context.Context, name string, args map[string]string) (*PromptResult, error) {
+	if c.closed.Load() {
+		return nil, fmt.Errorf("mcp: client is closed")
 	}
+	return getPrompt(ctx, c, name, args)
+}
 
-	var callResult CallResult
-	if err := json.Unmarshal(result, &callResult); err != nil {
-		return nil, fmt.Errorf("mcp: failed to parse tools/call response: %w", err)
+// Ping はサーバーが応答するかを確認する
+func (c *MCPClient) Ping(ctx context.Context) error {
+	if c.closed.Load() {
+		return fmt.Errorf("mcp: client is closed")
 	}
+	return ping(ctx, c)
+}
 
-	return &callResult, nil
+// OnNotification はサーバーからの通知を受け取るハンドラを設定する
+func (c *MCPClient) OnNotification(h NotificationHandler) {
+	c.pmu.Lock()
+	c.notify = h
+	c.pmu.Unlock()
 }
 
 // Close はクライアントを閉じ、サブプロセスを終了させる
@@ -195,8 +370,7 @@ func (c *MCPClient) Close() error {
 
 // sendRequest は JSON-RPC リクエストを送信し、レスポンスを待つ
 func (c *MCPClient) sendRequest(ctx context.Context, method string, params any) (json.RawMessage, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.readOnce.Do(func() { go c.readLoop() })
 
 	id := c.nextID.Add(1)
 
@@ -214,41 +388,30 @@ func (c *MCPClient) sendRequest(ctx context.Context, method string, params any)
 	}
 	data = append(data, '\n')
 
-	if _, err := c.stdin.Write(data); err != nil {
-		return nil, fmt.Errorf("failed to write request: %w", err)
+	// レスポンスの待ち受けを登録してから送信する
+	ch := make(chan scanResult, 1)
+	c.pmu.Lock()
+	if c.readErr != nil {
+		err := c.readErr
+		c.pmu.Unlock()
+		return nil, err
 	}
+	c.pending[id] = ch
+	c.pmu.Unlock()
+	defer func() {
+		c.pmu.Lock()
+		delete(c.pending, id)
+		c.pmu.Unlock()
+	}()
 
-	// レスポンスを読み取る（コンテキストキャンセル対応）
-	type scanResult struct {
-		resp jsonRPCResponse
-		err  error
+	c.mu.Lock()
+	_, err = c.stdin.Write(data)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write request: %w", err)
 	}
-	ch := make(chan scanResult, 1)
-	go func() {
-		for {
-			if !c.scanner.Scan() {
-				err := c.scanner.Err()
-				if err == nil {
-					err = fmt.Errorf("unexpected EOF")
-				}
-				ch <- scanResult{err: err}
-				return
-			}
-			line := c.scanner.Bytes()
-			// 非 JSON 行（MCP サーバーのバナー出力等）をスキップ
-			if len(line) == 0 || line[0] != '{' {
-				continue
-			}
-			var resp jsonRPCResponse
-			if err := json.Unmarshal(line, &resp); err != nil {
-				ch <- scanResult{err: fmt.Errorf("failed to parse response: %w", err)}
-				return
-			}
-			ch <- scanResult{resp: resp}
-			return
-		}
-	}()
 
+	// レスポンスを待つ（コンテキストキャンセル対応）
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -263,6 +426,68 @@ func (c *MCPClient) sendRequest(ctx context.Context, method string, params any)
 	}
 }
 
+// readLoop は stdout を読み続け、レスポンスを待機中のリクエストへ、通知をハンドラへ渡す。
+// stdout が閉じると待機中のリクエストをすべて失敗させる。
+func (c *MCPClient) readLoop() {
+	for c.scanner.Scan() {
+		line := c.scanner.Bytes()
+		// 非 JSON 行（MCP サーバーのバナー出力等）をスキップ
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var env jsonRPCEnvelope
+		if err := json.Unmarshal(line, &env); err != nil {
+			// どのリクエストへの応答か判別できないため、待機中のものをすべて失敗させる
+			c.failPending(fmt.Errorf("failed to parse response: %w", err))
+			continue
+		}
+		if env.Method != "" {
+			// サーバーからの通知（id なし）。サーバーからのリクエストには対応しない
+			if len(env.ID) == 0 {
+				c.pmu.Lock()
+				h := c.notify
+				c.pmu.Unlock()
+				if h != nil {
+					h(env.Method)
+				}
+			}
+			continue
+		}
+		var resp jsonRPCResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			c.failPending(fmt.Errorf("failed to parse response: %w", err))
+			continue
+		}
+		c.pmu.Lock()
+		ch, ok := c.pending[resp.ID]
+		delete(c.pending, resp.ID)
+		c.pmu.Unlock()
+		if ok {
+			ch <- scanResult{resp: resp}
+		}
+		// 待機者がいない（タイムアウト済み）レスポンスは破棄
+	}
+
+	err := c.scanner.Err()
+	if err == nil {
+		err = fmt.Errorf("unexpected EOF")
+	}
+	c.pmu.Lock()
+	c.readErr = err
+	c.pmu.Unlock()
+	c.failPending(err)
+}
+
+// failPending は待機中のリクエストすべてに err を返す。
+func (c *MCPClient) failPending(err error) {
+	c.pmu.Lock()
+	defer c.pmu.Unlock()
+	for id, ch := range c.pending {
+		ch <- scanResult{err: err}
+		delete(c.pending, id)
+	}
+}
+
 // sendNotification は JSON-RPC 通知を送信する（id なし、レスポンス不要）
 func (c *MCPClient) sendNotification(method string) error {
 	// 通知は id フィールドを含まない
@@ -277,7 +502,10 @@ func (c *MCPClient) sendNotification(method string) error {
 	}
 	data = append(data, '\n')
 
-	if _, err := c.stdin.Write(data); err != nil {
+	c.mu.Lock()
+	_, err = c.stdin.Write(data)
+	c.mu.Unlock()
+	if err != nil {
 		return fmt.Errorf("failed to write notification: %w", err)
 	}
 
@@ -14,7 +14,7 @@ var envVarPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
 
 // LoadConfig は指定パスから MCP 設定ファイルを読み込む。
 // ファイルが存在しない場合は nil, nil を返す（graceful skip）。
-// env フィールドの値に含まれる ${VAR} はホスト環境変数から展開される。
+// env・headers の値、command・args・url に含まれる ${VAR} はホスト環境変数から展開される。
 func LoadConfig(path string) (*MCPConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -29,13 +29,18 @@ func LoadConfig(path string) (*MCPConfig, error) {
 		return nil, fmt.Errorf("mcp: failed to parse config %s: %w", path, err)
 	}
 
-	// 環境変数を展開（env, args, command の ${VAR} を展開）
+	// 環境変数を展開（env, headers, args, command, url の ${VAR} を展開）
 	for i := range cfg.Servers {
 		expandEnvVars(cfg.Servers[i].Env)
+		expandEnvVars(cfg.Servers[i].Headers)
 		cfg.Servers[i].Command = expandEnvString(cfg.Servers[i].Command)
+		cfg.Servers[i].URL = expandEnvString(cfg.Servers[i].URL)
 		for j := range cfg.Servers[i].Args {
 			cfg.Servers[i].Args[j] = expandEnvString(cfg.Servers[i].Args[j])
 		}
+		if err := cfg.Servers[i].validatePolicy(); err != nil {
+			return nil, fmt.Errorf("mcp: %s: %w", path, err)
+		}
 	}
 
 	return &cfg, nil
@@ -219,3 +219,46 @@ func TestLoadConfig_EmptyServers(t *testing.T) {
 		t.Fatalf("expected 0 servers, got %d", len(cfg.Servers))
 	}
 }
+
+func TestLoadConfig_RemoteServer(t *testing.T) {
+	// url と headers の ${VAR} が展開され、transport 省略時は http になること
+	t.Setenv("TEST_MCP_BURP_HOST", "burp.internal:9876")
+	t.Setenv("TEST_MCP_TOKEN", "secret")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mcp.yaml")
+	content := `servers:
+  - name: burp
+    url: "http://${TEST_MCP_BURP_HOST}/mcp"
+    headers:
+      Authorization: "Bearer ${TEST_MCP_TOKEN}"
+  - name: scanner
+    transport: sse
+    url: "http://scanner.internal/sse"
+  - name: local
+    command: node
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+
+	burp := cfg.Servers[0]
+	if burp.URL != "http://burp.internal:9876/mcp" {
+		t.Errorf("expected expanded url, got %q", burp.URL)
+	}
+	if burp.Headers["Authorization"] != "Bearer secret" {
+		t.Errorf("expected expanded header, got %q", burp.Headers["Authorization"])
+	}
+
+	want := []string{TransportHTTP, TransportSSE, TransportStdio}
+	for i, s := range cfg.Servers {
+		if got := s.TransportType(); got != want[i] {
+			t.Errorf("%s: TransportType() = %q, want %q", s.Name, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,522 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPClient は HTTP 経由でリモートの MCP サーバーと通信する。
+//
+//   - TransportHTTP（Streamable HTTP）: リクエストごとに POST し、レスポンスは
+//     application/json または text/event-stream で受け取る。Mcp-Session-Id を引き継ぐ。
+//   - TransportSSE（HTTP+SSE）: GET で SSE ストリームを開き、endpoint イベントで
+//     通知された URL へリクエストを POST する。レスポンスはストリームの message イベントで届く。
+type HTTPClient struct {
+	url       string
+	transport string
+	headers   map[string]string
+	http      *http.Client
+
+	nextID atomic.Int64
+	closed atomic.Bool
+
+	mu        sync.Mutex
+	sessionID string // Streamable HTTP のセッション ID
+	notify    NotificationHandler
+
+	// HTTP+SSE トランスポート用
+	endpoint  string                         // POST 先（endpoint イベントで確定）
+	pending   map[int64]chan jsonRPCResponse // リクエスト ID → レスポンス待ち
+	streamErr error                          // ストリーム切断時のエラー
+	cancel    context.CancelFunc             // ストリームの停止
+}
+
+// NewHTTPClient はリモート MCP サーバーのクライアントを作成する。
+// transport は TransportHTTP または TransportSSE。headers は全リクエストに付与する（認証等）。
+// 接続は Initialize で開始する。
+func NewHTTPClient(rawURL, transport string, headers map[string]string) (*HTTPClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("mcp: invalid server url %q", rawURL)
+	}
+	if transport != TransportHTTP && transport != TransportSSE {
+		return nil, fmt.Errorf("mcp: unsupported HTTP transport %q", transport)
+	}
+	return &HTTPClient{
+		url:       rawURL,
+		transport: transport,
+		headers:   headers,
+		http:      &http.Client{},
+		pending:   make(map[int64]chan jsonRPCResponse),
+	}, nil
+}
+
+// Initialize は接続を確立し、MCP プロトコルのハンドシェイクを行う。
+func (c *HTTPClient) Initialize(ctx context.Context) error {
+	if c.closed.Load() {
+		return fmt.Errorf("mcp: client is closed")
+	}
+	if c.transport == TransportSSE {
+		if err := c.connectSSE(ctx); err != nil {
+			return fmt.Errorf("mcp: initialize failed: %w", err)
+		}
+	}
+	return initialize(ctx, c)
+}
+
+// ListTools は MCP サーバーからツール一覧を取得する
+func (c *HTTPClient) ListTools(ctx context.Context) ([]ToolSchema, error) {
+	if c.closed.Load() {
+		return nil, fmt.Errorf("mcp: client is closed")
+	}
+	return listTools(ctx, c)
+}
+
+// CallTool は MCP サーバーのツールを呼び出す
+func (c *HTTPClient) CallTool(ctx context.Context, name string, args map[string]any) (*CallResult, error) {
+	if c.closed.Load() {
+		return nil, fmt.Errorf("mcp: client is closed")
+	}
+	return callTool(ctx, c, name, args)
+}
+
+// ListResources は MCP サーバーが公開するリソース一覧を取得する
+func (c *HTTPClient) ListResources(ctx context.Context) ([]Resource, error) {
+	if c.closed.Load() {
+		return nil, fmt.Errorf("mcp: client is closed")
+	}
+	return listResources(ctx, c)
+}
+
+// ReadResource はリソースの内容を取得する
+func (c *HTTPClient) ReadResource(ctx context.Context, uri string) ([]ResourceContent, error) {
+	if c.closed.Load() {
+		return nil, fmt.Errorf("mcp: client is closed")
+	}
+	return readResource(ctx, c, uri)
+}
+
+// ListPrompts は MCP サーバーが公開するプロンプト一覧を取得する
+func (c *HTTPClient) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	if c.closed.Load() {
+		return nil, fmt.Errorf("mcp: client is closed")
+	}
+	return listPrompts(ctx, c)
+}
+
+// GetPrompt は引数を展開したプロンプトを取得する
+func (c *HTTPClient) GetPrompt(ctx context.Context, name string, args map[string]string) (*PromptResult, error) {
+	if c.closed.Load() {
+		return nil, fmt.Errorf("mcp: client is closed")
+	}
+	return getPrompt(ctx, c, name, args)
+}
+
+// Ping はサーバーが応答するかを確認する
+func (c *HTTPClient) Ping(ctx context.Context) error {
+	if c.closed.Load() {
+		return fmt.Errorf("mcp: client is closed")
+	}
+	return ping(ctx, c)
+}
+
+// OnNotification はサーバーからの通知を受け取るハンドラを設定する。
+// 通知はレスポンスの SSE ストリーム（HTTP+SSE では常設ストリーム）で届いたものに限られる。
+func (c *HTTPClient) OnNotification(h NotificationHandler) {
+	c.mu.Lock()
+	c.notify = h
+	c.mu.Unlock()
+}
+
+// handleMessage はストリームで届いたメッセージが通知ならハンドラへ渡し、true を返す。
+func (c *HTTPClient) handleMessage(data string) bool {
+	var env jsonRPCEnvelope
+	if json.Unmarshal([]byte(data), &env) != nil || env.Method == "" {
+		return false
+	}
+	if len(env.ID) == 0 {
+		c.mu.Lock()
+		h := c.notify
+		c.mu.Unlock()
+		if h != nil {
+			h(env.Method)
+		}
+	}
+	return true
+}
+
+// Close は SSE ストリームを停止し、Streamable HTTP のセッションを終了する。
+func (c *HTTPClient) Close() error {
+	if c.closed.Swap(true) {
+		return nil // 既に閉じている
+	}
+
+	c.mu.Lock()
+	cancel, sessionID := c.cancel, c.sessionID
+	c.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+
+	// セッション終了の通知はベストエフォート（未対応のサーバーは 405 を返す）
+	if sessionID != "" {
+		ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.url, nil)
+		if err == nil {
+			c.setHeaders(req)
+			if resp, err := c.http.Do(req); err == nil {
+				_ = resp.Body.Close()
+			}
+		}
+	}
+	return nil
+}
+
+// sendRequest は JSON-RPC リクエストを送信し、レスポンスを待つ
+func (c *HTTPClient) sendRequest(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id := c.nextID.Add(1)
+	req := jsonRPCRequest{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  method,
+		Params:  params,
+	}
+
+	var (
+		resp jsonRPCResponse
+		err  error
+	)
+	if c.transport == TransportSSE {
+		resp, err = c.roundTripSSE(ctx, req)
+	} else {
+		resp, err = c.roundTripHTTP(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("JSON-RPC error %d: %s", resp.Error.Code, resp.Error.Message)
+	}
+	return resp.Result, nil
+}
+
+// sendNotification は JSON-RPC 通知を送信する（id なし、レスポンス不要）
+func (c *HTTPClient) sendNotification(method string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	target := c.url
+	if c.transport == TransportSSE {
+		c.mu.Lock()
+		target = c.endpoint
+		c.mu.Unlock()
+	}
+	resp, err := c.post(ctx, target, map[string]any{
+		"jsonrpc": "2.0",
+		"method":  method,
+	})
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return nil
+}
+
+// roundTripHTTP は Streamable HTTP でリクエストを POST し、レスポンスを読み取る。
+func (c *HTTPClient) roundTripHTTP(ctx context.Context, req jsonRPCRequest) (jsonRPCResponse, error) {
+	resp, err := c.post(ctx, c.url, req)
+	if err != nil {
+		return jsonRPCResponse{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if sid := resp.Header.Get("Mcp-Session-Id"); sid != "" {
+		c.mu.Lock()
+		c.sessionID = sid
+		c.mu.Unlock()
+	}
+
+	// SSE で返された場合は、同じ ID のレスポンスが届くまで読む（途中の通知等は無視）
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		var (
+			found   jsonRPCResponse
+			matched bool
+		)
+		err := readSSE(resp.Body, func(event, data string) bool {
+			if (event != "" && event != "message") || c.handleMessage(data) {
+				return true
+			}
+			var r jsonRPCResponse
+			if json.Unmarshal([]byte(data), &r) != nil || r.ID != req.ID {
+				return true
+			}
+			found, matched = r, true
+			return false
+		})
+		if matched {
+			return found, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("unexpected EOF")
+		}
+		return jsonRPCResponse{}, fmt.Errorf("failed to read response stream: %w", err)
+	}
+
+	var r jsonRPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return jsonRPCResponse{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return r, nil
+}
+
+// roundTripSSE は HTTP+SSE でリクエストを POST し、ストリームからレスポンスを待つ。
+func (c *HTTPClient) roundTripSSE(ctx context.Context, req jsonRPCRequest) (jsonRPCResponse, error) {
+	ch := make(chan jsonRPCResponse, 1)
+	c.mu.Lock()
+	if c.streamErr != nil {
+		err := c.streamErr
+		c.mu.Unlock()
+		return jsonRPCResponse{}, err
+	}
+	c.pending[req.ID] = ch
+	endpoint := c.endpoint
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}()
+
+	resp, err := c.post(ctx, endpoint, req)
+	if err != nil {
+		return jsonRPCResponse{}, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	select {
+	case <-ctx.Done():
+		return jsonRPCResponse{}, ctx.Err()
+	case r, ok := <-ch:
+		if !ok {
+			c.mu.Lock()
+			err := c.streamErr
+			c.mu.Unlock()
+			return jsonRPCResponse{}, err
+		}
+		return r, nil
+	}
+}
+
+// connectSSE は SSE ストリームを開き、endpoint イベントを受信するまで待つ。
+// ストリームは Close まで読み続け、message イベントを待機中のリクエストへ振り分ける。
+func (c *HTTPClient) connectSSE(ctx context.Context) error {
+	streamCtx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, c.url, nil)
+	if err != nil {
+		cancel()
+		return err
+	}
+	c.setHeaders(req)
+	req.Header.Set("Accept", "text/event-stream")
+
+	type connResult struct {
+		resp *http.Response
+		err  error
+	}
+	connCh := make(chan connResult, 1)
+	go func() {
+		resp, err := c.http.Do(req)
+		connCh <- connResult{resp, err}
+	}()
+
+	var resp *http.Response
+	select {
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	case r := <-connCh:
+		if r.err != nil {
+			cancel()
+			return fmt.Errorf("failed to open event stream: %w", r.err)
+		}
+		resp = r.resp
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		cancel()
+		return fmt.Errorf("failed to open event stream: HTTP %d", resp.StatusCode)
+	}
+
+	c.mu.Lock()
+	c.cancel = cancel
+	c.mu.Unlock()
+
+	ready := make(chan string, 1)
+	go c.readStream(resp.Body, ready)
+
+	select {
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	case endpoint, ok := <-ready:
+		if !ok {
+			c.mu.Lock()
+			err := c.streamErr
+			c.mu.Unlock()
+			return fmt.Errorf("event stream closed before endpoint: %w", err)
+		}
+		c.mu.Lock()
+		c.endpoint = endpoint
+		c.mu.Unlock()
+		return nil
+	}
+}
+
+// readStream は SSE ストリームを読み、endpoint を ready に、レスポンスを pending に渡す。
+// ストリームが終わると待機中のリクエストをすべて失敗させる。
+func (c *HTTPClient) readStream(body io.ReadCloser, ready chan<- string) {
+	defer func() { _ = body.Close() }()
+
+	gotEndpoint := false
+	err := readSSE(body, func(event, data string) bool {
+		switch event {
+		case "endpoint":
+			if !gotEndpoint {
+				gotEndpoint = true
+				ready <- c.resolve(strings.TrimSpace(data))
+			}
+		case "", "message":
+			if c.handleMessage(data) {
+				return true
+			}
+			var r jsonRPCResponse
+			if json.Unmarshal([]byte(data), &r) != nil || r.ID == 0 {
+				return true
+			}
+			c.mu.Lock()
+			ch, ok := c.pending[r.ID]
+			c.mu.Unlock()
+			if ok {
+				ch <- r
+			}
+		}
+		return true
+	})
+	if err == nil {
+		err = fmt.Errorf("unexpected EOF")
+	}
+
+	c.mu.Lock()
+	c.streamErr = fmt.Errorf("event stream closed: %w", err)
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	if !gotEndpoint {
+		close(ready)
+	}
+}
+
+// resolve は endpoint イベントの URL（相対パス可）をサーバー URL 基準で解決する。
+func (c *HTTPClient) resolve(ref string) string {
+	base, err := url.Parse(c.url)
+	if err != nil {
+		return ref
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// post は JSON-RPC メッセージを POST する。2xx 以外はエラーにする。
+func (c *HTTPClient) post(ctx context.Context, target string, msg any) (*http.Response, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	c.setHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// setHeaders は設定されたヘッダーとセッション ID をリクエストに付与する。
+func (c *HTTPClient) setHeaders(req *http.Request) {
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	c.mu.Lock()
+	sid := c.sessionID
+	c.mu.Unlock()
+	if sid != "" {
+		req.Header.Set("Mcp-Session-Id", sid)
+	}
+}
+
+// readSSE は Server-Sent Events を読み、イベントごとに fn を呼ぶ。
+// fn が false を返すと読み取りを終了する。ストリーム終端では nil を返す。
+func readSSE(r io.Reader, fn func(event, data string) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var (
+		event string
+		data  []string
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 && !fn(event, strings.Join(data, "\n")) {
+				return nil
+			}
+			event, data = "", nil
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		fn(event, strings.Join(data, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// handleRPC はテスト用 MCP サーバーの JSON-RPC 処理。通知（id なし）には nil を返す。
+func handleRPC(req jsonRPCRequest) *jsonRPCResponse {
+	if req.ID == 0 {
+		return nil
+	}
+	resp := &jsonRPCResponse{JSONRPC: "2.0", ID: req.ID}
+	var result any
+	switch req.Method {
+	case "initialize":
+		result = map[string]any{"protocolVersion": "2024-11-05", "capabilities": map[string]any{}}
+	case "ping":
+		result = map[string]any{}
+	case "tools/list":
+		result = map[string]any{"tools": []map[string]any{
+			{"name": "scan", "description": "Remote scan", "inputSchema": map[string]any{"type": "object"}},
+		}}
+	case "tools/call":
+		params, _ := req.Params.(map[string]any)
+		args, _ := params["arguments"].(map[string]any)
+		result = map[string]any{"content": []map[string]any{
+			{"type": "text", "text": fmt.Sprintf("scanned %v", args["host"])},
+		}}
+	case "resources/list":
+		result = map[string]any{"resources": []map[string]any{
+			{"uri": "wordlist://common", "name": "common.txt"},
+		}}
+	case "resources/read":
+		params, _ := req.Params.(map[string]any)
+		result = map[string]any{"contents": []map[string]any{
+			{"uri": params["uri"], "mimeType": "text/plain", "text": "admin\nbackup"},
+		}}
+	case "prompts/list":
+		result = map[string]any{"prompts": []map[string]any{
+			{"name": "sqli", "description": "SQLi methodology", "arguments": []map[string]any{{"name": "url", "required": true}}},
+		}}
+	case "prompts/get":
+		params, _ := req.Params.(map[string]any)
+		args, _ := params["arguments"].(map[string]any)
+		result = map[string]any{"messages": []map[string]any{
+			{"role": "user", "content": map[string]any{"type": "text", "text": fmt.Sprintf("Test %v", args["url"])}},
+			{"role": "user", "content": map[string]any{"type": "text", "text": "Use sqlmap"}},
+		}}
+	default:
+		resp.Error = &jsonRPCError{Code: -32601, Message: "method not found"}
+		return resp
+	}
+	resp.Result, _ = json.Marshal(result)
+	return resp
+}
+
+// newStreamableServer は Streamable HTTP のスタンドインサーバーを起動する。
+// useSSE が true の場合はレスポンスを text/event-stream で返す。
+func newStreamableServer(t *testing.T, useSSE bool) (*httptest.Server, *[]http.Header) {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		headers []http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Clone())
+		mu.Unlock()
+
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		var req jsonRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if req.Method == "initialize" {
+			w.Header().Set("Mcp-Session-Id", "sess-1")
+		} else if r.Header.Get("Mcp-Session-Id") != "sess-1" {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+		resp := handleRPC(req)
+		if resp == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		data, _ := json.Marshal(resp)
+		if useSSE {
+			w.Header().Set("Content-Type", "text/event-stream")
+			// レスポンスの前に無関係な通知が届いても読み飛ばす
+			fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &headers
+}
+
+// newLegacySSEServer は HTTP+SSE のスタンドインサーバーを起動する。
+// GET /sse でストリームを開き、POST /messages のレスポンスをストリームに流す。
+func newLegacySSEServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	out := make(chan []byte, 16)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sse", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		fmt.Fprintf(w, "event: endpoint\ndata: /messages?session=abc\n\n")
+		flusher.Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case data := <-out:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+				flusher.Flush()
+			}
+		}
+	})
+	mux.HandleFunc("POST /messages", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("session") != "abc" {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		var req jsonRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if resp := handleRPC(req); resp != nil {
+			data, _ := json.Marshal(resp)
+			out <- data
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// exerciseClient は Initialize → ListTools → CallTool を実行して結果を検証する。
+func exerciseClient(t *testing.T, c Client) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "scan" {
+		t.Fatalf("tools: %+v", tools)
+	}
+	res, err := c.CallTool(ctx, "scan", map[string]any{"host": "10.0.0.5"})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if len(res.Content) != 1 || res.Content[0].Text != "scanned 10.0.0.5" {
+		t.Errorf("CallTool result: %+v", res)
+	}
+}
+
+func TestHTTPClient_Streamable(t *testing.T) {
+	for _, useSSE := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sse=%v", useSSE), func(t *testing.T) {
+			srv, headers := newStreamableServer(t, useSSE)
+			c, err := NewHTTPClient(srv.URL, TransportHTTP, map[string]string{"Authorization": "Bearer tok"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			exerciseClient(t, c)
+			if err := c.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			for _, h := range *headers {
+				if h.Get("Authorization") != "Bearer tok" {
+					t.Errorf("configured header not sent: %v", h)
+				}
+			}
+			if last := (*headers)[len(*headers)-1]; last.Get("Mcp-Session-Id") != "sess-1" {
+				t.Error("session should be terminated with its session id")
+			}
+		})
+	}
+}
+
+func TestHTTPClient_LegacySSE(t *testing.T) {
+	srv := newLegacySSEServer(t)
+	c, err := NewHTTPClient(srv.URL+"/sse", TransportSSE, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	exerciseClient(t, c)
+}
+
+func TestHTTPClient_Errors(t *testing.T) {
+	if _, err := NewHTTPClient("ftp://example.com", TransportHTTP, nil); err == nil {
+		t.Error("expected error for non-HTTP url")
+	}
+	if _, err := NewHTTPClient("http://example.com", TransportStdio, nil); err == nil {
+		t.Error("expected error for non-HTTP transport")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	c, err := NewHTTPClient(srv.URL, TransportHTTP, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Initialize(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "HTTP 403") {
+		t.Errorf("expected HTTP 403 error, got %v", err)
+	}
+
+	sse, err := NewHTTPClient(srv.URL, TransportSSE, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sse.Initialize(context.Background()); err == nil {
+		t.Error("expected error when the event stream cannot be opened")
+	}
+
+	_ = c.Close()
+	if _, err := c.ListTools(context.Background()); err == nil {
+		t.Error("expected error after Close")
+	}
+}
+
+func TestReadSSE(t *testing.T) {
+	input := ": comment\nevent: endpoint\ndata: /messages\n\ndata: line1\ndata: line2\n\n"
+	var got []string
+	if err := readSSE(strings.NewReader(input), func(event, data string) bool {
+		got = append(got, event+"|"+data)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"endpoint|/messages", "|line1\nline2"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestManager_MixedTransports(t *testing.T) {
+	// stdio サーバーを動かしたまま、リロードでリモートサーバーを追加する
+	mgr, pairs := newTestManager(t, []ServerConfig{{Name: "local", Command: "echo"}})
+	defer pairs[0].mock.close()
+	mgr.tools["local"] = []ToolSchema{{Server: "local", Name: "local_tool"}}
+
+	remote, _ := newStreamableServer(t, false)
+	legacy := newLegacySSEServer(t)
+	path := filepath.Join(t.TempDir(), "mcp.yaml")
+	content := fmt.Sprintf(`servers:
+  - name: local
+    command: echo
+  - name: remote
+    url: %s
+  - name: legacy
+    transport: sse
+    url: %s/sse
+`, remote.URL, legacy.URL)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := mgr.Reload(ctx, path); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	defer mgr.Close()
+
+	if got := len(mgr.ListAllTools()); got != 3 {
+		t.Fatalf("expected 3 tools across transports, got %d: %+v", got, mgr.ListAllTools())
+	}
+	for _, server := range []string{"remote", "legacy"} {
+		res, err := mgr.CallTool(ctx, server, "scan", map[string]any{"host": "10.0.0.9"})
+		if err != nil {
+			t.Fatalf("CallTool(%s): %v", server, err)
+		}
+		if res.Content[0].Text != "scanned 10.0.0.9" {
+			t.Errorf("CallTool(%s): %+v", server, res)
+		}
+	}
+}
+
+func TestHTTPClient_ResourcesAndPrompts(t *testing.T) {
+	srv, _ := newStreamableServer(t, true)
+	c, err := NewHTTPClient(srv.URL, TransportHTTP, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	resources, err := c.ListResources(ctx)
+	if err != nil || len(resources) != 1 || resources[0].URI != "wordlist://common" {
+		t.Fatalf("ListResources: %+v, %v", resources, err)
+	}
+	contents, err := c.ReadResource(ctx, "wordlist://common")
+	if err != nil || len(contents) != 1 || contents[0].Text != "admin\nbackup" {
+		t.Fatalf("ReadResource: %+v, %v", contents, err)
+	}
+	prompts, err := c.ListPrompts(ctx)
+	if err != nil || len(prompts) != 1 || !prompts[0].Arguments[0].Required {
+		t.Fatalf("ListPrompts: %+v, %v", prompts, err)
+	}
+	prompt, err := c.GetPrompt(ctx, "sqli", map[string]string{"url": "http://10.0.0.5/"})
+	if err != nil {
+		t.Fatalf("GetPrompt: %v", err)
+	}
+	if got := prompt.Text(); got != "Test http://10.0.0.5/\n\nUse sqlmap" {
+		t.Errorf("prompt text: %q", got)
+	}
+}
+
+func TestManager_ResourcesAndPrompts(t *testing.T) {
+	// prompts 非対応のサーバー（stdio）が混ざっていても一覧は取得できる
+	remote, _ := newStreamableServer(t, false)
+	httpClient, err := NewHTTPClient(remote.URL, TransportHTTP, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpClient.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	local := serveOverPipes(t, newTestServer())
+
+	mgr := &MCPManager{
+		clients: map[string]Client{"remote": httpClient, "local": local},
+		configs: []ServerConfig{{Name: "remote", URL: remote.URL}, {Name: "local", Command: "x"}},
+		tools:   make(map[string][]ToolSchema),
+		states:  make(map[string]*serverState),
+	}
+	defer mgr.Close()
+
+	resources, err := mgr.ListResources(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 2 || resources[0].Server != "local" || resources[1].Server != "remote" {
+		t.Errorf("resources should be aggregated in server order with their server: %+v", resources)
+	}
+
+	prompts, err := mgr.ListPrompts(ctx, "")
+	if err != nil || len(prompts) != 1 || prompts[0].Server != "remote" {
+		t.Errorf("servers without prompts should be skipped: %+v, %v", prompts, err)
+	}
+	if _, err := mgr.ListPrompts(ctx, "local"); err == nil || !isMethodNotFound(err) {
+		t.Errorf("listing a single server should report method not found, got %v", err)
+	}
+
+	contents, err := mgr.ReadResource(ctx, "local", "test://notes")
+	if err != nil || contents[0].Text != "hello" {
+		t.Errorf("ReadResource: %+v, %v", contents, err)
+	}
+	prompt, err := mgr.GetPrompt(ctx, "remote", "sqli", map[string]string{"url": "u"})
+	if err != nil || !strings.HasPrefix(prompt.Text(), "Test u") {
+		t.Errorf("GetPrompt: %+v, %v", prompt, err)
+	}
+	if _, err := mgr.ReadResource(ctx, "missing", "x"); err == nil || !strings.Contains(err.Error(), "unknown server") {
+		t.Errorf("expected unknown server error, got %v", err)
+	}
+}
@@ -2,16 +2,65 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
-// MCPManager は複数の MCP サーバーを管理し、ツールの集約・ルーティングを行う
+// サーバーの状態（/mcp で表示）
+const (
+	StatusRunning = "running" // 接続済み
+	StatusDown    = "down"    // 停止・起動失敗（再起動待ち）
+)
+
+// ヘルスチェック・再起動の設定
+var (
+	monitorTick       = time.Second      // Monitor の巡回間隔
+	pingTimeout       = 10 * time.Second // ヘルスチェックの ping タイムアウト
+	restartBackoffMin = 2 * time.Second  // 再起動バックオフの初期値（失敗ごとに倍）
+	restartBackoffMax = 5 * time.Minute  // 再起動バックオフの上限
+)
+
+// serverState はサーバーごとの稼働状態。
+type serverState struct {
+	status    string
+	lastErr   string
+	restarts  int       // 自動再起動に成功した回数
+	failures  int       // 連続失敗回数（バックオフ計算用）
+	nextRetry time.Time // 次の再起動試行時刻（StatusDown 時）
+	lastCheck time.Time // 最後のヘルスチェック時刻
+	inflight  int       // 実行中の CallTool 数（実行中は ping しない）
+	busy      bool      // ヘルスチェック・再起動の実行中
+}
+
+// ServerStatus はサーバーの稼働状態のスナップショット（/mcp 表示用）。
+type ServerStatus struct {
+	Name      string
+	Transport string
+	Status    string
+	Tools     int
+	Restarts  int
+	LastError string
+	NextRetry time.Time // Status が StatusDown の場合の次の再起動試行時刻
+}
+
+// MCPManager は複数の MCP サーバーを管理し、ツールの集約・ルーティングを行う。
+// Reload・自動再起動による実行中の差し替えに備え、各マップへのアクセスは mu で保護する。
 type MCPManager struct {
-	clients map[string]*MCPClient   // サーバー名 → クライアント
+	mu      sync.RWMutex
+	clients map[string]Client       // サーバー名 → クライアント
 	configs []ServerConfig          // 設定されたサーバー一覧
 	tools   map[string][]ToolSchema // サーバー名 → ツール一覧
+	states  map[string]*serverState // サーバー名 → 稼働状態
+
+	toolsChanged func()           // ツール一覧が変わった時のコールバック（nil = 通知なし）
+	notice       func(msg string) // ヘルスチェック・再起動の結果の通知先（nil = log に出す）
 }
 
 // NewManager は設定ファイルからマネージャーを作成する。
@@ -26,16 +75,97 @@ func NewManager(configPath string) (*MCPManager, error) {
 	}
 
 	return &MCPManager{
-		clients: make(map[string]*MCPClient),
+		clients: make(map[string]Client),
 		configs: cfg.Servers,
 		tools:   make(map[string][]ToolSchema),
+		states:  make(map[string]*serverState),
 	}, nil
 }
 
+// OnToolsChanged はサーバーの再起動や tools/list_changed 通知でツール一覧が
+// 変わった時に呼ばれるコールバックを設定する（Brain のシステムプロンプト更新用）。
+func (m *MCPManager) OnToolsChanged(fn func()) {
+	m.mu.Lock()
+	m.toolsChanged = fn
+	m.mu.Unlock()
+}
+
+// OnNotice はヘルスチェックの失敗・再起動などの通知先を設定する。
+// Monitor は TUI の実行中に動くため、設定すれば stderr ではなくこちらに送る。
+func (m *MCPManager) OnNotice(fn func(msg string)) {
+	m.mu.Lock()
+	m.notice = fn
+	m.mu.Unlock()
+}
+
+// noticef は OnNotice の通知先（未設定なら log）にメッセージを送る。
+func (m *MCPManager) noticef(format string, args ...any) {
+	m.mu.RLock()
+	fn := m.notice
+	m.mu.RUnlock()
+	msg := fmt.Sprintf(format, args...)
+	if fn == nil {
+		log.Print("[mcp] " + msg)
+		return
+	}
+	fn("MCP: " + msg)
+}
+
 // StartAll は全サーバーを起動し、Initialize と ListTools を実行する。
-// 個別のサーバー起動に失敗した場合はログに警告を出して続行する。
+// 個別のサーバー起動に失敗した場合はログに警告を出して続行する（Monitor が再起動を試みる）。
 func (m *MCPManager) StartAll(ctx context.Context) error {
-	for _, cfg := range m.configs {
+	m.mu.RLock()
+	configs := m.configs
+	m.mu.RUnlock()
+
+	for _, cfg := range configs {
+		client, tools, err := startServer(ctx, cfg)
+		if err != nil {
+			log.Printf("[mcp] WARNING: %v", err)
+			m.markDown(cfg.Name, nil, err)
+			continue
+		}
+		m.register(cfg.Name, client, tools)
+	}
+
+	return nil
+}
+
+// startServer はサーバーに接続（stdio ならプロセスを起動）し、Initialize と ListTools を実行する。
+// 失敗した場合は接続を閉じてエラーを返す。
+func startServer(ctx context.Context, cfg ServerConfig) (Client, []ToolSchema, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start server %q: %w", cfg.Name, err)
+	}
+
+	// Initialize ハンドシェイク
+	if err := client.Initialize(ctx); err != nil {
+		_ = client.Close()
+		return nil, nil, fmt.Errorf("failed to initialize server %q: %w", cfg.Name, err)
+	}
+
+	// ツール一覧を取得
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		_ = client.Close()
+		return nil, nil, fmt.Errorf("failed to list tools from server %q: %w", cfg.Name, err)
+	}
+
+	// サーバー名を各ツールに設定
+	for i := range tools {
+		tools[i].Server = cfg.Name
+	}
+	return client, tools, nil
+}
+
+// newClient は設定のトランスポートに応じたクライアントを作成する。
+func newClient(cfg ServerConfig) (Client, error) {
+	switch transport := cfg.TransportType(); transport {
+	case TransportStdio:
+		if cfg.Command == "" {
+			return nil, fmt.Errorf("mcp: stdio server requires 'command'")
+		}
 		// 環境変数を "KEY=VALUE" 形式に変換
 		var env []string
 		if len(cfg.Env) > 0 {
@@ -45,39 +175,316 @@ func (m *MCPManager) StartAll(ctx context.Context) error {
 				env = append(env, k+"="+v)
 			}
 		}
+		return NewStdioClient(cfg.Command, cfg.Args, env)
+	case TransportHTTP, TransportSSE:
+		return NewHTTPClient(cfg.URL, transport, cfg.Headers)
+	default:
+		return nil, fmt.Errorf("mcp: unknown transport %q", cfg.Transport)
+	}
+}
 
-		client, err := NewStdioClient(cfg.Command, cfg.Args, env)
-		if err != nil {
-			log.Printf("[mcp] WARNING: failed to start server %q: %v", cfg.Name, err)
-			continue
+// Reload は設定ファイルを読み直し、追加・変更されたサーバーを（再）起動し、
+// 削除・変更されたサーバーを停止する。設定が変わらないサーバーはそのまま動かし続ける。
+// 戻り値は停止または起動を試みたサーバー名。停止・起動に失敗したサーバーは残りを反映したうえで
+// エラーにまとめて返す（起動に失敗したサーバーのツールは一覧から外れ、Monitor が再起動を試みる）。
+func (m *MCPManager) Reload(ctx context.Context, configPath string) ([]string, error) {
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: failed to load config: %w", err)
+	}
+	var next []ServerConfig
+	if cfg != nil {
+		next = cfg.Servers
+	}
+
+	m.mu.RLock()
+	prev := make(map[string]ServerConfig, len(m.configs))
+	for _, c := range m.configs {
+		prev[c.Name] = c
+	}
+	m.mu.RUnlock()
+
+	var changed []string
+	var errs []error
+	keep := make(map[string]bool, len(next))
+	for _, c := range next {
+		if old, ok := prev[c.Name]; ok && reflect.DeepEqual(old.connection(), c.connection()) {
+			keep[c.Name] = true
 		}
-		m.clients[cfg.Name] = client
+	}
 
-		// Initialize ハンドシェイク
-		if err := client.Initialize(ctx); err != nil {
-			log.Printf("[mcp] WARNING: failed to initialize server %q: %v", cfg.Name, err)
-			_ = client.Close()
-			delete(m.clients, cfg.Name)
+	// 削除・変更されたサーバーを停止
+	m.mu.Lock()
+	for name := range prev {
+		if keep[name] {
 			continue
 		}
+		if client, ok := m.clients[name]; ok {
+			if err := client.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("mcp: failed to close server %q: %w", name, err))
+			}
+			delete(m.clients, name)
+		}
+		delete(m.tools, name)
+		delete(m.states, name)
+		changed = append(changed, name)
+	}
+	m.configs = next
+	m.mu.Unlock()
 
-		// ツール一覧を取得
-		tools, err := client.ListTools(ctx)
+	// 追加・変更されたサーバーを起動（起動中も他サーバーの呼び出しはブロックしない）
+	for _, c := range next {
+		if keep[c.Name] {
+			continue
+		}
+		if _, ok := prev[c.Name]; !ok {
+			changed = append(changed, c.Name)
+		}
+		client, tools, err := startServer(ctx, c)
 		if err != nil {
-			log.Printf("[mcp] WARNING: failed to list tools from server %q: %v", cfg.Name, err)
-			_ = client.Close()
-			delete(m.clients, cfg.Name)
+			errs = append(errs, err)
+			m.markDown(c.Name, nil, err)
 			continue
 		}
+		m.register(c.Name, client, tools)
+	}
+	return changed, errors.Join(errs...)
+}
 
-		// サーバー名を各ツールに設定
-		for i := range tools {
-			tools[i].Server = cfg.Name
+// register は起動済みのクライアントを登録し、状態を running にする。
+// tools/list_changed 通知を受けたらツール一覧を取り直す。
+func (m *MCPManager) register(name string, client Client, tools []ToolSchema) {
+	client.OnNotification(func(method string) {
+		if method == "notifications/tools/list_changed" {
+			go m.refreshTools(name, client)
 		}
-		m.tools[cfg.Name] = tools
+	})
+
+	m.mu.Lock()
+	m.clients[name] = client
+	m.tools[name] = tools
+	st := m.stateLocked(name)
+	st.status = StatusRunning
+	st.lastErr = ""
+	st.failures = 0
+	st.lastCheck = time.Now()
+	m.mu.Unlock()
+}
+
+// markDown はサーバーを停止状態にし、バックオフ後の再起動を予約する。
+// client が現在登録中のものでなければ（既に差し替え済み）何もしない。
+func (m *MCPManager) markDown(name string, client Client, cause error) {
+	m.mu.Lock()
+	if current, ok := m.clients[name]; ok && current != client {
+		m.mu.Unlock()
+		return
 	}
+	delete(m.clients, name)
+	delete(m.tools, name)
+	st := m.stateLocked(name)
+	st.status = StatusDown
+	st.lastErr = cause.Error()
+	st.failures++
+	st.nextRetry = time.Now().Add(restartBackoff(st.failures))
+	m.mu.Unlock()
 
-	return nil
+	if client != nil {
+		_ = client.Close()
+	}
+}
+
+// restartBackoff は連続失敗回数に応じた再起動までの待ち時間を返す。
+func restartBackoff(failures int) time.Duration {
+	d := restartBackoffMin
+	for i := 1; i < failures && d < restartBackoffMax; i++ {
+		d *= 2
+	}
+	return min(d, restartBackoffMax)
+}
+
+// stateLocked はサーバーの状態を返す（なければ作る）。m.mu を保持して呼ぶこと。
+func (m *MCPManager) stateLocked(name string) *serverState {
+	if m.states == nil {
+		m.states = make(map[string]*serverState)
+	}
+	st, ok := m.states[name]
+	if !ok {
+		st = &serverState{}
+		m.states[name] = st
+	}
+	return st
+}
+
+// configFor はサーバー名の設定を返す。
+func (m *MCPManager) configFor(name string) (ServerConfig, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, c := range m.configs {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return ServerConfig{}, false
+}
+
+// notifyToolsChanged は OnToolsChanged のコールバックを呼ぶ。
+func (m *MCPManager) notifyToolsChanged() {
+	m.mu.RLock()
+	fn := m.toolsChanged
+	m.mu.RUnlock()
+	if fn != nil {
+		fn()
+	}
+}
+
+// refreshTools は tools/list_changed 通知を受けたサーバーのツール一覧を取り直す。
+func (m *MCPManager) refreshTools(name string, client Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		m.noticef("failed to refresh tools from server %q: %v", name, err)
+		return
+	}
+	for i := range tools {
+		tools[i].Server = name
+	}
+
+	m.mu.Lock()
+	if m.clients[name] != client {
+		m.mu.Unlock()
+		return // 再起動・リロードで差し替え済み
+	}
+	m.tools[name] = tools
+	m.mu.Unlock()
+	m.notifyToolsChanged()
+}
+
+// Monitor はサーバーのヘルスチェックと停止したサーバーの自動再起動を ctx のキャンセルまで続ける。
+//   - running のサーバーは health_interval ごとに ping し、応答がなければ停止扱いにする
+//     （CallTool の実行中は ping しない）
+//   - down のサーバーはバックオフ（2s から倍々、上限 5m）を挟んで再起動を試みる
+func (m *MCPManager) Monitor(ctx context.Context) {
+	ticker := time.NewTicker(monitorTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkServers(ctx)
+		}
+	}
+}
+
+// checkServers はヘルスチェック・再起動が必要なサーバーについてそれぞれ goroutine を起動する。
+func (m *MCPManager) checkServers(ctx context.Context) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, cfg := range m.configs {
+		st := m.stateLocked(cfg.Name)
+		if st.busy {
+			continue
+		}
+		switch st.status {
+		case StatusRunning:
+			client, ok := m.clients[cfg.Name]
+			if !ok || st.inflight > 0 || now.Sub(st.lastCheck) < cfg.healthInterval() {
+				continue
+			}
+			st.busy = true
+			st.lastCheck = now
+			go m.healthCheck(ctx, cfg.Name, client)
+		case StatusDown:
+			if now.Before(st.nextRetry) {
+				continue
+			}
+			st.busy = true
+			go m.restart(ctx, cfg)
+		}
+	}
+}
+
+// healthCheck はサーバーに ping し、応答がなければ停止扱いにする。
+// ping に対応していないサーバーの method not found も応答があったものとして正常とみなす。
+func (m *MCPManager) healthCheck(ctx context.Context, name string, client Client) {
+	defer m.setBusy(name, false)
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	err := client.Ping(pingCtx)
+	if err == nil || ctx.Err() != nil || isMethodNotFound(err) {
+		return
+	}
+	m.noticef("server %q failed health check: %v", name, err)
+	m.markDown(name, client, err)
+	m.notifyToolsChanged()
+}
+
+// restart は停止中のサーバーを起動し直す。
+func (m *MCPManager) restart(ctx context.Context, cfg ServerConfig) {
+	defer m.setBusy(cfg.Name, false)
+	client, tools, err := startServer(ctx, cfg)
+	if ctx.Err() != nil {
+		if client != nil {
+			_ = client.Close()
+		}
+		return
+	}
+	if current, ok := m.configFor(cfg.Name); !ok || !reflect.DeepEqual(current.connection(), cfg.connection()) {
+		// 再起動中にリロードで削除・変更された
+		if client != nil {
+			_ = client.Close()
+		}
+		return
+	}
+	if err != nil {
+		m.noticef("restart failed: %v", err)
+		m.markDown(cfg.Name, nil, err)
+		return
+	}
+	m.register(cfg.Name, client, tools)
+	m.mu.Lock()
+	m.stateLocked(cfg.Name).restarts++
+	m.mu.Unlock()
+	m.noticef("server %q restarted", cfg.Name)
+	m.notifyToolsChanged()
+}
+
+// setBusy はヘルスチェック・再起動の実行中フラグを設定する。
+func (m *MCPManager) setBusy(name string, busy bool) {
+	m.mu.Lock()
+	if st, ok := m.states[name]; ok {
+		st.busy = busy
+	}
+	m.mu.Unlock()
+}
+
+// Status は設定順に各サーバーの稼働状態を返す。
+func (m *MCPManager) Status() []ServerStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]ServerStatus, 0, len(m.configs))
+	for _, cfg := range m.configs {
+		ss := ServerStatus{
+			Name:      cfg.Name,
+			Transport: cfg.TransportType(),
+			Status:    StatusDown,
+			Tools:     len(m.tools[cfg.Name]),
+		}
+		if _, ok := m.clients[cfg.Name]; ok {
+			ss.Status = StatusRunning
+		}
+		if st, ok := m.states[cfg.Name]; ok {
+			ss.Restarts = st.restarts
+			ss.LastError = st.lastErr
+			if ss.Status == StatusDown {
+				ss.NextRetry = st.nextRetry
+			}
+		}
+		out = append(out, ss)
+	}
+	return out
 }
 
 // startAllWithClients は既に注入済みのクライアントに対して Initialize と ListTools を実行する。
@@ -118,6 +525,8 @@ func (m *MCPManager) startAllWithClients(ctx context.Context) error {
 
 // ListAllTools は全サーバーのツールを集約して返す
 func (m *MCPManager) ListAllTools() []ToolSchema {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	var all []ToolSchema
 	for _, tools := range m.tools {
 		all = append(all, tools...)
@@ -125,18 +534,144 @@ func (m *MCPManager) ListAllTools() []ToolSchema {
 	return all
 }
 
-// CallTool は指定されたサーバーのツールを呼び出す
+// CallTool は指定されたサーバーのツールを呼び出す。
+// サーバー設定の timeout（デフォルト DefaultCallTimeout）を超えるとエラーを返す。
+// 呼び出しが失敗した場合は次の巡回でヘルスチェックを行う。
 func (m *MCPManager) CallTool(ctx context.Context, server, tool string, args map[string]any) (*CallResult, error) {
+	var result *CallResult
+	err := m.do(ctx, server, tool, func(ctx context.Context, c Client) (err error) {
+		result, err = c.CallTool(ctx, tool, args)
+		return err
+	})
+	return result, err
+}
+
+// ReadResource は指定されたサーバーのリソースを読み込む。
+func (m *MCPManager) ReadResource(ctx context.Context, server, uri string) ([]ResourceContent, error) {
+	var contents []ResourceContent
+	err := m.do(ctx, server, uri, func(ctx context.Context, c Client) (err error) {
+		contents, err = c.ReadResource(ctx, uri)
+		return err
+	})
+	return contents, err
+}
+
+// GetPrompt は指定されたサーバーのプロンプトを引数を展開して取得する。
+func (m *MCPManager) GetPrompt(ctx context.Context, server, name string, args map[string]string) (*PromptResult, error) {
+	var prompt *PromptResult
+	err := m.do(ctx, server, name, func(ctx context.Context, c Client) (err error) {
+		prompt, err = c.GetPrompt(ctx, name, args)
+		return err
+	})
+	return prompt, err
+}
+
+// ListResources は稼働中の全サーバーのリソースを集約して返す（server 指定時はそのサーバーのみ）。
+// resources に対応していないサーバーは無視する。
+func (m *MCPManager) ListResources(ctx context.Context, server string) ([]Resource, error) {
+	var all []Resource
+	err := m.each(ctx, server, func(ctx context.Context, name string, c Client) error {
+		list, err := c.ListResources(ctx)
+		for _, r := range list {
+			r.Server = name
+			all = append(all, r)
+		}
+		return err
+	})
+	return all, err
+}
+
+// ListPrompts は稼働中の全サーバーのプロンプトを集約して返す（server 指定時はそのサーバーのみ）。
+// prompts に対応していないサーバーは無視する。
+func (m *MCPManager) ListPrompts(ctx context.Context, server string) ([]Prompt, error) {
+	var all []Prompt
+	err := m.each(ctx, server, func(ctx context.Context, name string, c Client) error {
+		list, err := c.ListPrompts(ctx)
+		for _, p := range list {
+			p.Server = name
+			all = append(all, p)
+		}
+		return err
+	})
+	return all, err
+}
+
+// each は対象サーバーに順に fn を実行する。server 指定時のエラーはそのまま返し、
+// 全サーバー対象の場合は失敗したサーバーを読み飛ばす（機能非対応のサーバーがあるため）。
+func (m *MCPManager) each(ctx context.Context, server string, fn func(ctx context.Context, name string, c Client) error) error {
+	var names []string
+	if server != "" {
+		names = []string{server}
+	} else {
+		m.mu.RLock()
+		for name := range m.clients {
+			names = append(names, name)
+		}
+		m.mu.RUnlock()
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		err := m.do(ctx, name, "", func(ctx context.Context, c Client) error {
+			return fn(ctx, name, c)
+		})
+		if err != nil && server != "" {
+			return err
+		}
+	}
+	return nil
+}
+
+// do はサーバーのクライアントで fn を実行する。
+// サーバー設定の timeout を適用し、実行中の呼び出し数と失敗時のヘルスチェック予約を管理する。
+// label はタイムアウト時のエラーメッセージに使う（ツール名・URI 等）。
+func (m *MCPManager) do(ctx context.Context, server, label string, fn func(ctx context.Context, c Client) error) error {
+	m.mu.Lock()
 	client, ok := m.clients[server]
 	if !ok {
-		return nil, fmt.Errorf("mcp: unknown server %q", server)
+		st, known := m.states[server]
+		m.mu.Unlock()
+		if known && st.status == StatusDown {
+			return fmt.Errorf("mcp: server %q is down (%s); restart is pending", server, st.lastErr)
+		}
+		return fmt.Errorf("mcp: unknown server %q", server)
+	}
+	st := m.stateLocked(server)
+	st.inflight++
+	timeout := DefaultCallTimeout
+	for _, c := range m.configs {
+		if c.Name == server {
+			timeout = c.callTimeout()
+		}
+	}
+	m.mu.Unlock()
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := fn(callCtx, client)
+
+	m.mu.Lock()
+	st.inflight--
+	if err != nil && ctx.Err() == nil && !isMethodNotFound(err) {
+		st.lastCheck = time.Time{} // 次の巡回で ping する
+	}
+	m.mu.Unlock()
+
+	if err != nil && errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return fmt.Errorf("mcp: %s/%s timed out after %s", server, label, timeout)
 	}
-	return client.CallTool(ctx, tool, args)
+	return err
+}
+
+// isMethodNotFound はサーバーがメソッドに対応していないことを示す JSON-RPC エラーかを返す。
+func isMethodNotFound(err error) bool {
+	return strings.Contains(err.Error(), fmt.Sprintf("JSON-RPC error %d", rpcMethodNotFound))
 }
 
 // IsProposalRequired は指定サーバーがユーザー承認を要求するかどうかを返す。
 // デフォルトは false。
 func (m *MCPManager) IsProposalRequired(server string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	for _, cfg := range m.configs {
 		if cfg.Name == server {
 			if cfg.ProposalRequired != nil {
@@ -150,6 +685,8 @@ func (m *MCPManager) IsProposalRequired(server string) bool {
 
 // Close は全 MCP サーバーのプロセスを終了させる
 func (m *MCPManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	var lastErr error
 	for name, client := range m.clients {
 		if err := client.Close(); err != nil {
@@ -3,8 +3,13 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -21,7 +26,7 @@ func newTestManager(t *testing.T, configs []ServerConfig) (*MCPManager, []*mockS
 	t.Helper()
 
 	pairs := make([]*mockServerPair, len(configs))
-	clients := make(map[string]*MCPClient, len(configs))
+	clients := make(map[string]Client, len(configs))
 
 	for i, cfg := range configs {
 		mock, client := newMockMCPServer(t)
@@ -402,7 +407,7 @@ func TestManager_StartAllWithClients_MissingClient(t *testing.T) {
 		{Name: "missing-server", Command: "echo"},
 	}
 	mgr := &MCPManager{
-		clients: make(map[string]*MCPClient), // 空: クライアントなし
+		clients: make(map[string]Client), // 空: クライアントなし
 		configs: configs,
 		tools:   make(map[string][]ToolSchema),
 	}
@@ -425,7 +430,7 @@ func TestManager_StartAllWithClients_MissingClient(t *testing.T) {
 func TestManager_Close_NoClients(t *testing.T) {
 	// クライアントがない場合の Close
 	mgr := &MCPManager{
-		clients: make(map[string]*MCPClient),
+		clients: make(map[string]Client),
 		configs: nil,
 		tools:   make(map[string][]ToolSchema),
 	}
@@ -525,3 +530,304 @@ func TestManager_StartAll_WithMock(t *testing.T) {
 		t.Errorf("expected server 'mock-server', got '%s'", tools[0].Server)
 	}
 }
+
+func TestManager_Reload(t *testing.T) {
+	// server-a は設定そのまま → 維持、server-b は削除 → 停止、server-c は追加（起動失敗）
+	configs := []ServerConfig{
+		{Name: "server-a", Command: "echo"},
+		{Name: "server-b", Command: "echo"},
+	}
+	mgr, pairs := newTestManager(t, configs)
+	defer func() {
+		for _, p := range pairs {
+			p.mock.close()
+		}
+	}()
+	mgr.tools["server-a"] = []ToolSchema{{Server: "server-a", Name: "tool_a"}}
+	mgr.tools["server-b"] = []ToolSchema{{Server: "server-b", Name: "tool_b"}}
+	clientA := mgr.clients["server-a"]
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mcp.yaml")
+	content := `servers:
+  - name: server-a
+    command: echo
+  - name: server-c
+    command: /nonexistent/mcp-server-binary
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	changed, err := mgr.Reload(ctx, path)
+	if err == nil || !strings.Contains(err.Error(), "server-c") {
+		t.Errorf("Reload should report the server that failed to start, got %v", err)
+	}
+	if len(changed) != 2 || changed[0] != "server-b" || changed[1] != "server-c" {
+		t.Errorf("changed: got %v, want [server-b server-c]", changed)
+	}
+
+	if mgr.clients["server-a"] != clientA {
+		t.Error("unchanged server-a should keep its client")
+	}
+	if _, ok := mgr.clients["server-b"]; ok {
+		t.Error("removed server-b should be stopped")
+	}
+	if _, ok := mgr.clients["server-c"]; ok {
+		t.Error("server-c failed to start and should not be registered")
+	}
+	tools := mgr.ListAllTools()
+	if len(tools) != 1 || tools[0].Name != "tool_a" {
+		t.Errorf("tools after reload: %+v", tools)
+	}
+	if len(mgr.configs) != 2 {
+		t.Errorf("configs should be replaced, got %+v", mgr.configs)
+	}
+}
+
+func TestManager_Reload_InvalidConfigKeepsServers(t *testing.T) {
+	mgr, pairs := newTestManager(t, []ServerConfig{{Name: "server-a", Command: "echo"}})
+	defer pairs[0].mock.close()
+	mgr.tools["server-a"] = []ToolSchema{{Server: "server-a", Name: "tool_a"}}
+
+	path := filepath.Join(t.TempDir(), "mcp.yaml")
+	if err := os.WriteFile(path, []byte("servers: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mgr.Reload(context.Background(), path); err == nil {
+		t.Fatal("expected error for invalid YAML")
+	}
+	if len(mgr.ListAllTools()) != 1 {
+		t.Error("servers should be kept when the new config is invalid")
+	}
+}
+
+// fastMonitor はテスト用にヘルスチェック・再起動の間隔を短くする。
+func fastMonitor(t *testing.T) {
+	t.Helper()
+	tick, backoff := monitorTick, restartBackoffMin
+	monitorTick, restartBackoffMin = 10*time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() { monitorTick, restartBackoffMin = tick, backoff })
+}
+
+// waitFor は cond が true になるまで待つ。
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManager_Monitor_RestartsAndDetectsFailure(t *testing.T) {
+	fastMonitor(t)
+
+	// healthy が false の間は 503 を返すリモートサーバー
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var req jsonRPCRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		resp := handleRPC(req)
+		if resp == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	mgr := &MCPManager{
+		clients: make(map[string]Client),
+		configs: []ServerConfig{{Name: "remote", URL: srv.URL, HealthInterval: 20 * time.Millisecond}},
+		tools:   make(map[string][]ToolSchema),
+	}
+	defer mgr.Close()
+	var changes atomic.Int32
+	mgr.OnToolsChanged(func() { changes.Add(1) })
+	// Monitor は TUI の実行中に動くため、結果は log ではなく OnNotice に送る
+	var noticeMu sync.Mutex
+	var notices []string
+	mgr.OnNotice(func(msg string) {
+		noticeMu.Lock()
+		notices = append(notices, msg)
+		noticeMu.Unlock()
+	})
+
+	// 起動時に失敗したサーバーは down として記録される
+	if err := mgr.StartAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if st := mgr.Status()[0]; st.Status != StatusDown || !strings.Contains(st.LastError, "503") {
+		t.Fatalf("status after failed start: %+v", st)
+	}
+	if _, err := mgr.CallTool(context.Background(), "remote", "scan", nil); err == nil || !strings.Contains(err.Error(), "is down") {
+		t.Errorf("CallTool on down server: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mgr.Monitor(ctx)
+
+	// 復旧すると再起動され、ツールが戻る
+	healthy.Store(true)
+	waitFor(t, "restart", func() bool { return mgr.Status()[0].Status == StatusRunning })
+	st := mgr.Status()[0]
+	if st.Restarts != 1 || st.Tools != 1 {
+		t.Errorf("status after restart: %+v", st)
+	}
+	if changes.Load() == 0 {
+		t.Error("OnToolsChanged should be called after restart")
+	}
+
+	// ヘルスチェックに失敗すると down になり、ツールが一覧から外れる
+	healthy.Store(false)
+	waitFor(t, "health check failure", func() bool { return mgr.Status()[0].Status == StatusDown })
+	if len(mgr.ListAllTools()) != 0 {
+		t.Error("tools of a down server should be removed")
+	}
+	noticeMu.Lock()
+	defer noticeMu.Unlock()
+	got := strings.Join(notices, "\n")
+	for _, want := range []string{`MCP: server "remote" restarted`, `MCP: server "remote" failed health check`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected notice %q, got %q", want, got)
+		}
+	}
+}
+
+func TestManager_Monitor_PingNotSupported(t *testing.T) {
+	fastMonitor(t)
+
+	// ping を実装していないサーバー（method not found を返す）
+	var pings atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonRPCRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Method == "ping" {
+			pings.Add(1)
+			req.Method = "unsupported/ping"
+		}
+		resp := handleRPC(req)
+		if resp == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	mgr := &MCPManager{
+		clients: make(map[string]Client),
+		configs: []ServerConfig{{Name: "remote", URL: srv.URL, HealthInterval: 20 * time.Millisecond}},
+		tools:   make(map[string][]ToolSchema),
+	}
+	defer mgr.Close()
+	var notices atomic.Int32
+	mgr.OnNotice(func(string) { notices.Add(1) })
+	if err := mgr.StartAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mgr.Monitor(ctx)
+
+	// 何度ヘルスチェックしても停止扱いにならず、再起動もしない
+	waitFor(t, "health checks", func() bool { return pings.Load() >= 3 })
+	if st := mgr.Status()[0]; st.Status != StatusRunning || st.Restarts != 0 {
+		t.Errorf("server without ping should stay running: %+v", st)
+	}
+	if notices.Load() != 0 {
+		t.Errorf("unexpected notices: %d", notices.Load())
+	}
+}
+
+func TestManager_CallTool_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewHTTPClient(srv.URL, TransportHTTP, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := &MCPManager{
+		clients: map[string]Client{"slow": client},
+		configs: []ServerConfig{{Name: "slow", URL: srv.URL, Timeout: 50 * time.Millisecond}},
+		tools:   make(map[string][]ToolSchema),
+	}
+	defer mgr.Close()
+
+	start := time.Now()
+	_, err = mgr.CallTool(context.Background(), "slow", "scan", nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("CallTool should return at the configured timeout")
+	}
+}
+
+func TestManager_ToolsListChanged(t *testing.T) {
+	mgr, pairs := newTestManager(t, []ServerConfig{{Name: "srv", Command: "echo"}})
+	defer pairs[0].mock.close()
+	mock, client := pairs[0].mock, pairs[0].client
+	mgr.register("srv", client, []ToolSchema{{Server: "srv", Name: "old_tool"}})
+	changed := make(chan struct{}, 1)
+	mgr.OnToolsChanged(func() { changed <- struct{}{} })
+
+	// 読み取りを開始させるため ping を1回通す
+	go func() { _ = client.Ping(context.Background()) }()
+	req := mock.readRequest(t)
+	mock.writeResponse(t, req.ID, map[string]any{})
+
+	// list_changed 通知 → tools/list の再取得
+	if _, err := mock.clientStdoutWriter.Write([]byte(`{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	req = mock.readRequest(t)
+	if req.Method != "tools/list" {
+		t.Fatalf("expected tools/list after list_changed, got %q", req.Method)
+	}
+	mock.writeResponse(t, req.ID, map[string]any{"tools": []map[string]any{{"name": "new_tool"}}})
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnToolsChanged not called")
+	}
+	tools := mgr.ListAllTools()
+	if len(tools) != 1 || tools[0].Name != "new_tool" || tools[0].Server != "srv" {
+		t.Errorf("tools after refresh: %+v", tools)
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{4, 16 * time.Second},
+		{20, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := restartBackoff(tt.failures); got != tt.want {
+			t.Errorf("restartBackoff(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
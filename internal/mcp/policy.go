@@ -0,0 +1,198 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// Decision は MCP ツール呼び出しに対する承認ゲートの判定。
+type Decision int
+
+const (
+	DecisionAllow   Decision = iota // 承認なしで実行
+	DecisionApprove                 // ユーザー承認が必要
+	DecisionDeny                    // 実行しない
+)
+
+// ルールのアクション
+const (
+	RuleAllow   = "allow"
+	RuleApprove = "approve"
+	RuleDeny    = "deny"
+)
+
+// ToolPolicy はツール単位の承認設定（サーバーの proposal_required を上書きする）。
+type ToolPolicy struct {
+	ProposalRequired *bool `yaml:"proposal_required,omitempty"`
+}
+
+// ArgRule は引数の値に基づく承認ルール。上から順に評価し、最初に一致したルールを適用する。
+//
+// match / not_match の両方を省略した場合はツール名だけで一致とみなす。
+type ArgRule struct {
+	// Tool は対象ツール名のグロブ（例: "browser_*"）。省略時は全ツール
+	Tool string `yaml:"tool,omitempty"`
+	// Arg は対象の引数名。省略時は引数全体の JSON を対象にする
+	Arg string `yaml:"arg,omitempty"`
+	// Match は値がこの正規表現に一致したら適用する
+	Match string `yaml:"match,omitempty"`
+	// NotMatch は値がこの正規表現に一致しなければ適用する（スコープ外 URL の拒否等）
+	NotMatch string `yaml:"not_match,omitempty"`
+	// Action は allow | approve | deny
+	Action string `yaml:"action"`
+	// Reason は拒否・承認時に表示する理由
+	Reason string `yaml:"reason,omitempty"`
+
+	// match / notMatch は validate でコンパイルした Match / NotMatch（呼び出しのたびにコンパイルしない）
+	match, notMatch *regexp.Regexp
+}
+
+// validate はルールの書式を検証し、正規表現をコンパイルして保持する。
+func (r *ArgRule) validate() error {
+	switch r.Action {
+	case RuleAllow, RuleApprove, RuleDeny:
+	default:
+		return fmt.Errorf("invalid action %q (want allow, approve or deny)", r.Action)
+	}
+	if _, err := path.Match(r.Tool, ""); err != nil {
+		return fmt.Errorf("invalid tool pattern %q: %w", r.Tool, err)
+	}
+	var err error
+	if r.match, err = compilePattern(r.Match); err != nil {
+		return err
+	}
+	if r.notMatch, err = compilePattern(r.NotMatch); err != nil {
+		return err
+	}
+	return nil
+}
+
+// compilePattern は空でなければ正規表現をコンパイルする。
+func compilePattern(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", expr, err)
+	}
+	return re, nil
+}
+
+// applies はルールが呼び出しに一致するかを返す。validate 済みであること。
+func (r ArgRule) applies(tool string, args map[string]any) bool {
+	if r.Tool != "" {
+		if ok, _ := path.Match(r.Tool, tool); !ok {
+			return false
+		}
+	}
+	if r.match == nil && r.notMatch == nil {
+		return true
+	}
+	value, ok := argValue(args, r.Arg)
+	if !ok {
+		return false
+	}
+	if r.match != nil && !r.match.MatchString(value) {
+		return false
+	}
+	if r.notMatch != nil && r.notMatch.MatchString(value) {
+		return false
+	}
+	return true
+}
+
+// argValue は照合に使う引数の値を文字列で返す。文字列以外は JSON にする。
+func argValue(args map[string]any, name string) (string, bool) {
+	var v any = args
+	if name != "" {
+		var ok bool
+		if v, ok = args[name]; !ok {
+			return "", false
+		}
+	}
+	if s, ok := v.(string); ok {
+		return s, true
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// validatePolicy はサーバー設定の承認ルールを検証し、正規表現をコンパイルする。
+// c.Rules の要素をその場で更新するため、LoadConfig で読み込んだ設定に対して呼ぶ。
+func (c ServerConfig) validatePolicy() error {
+	for i := range c.Rules {
+		if err := c.Rules[i].validate(); err != nil {
+			return fmt.Errorf("server %q rule %d: %w", c.Name, i+1, err)
+		}
+	}
+	return nil
+}
+
+// decide は呼び出しの承認判定と理由を返す。
+// 引数ルール → ツール単位の設定 → サーバーの proposal_required の順に評価する。
+func (c ServerConfig) decide(tool string, args map[string]any) (Decision, string) {
+	for _, r := range c.Rules {
+		if !r.applies(tool, args) {
+			continue
+		}
+		reason := r.Reason
+		if reason == "" {
+			reason = fmt.Sprintf("matched %s rule for %s.%s", r.Action, c.Name, tool)
+		}
+		switch r.Action {
+		case RuleDeny:
+			return DecisionDeny, reason
+		case RuleApprove:
+			return DecisionApprove, reason
+		default:
+			return DecisionAllow, reason
+		}
+	}
+	if p, ok := c.Tools[tool]; ok && p.ProposalRequired != nil {
+		if *p.ProposalRequired {
+			return DecisionApprove, fmt.Sprintf("%s.%s requires approval", c.Name, tool)
+		}
+		return DecisionAllow, ""
+	}
+	if c.ProposalRequired != nil && *c.ProposalRequired {
+		return DecisionApprove, fmt.Sprintf("%s requires approval", c.Name)
+	}
+	return DecisionAllow, ""
+}
+
+// connection は承認設定を除いた接続設定を返す。
+// 承認ルールだけの変更ではサーバーを再起動しないよう、リロード時の比較に使う。
+func (c ServerConfig) connection() ServerConfig {
+	c.ProposalRequired = nil
+	c.Tools = nil
+	c.Rules = nil
+	return c
+}
+
+// CheckCall はツール呼び出しの承認判定と理由を返す。
+// 未知のサーバーは DecisionAllow（呼び出し自体が失敗する）。
+func (m *MCPManager) CheckCall(server, tool string, args map[string]any) (Decision, string) {
+	cfg, ok := m.configFor(server)
+	if !ok {
+		return DecisionAllow, ""
+	}
+	return cfg.decide(tool, args)
+}
+
+// FormatArgs は承認表示用に引数を整形済み JSON にする。
+func FormatArgs(args map[string]any) string {
+	if len(args) == 0 {
+		return "{}"
+	}
+	data, err := json.MarshalIndent(args, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", args)
+	}
+	return string(data)
+}
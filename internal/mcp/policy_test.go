@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestServerConfig_Decide(t *testing.T) {
+	trueVal, falseVal := true, false
+	cfg := ServerConfig{
+		Name:             "playwright",
+		ProposalRequired: &falseVal,
+		Tools: map[string]ToolPolicy{
+			"browser_click":    {ProposalRequired: &trueVal},
+			"browser_snapshot": {ProposalRequired: &falseVal},
+		},
+		Rules: []ArgRule{
+			{Tool: "browser_navigate", Arg: "url", NotMatch: `^https?://10\.0\.0\.`, Action: RuleDeny, Reason: "out of scope"},
+			{Tool: "browser_*", Arg: "", Match: `password`, Action: RuleApprove},
+		},
+	}
+	if err := cfg.validatePolicy(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]any
+		want Decision
+	}{
+		{"in scope", "browser_navigate", map[string]any{"url": "http://10.0.0.5/"}, DecisionAllow},
+		{"out of scope", "browser_navigate", map[string]any{"url": "https://example.com/"}, DecisionDeny},
+		{"missing arg does not match", "browser_navigate", map[string]any{}, DecisionAllow},
+		{"whole-args rule", "browser_type", map[string]any{"field": "password", "text": "x"}, DecisionApprove},
+		{"tool override", "browser_click", map[string]any{"ref": "e1"}, DecisionApprove},
+		{"tool override allow", "browser_snapshot", nil, DecisionAllow},
+		{"server default", "browser_close", nil, DecisionAllow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := cfg.decide(tt.tool, tt.args)
+			if got != tt.want {
+				t.Errorf("decide(%s, %v) = %v (%s), want %v", tt.tool, tt.args, got, reason, tt.want)
+			}
+		})
+	}
+
+	if _, reason := cfg.decide("browser_navigate", map[string]any{"url": "http://evil/"}); reason != "out of scope" {
+		t.Errorf("reason: got %q", reason)
+	}
+
+	// サーバー単位で承認必須なら、ツール・ルールに一致しない呼び出しは承認が必要
+	cfg.ProposalRequired = &trueVal
+	if got, _ := cfg.decide("browser_close", nil); got != DecisionApprove {
+		t.Errorf("server-level proposal_required should apply, got %v", got)
+	}
+}
+
+func TestLoadConfig_PolicyValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`servers:
+  - name: playwright
+    command: npx
+    tools:
+      browser_click:
+        proposal_required: true
+    rules:
+      - tool: browser_navigate
+        arg: url
+        not_match: '^http://10\.'
+        action: deny
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	s := cfg.Servers[0]
+	if p := s.Tools["browser_click"].ProposalRequired; p == nil || !*p {
+		t.Errorf("tools override not parsed: %+v", s.Tools)
+	}
+	if len(s.Rules) != 1 || s.Rules[0].NotMatch != `^http://10\.` {
+		t.Errorf("rules not parsed: %+v", s.Rules)
+	}
+	// 正規表現は読み込み時にコンパイル済み
+	if s.Rules[0].notMatch == nil {
+		t.Error("not_match should be compiled by LoadConfig")
+	}
+	if got, _ := s.decide("browser_navigate", map[string]any{"url": "https://example.com/"}); got != DecisionDeny {
+		t.Errorf("loaded rule should deny out-of-scope urls, got %v", got)
+	}
+
+	for _, bad := range []string{
+		"action: block",
+		"action: deny\n        match: '('",
+		"action: deny\n        tool: '['",
+	} {
+		write("servers:\n  - name: s\n    command: x\n    rules:\n      - " + bad + "\n")
+		if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "rule 1") {
+			t.Errorf("expected rule error for %q, got %v", bad, err)
+		}
+	}
+}
+
+func TestServerConfig_ConnectionIgnoresPolicy(t *testing.T) {
+	trueVal := true
+	a := ServerConfig{Name: "s", Command: "x"}
+	b := a
+	b.ProposalRequired = &trueVal
+	b.Rules = []ArgRule{{Action: RuleDeny}}
+	b.Tools = map[string]ToolPolicy{"t": {ProposalRequired: &trueVal}}
+	// 承認設定だけの変更ではリロード時にサーバーを再起動しない
+	if !reflect.DeepEqual(a.connection(), b.connection()) {
+		t.Error("connection() should ignore approval settings")
+	}
+	b.Command = "y"
+	if reflect.DeepEqual(a.connection(), b.connection()) {
+		t.Error("connection() should keep the command")
+	}
+}
+
+func TestFormatArgs(t *testing.T) {
+	if got := FormatArgs(nil); got != "{}" {
+		t.Errorf("FormatArgs(nil) = %q", got)
+	}
+	got := FormatArgs(map[string]any{"url": "http://10.0.0.5/"})
+	if !strings.Contains(got, `"url": "http://10.0.0.5/"`) {
+		t.Errorf("FormatArgs = %q", got)
+	}
+}
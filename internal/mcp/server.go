@@ -0,0 +1,199 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ToolHandler は MCP サーバーとして公開するツールの処理。
+// 返したエラーは isError: true のツール結果としてクライアントに返す。
+type ToolHandler func(ctx context.Context, args map[string]any) (string, error)
+
+// Server は JSON-RPC 2.0 over stdio の MCP サーバー。
+// 登録したツールとリソースを他のエージェント・IDE に公開する。
+// tools/call は並行に処理するため、ハンドラーは goroutine セーフであること。
+type Server struct {
+	name    string
+	version string
+
+	tools    map[string]ToolSchema
+	handlers map[string]ToolHandler
+
+	listResources func() ([]Resource, error)       // nil = リソースなし
+	readResource  func(uri string) (string, error) // resources/read
+
+	wmu sync.Mutex // 出力の排他制御
+}
+
+// NewServer はサーバー名・バージョンを指定して Server を作成する。
+func NewServer(name, version string) *Server {
+	return &Server{
+		name:     name,
+		version:  version,
+		tools:    make(map[string]ToolSchema),
+		handlers: make(map[string]ToolHandler),
+	}
+}
+
+// AddTool はツールを登録する。
+func (s *Server) AddTool(tool ToolSchema, h ToolHandler) {
+	s.tools[tool.Name] = tool
+	s.handlers[tool.Name] = h
+}
+
+// SetResources はリソースの一覧取得・読み込み処理を設定する。
+func (s *Server) SetResources(list func() ([]Resource, error), read func(uri string) (string, error)) {
+	s.listResources = list
+	s.readResource = read
+}
+
+// serverRequest はクライアントからのリクエスト。ID は文字列・数値のどちらも受け付ける。
+type serverRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type serverResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+// JSON-RPC エラーコード
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+// Serve は r から改行区切りの JSON-RPC メッセージを読み、w にレスポンスを書く。
+// r が EOF になるか ctx がキャンセルされると、処理中のリクエストの完了を待って返る。
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req serverRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.write(w, serverResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+				Error: &jsonRPCError{Code: rpcParseError, Message: "parse error"}})
+			continue
+		}
+		if len(req.ID) == 0 {
+			continue // 通知（notifications/initialized 等）には応答しない
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, rpcErr := s.handle(ctx, req)
+			resp := serverResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}
+			if rpcErr == nil && result == nil {
+				resp.Result = struct{}{}
+			}
+			s.write(w, resp)
+		}()
+	}
+	return scanner.Err()
+}
+
+// handle は1つのリクエストを処理する。
+func (s *Server) handle(ctx context.Context, req serverRequest) (any, *jsonRPCError) {
+	switch req.Method {
+	case "initialize":
+		caps := map[string]any{"tools": map[string]any{}}
+		if s.listResources != nil {
+			caps["resources"] = map[string]any{}
+		}
+		return map[string]any{
+			"protocolVersion": "2024-11-05",
+			"capabilities":    caps,
+			"serverInfo":      map[string]any{"name": s.name, "version": s.version},
+		}, nil
+
+	case "ping":
+		return nil, nil
+
+	case "tools/list":
+		names := make([]string, 0, len(s.tools))
+		for name := range s.tools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		list := make([]ToolSchema, 0, len(names))
+		for _, name := range names {
+			list = append(list, s.tools[name])
+		}
+		return map[string]any{"tools": list}, nil
+
+	case "tools/call":
+		var p struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &jsonRPCError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		h, ok := s.handlers[p.Name]
+		if !ok {
+			return nil, &jsonRPCError{Code: rpcInvalidParams, Message: fmt.Sprintf("unknown tool %q", p.Name)}
+		}
+		text, err := h(ctx, p.Arguments)
+		if err != nil {
+			return CallResult{Content: []ContentBlock{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		return CallResult{Content: []ContentBlock{{Type: "text", Text: text}}}, nil
+
+	case "resources/list":
+		if s.listResources == nil {
+			return map[string]any{"resources": []Resource{}}, nil
+		}
+		list, err := s.listResources()
+		if err != nil {
+			return nil, &jsonRPCError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		return map[string]any{"resources": list}, nil
+
+	case "resources/read":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil || s.readResource == nil {
+			return nil, &jsonRPCError{Code: rpcInvalidParams, Message: "invalid resource request"}
+		}
+		text, err := s.readResource(p.URI)
+		if err != nil {
+			return nil, &jsonRPCError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		return map[string]any{"contents": []ResourceContent{{URI: p.URI, MimeType: "text/plain", Text: text}}}, nil
+	}
+	return nil, &jsonRPCError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+}
+
+// write は1つのメッセージを改行区切りで書き込む。
+func (s *Server) write(w io.Writer, resp serverResponse) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	_, _ = w.Write(data)
+}
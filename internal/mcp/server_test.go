@@ -0,0 +1,151 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer は echo / fail ツールとリソース1件を持つサーバーを作る。
+func newTestServer() *Server {
+	srv := NewServer("test", "0.0.1")
+	srv.AddTool(ToolSchema{Name: "echo", Description: "Echo", InputSchema: map[string]any{"type": "object"}},
+		func(_ context.Context, args map[string]any) (string, error) {
+			return fmt.Sprintf("echo %v", args["text"]), nil
+		})
+	srv.AddTool(ToolSchema{Name: "fail", InputSchema: map[string]any{"type": "object"}},
+		func(_ context.Context, _ map[string]any) (string, error) {
+			return "", errors.New("blacklist: command blocked")
+		})
+	srv.SetResources(
+		func() ([]Resource, error) {
+			return []Resource{{URI: "test://notes", Name: "notes"}}, nil
+		},
+		func(uri string) (string, error) {
+			if uri != "test://notes" {
+				return "", fmt.Errorf("unknown resource %q", uri)
+			}
+			return "hello", nil
+		})
+	return srv
+}
+
+// serveOverPipes はサーバーを起動し、接続したクライアントを返す。
+func serveOverPipes(t *testing.T, srv *Server) *MCPClient {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = srv.Serve(context.Background(), serverR, serverW)
+		_ = serverW.Close()
+	}()
+	t.Cleanup(func() {
+		_ = clientW.Close()
+		<-done
+	})
+	return newClientFromPipes(clientW, clientR)
+}
+
+func TestServer_ToolsRoundTrip(t *testing.T) {
+	c := serveOverPipes(t, newTestServer())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "echo" || tools[1].Name != "fail" {
+		t.Fatalf("tools should be listed in name order: %+v", tools)
+	}
+
+	res, err := c.CallTool(ctx, "echo", map[string]any{"text": "hi"})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError || res.Content[0].Text != "echo hi" {
+		t.Errorf("echo result: %+v", res)
+	}
+
+	res, err = c.CallTool(ctx, "fail", nil)
+	if err != nil {
+		t.Fatalf("CallTool(fail): %v", err)
+	}
+	if !res.IsError || !strings.Contains(res.Content[0].Text, "blacklist") {
+		t.Errorf("handler error should be returned as an error result: %+v", res)
+	}
+
+	if _, err := c.CallTool(ctx, "missing", nil); err == nil {
+		t.Error("expected error for unknown tool")
+	}
+}
+
+func TestServer_Resources(t *testing.T) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go func() {
+		_ = newTestServer().Serve(context.Background(), serverR, serverW)
+		_ = serverW.Close()
+	}()
+	defer clientW.Close()
+
+	reader := bufio.NewReader(clientR)
+	call := func(line string) map[string]any {
+		t.Helper()
+		if _, err := io.WriteString(clientW, line+"\n"); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m map[string]any
+		if err := json.Unmarshal(resp, &m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	// 通知には応答せず、文字列 ID もそのまま返す
+	if _, err := io.WriteString(clientW, `{"jsonrpc":"2.0","method":"notifications/initialized"}`+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	resp := call(`{"jsonrpc":"2.0","id":"a","method":"resources/list"}`)
+	if resp["id"] != "a" {
+		t.Errorf("id should be echoed: %v", resp)
+	}
+	list := resp["result"].(map[string]any)["resources"].([]any)
+	if len(list) != 1 || list[0].(map[string]any)["uri"] != "test://notes" {
+		t.Errorf("resources/list: %v", resp)
+	}
+
+	resp = call(`{"jsonrpc":"2.0","id":2,"method":"resources/read","params":{"uri":"test://notes"}}`)
+	contents := resp["result"].(map[string]any)["contents"].([]any)
+	if contents[0].(map[string]any)["text"] != "hello" {
+		t.Errorf("resources/read: %v", resp)
+	}
+
+	resp = call(`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"test://other"}}`)
+	if resp["error"] == nil {
+		t.Errorf("expected error for unknown resource: %v", resp)
+	}
+
+	resp = call(`{"jsonrpc":"2.0","id":4,"method":"prompts/list"}`)
+	if e, _ := resp["error"].(map[string]any); e == nil || e["code"] != float64(rpcMethodNotFound) {
+		t.Errorf("expected method not found: %v", resp)
+	}
+}
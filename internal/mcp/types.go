@@ -1,8 +1,14 @@
-// Package mcp は MCP (Model Context Protocol) クライアントを提供する。
-// JSON-RPC 2.0 over stdio で MCP サーバーと通信し、
-// ツールの列挙・呼び出しを行う。
+// Package mcp は MCP (Model Context Protocol) クライアントとサーバーを提供する。
+// JSON-RPC 2.0 over stdio または HTTP（Streamable HTTP / HTTP+SSE）で
+// MCP サーバーと通信し、ツールの列挙・呼び出しを行う。
+// Server は Pentecter 自身のツール・リソースを stdio で公開する（pentecter mcp-serve）。
 package mcp
 
+import (
+	"strings"
+	"time"
+)
+
 // ToolSchema は MCP サーバーの tools/list レスポンスにおけるツール定義
 type ToolSchema struct {
 	// Server はこのツールが所属する MCP サーバー名
@@ -31,18 +37,141 @@ type ContentBlock struct {
 	Text string `json:"text,omitempty"`
 }
 
+// Resource は MCP resources/list レスポンスにおけるリソース定義
+type Resource struct {
+	// Server はこのリソースを公開する MCP サーバー名
+	Server string `json:"-"`
+	// URI はリソースの一意な識別子
+	URI string `json:"uri"`
+	// Name は表示名
+	Name string `json:"name"`
+	// Description はリソースの説明
+	Description string `json:"description,omitempty"`
+	// MimeType はコンテンツの MIME タイプ
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// ResourceContent は MCP resources/read レスポンスのコンテンツ
+type ResourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	// Blob はバイナリコンテンツ（base64）。Text とどちらか一方
+	Blob string `json:"blob,omitempty"`
+}
+
+// Prompt は MCP prompts/list レスポンスにおけるプロンプトテンプレート定義
+type Prompt struct {
+	// Server はこのプロンプトを公開する MCP サーバー名
+	Server string `json:"-"`
+	// Name はプロンプトの一意な名前
+	Name string `json:"name"`
+	// Description はプロンプトの説明
+	Description string `json:"description,omitempty"`
+	// Arguments はプロンプトが受け取る引数
+	Arguments []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument はプロンプトの引数定義
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage は prompts/get で返されるメッセージ
+type PromptMessage struct {
+	// Role は "user" または "assistant"
+	Role    string       `json:"role"`
+	Content ContentBlock `json:"content"`
+}
+
+// PromptResult は MCP prompts/get の結果
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// Text はプロンプトのテキストメッセージを連結して返す。
+func (r *PromptResult) Text() string {
+	var parts []string
+	for _, m := range r.Messages {
+		if m.Content.Text != "" {
+			parts = append(parts, m.Content.Text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// トランスポート種別
+const (
+	TransportStdio = "stdio" // サブプロセスの stdin/stdout
+	TransportHTTP  = "http"  // Streamable HTTP
+	TransportSSE   = "sse"   // HTTP+SSE（旧仕様）
+)
+
 // ServerConfig は YAML 設定ファイルにおける MCP サーバー定義
 type ServerConfig struct {
 	// Name はサーバーの識別名
 	Name string `yaml:"name"`
-	// Command は起動するコマンド
+	// Transport は通信方式（stdio | http | sse）。省略時は url があれば http、なければ stdio
+	Transport string `yaml:"transport,omitempty"`
+	// Command は起動するコマンド（stdio）
 	Command string `yaml:"command"`
-	// Args はコマンドライン引数
+	// Args はコマンドライン引数（stdio）
 	Args []string `yaml:"args"`
 	// Env はサーバーに渡す環境変数（${VAR} はホスト環境から展開される）
 	Env map[string]string `yaml:"env,omitempty"`
+	// URL はリモートサーバーのエンドポイント（http / sse）
+	URL string `yaml:"url,omitempty"`
+	// Headers は HTTP リクエストに付与するヘッダー（認証トークン等。${VAR} 展開対応）
+	Headers map[string]string `yaml:"headers,omitempty"`
 	// ProposalRequired が true の場合、Brain はツール呼び出し前にユーザー承認を求める
 	ProposalRequired *bool `yaml:"proposal_required,omitempty"`
+	// Tools はツール単位の承認設定（ProposalRequired を上書き）
+	Tools map[string]ToolPolicy `yaml:"tools,omitempty"`
+	// Rules は引数の値に基づく承認ルール（Tools より優先）
+	Rules []ArgRule `yaml:"rules,omitempty"`
+	// Timeout は1回のツール呼び出しのタイムアウト（省略時 DefaultCallTimeout）
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// HealthInterval はヘルスチェック（ping）の間隔（省略時 DefaultHealthInterval）
+	HealthInterval time.Duration `yaml:"health_interval,omitempty"`
+}
+
+// デフォルト値
+const (
+	DefaultCallTimeout    = 2 * time.Minute
+	DefaultHealthInterval = 30 * time.Second
+)
+
+// callTimeout はツール呼び出しのタイムアウトを返す。
+func (c ServerConfig) callTimeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return DefaultCallTimeout
+}
+
+// healthInterval はヘルスチェックの間隔を返す。
+func (c ServerConfig) healthInterval() time.Duration {
+	if c.HealthInterval > 0 {
+		return c.HealthInterval
+	}
+	return DefaultHealthInterval
+}
+
+// TransportType は実際に使うトランスポート種別を返す。
+func (c ServerConfig) TransportType() string {
+	switch {
+	case c.Transport == "streamable-http":
+		return TransportHTTP
+	case c.Transport != "":
+		return c.Transport
+	case c.URL != "":
+		return TransportHTTP
+	default:
+		return TransportStdio
+	}
 }
 
 // MCPConfig は MCP 設定ファイルのトップレベル構造体
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Command は通知ごとにローカルコマンドを実行する Sender（notify-send・独自スクリプト等）。
+//
+// シェルを介さずに実行する。引数の {{kind}} {{host}} {{title}} {{message}} {{severity}} を
+// 通知の内容で置き換え、同じ値を PENTECTER_* 環境変数に、Notification の JSON を標準入力に渡す。
+type Command struct {
+	name string
+	args []string
+}
+
+// NewCommand は Command を作成する。
+func NewCommand(name string, args []string) (*Command, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("notify: command is empty")
+	}
+	return &Command{name: name, args: args}, nil
+}
+
+// Send はコマンドを実行し、終了コードが 0 以外なら stderr を含むエラーを返す。
+func (c *Command) Send(ctx context.Context, n Notification) error {
+	vars := map[string]string{
+		"kind":     string(n.Kind),
+		"host":     n.Host,
+		"title":    n.Title,
+		"message":  n.Message,
+		"severity": n.Severity,
+	}
+	// 置換後の値に含まれる {{...}} を再び置き換えないよう1パスで置換する
+	pairs := make([]string, 0, 2*len(vars))
+	for k, v := range vars {
+		pairs = append(pairs, "{{"+k+"}}", v)
+	}
+	r := strings.NewReplacer(pairs...)
+	args := make([]string, len(c.args))
+	for i, a := range c.args {
+		args[i] = r.Replace(a)
+	}
+
+	stdin, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("notify: marshal notification: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, c.name, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Env = append(os.Environ(),
+		"PENTECTER_EVENT="+vars["kind"],
+		"PENTECTER_TARGET_ID="+strconv.Itoa(n.TargetID),
+		"PENTECTER_HOST="+vars["host"],
+		"PENTECTER_TITLE="+vars["title"],
+		"PENTECTER_MESSAGE="+vars["message"],
+		"PENTECTER_SEVERITY="+vars["severity"],
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("notify: command %s: %w: %s", c.name, err, msg)
+		}
+		return fmt.Errorf("notify: command %s: %w", c.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Email は通知を SMTP で送る Sender。
+// サーバーが STARTTLS に対応していれば net/smtp が自動で使う。
+type Email struct {
+	addr     string // host:port
+	username string
+	password string
+	from     string
+	to       []string
+
+	// sendMail は smtp.SendMail（テストで差し替える）
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmail は Email を作成する。username が空なら認証しない。
+func NewEmail(addr, username, password, from string, to []string) (*Email, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return nil, fmt.Errorf("notify: invalid smtp address %q (want host:port)", addr)
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("notify: invalid from address %q: %w", from, err)
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("notify: email needs at least one recipient")
+	}
+	for _, rcpt := range to {
+		if _, err := mail.ParseAddress(rcpt); err != nil {
+			return nil, fmt.Errorf("notify: invalid recipient %q: %w", rcpt, err)
+		}
+	}
+	return &Email{
+		addr:     addr,
+		username: username,
+		password: password,
+		from:     from,
+		to:       to,
+		sendMail: smtp.SendMail,
+	}, nil
+}
+
+// Send は通知をメールで送る。smtp.SendMail は ctx に対応しないため、
+// ctx が先に終わった場合は送信の完了を待たずにエラーを返す。
+func (e *Email) Send(ctx context.Context, n Notification) error {
+	var auth smtp.Auth
+	if e.username != "" {
+		host, _, _ := net.SplitHostPort(e.addr)
+		auth = smtp.PlainAuth("", e.username, e.password, host)
+	}
+	msg := e.message(n)
+
+	done := make(chan error, 1)
+	go func() { done <- e.sendMail(e.addr, auth, e.from, e.to, msg) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("notify: smtp send: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("notify: smtp send: %w", ctx.Err())
+	}
+}
+
+// message は RFC 5322 形式のメール本文を作る（件名は日本語を含みうるので MIME エンコード）。
+func (e *Email) message(n Notification) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", e.from)
+	fmt.Fprintf(&sb, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&sb, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Subject()))
+	fmt.Fprintf(&sb, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("\r\n")
+	body := n.Message
+	if n.Host != "" {
+		body = "Target: " + n.Host + "\n\n" + body
+	}
+	sb.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	sb.WriteString("\r\n")
+	return []byte(sb.String())
+}
@@ -0,0 +1,282 @@
+// Package notify は Agent のイベント（Proposal・停滞・重大な発見など）を
+// webhook・メール（SMTP）・ローカルコマンドで通知する。
+//
+// 長時間のエンゲージメントで TUI を見ていなくても、承認待ちや停滞に気づけるようにする。
+// Notifier は agent.Event のストリームを Tap で中継しながら、
+// 経路（Route）ごとのフィルターに合うイベントだけを非同期に送信する。
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+)
+
+// Kind は通知の種別（config の events に書く名前）。
+type Kind string
+
+const (
+	KindProposal Kind = "proposal" // 承認待ちの Proposal
+	KindStalled  Kind = "stalled"  // 連続失敗で方針指示を待っている
+	KindFinding  Kind = "finding"  // 深刻度が閾値以上の発見物（memory）
+	KindComplete Kind = "complete" // アセスメント完了
+	KindError    Kind = "error"    // リカバリー不能なエラー
+)
+
+// DefaultKinds は events を省略したときに通知する種別。
+var DefaultKinds = []Kind{KindProposal, KindStalled, KindFinding}
+
+// DefaultMinSeverity は min_severity を省略したときの finding の閾値。
+const DefaultMinSeverity = "critical"
+
+// sendTimeout は1件の送信にかける時間の上限。
+const sendTimeout = 15 * time.Second
+
+var allKinds = []Kind{KindProposal, KindStalled, KindFinding, KindComplete, KindError}
+
+// severities は深刻度を低い順に並べたもの。
+var severities = []string{"info", "low", "medium", "high", "critical"}
+
+// ParseKinds は種別名を検証して Kind に変換する。空なら DefaultKinds。
+func ParseKinds(names []string) ([]Kind, error) {
+	if len(names) == 0 {
+		return DefaultKinds, nil
+	}
+	kinds := make([]Kind, 0, len(names))
+	for _, name := range names {
+		k := Kind(strings.ToLower(strings.TrimSpace(name)))
+		valid := false
+		for _, known := range allKinds {
+			if k == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("notify: unknown event %q (available: proposal, stalled, finding, complete, error)", name)
+		}
+		kinds = append(kinds, k)
+	}
+	return kinds, nil
+}
+
+// ParseSeverity は深刻度名を検証して小文字で返す。空なら DefaultMinSeverity。
+func ParseSeverity(name string) (string, error) {
+	if name == "" {
+		return DefaultMinSeverity, nil
+	}
+	s := strings.ToLower(strings.TrimSpace(name))
+	if severityRank(s) < 0 {
+		return "", fmt.Errorf("notify: unknown severity %q (available: %s)", name, strings.Join(severities, ", "))
+	}
+	return s, nil
+}
+
+// severityRank は深刻度の順位を返す（未知の値は -1）。
+func severityRank(s string) int {
+	for i, name := range severities {
+		if s == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Notification は送信する通知の内容。
+type Notification struct {
+	Kind     Kind      `json:"kind"`
+	TargetID int       `json:"target_id,omitempty"`
+	Host     string    `json:"host,omitempty"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	Severity string    `json:"severity,omitempty"` // finding のみ
+	Time     time.Time `json:"time"`
+}
+
+// Subject は件名・見出し用の1行を返す（例: "[pentecter] Approval required — 10.0.0.5"）。
+func (n Notification) Subject() string {
+	s := "[pentecter] " + n.Title
+	if n.Host != "" {
+		s += " — " + n.Host
+	}
+	return s
+}
+
+// Text は件名と本文をまとめたプレーンテキストを返す。
+func (n Notification) Text() string {
+	if n.Message == "" {
+		return n.Subject()
+	}
+	return n.Subject() + "\n" + n.Message
+}
+
+// FromEvent は通知対象のイベントを Notification に変換する。対象外なら false。
+func FromEvent(e agent.Event) (Notification, bool) {
+	n := Notification{TargetID: e.TargetID, Host: e.Host, Message: e.Message, Time: time.Now()}
+	switch e.Type {
+	case agent.EventProposal:
+		if e.Proposal == nil {
+			return n, false
+		}
+		n.Kind, n.Title = KindProposal, "Approval required"
+		n.Message = proposalText(e.Proposal)
+	case agent.EventStalled:
+		n.Kind, n.Title = KindStalled, "Agent stalled"
+	case agent.EventComplete:
+		n.Kind, n.Title = KindComplete, "Assessment complete"
+	case agent.EventError:
+		n.Kind, n.Title = KindError, "Agent error"
+	case agent.EventLog:
+		if e.Memory == nil {
+			return n, false
+		}
+		n.Kind = KindFinding
+		n.Severity = strings.ToLower(e.Memory.Severity)
+		if n.Severity == "" {
+			n.Severity = "info"
+		}
+		n.Title = fmt.Sprintf("%s %s: %s", strings.ToUpper(n.Severity), e.Memory.Type, e.Memory.Title)
+		n.Message = e.Memory.Description
+	default:
+		return n, false
+	}
+	return n, true
+}
+
+// proposalText は Proposal の説明と実行予定のコマンドを返す。
+func proposalText(p *agent.Proposal) string {
+	cmd := strings.TrimSpace(p.Tool + " " + strings.Join(p.Args, " "))
+	if p.MCPArgs != "" {
+		cmd += " " + p.MCPArgs
+	}
+	if cmd == "" {
+		return p.Description
+	}
+	return p.Description + "\n$ " + cmd
+}
+
+// Sender は通知を1件送る（webhook・メール・コマンド）。
+type Sender interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// Route は送信先と、そこへ送る通知のフィルター。
+type Route struct {
+	Name        string // エラー表示用（例: "webhook hooks.slack.com"）
+	Sender      Sender
+	Kinds       []Kind // 空 = DefaultKinds
+	MinSeverity string // finding の閾値（空 = DefaultMinSeverity）
+}
+
+// accepts は通知がこの経路のフィルターを通過するかを返す。
+func (r Route) accepts(n Notification) bool {
+	kinds := r.Kinds
+	if len(kinds) == 0 {
+		kinds = DefaultKinds
+	}
+	matched := false
+	for _, k := range kinds {
+		if k == n.Kind {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	if n.Kind != KindFinding {
+		return true
+	}
+	minSeverity := r.MinSeverity
+	if minSeverity == "" {
+		minSeverity = DefaultMinSeverity
+	}
+	return severityRank(n.Severity) >= severityRank(minSeverity)
+}
+
+// Notifier はイベントを経路ごとにフィルターして送信する。
+type Notifier struct {
+	routes  []Route
+	onError func(route string, err error)
+	wg      sync.WaitGroup
+}
+
+// New は経路を持つ Notifier を作成する。
+func New(routes ...Route) *Notifier {
+	return &Notifier{routes: routes}
+}
+
+// OnError は送信失敗時のコールバックを設定する（送信 goroutine から呼ばれる）。
+func (n *Notifier) OnError(fn func(route string, err error)) *Notifier {
+	n.onError = fn
+	return n
+}
+
+// Len は経路の数を返す。
+func (n *Notifier) Len() int {
+	if n == nil {
+		return 0
+	}
+	return len(n.routes)
+}
+
+// Notify はイベントが通知対象なら、フィルターに合う経路へ非同期に送信する。
+// 送信の完了は待たない（Wait で待てる）。
+func (n *Notifier) Notify(ctx context.Context, e agent.Event) {
+	if n.Len() == 0 {
+		return
+	}
+	note, ok := FromEvent(e)
+	if !ok {
+		return
+	}
+	for _, r := range n.routes {
+		if !r.accepts(note) {
+			continue
+		}
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			sctx, cancel := context.WithTimeout(ctx, sendTimeout)
+			defer cancel()
+			if err := r.Sender.Send(sctx, note); err != nil && n.onError != nil {
+				n.onError(r.Name, err)
+			}
+		}()
+	}
+}
+
+// Wait は送信中の通知がすべて終わるまで待つ。
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// Tap は in のイベントをそのまま返り値のチャネルへ中継しつつ Notify する。
+// TUI の前に挟んで使う。in が閉じるか ctx が終わると返り値のチャネルを閉じる。
+func (n *Notifier) Tap(ctx context.Context, in <-chan agent.Event) <-chan agent.Event {
+	out := make(chan agent.Event, cap(in))
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case e, ok := <-in:
+				if !ok {
+					return
+				}
+				n.Notify(ctx, e)
+				select {
+				case out <- e:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,313 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+	"github.com/0x6d61/pentecter/pkg/schema"
+)
+
+// recorder は受け取った webhook のボディを記録するテスト用サーバー。
+type recorder struct {
+	mu     sync.Mutex
+	bodies []string
+	status int
+}
+
+func newRecorder(t *testing.T) (*recorder, *httptest.Server) {
+	t.Helper()
+	rec := &recorder{status: http.StatusOK}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		rec.bodies = append(rec.bodies, string(data))
+		status := rec.status
+		rec.mu.Unlock()
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("invalid_token"))
+	}))
+	t.Cleanup(srv.Close)
+	return rec, srv
+}
+
+func (r *recorder) got() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.bodies...)
+}
+
+func memoryEvent(severity, title string) agent.Event {
+	return agent.Event{Type: agent.EventLog, Source: agent.SourceAI, TargetID: 1, Host: "10.0.0.5",
+		Message: "📝 " + title,
+		Memory:  &schema.Memory{Type: schema.MemoryVulnerability, Title: title, Description: "details", Severity: severity}}
+}
+
+func TestNotifier_FiltersPerRoute(t *testing.T) {
+	rec, srv := newRecorder(t)
+	hook, err := NewWebhook(srv.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := New(Route{Name: "slack", Sender: hook, Kinds: []Kind{KindProposal, KindFinding}, MinSeverity: "high"})
+
+	ctx := context.Background()
+	n.Notify(ctx, agent.Event{Type: agent.EventProposal, TargetID: 1, Host: "10.0.0.5",
+		Proposal: &agent.Proposal{Description: "Exploit vsftpd backdoor", Tool: "msfconsole", Args: []string{"-q", "-x", "run"}}})
+	n.Notify(ctx, agent.Event{Type: agent.EventStalled, Host: "10.0.0.5", Message: "Stalled"}) // 種別で除外
+	n.Notify(ctx, memoryEvent("medium", "Directory listing"))                                  // 深刻度で除外
+	n.Notify(ctx, memoryEvent("Critical", "SQL injection in /login"))
+	n.Notify(ctx, agent.Event{Type: agent.EventLog, Message: "nmap done"}) // 通知対象外
+	n.Wait()
+
+	bodies := rec.got()
+	if len(bodies) != 2 {
+		t.Fatalf("want 2 webhook calls, got %d: %v", len(bodies), bodies)
+	}
+	joined := strings.Join(bodies, "\n")
+	for _, want := range []string{
+		"Approval required — 10.0.0.5",
+		"$ msfconsole -q -x run",
+		"CRITICAL vulnerability: SQL injection in /login",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("webhook bodies should contain %q:\n%s", want, joined)
+		}
+	}
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(bodies[0]), &payload); err != nil || payload["text"] == "" {
+		t.Errorf("slack payload should be {\"text\": ...}, got %s", bodies[0])
+	}
+}
+
+func TestRoute_Defaults(t *testing.T) {
+	r := Route{}
+	for _, tt := range []struct {
+		n    Notification
+		want bool
+	}{
+		{Notification{Kind: KindProposal}, true},
+		{Notification{Kind: KindStalled}, true},
+		{Notification{Kind: KindComplete}, false},
+		{Notification{Kind: KindFinding, Severity: "high"}, false},
+		{Notification{Kind: KindFinding, Severity: "critical"}, true},
+	} {
+		if got := r.accepts(tt.n); got != tt.want {
+			t.Errorf("accepts(%s/%s) = %v, want %v", tt.n.Kind, tt.n.Severity, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := ParseKinds([]string{"proposal", "pwned"}); err == nil || !strings.Contains(err.Error(), `"pwned"`) {
+		t.Errorf("unknown event should fail, got %v", err)
+	}
+	kinds, err := ParseKinds([]string{"Error", " complete "})
+	if err != nil || len(kinds) != 2 || kinds[0] != KindError || kinds[1] != KindComplete {
+		t.Errorf("ParseKinds = %v, %v", kinds, err)
+	}
+	if s, err := ParseSeverity(""); err != nil || s != DefaultMinSeverity {
+		t.Errorf("empty severity = %q, %v", s, err)
+	}
+	if _, err := ParseSeverity("urgent"); err == nil {
+		t.Error("unknown severity should fail")
+	}
+}
+
+func TestWebhook_FormatsAndErrors(t *testing.T) {
+	rec, srv := newRecorder(t)
+	note := Notification{Kind: KindStalled, Host: "10.0.0.5", Title: "Agent stalled", Message: "3 failures", Time: time.Now()}
+
+	teams, err := NewWebhook(srv.URL, FormatTeams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := teams.Send(context.Background(), note); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := NewWebhook(srv.URL, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.Send(context.Background(), note); err != nil {
+		t.Fatal(err)
+	}
+	bodies := rec.got()
+	if !strings.Contains(bodies[0], `"@type":"MessageCard"`) || !strings.Contains(bodies[0], `"themeColor":"FFD700"`) {
+		t.Errorf("teams payload = %s", bodies[0])
+	}
+	var got Notification
+	if err := json.Unmarshal([]byte(bodies[1]), &got); err != nil || got.Kind != KindStalled || got.Host != "10.0.0.5" {
+		t.Errorf("json payload = %s (%v)", bodies[1], err)
+	}
+
+	rec.status = http.StatusForbidden
+	if err := teams.Send(context.Background(), note); err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "invalid_token") {
+		t.Errorf("non-2xx should fail with status and body, got %v", err)
+	}
+
+	for _, tt := range []struct{ url, format string }{{"ftp://example.com", ""}, {"not a url", ""}, {srv.URL, "discord"}} {
+		if _, err := NewWebhook(tt.url, tt.format); err == nil {
+			t.Errorf("NewWebhook(%q, %q) should fail", tt.url, tt.format)
+		}
+	}
+}
+
+func TestWebhook_ErrorHidesToken(t *testing.T) {
+	_, srv := newRecorder(t)
+	hook, err := NewWebhook(srv.URL+"/services/T000/B000/s3cr3tT0ken", FormatSlack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	err = hook.Send(context.Background(), Notification{Kind: KindStalled, Title: "Agent stalled"})
+	if err == nil {
+		t.Fatal("expected error for a closed server")
+	}
+	if strings.Contains(err.Error(), "s3cr3tT0ken") || !strings.Contains(err.Error(), srv.Listener.Addr().String()) {
+		t.Errorf("error should name the host without the token path: %v", err)
+	}
+	if _, err := NewWebhook("ftp://hooks.example.com/s3cr3tT0ken", ""); err == nil || strings.Contains(err.Error(), "s3cr3tT0ken") {
+		t.Errorf("invalid url error should not echo the url: %v", err)
+	}
+}
+
+func TestNotifier_OnError(t *testing.T) {
+	rec, srv := newRecorder(t)
+	rec.status = http.StatusInternalServerError
+	hook, _ := NewWebhook(srv.URL, "")
+
+	var mu sync.Mutex
+	var failed []string
+	n := New(Route{Name: "webhook test", Sender: hook}).OnError(func(route string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, route+": "+err.Error())
+	})
+	n.Notify(context.Background(), agent.Event{Type: agent.EventStalled, Message: "stalled"})
+	n.Wait()
+	if len(failed) != 1 || !strings.HasPrefix(failed[0], "webhook test: ") {
+		t.Errorf("send failure should be reported, got %v", failed)
+	}
+}
+
+func TestNotifier_TapForwardsEvents(t *testing.T) {
+	rec, srv := newRecorder(t)
+	hook, _ := NewWebhook(srv.URL, "")
+	n := New(Route{Name: "slack", Sender: hook})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := make(chan agent.Event, 4)
+	out := n.Tap(ctx, in)
+	in <- agent.Event{Type: agent.EventLog, Message: "hello"}
+	in <- agent.Event{Type: agent.EventStalled, Message: "stalled"}
+	close(in)
+
+	var types []agent.EventType
+	for e := range out {
+		types = append(types, e.Type)
+	}
+	n.Wait()
+	if len(types) != 2 || types[0] != agent.EventLog || types[1] != agent.EventStalled {
+		t.Errorf("all events should be forwarded in order, got %v", types)
+	}
+	if len(rec.got()) != 1 {
+		t.Errorf("only the stalled event should be sent, got %d", len(rec.got()))
+	}
+}
+
+func TestEmail_Send(t *testing.T) {
+	if _, err := NewEmail("smtp.example.com", "", "", "pentecter@example.com", []string{"ops@example.com"}); err == nil {
+		t.Error("address without port should fail")
+	}
+	if _, err := NewEmail("smtp.example.com:587", "", "", "pentecter@example.com", nil); err == nil {
+		t.Error("no recipients should fail")
+	}
+
+	e, err := NewEmail("smtp.example.com:587", "bot", "secret", "pentecter@example.com", []string{"ops@example.com", "lead@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	var gotAuth smtp.Auth
+	e.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotFrom, gotTo, gotMsg = addr, a, from, to, msg
+		return nil
+	}
+	note := Notification{Kind: KindProposal, Host: "10.0.0.5", Title: "Approval required",
+		Message: "Exploit vsftpd\n$ msfconsole", Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	if err := e.Send(context.Background(), note); err != nil {
+		t.Fatal(err)
+	}
+	if gotAddr != "smtp.example.com:587" || gotFrom != "pentecter@example.com" || len(gotTo) != 2 || gotAuth == nil {
+		t.Errorf("sendMail called with addr=%s from=%s to=%v auth=%v", gotAddr, gotFrom, gotTo, gotAuth)
+	}
+	msg := string(gotMsg)
+	for _, want := range []string{
+		"To: ops@example.com, lead@example.com\r\n",
+		"Subject: =?utf-8?q?[pentecter]_Approval_required_=E2=80=94_10.0.0.5?=\r\n",
+		"Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n",
+		"\r\n\r\nTarget: 10.0.0.5\r\n\r\nExploit vsftpd\r\n$ msfconsole\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message should contain %q:\n%s", want, msg)
+		}
+	}
+
+	e.sendMail = func(string, smtp.Auth, string, []string, []byte) error { return errors.New("535 auth failed") }
+	if err := e.Send(context.Background(), note); err == nil || !strings.Contains(err.Error(), "535") {
+		t.Errorf("smtp error should be returned, got %v", err)
+	}
+}
+
+func TestCommand_Send(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	out := filepath.Join(t.TempDir(), "out.txt")
+	c, err := NewCommand(sh, []string{"-c", `printf '%s|%s|' "$1" "$PENTECTER_EVENT" > "$2"; cat >> "$2"`, "notify", "{{title}} on {{host}}", out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := Notification{Kind: KindStalled, Host: "10.0.0.5", Title: "Agent stalled {{host}}", Time: time.Now()}
+	if err := c.Send(context.Background(), note); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	if !strings.HasPrefix(got, "Agent stalled {{host}} on 10.0.0.5|stalled|{") || !strings.Contains(got, `"kind":"stalled"`) {
+		t.Errorf("command output = %q", got)
+	}
+
+	fail, _ := NewCommand(sh, []string{"-c", "echo boom >&2; exit 3"})
+	if err := fail.Send(context.Background(), note); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("failing command should report stderr, got %v", err)
+	}
+	if _, err := NewCommand(" ", nil); err == nil {
+		t.Error("empty command should fail")
+	}
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// webhook のペイロード形式。
+const (
+	FormatSlack = "slack" // {"text": "..."}（Slack・Mattermost・Discord の /slack 互換）
+	FormatTeams = "teams" // MessageCard（Microsoft Teams の Incoming Webhook）
+	FormatJSON  = "json"  // Notification をそのまま JSON にする
+)
+
+// Webhook は通知を HTTP POST で送る Sender。
+type Webhook struct {
+	url    string
+	host   string // エラー表示用（URL のパスは Slack・Teams ではトークンなので出さない）
+	format string
+	client *http.Client
+}
+
+// NewWebhook は Webhook を作成する。format が空なら slack。
+func NewWebhook(rawURL, format string) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("notify: invalid webhook url (want http(s)://host/...)")
+	}
+	switch format {
+	case "":
+		format = FormatSlack
+	case FormatSlack, FormatTeams, FormatJSON:
+	default:
+		return nil, fmt.Errorf("notify: unknown webhook format %q (available: slack, teams, json)", format)
+	}
+	return &Webhook{url: rawURL, host: u.Host, format: format, client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// Send は通知を POST し、2xx 以外ならエラーを返す。
+func (w *Webhook) Send(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(w.payload(n))
+	if err != nil {
+		return fmt.Errorf("notify: marshal webhook payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return w.requestError("create webhook request", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return w.requestError("webhook request", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notify: webhook returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// requestError は *url.Error が持つ URL を外し、ホストだけを付けたエラーを返す。
+// エラーは OnError で TUI に表示されるため、トークンを含むパスを出さない。
+func (w *Webhook) requestError(op string, err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return fmt.Errorf("notify: %s to %s: %w", op, w.host, err)
+}
+
+// payload は形式ごとのリクエストボディを返す。
+func (w *Webhook) payload(n Notification) any {
+	switch w.format {
+	case FormatTeams:
+		return map[string]any{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    n.Subject(),
+			"title":      n.Subject(),
+			"text":       n.Message,
+			"themeColor": themeColor(n),
+		}
+	case FormatJSON:
+		return n
+	default:
+		return map[string]string{"text": n.Text()}
+	}
+}
+
+// themeColor は Teams のカードの色（重大な発見とエラーは赤、承認待ちと停滞は黄）。
+func themeColor(n Notification) string {
+	switch {
+	case n.Kind == KindError, n.Kind == KindFinding && severityRank(n.Severity) >= severityRank("high"):
+		return "FF5555"
+	case n.Kind == KindProposal, n.Kind == KindStalled:
+		return "FFD700"
+	}
+	return "00D7FF"
+}
@@ -0,0 +1,413 @@
+// Package playbook は YAML で定義する偵察プレイブックを管理する。
+//
+// プレイブックはトリガー（サービス名・ポート・バナー正規表現・発見パス）に一致した
+// ポートに対して SubAgent が実行するフェーズ・コマンド・完了条件を定義する:
+//
+//	name: wordpress
+//	description: WordPress enumeration
+//	triggers:
+//	  - banner: '(?i)wordpress'
+//	  - path: '^/wp-(login\.php|admin|content)'
+//	phases:
+//	  - name: enumerate
+//	    goal: Enumerate plugins, themes and users
+//	    commands:
+//	      - wpscan --url {{url}} -e ap,at,u --no-banner
+//	completion:
+//	  - Plugins, themes and users are enumerated
+//
+// workflow: web のプレイブックは組み込みの HTTP 偵察ワークフローを置き換える。
+package playbook
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WorkflowWeb は HTTP ポートの標準偵察ワークフローを置き換えるプレイブック。
+const WorkflowWeb = "web"
+
+// Trigger はプレイブックを起動する条件。指定したフィールドはすべて満たす必要がある（AND）。
+// 複数の Trigger はいずれかに一致すればよい（OR）。
+type Trigger struct {
+	Service []string `yaml:"service,omitempty"` // nmap のサービス名（"http", "microsoft-ds"）
+	Port    []int    `yaml:"port,omitempty"`
+	Banner  string   `yaml:"banner,omitempty"` // バナー・検出技術に対する正規表現
+	Path    string   `yaml:"path,omitempty"`   // 発見済み endpoint パスに対する正規表現
+
+	banner *regexp.Regexp
+	path   *regexp.Regexp
+}
+
+// Phase はプレイブックの1フェーズ。
+type Phase struct {
+	Name         string   `yaml:"name"`
+	Goal         string   `yaml:"goal,omitempty"`
+	Commands     []string `yaml:"commands,omitempty"`     // {{host}} 等のプレースホルダーを展開する
+	Instructions string   `yaml:"instructions,omitempty"` // 補足の手順・判断基準
+}
+
+// Playbook は偵察プレイブック1件。
+type Playbook struct {
+	Name        string    `yaml:"name"`
+	Description string    `yaml:"description,omitempty"`
+	Workflow    string    `yaml:"workflow,omitempty"` // "web" = 組み込み HTTP 偵察を置き換える。空 = 追加の SubAgent
+	Triggers    []Trigger `yaml:"triggers"`
+	Phases      []Phase   `yaml:"phases"`
+	// RequiredFlags はバイナリ名 → 必須フラグ（欠けていれば SubAgent が自動付与する）
+	RequiredFlags map[string][]string `yaml:"required_flags,omitempty"`
+	// ForbiddenFlags はバイナリ名 → 禁止フラグ（SubAgent が自動で取り除く）
+	ForbiddenFlags map[string][]string `yaml:"forbidden_flags,omitempty"`
+	Rules          []string            `yaml:"rules,omitempty"`
+	Completion     []string            `yaml:"completion,omitempty"` // 完了条件
+	MaxTurns       int                 `yaml:"max_turns,omitempty"`
+}
+
+// Subject はトリガー判定の対象となるポートの情報。
+type Subject struct {
+	Host         string
+	Port         int
+	Service      string
+	Banner       string
+	Technologies []string
+	Paths        []string // 発見済み endpoint パス
+}
+
+// compile はトリガーの正規表現をコンパイルする。
+func (p *Playbook) compile() error {
+	for i := range p.Triggers {
+		tr := &p.Triggers[i]
+		if tr.Banner != "" {
+			re, err := regexp.Compile(tr.Banner)
+			if err != nil {
+				return fmt.Errorf("trigger %d: invalid banner regexp: %w", i, err)
+			}
+			tr.banner = re
+		}
+		if tr.Path != "" {
+			re, err := regexp.Compile(tr.Path)
+			if err != nil {
+				return fmt.Errorf("trigger %d: invalid path regexp: %w", i, err)
+			}
+			tr.path = re
+		}
+	}
+	return nil
+}
+
+// Matches はいずれかのトリガーが対象に一致するかを返す。トリガーがなければ一致しない。
+func (p *Playbook) Matches(s Subject) bool {
+	for _, tr := range p.Triggers {
+		if tr.matches(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (tr Trigger) matches(s Subject) bool {
+	if len(tr.Service) == 0 && len(tr.Port) == 0 && tr.banner == nil && tr.path == nil {
+		return false
+	}
+	if len(tr.Service) > 0 && !containsFold(tr.Service, s.Service) {
+		return false
+	}
+	if len(tr.Port) > 0 && !containsInt(tr.Port, s.Port) {
+		return false
+	}
+	if tr.banner != nil {
+		texts := append([]string{s.Banner}, s.Technologies...)
+		if !anyMatch(tr.banner, texts) {
+			return false
+		}
+	}
+	if tr.path != nil && !anyMatch(tr.path, s.Paths) {
+		return false
+	}
+	return true
+}
+
+// Render はプレイブックを SubAgent 用のプロンプトに展開する。
+// vars のキーは "{{key}}" として Commands / Instructions / Rules 内で置換される。
+func (p *Playbook) Render(vars map[string]string) string {
+	expand := newExpander(vars)
+
+	var sb strings.Builder
+	desc := p.Description
+	if desc == "" {
+		desc = p.Name
+	}
+	fmt.Fprintf(&sb, "You are a reconnaissance agent running the %q playbook for %s (port %s).\n",
+		p.Name, vars["host"], vars["port"])
+	fmt.Fprintf(&sb, "Playbook: %s\n", expand(desc))
+	sb.WriteString("Your ReconTree is automatically updated as you run commands.\n")
+
+	if flags := p.renderFlags(); flags != "" {
+		sb.WriteString("\nMANDATORY FLAGS:\n")
+		sb.WriteString(flags)
+	}
+
+	sb.WriteString("\nWORKFLOW — Execute phases in this order:\n")
+	for i, ph := range p.Phases {
+		fmt.Fprintf(&sb, "\n%d. %s", i+1, strings.ToUpper(strings.ReplaceAll(ph.Name, "_", " ")))
+		if ph.Goal != "" {
+			fmt.Fprintf(&sb, " — %s", expand(ph.Goal))
+		}
+		sb.WriteString("\n")
+		for _, cmd := range ph.Commands {
+			fmt.Fprintf(&sb, "   %s\n", expand(cmd))
+		}
+		if ph.Instructions != "" {
+			for _, line := range strings.Split(strings.TrimRight(expand(ph.Instructions), "\n"), "\n") {
+				fmt.Fprintf(&sb, "   %s\n", line)
+			}
+		}
+	}
+
+	if len(p.Rules) > 0 {
+		sb.WriteString("\nRULES (VIOLATION = FAILURE):\n")
+		for _, r := range p.Rules {
+			fmt.Fprintf(&sb, "- %s\n", expand(r))
+		}
+	}
+
+	sb.WriteString("\nCOMPLETION CRITERIA:\n")
+	for _, c := range p.Completion {
+		fmt.Fprintf(&sb, "- %s\n", expand(c))
+	}
+	sb.WriteString("- Report all findings with \"memory\" action\n")
+	sb.WriteString("- When all criteria are met, use \"complete\" action to finish\n")
+	return sb.String()
+}
+
+// renderFlags は必須・禁止フラグの説明を返す。
+func (p *Playbook) renderFlags() string {
+	var sb strings.Builder
+	for _, bin := range sortedKeys(p.RequiredFlags) {
+		fmt.Fprintf(&sb, "  EVERY %s command MUST include: %s\n", bin, strings.Join(p.RequiredFlags[bin], " "))
+	}
+	for _, bin := range sortedKeys(p.ForbiddenFlags) {
+		fmt.Fprintf(&sb, "  Do NOT use with %s: %s\n", bin, strings.Join(p.ForbiddenFlags[bin], " "))
+	}
+	return sb.String()
+}
+
+// EnforceFlags はコマンドに必須フラグを付与し、禁止フラグ（と値）を取り除く。
+// 対象はコマンド先頭のバイナリがプレイブックに定義されている場合のみ。
+// "--flag" と "-flag=value" は "-flag" と同じフラグとして扱う。
+func (p *Playbook) EnforceFlags(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return command
+	}
+	bin := filepath.Base(fields[0])
+	required, forbidden := p.RequiredFlags[bin], p.ForbiddenFlags[bin]
+	if len(required) == 0 && len(forbidden) == 0 {
+		return command
+	}
+
+	changed := false
+	if len(forbidden) > 0 {
+		kept := fields[:1]
+		for i := 1; i < len(fields); i++ {
+			name, inline := flagName(fields[i])
+			if name == "" || !hasFlag(forbidden, name) {
+				kept = append(kept, fields[i])
+				continue
+			}
+			changed = true
+			// "-recursion-depth 3" / "-w /path" のように値を取るフラグは値も取り除く
+			if !inline && i+1 < len(fields) && !strings.HasPrefix(fields[i+1], "-") {
+				i++
+			}
+		}
+		fields = kept
+	}
+
+	for _, flag := range required {
+		parts := strings.Fields(flag)
+		if len(parts) == 0 {
+			continue
+		}
+		if name, _ := flagName(parts[0]); name != "" && hasFlag(fields[1:], name) {
+			continue
+		}
+		fields = append(fields, parts...)
+		changed = true
+	}
+
+	if !changed {
+		return command
+	}
+	return strings.Join(fields, " ")
+}
+
+// flagName はトークンがフラグなら "-name" 形式に正規化した名前と、"=value" を含むかを返す。
+// フラグでなければ空文字を返す。
+func flagName(tok string) (string, bool) {
+	if len(tok) < 2 || tok[0] != '-' || tok == "--" {
+		return "", false
+	}
+	name := "-" + strings.TrimLeft(tok, "-")
+	name, _, inline := strings.Cut(name, "=")
+	return name, inline
+}
+
+// hasFlag は tokens（コマンドの引数やプレイブックのフラグ定義）に正規化したフラグ名が含まれるかを返す。
+func hasFlag(tokens []string, name string) bool {
+	for _, tok := range tokens {
+		if n, _ := flagName(tok); n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TurnLimit は MaxTurns を返す（未指定なら def）。
+func (p *Playbook) TurnLimit(def int) int {
+	if p.MaxTurns > 0 {
+		return p.MaxTurns
+	}
+	return def
+}
+
+// Registry はロード済みプレイブックを管理する。
+type Registry struct {
+	playbooks map[string]*Playbook
+}
+
+// NewRegistry は空の Registry を返す。
+func NewRegistry() *Registry {
+	return &Registry{playbooks: make(map[string]*Playbook)}
+}
+
+// LoadDir は dir 以下の *.yaml / *.yml をロードする。
+// ディレクトリが存在しなくてもエラーにはしない。
+func (r *Registry) LoadDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() || !(strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
+			return nil
+		}
+		if loadErr := r.loadFile(path); loadErr != nil {
+			return fmt.Errorf("load %s: %w", path, loadErr)
+		}
+		return nil
+	})
+}
+
+// loadFile は単一のプレイブックファイルを読み込んで登録する。
+func (r *Registry) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var pb Playbook
+	if err := yaml.Unmarshal(data, &pb); err != nil {
+		return fmt.Errorf("parse yaml: %w", err)
+	}
+	return r.Register(&pb)
+}
+
+// Register はプレイブックを検証して登録する（テスト・組み込み向け）。
+func (r *Registry) Register(pb *Playbook) error {
+	if pb.Name == "" {
+		return fmt.Errorf("playbook missing 'name' field")
+	}
+	if len(pb.Phases) == 0 {
+		return fmt.Errorf("playbook %q has no phases", pb.Name)
+	}
+	if err := pb.compile(); err != nil {
+		return fmt.Errorf("playbook %q: %w", pb.Name, err)
+	}
+	r.playbooks[pb.Name] = pb
+	return nil
+}
+
+// Get は名前でプレイブックを返す。
+func (r *Registry) Get(name string) (*Playbook, bool) {
+	if r == nil {
+		return nil, false
+	}
+	pb, ok := r.playbooks[name]
+	return pb, ok
+}
+
+// All は全プレイブックを名前順で返す。
+func (r *Registry) All() []*Playbook {
+	if r == nil {
+		return nil
+	}
+	result := make([]*Playbook, 0, len(r.playbooks))
+	for _, pb := range r.playbooks {
+		result = append(result, pb)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Match は対象に一致するプレイブックを名前順で返す。workflow が一致するものに限る。
+func (r *Registry) Match(workflow string, s Subject) []*Playbook {
+	var result []*Playbook
+	for _, pb := range r.All() {
+		if pb.Workflow == workflow && pb.Matches(s) {
+			result = append(result, pb)
+		}
+	}
+	return result
+}
+
+// newExpander は "{{key}}" プレースホルダーを置換する関数を返す。
+func newExpander(vars map[string]string) func(string) string {
+	pairs := make([]string, 0, len(vars)*2)
+	for _, k := range sortedKeys(vars) {
+		pairs = append(pairs, "{{"+k+"}}", vars[k])
+	}
+	rep := strings.NewReplacer(pairs...)
+	return rep.Replace
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func anyMatch(re *regexp.Regexp, texts []string) bool {
+	for _, t := range texts {
+		if t != "" && re.MatchString(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, n int) bool {
+	for _, v := range list {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
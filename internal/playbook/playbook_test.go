@@ -0,0 +1,218 @@
+package playbook_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/pentecter/internal/playbook"
+)
+
+const wordpressYAML = `name: wordpress
+description: WordPress enumeration on {{url}}
+max_turns: 25
+triggers:
+  - banner: '(?i)wordpress'
+  - path: '^/wp-(login\.php|admin)'
+required_flags:
+  wpscan: ["--no-banner"]
+phases:
+  - name: version_and_components
+    goal: identify core version
+    commands:
+      - wpscan --url {{url}} -e vp
+    instructions: |
+      Fall back to curl {{url}}/readme.html
+rules:
+  - Do NOT brute-force credentials
+completion:
+  - Plugins and users are enumerated
+`
+
+func writePlaybook(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistry_LoadDir(t *testing.T) {
+	dir := t.TempDir()
+	writePlaybook(t, dir, "wordpress.yaml", wordpressYAML)
+	writePlaybook(t, dir, "README.md", "# not a playbook")
+
+	reg := playbook.NewRegistry()
+	if err := reg.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	pb, ok := reg.Get("wordpress")
+	if !ok {
+		t.Fatal("wordpress playbook not loaded")
+	}
+	if pb.MaxTurns != 25 || len(pb.Phases) != 1 || len(pb.Triggers) != 2 {
+		t.Errorf("unexpected playbook: %+v", pb)
+	}
+	if len(reg.All()) != 1 {
+		t.Errorf("All() = %d, want 1", len(reg.All()))
+	}
+}
+
+func TestRegistry_LoadDir_NotExist(t *testing.T) {
+	reg := playbook.NewRegistry()
+	if err := reg.LoadDir(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("missing dir should not be an error: %v", err)
+	}
+}
+
+func TestRegistry_LoadDir_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"missing name", "phases:\n  - name: a\n", "missing 'name'"},
+		{"no phases", "name: x\n", "no phases"},
+		{"bad regexp", "name: x\ntriggers:\n  - banner: '('\nphases:\n  - name: a\n", "invalid banner regexp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writePlaybook(t, dir, "bad.yaml", tt.content)
+			err := playbook.NewRegistry().LoadDir(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlaybook_Matches(t *testing.T) {
+	reg := playbook.NewRegistry()
+	if err := reg.Register(&playbook.Playbook{
+		Name: "mixed",
+		Triggers: []playbook.Trigger{
+			{Service: []string{"http"}, Port: []int{8080}},
+			{Banner: `(?i)jenkins`},
+			{Path: `^/wp-login\.php$`},
+		},
+		Phases: []playbook.Phase{{Name: "a"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	pb, _ := reg.Get("mixed")
+
+	tests := []struct {
+		name    string
+		subject playbook.Subject
+		want    bool
+	}{
+		{"service and port", playbook.Subject{Port: 8080, Service: "http"}, true},
+		{"service only (port mismatch)", playbook.Subject{Port: 80, Service: "http"}, false},
+		{"banner", playbook.Subject{Port: 80, Service: "http", Banner: "Jetty 9.4 (Jenkins)"}, true},
+		{"technology", playbook.Subject{Port: 80, Technologies: []string{"Jenkins 2.319"}}, true},
+		{"path", playbook.Subject{Port: 80, Paths: []string{"/admin", "/wp-login.php"}}, true},
+		{"nothing", playbook.Subject{Port: 22, Service: "ssh", Banner: "OpenSSH 8.2"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pb.Matches(tt.subject); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_Match_Workflow(t *testing.T) {
+	reg := playbook.NewRegistry()
+	_ = reg.Register(&playbook.Playbook{Name: "web-recon", Workflow: playbook.WorkflowWeb,
+		Triggers: []playbook.Trigger{{Service: []string{"http"}}}, Phases: []playbook.Phase{{Name: "a"}}})
+	_ = reg.Register(&playbook.Playbook{Name: "extra",
+		Triggers: []playbook.Trigger{{Service: []string{"http"}}}, Phases: []playbook.Phase{{Name: "a"}}})
+
+	s := playbook.Subject{Port: 80, Service: "http"}
+	web := reg.Match(playbook.WorkflowWeb, s)
+	if len(web) != 1 || web[0].Name != "web-recon" {
+		t.Errorf("web match = %v", web)
+	}
+	extra := reg.Match("", s)
+	if len(extra) != 1 || extra[0].Name != "extra" {
+		t.Errorf("additional match = %v", extra)
+	}
+}
+
+func TestPlaybook_Render(t *testing.T) {
+	dir := t.TempDir()
+	writePlaybook(t, dir, "wordpress.yaml", wordpressYAML)
+	reg := playbook.NewRegistry()
+	if err := reg.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	pb, _ := reg.Get("wordpress")
+
+	prompt := pb.Render(map[string]string{"host": "10.10.11.100", "port": "80", "url": "http://10.10.11.100"})
+	for _, want := range []string{
+		`"wordpress" playbook for 10.10.11.100 (port 80)`,
+		"WordPress enumeration on http://10.10.11.100",
+		"EVERY wpscan command MUST include: --no-banner",
+		"1. VERSION AND COMPONENTS — identify core version",
+		"   wpscan --url http://10.10.11.100 -e vp",
+		"   Fall back to curl http://10.10.11.100/readme.html",
+		"- Do NOT brute-force credentials",
+		"- Plugins and users are enumerated",
+		`"complete" action`,
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q\nprompt:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "{{") {
+		t.Errorf("prompt has unexpanded placeholders:\n%s", prompt)
+	}
+}
+
+func TestPlaybook_EnforceFlags(t *testing.T) {
+	pb := &playbook.Playbook{
+		RequiredFlags:  map[string][]string{"ffuf": {"-of json"}},
+		ForbiddenFlags: map[string][]string{"ffuf": {"-recursion", "-recursion-depth", "-w"}},
+	}
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"adds required", "ffuf -u http://x/FUZZ", "ffuf -u http://x/FUZZ -of json"},
+		{"keeps existing", "ffuf -u http://x/FUZZ -of json", "ffuf -u http://x/FUZZ -of json"},
+		{"keeps existing with =", "ffuf -u http://x/FUZZ -of=json", "ffuf -u http://x/FUZZ -of=json"},
+		{"strips forbidden with value", "ffuf -u http://x/FUZZ -recursion -recursion-depth 3 -of json", "ffuf -u http://x/FUZZ -of json"},
+		{"strips = value", "ffuf -u http://x/FUZZ -recursion=true -recursion-depth=3", "ffuf -u http://x/FUZZ -of json"},
+		{"strips double dash", "ffuf -u http://x/FUZZ --recursion --recursion-depth 3", "ffuf -u http://x/FUZZ -of json"},
+		{"strips non-numeric value", "ffuf -w /usr/share/wordlists/common.txt -u http://x/FUZZ", "ffuf -u http://x/FUZZ -of json"},
+		{"other binary untouched", "curl -s http://x/", "curl -s http://x/"},
+		{"absolute path", "/usr/bin/ffuf -u http://x/FUZZ", "/usr/bin/ffuf -u http://x/FUZZ -of json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pb.EnforceFlags(tt.in); got != tt.want {
+				t.Errorf("EnforceFlags(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepoPlaybooks_Load(t *testing.T) {
+	// リポジトリ同梱の playbooks/ がすべて読み込めること
+	reg := playbook.NewRegistry()
+	if err := reg.LoadDir(filepath.Join("..", "..", "playbooks")); err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	for _, name := range []string{"web-recon", "wordpress", "jenkins"} {
+		if _, ok := reg.Get(name); !ok {
+			t.Errorf("playbook %q not found", name)
+		}
+	}
+	web, _ := reg.Get("web-recon")
+	if web.Workflow != playbook.WorkflowWeb {
+		t.Errorf("web-recon workflow = %q, want web", web.Workflow)
+	}
+}
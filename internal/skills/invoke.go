@@ -0,0 +1,326 @@
+package skills
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// パラメータ型
+const (
+	ParamString = "string"
+	ParamInt    = "int"
+	ParamBool   = "bool"
+	ParamEnum   = "enum"
+)
+
+// Param はスキルが受け取る型付きパラメータを定義する。
+type Param struct {
+	Name        string   `yaml:"name"`
+	Type        string   `yaml:"type"` // string | int | bool | enum（省略時 string）
+	Description string   `yaml:"description"`
+	Required    bool     `yaml:"required"`
+	Default     string   `yaml:"default"`
+	Values      []string `yaml:"values"` // enum の許容値
+}
+
+// check は値を型に従って検証し、正規化した値を返す。
+func (p Param) check(value string) (string, error) {
+	switch p.Type {
+	case ParamInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("%s must be an integer, got %q", p.Name, value)
+		}
+		return strconv.Itoa(n), nil
+	case ParamBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%s must be true or false, got %q", p.Name, value)
+		}
+		return strconv.FormatBool(b), nil
+	case ParamEnum:
+		if !slices.Contains(p.Values, value) {
+			return "", fmt.Errorf("%s must be one of %s, got %q", p.Name, strings.Join(p.Values, "|"), value)
+		}
+	}
+	return value, nil
+}
+
+// Step はスキルの手順の1ステップ。
+type Step struct {
+	Name    string `yaml:"name"`
+	Tool    string `yaml:"tool"`    // このステップで使うツール（完了判定でコマンドと照合）
+	Command string `yaml:"command"` // 推奨コマンド（{{param}} を展開）
+	Success string `yaml:"success"` // 成功条件の正規表現（空 = exit code 0 で成功）
+
+	successRe *regexp.Regexp
+}
+
+// Done は実行済みコマンドの結果がこのステップの完了条件を満たすかを返す。
+// Tool が指定されている場合、コマンドにそのツールが含まれていなければ対象外とする。
+func (st Step) Done(command, output string, exitCode int) bool {
+	if st.Tool != "" && !usesTool(command, st.Tool) {
+		return false
+	}
+	if st.successRe != nil {
+		return st.successRe.MatchString(output)
+	}
+	return exitCode == 0
+}
+
+// usesTool はコマンド中に tool がバイナリとして現れるかを判定する（sudo やパイプ経由も含む）。
+func usesTool(command, tool string) bool {
+	for _, f := range strings.Fields(command) {
+		if filepath.Base(f) == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage はスキルの呼び出し書式を返す（例: "/web-recon url=<string> [depth=<int>]"）。
+func (sk *Skill) Usage() string {
+	var sb strings.Builder
+	sb.WriteString("/" + sk.Name)
+	for _, p := range sk.Params {
+		typ := p.Type
+		if p.Type == ParamEnum {
+			typ = strings.Join(p.Values, "|")
+		}
+		if p.Required {
+			fmt.Fprintf(&sb, " %s=<%s>", p.Name, typ)
+		} else {
+			fmt.Fprintf(&sb, " [%s=<%s>]", p.Name, typ)
+		}
+	}
+	return sb.String()
+}
+
+// Invocation は検証済みのスキル呼び出し。
+type Invocation struct {
+	Skill *Skill
+	Args  map[string]string // パラメータ名 → 値（デフォルト適用済み）
+}
+
+// Parse はユーザー入力がスキル呼び出し（/skill-name key=value ...）ならパースして検証する。
+// スキル呼び出しでない入力（通常のメッセージ・未知のスラッシュコマンド）は nil, nil を返す。
+//
+// 引数は key=value 形式か、パラメータの定義順に割り当てる位置引数で指定する。
+// 値はダブル/シングルクォートで囲むと空白を含められる。
+func (r *Registry) Parse(input string) (*Invocation, error) {
+	input = strings.TrimSpace(input)
+	if !strings.HasPrefix(input, "/") {
+		return nil, nil
+	}
+	tokens, err := splitArgs(strings.TrimPrefix(input, "/"))
+	if err != nil || len(tokens) == 0 {
+		return nil, nil
+	}
+	sk, ok := r.Get(tokens[0])
+	if !ok {
+		return nil, nil
+	}
+
+	byName := make(map[string]Param, len(sk.Params))
+	for _, p := range sk.Params {
+		byName[p.Name] = p
+	}
+
+	args := make(map[string]string)
+	var positional []string
+	for _, tok := range tokens[1:] {
+		key, value, ok := strings.Cut(tok, "=")
+		if !ok || !paramNameRe.MatchString(key) {
+			// "http://host/?id=1" のような値は位置引数として扱う
+			positional = append(positional, tok)
+			continue
+		}
+		if _, known := byName[key]; !known {
+			return nil, fmt.Errorf("/%s: unknown parameter %q (usage: %s)", sk.Name, key, sk.Usage())
+		}
+		args[key] = value
+	}
+	for _, value := range positional {
+		assigned := false
+		for _, p := range sk.Params {
+			if _, set := args[p.Name]; !set {
+				args[p.Name] = value
+				assigned = true
+				break
+			}
+		}
+		if !assigned {
+			return nil, fmt.Errorf("/%s: too many arguments (usage: %s)", sk.Name, sk.Usage())
+		}
+	}
+	return sk.bind(args)
+}
+
+// Invoke はスキル名と引数マップから検証済みの呼び出しを作る（Brain の use_skill 用）。
+func (r *Registry) Invoke(name string, args map[string]string) (*Invocation, error) {
+	sk, ok := r.Get(name)
+	if !ok {
+		var names []string
+		for _, s := range r.All() {
+			names = append(names, s.Name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown skill %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	bound := make(map[string]string, len(args))
+	for k, v := range args {
+		if !slices.ContainsFunc(sk.Params, func(p Param) bool { return p.Name == k }) {
+			return nil, fmt.Errorf("/%s: unknown parameter %q (usage: %s)", sk.Name, k, sk.Usage())
+		}
+		bound[k] = v
+	}
+	return sk.bind(bound)
+}
+
+// bind は必須チェック・デフォルト適用・型検証を行い Invocation を返す。
+func (sk *Skill) bind(args map[string]string) (*Invocation, error) {
+	for _, p := range sk.Params {
+		value, set := args[p.Name]
+		if !set {
+			if p.Required {
+				return nil, fmt.Errorf("/%s: missing required parameter %q (usage: %s)", sk.Name, p.Name, sk.Usage())
+			}
+			if p.Default == "" {
+				continue
+			}
+			value = p.Default
+		}
+		normalized, err := p.check(value)
+		if err != nil {
+			return nil, fmt.Errorf("/%s: %w (usage: %s)", sk.Name, err, sk.Usage())
+		}
+		args[p.Name] = normalized
+	}
+	return &Invocation{Skill: sk, Args: args}, nil
+}
+
+var paramNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// splitArgs は空白区切りでトークン化する。クォートで囲まれた部分は1トークンとして扱う。
+func splitArgs(s string) ([]string, error) {
+	var (
+		tokens []string
+		cur    strings.Builder
+		quote  rune
+		inTok  bool
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inTok = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inTok {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+				inTok = false
+			}
+		default:
+			cur.WriteRune(r)
+			inTok = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inTok {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens, nil
+}
+
+// expand は {{name}} プレースホルダをパラメータ値と vars で置換する。
+// 同名の場合はパラメータ値を優先する。
+func (inv *Invocation) expand(text string, vars map[string]string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	var pairs []string
+	for k, v := range vars {
+		if _, ok := inv.Args[k]; !ok {
+			pairs = append(pairs, "{{"+k+"}}", v)
+		}
+	}
+	for k, v := range inv.Args {
+		pairs = append(pairs, "{{"+k+"}}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// StepCommand は i 番目のステップの推奨コマンドを展開して返す。
+func (inv *Invocation) StepCommand(i int, vars map[string]string) string {
+	return inv.expand(inv.Skill.Steps[i].Command, vars)
+}
+
+// Prompt はパラメータを展開したスキルのプロンプトを返す。
+// パラメータとステップがあれば、その一覧を末尾に付加する。
+func (inv *Invocation) Prompt(vars map[string]string) string {
+	var sb strings.Builder
+	sb.WriteString(inv.expand(strings.TrimSpace(inv.Skill.Prompt), vars))
+
+	if len(inv.Args) > 0 {
+		sb.WriteString("\n\nParameters:\n")
+		names := make([]string, 0, len(inv.Args))
+		for k := range inv.Args {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			fmt.Fprintf(&sb, "- %s: %s\n", k, inv.Args[k])
+		}
+	}
+
+	if len(inv.Skill.Steps) > 0 {
+		if len(inv.Args) == 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\nSteps (complete in order):\n")
+		sb.WriteString(inv.Checklist(0, vars))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// Checklist はステップの進捗チェックリストを返す。done は完了済みステップ数。
+func (inv *Invocation) Checklist(done int, vars map[string]string) string {
+	var sb strings.Builder
+	for i, st := range inv.Skill.Steps {
+		mark := " "
+		if i < done {
+			mark = "x"
+		}
+		fmt.Fprintf(&sb, "- [%s] %d. %s", mark, i+1, st.Name)
+		if i == done {
+			sb.WriteString(" ← current")
+		}
+		sb.WriteString("\n")
+		if i < done {
+			continue
+		}
+		if cmd := inv.StepCommand(i, vars); cmd != "" {
+			fmt.Fprintf(&sb, "      command: %s\n", cmd)
+		}
+		switch {
+		case st.Success != "":
+			fmt.Fprintf(&sb, "      success: output matches /%s/\n", st.Success)
+		case st.Tool != "":
+			fmt.Fprintf(&sb, "      success: %s exits with code 0\n", st.Tool)
+		}
+	}
+	return sb.String()
+}
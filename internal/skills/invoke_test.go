@@ -0,0 +1,223 @@
+package skills_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/pentecter/internal/skills"
+)
+
+const webReconMD = `---
+name: web-recon
+description: Web recon
+params:
+  - name: url
+    required: true
+  - name: depth
+    type: int
+    default: 2
+  - name: mode
+    type: enum
+    values: [quick, full]
+    default: quick
+  - name: verbose
+    type: bool
+requires: [ffuf, curl]
+steps:
+  - name: Headers
+    tool: curl
+    command: curl -ik {{url}}
+  - name: Directories
+    tool: ffuf
+    command: ffuf -u {{url}}/FUZZ -recursion-depth {{depth}}
+    success: "Status: 200"
+---
+
+Recon {{url}} ({{mode}}) on {{target}}.
+`
+
+func loadSkill(t *testing.T, md string) *skills.Registry {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "skill.md"), []byte(md), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reg := skills.NewRegistry()
+	if err := reg.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	return reg
+}
+
+func TestRegistry_Parse(t *testing.T) {
+	reg := loadSkill(t, webReconMD)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr string
+	}{
+		{"named", "/web-recon url=http://a depth=3 mode=full verbose=yes",
+			nil, "verbose must be true or false"},
+		{"defaults", "/web-recon url=http://a",
+			map[string]string{"url": "http://a", "depth": "2", "mode": "quick"}, ""},
+		{"positional", "/web-recon http://a/?id=1 5",
+			map[string]string{"url": "http://a/?id=1", "depth": "5", "mode": "quick"}, ""},
+		{"quoted", `/web-recon url="http://a/x y" verbose=true`,
+			map[string]string{"url": "http://a/x y", "depth": "2", "mode": "quick", "verbose": "true"}, ""},
+		{"missing required", "/web-recon depth=1", nil, `missing required parameter "url"`},
+		{"bad enum", "/web-recon http://a mode=slow", nil, "mode must be one of quick|full"},
+		{"unknown param", "/web-recon http://a level=9", nil, `unknown parameter "level"`},
+		{"too many", "/web-recon a 1 quick true extra", nil, "too many arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv, err := reg.Parse(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), "/web-recon url=<string> [depth=<int>] [mode=<quick|full>] [verbose=<bool>]") {
+					t.Errorf("error should include usage: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if len(inv.Args) != len(tt.want) {
+				t.Errorf("Args: got %v, want %v", inv.Args, tt.want)
+			}
+			for k, v := range tt.want {
+				if inv.Args[k] != v {
+					t.Errorf("Args[%s]: got %q, want %q", k, inv.Args[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistry_Invoke(t *testing.T) {
+	reg := loadSkill(t, webReconMD)
+
+	inv, err := reg.Invoke("web-recon", map[string]string{"url": "http://a", "mode": "full"})
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if inv.Args["depth"] != "2" || inv.Args["mode"] != "full" {
+		t.Errorf("Args: %v", inv.Args)
+	}
+
+	if _, err := reg.Invoke("nope", nil); err == nil || !strings.Contains(err.Error(), "available: web-recon") {
+		t.Errorf("unknown skill error: %v", err)
+	}
+	if _, err := reg.Invoke("web-recon", map[string]string{"url": "http://a", "depth": "x"}); err == nil {
+		t.Error("type error should be reported")
+	}
+}
+
+func TestRegistry_Parse_NotSkill(t *testing.T) {
+	reg := loadSkill(t, webReconMD)
+	for _, input := range []string{"scan port 80", "/unknown x=1", "/"} {
+		inv, err := reg.Parse(input)
+		if inv != nil || err != nil {
+			t.Errorf("Parse(%q) = %v, %v; want nil, nil", input, inv, err)
+		}
+	}
+}
+
+func TestInvocation_PromptAndChecklist(t *testing.T) {
+	reg := loadSkill(t, webReconMD)
+	inv, err := reg.Parse("/web-recon http://a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vars := map[string]string{"target": "10.0.0.5"}
+
+	prompt := inv.Prompt(vars)
+	for _, want := range []string{
+		"Recon http://a (quick) on 10.0.0.5.",
+		"- depth: 2",
+		"- [ ] 1. Headers ← current",
+		"command: ffuf -u http://a/FUZZ -recursion-depth 2",
+		"success: output matches /Status: 200/",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	list := inv.Checklist(1, vars)
+	if !strings.Contains(list, "- [x] 1. Headers\n") || !strings.Contains(list, "- [ ] 2. Directories ← current") {
+		t.Errorf("Checklist(1):\n%s", list)
+	}
+	if strings.Contains(list, "curl -ik") {
+		t.Errorf("completed step should not repeat its command:\n%s", list)
+	}
+}
+
+func TestStep_Done(t *testing.T) {
+	reg := loadSkill(t, webReconMD)
+	sk, _ := reg.Get("web-recon")
+	headers, dirs := sk.Steps[0], sk.Steps[1]
+
+	if !headers.Done("curl -ik http://a", "", 0) {
+		t.Error("curl with exit 0 should complete the step")
+	}
+	if headers.Done("curl -ik http://a", "", 7) {
+		t.Error("non-zero exit should not complete a step without success pattern")
+	}
+	if headers.Done("wget http://a", "", 0) {
+		t.Error("other tool should not complete the step")
+	}
+	if !dirs.Done("sudo /usr/bin/ffuf -u http://a/FUZZ", "admin [Status: 200, Size: 10]", 1) {
+		t.Error("success pattern should decide regardless of exit code")
+	}
+	if dirs.Done("ffuf -u http://a/FUZZ", "nothing found", 0) {
+		t.Error("missing success pattern should not complete the step")
+	}
+}
+
+func TestSkill_MissingTools(t *testing.T) {
+	reg := loadSkill(t, webReconMD)
+	sk, _ := reg.Get("web-recon")
+	got := sk.MissingTools(func(name string) bool { return name == "curl" })
+	if len(got) != 1 || got[0] != "ffuf" {
+		t.Errorf("MissingTools: got %v, want [ffuf]", got)
+	}
+}
+
+func TestRegistry_LoadDir_InvalidSkillDefinition(t *testing.T) {
+	tests := map[string]string{
+		"unknown type":  "params:\n  - name: x\n    type: float\n",
+		"enum no value": "params:\n  - name: x\n    type: enum\n",
+		"bad default":   "params:\n  - name: x\n    type: int\n    default: abc\n",
+		"duplicate":     "params:\n  - name: x\n  - name: x\n",
+		"bad regex":     "steps:\n  - name: s\n    success: \"(\"\n",
+	}
+	for name, front := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "broken.md"), []byte("---\nname: broken\n"+front+"---\n\nbody"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "ok.md"), []byte("---\nname: ok\n---\n\nbody"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			reg := skills.NewRegistry()
+			// 不正なスキルは登録せずにエラーとして報告し、他のスキルはロードする
+			if err := reg.LoadDir(dir); err == nil || !strings.Contains(err.Error(), "broken.md") {
+				t.Errorf("LoadDir should report the invalid skill, got %v", err)
+			}
+			if _, ok := reg.Get("broken"); ok {
+				t.Error("invalid skill definition should be skipped")
+			}
+			if _, ok := reg.Get("ok"); !ok {
+				t.Error("valid skill should still be loaded")
+			}
+		})
+	}
+}
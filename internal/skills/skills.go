@@ -5,18 +5,39 @@
 //	---
 //	name: web-recon
 //	description: Webアプリ初期偵察
+//	params:
+//	  - name: url
+//	    type: string
+//	    required: true
+//	  - name: depth
+//	    type: int
+//	    default: 2
+//	requires: [ffuf]
+//	steps:
+//	  - name: Directory discovery
+//	    tool: ffuf
+//	    command: ffuf -u {{url}}/FUZZ -w /usr/share/wordlists/dirb/common.txt -recursion-depth {{depth}}
+//	    success: "Status: 200"
+//	subagent: false
 //	---
 //
-//	Perform web application reconnaissance...
+//	Perform web application reconnaissance on {{url}}...
 //
-// ユーザーが "/web-recon" と入力するとスキルのプロンプトが Brain のコンテキストに追加される。
+// ユーザーが "/web-recon url=http://10.0.0.5 depth=3" と入力すると、
+// パラメータを検証・展開したスキルのプロンプトが Brain のコンテキストに追加される。
+// steps はメイン Loop が順に進捗を追跡し、subagent: true のスキルは
+// SmartSubAgent タスクとしてバックグラウンド実行される。
 package skills
 
 import (
+	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 )
@@ -25,12 +46,23 @@ import (
 type Skill struct {
 	Name        string `yaml:"name"`
 	Description string `yaml:"description"`
-	// Prompt は Brain に追加注入するプロンプトテキスト（Markdown 本文）。
-	Prompt string
+	// Params はスキル呼び出し時に受け取る型付きパラメータ。
+	Params []Param `yaml:"params"`
+	// Requires はスキルの実行に必要なツール名（tools.Registry / PATH で確認する）。
+	Requires []string `yaml:"requires"`
+	// Steps は順に実行する手順。Loop が各ステップの成功条件を追跡する。
+	Steps []Step `yaml:"steps"`
+	// SubAgent が true のスキルは SmartSubAgent タスクとして実行する。
+	SubAgent bool `yaml:"subagent"`
+	// MaxTurns は SubAgent 実行時の最大ターン数（0 = デフォルト）。
+	MaxTurns int `yaml:"max_turns"`
+	// Prompt は Brain に追加注入するプロンプトテキスト（Markdown 本文、.yaml では prompt フィールド）。
+	Prompt string `yaml:"prompt"`
 }
 
 // Registry はロード済みスキルを管理する。
 type Registry struct {
+	mu     sync.RWMutex
 	skills map[string]*Skill // key: skill name（スラッシュなし）
 }
 
@@ -41,8 +73,11 @@ func NewRegistry() *Registry {
 
 // LoadDir は dir 以下の *.md ファイルをロードする（*.yaml も後方互換で対応）。
 // ディレクトリが存在しなくてもエラーにはしない。
+// パラメータ定義やステップの成功条件が不正なスキルは登録せず、残りをロードしたうえで
+// エラーをまとめて返す。frontmatter や name のないファイルはスキルではないものとして無視する。
 func (r *Registry) LoadDir(dir string) error {
-	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+	var errs []error
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
@@ -50,91 +85,169 @@ func (r *Registry) LoadDir(dir string) error {
 			return nil
 		}
 
+		var sk *Skill
 		switch {
 		case strings.HasSuffix(path, ".md"):
-			if sk := parseMDSkill(path); sk != nil {
-				r.skills[sk.Name] = sk
-			}
+			sk, err = parseMDSkill(path)
 		case strings.HasSuffix(path, ".yaml"):
-			if sk := parseYAMLSkill(path); sk != nil {
-				r.skills[sk.Name] = sk
-			}
+			sk, err = parseYAMLSkill(path)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("load %s: %w", path, err))
+		} else if sk != nil {
+			r.add(sk)
 		}
 		return nil
 	})
+	return errors.Join(errs...)
+}
+
+// add はスキルを登録する（同名は上書き）。
+func (r *Registry) add(sk *Skill) {
+	r.mu.Lock()
+	r.skills[sk.Name] = sk
+	r.mu.Unlock()
+}
+
+// Reload は dir を読み直し、全スキルをアトミックに差し替える。
+// 読み込めないスキルがあれば差し替えずにエラーを返す（ツール定義の Reload と同じ）。
+// 実行中の Invocation は読み込み済みの *Skill を保持するため影響を受けない。
+func (r *Registry) Reload(dir string) error {
+	fresh := NewRegistry()
+	if err := fresh.LoadDir(dir); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.skills = fresh.skills
+	r.mu.Unlock()
+	return nil
 }
 
 // parseMDSkill は Markdown + frontmatter 形式のスキルファイルをパースする。
-func parseMDSkill(path string) *Skill {
+// スキルでないファイルは nil, nil を、定義が不正なスキルはエラーを返す。
+func parseMDSkill(path string) (*Skill, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil
+		return nil, nil
 	}
 	content := string(data)
 
 	// frontmatter を分離（--- で囲まれた YAML 部分）
 	if !strings.HasPrefix(content, "---") {
-		return nil
+		return nil, nil
 	}
 	parts := strings.SplitN(content, "---", 3)
 	if len(parts) < 3 {
-		return nil
+		return nil, nil
 	}
 
 	var sk Skill
 	if err := yaml.Unmarshal([]byte(parts[1]), &sk); err != nil || sk.Name == "" {
-		return nil
+		return nil, nil
 	}
 	sk.Prompt = strings.TrimSpace(parts[2])
-	return &sk
+	if err := sk.compile(); err != nil {
+		return nil, err
+	}
+	return &sk, nil
 }
 
 // parseYAMLSkill は後方互換のために YAML 形式もサポートする。
-func parseYAMLSkill(path string) *Skill {
+// frontmatter と同じフィールド（params / requires / steps / subagent 等）に加えて、
+// 本文の代わりに prompt フィールドを読む。
+func parseYAMLSkill(path string) (*Skill, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil
+		return nil, nil
 	}
 
-	// YAML の prompt フィールドを含む旧形式
-	var raw struct {
-		Name        string `yaml:"name"`
-		Description string `yaml:"description"`
-		Prompt      string `yaml:"prompt"`
+	var sk Skill
+	if err := yaml.Unmarshal(data, &sk); err != nil || sk.Name == "" {
+		return nil, nil
 	}
-	if err := yaml.Unmarshal(data, &raw); err != nil || raw.Name == "" {
-		return nil
+	if err := sk.compile(); err != nil {
+		return nil, err
 	}
-	return &Skill{Name: raw.Name, Description: raw.Description, Prompt: raw.Prompt}
+	return &sk, nil
 }
 
+// compile はパラメータ定義を検証し、ステップの成功条件をコンパイルする。
+func (sk *Skill) compile() error {
+	seen := make(map[string]bool, len(sk.Params))
+	for i := range sk.Params {
+		p := &sk.Params[i]
+		if p.Name == "" || seen[p.Name] {
+			return fmt.Errorf("skill %s: invalid or duplicate param name %q", sk.Name, p.Name)
+		}
+		seen[p.Name] = true
+		if p.Type == "" {
+			p.Type = ParamString
+		}
+		switch p.Type {
+		case ParamString, ParamInt, ParamBool:
+		case ParamEnum:
+			if len(p.Values) == 0 {
+				return fmt.Errorf("skill %s: enum param %q has no values", sk.Name, p.Name)
+			}
+		default:
+			return fmt.Errorf("skill %s: param %q has unknown type %q", sk.Name, p.Name, p.Type)
+		}
+		if p.Default != "" {
+			if _, err := p.check(p.Default); err != nil {
+				return fmt.Errorf("skill %s: default of %q: %w", sk.Name, p.Name, err)
+			}
+		}
+	}
+	for i := range sk.Steps {
+		st := &sk.Steps[i]
+		if st.Success == "" {
+			continue
+		}
+		re, err := regexp.Compile(st.Success)
+		if err != nil {
+			return fmt.Errorf("skill %s: step %d success pattern: %w", sk.Name, i+1, err)
+		}
+		st.successRe = re
+	}
+	return nil
+}
+
+// MissingTools は Requires のうち has が false を返すツール名を返す。
+func (sk *Skill) MissingTools(has func(name string) bool) []string {
+	var missing []string
+	for _, name := range sk.Requires {
+		if !has(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
 
 // Get はスキル名でスキルを検索する。
 func (r *Registry) Get(name string) (*Skill, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	sk, ok := r.skills[strings.TrimPrefix(name, "/")]
 	return sk, ok
 }
 
 // Expand はユーザー入力を検査し、スキル呼び出し（/skill-name）なら
-// スキルのプロンプトを返す。通常の入力はそのまま返す。
+// パラメータを展開したスキルのプロンプトを返す。通常の入力はそのまま返す。
+// パラメータの検証に失敗した場合もそのまま返す（詳細なエラーは Parse を使う）。
 func (r *Registry) Expand(input string) string {
 	input = strings.TrimSpace(input)
-	if !strings.HasPrefix(input, "/") {
+	inv, err := r.Parse(input)
+	if err != nil || inv == nil {
+		// 未知のスラッシュコマンドはそのまま返す
 		return input
 	}
-	name := strings.TrimPrefix(input, "/")
-	// スペースで区切られた追加引数は無視（将来の拡張用）
-	name = strings.Fields(name)[0]
-
-	if sk, ok := r.skills[name]; ok {
-		return strings.TrimSpace(sk.Prompt)
-	}
-	// 未知のスラッシュコマンドはそのまま返す
-	return input
+	return inv.Prompt(nil)
 }
 
 // All は登録済みの全スキルを返す。
 func (r *Registry) All() []*Skill {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	result := make([]*Skill, 0, len(r.skills))
 	for _, sk := range r.skills {
 		result = append(result, sk)
@@ -3,6 +3,7 @@ package skills_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/0x6d61/pentecter/internal/skills"
@@ -111,6 +112,58 @@ prompt: "Test for SQL injection"
 	}
 }
 
+func TestRegistry_LoadDir_YAMLSkill_FullDefinition(t *testing.T) {
+	dir := t.TempDir()
+
+	// .md の frontmatter と同じフィールドを読む
+	yamlContent := `name: probe
+description: "Probe"
+params:
+  - name: url
+    required: true
+requires: [curl]
+steps:
+  - name: Fetch
+    tool: curl
+    command: curl -ik {{url}}
+    success: "HTTP/1\\.1 200"
+subagent: true
+max_turns: 5
+prompt: "Probe {{url}}"
+`
+	if err := os.WriteFile(filepath.Join(dir, "probe.yaml"), []byte(yamlContent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reg := skills.NewRegistry()
+	if err := reg.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	sk, ok := reg.Get("probe")
+	if !ok {
+		t.Fatal("probe skill not found")
+	}
+	if len(sk.Params) != 1 || sk.Params[0].Type != skills.ParamString || len(sk.Requires) != 1 ||
+		len(sk.Steps) != 1 || !sk.SubAgent || sk.MaxTurns != 5 || sk.Prompt != "Probe {{url}}" {
+		t.Errorf("unexpected skill: %+v", sk)
+	}
+	// compile() でステップの成功条件がコンパイルされている
+	if !sk.Steps[0].Done("curl -ik http://x/", "HTTP/1.1 200 OK", 0) {
+		t.Error("step success pattern should be compiled")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "probe.yaml"), []byte(strings.Replace(yamlContent, `success: "HTTP/1\\.1 200"`, `success: "("`, 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// 不正な定義はリロードでエラーになり、読み込み済みのスキルはそのまま残る
+	if err := reg.Reload(dir); err == nil {
+		t.Error("Reload should report the invalid YAML skill")
+	}
+	if _, ok := reg.Get("probe"); !ok {
+		t.Error("skills should be kept when reload fails")
+	}
+}
+
 func TestRegistry_LoadDir_YAMLSkill_InvalidYAML(t *testing.T) {
 	dir := t.TempDir()
 
@@ -292,3 +345,37 @@ func TestRegistry_LoadDir_MDSkill_IncompleteFrontmatter(t *testing.T) {
 		t.Errorf("expected 0 skills for incomplete frontmatter, got %d", len(all))
 	}
 }
+
+func TestRegistry_Reload(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) {
+		t.Helper()
+		md := "---\nname: " + name + "\ndescription: test\n---\n\nDo " + name + ".\n"
+		if err := os.WriteFile(filepath.Join(dir, name+".md"), []byte(md), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("alpha")
+
+	reg := skills.NewRegistry()
+	if err := reg.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "alpha.md")); err != nil {
+		t.Fatal(err)
+	}
+	write("beta")
+	if err := reg.Reload(dir); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if _, ok := reg.Get("alpha"); ok {
+		t.Error("alpha should be removed after reload")
+	}
+	if _, ok := reg.Get("beta"); !ok {
+		t.Error("beta should be loaded after reload")
+	}
+	if len(reg.All()) != 1 {
+		t.Errorf("All: got %d skills, want 1", len(reg.All()))
+	}
+}
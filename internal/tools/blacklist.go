@@ -1,29 +1,55 @@
 package tools
 
-import "regexp"
+import (
+	"regexp"
+	"sync"
+)
 
 // Blacklist はホスト実行コマンドの危険パターンを保持する。
 // Docker 実行にはチェックを省略する（隔離済みのため）。
 type Blacklist struct {
+	mu       sync.RWMutex
 	patterns []*regexp.Regexp
 }
 
 // NewBlacklist は patterns をコンパイルして Blacklist を返す。
 // 不正な正規表現はパニックではなくスキップする。
 func NewBlacklist(patterns []string) *Blacklist {
-	bl := &Blacklist{}
+	return &Blacklist{patterns: compilePatterns(patterns)}
+}
+
+// compilePatterns は正規表現をコンパイルする。不正なパターンは無視する。
+func compilePatterns(patterns []string) []*regexp.Regexp {
+	var compiled []*regexp.Regexp
 	for _, p := range patterns {
 		re, err := regexp.Compile(p)
 		if err != nil {
 			continue // 不正なパターンは無視
 		}
-		bl.patterns = append(bl.patterns, re)
+		compiled = append(compiled, re)
 	}
-	return bl
+	return compiled
+}
+
+// Replace はパターンをアトミックに差し替える（設定のホットリロード用）。
+func (b *Blacklist) Replace(patterns []string) {
+	compiled := compilePatterns(patterns)
+	b.mu.Lock()
+	b.patterns = compiled
+	b.mu.Unlock()
+}
+
+// Len は有効なパターン数を返す。
+func (b *Blacklist) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.patterns)
 }
 
 // Match は command がブラックリストのいずれかに一致するか検査する。
 func (b *Blacklist) Match(command string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 	for _, re := range b.patterns {
 		if re.MatchString(command) {
 			return true
@@ -42,3 +42,18 @@ func TestBlacklist_EmptyPatterns_NeverBlocks(t *testing.T) {
 		t.Error("empty blacklist should not block anything")
 	}
 }
+
+func TestBlacklist_Replace(t *testing.T) {
+	bl := tools.NewBlacklist([]string{`mkfs`})
+	bl.Replace([]string{`shutdown`, `reboot`})
+
+	if bl.Match("mkfs.ext4 /dev/sdb") {
+		t.Error("old pattern should be removed after Replace")
+	}
+	if !bl.Match("shutdown -h now") {
+		t.Error("new pattern should block after Replace")
+	}
+	if bl.Len() != 2 {
+		t.Errorf("Len: got %d, want 2", bl.Len())
+	}
+}
@@ -91,6 +91,24 @@ func (r *CommandRunner) resolveDocker(def *ToolDef) (useDocker bool, dockerAvail
 	return false, false
 }
 
+// LookupTool は binary 名に対応する ToolDef を返す（パーサー解決等に使用）。
+func (r *CommandRunner) LookupTool(binary string) (*ToolDef, bool) {
+	if r.registry == nil {
+		return nil, false
+	}
+	return r.registry.Get(binary)
+}
+
+// HasTool は name のツールが利用可能か（Registry に定義がある、または PATH に存在する）を返す。
+// Registry 定義のあるツールは Docker 実行の可能性があるためホストの PATH は確認しない。
+func (r *CommandRunner) HasTool(name string) bool {
+	if _, ok := r.LookupTool(name); ok {
+		return true
+	}
+	_, err := resolveBinary(name)
+	return err == nil
+}
+
 // SetAutoApprove はグローバル自動承認を切り替える。
 // true にすると、proposal_required: true が明示されたツール以外は全て自動実行される。
 func (r *CommandRunner) SetAutoApprove(v bool) {
@@ -212,7 +230,7 @@ func (r *CommandRunner) execute(
 		}
 
 		truncated := Truncate(rawTextLines, truncCfg)
-		entities := ExtractEntities(rawTextLines)
+		entities := ExtractEntitiesFrom(binary, rawTextLines)
 
 		res := &ToolResult{
 			ID:         id,
@@ -12,6 +12,10 @@ type ToolDef struct {
 	TimeoutSec  int          `yaml:"timeout"` // コンテキストタイムアウト（秒）。0 なら 300 秒
 	Output      OutputConfig `yaml:"output"`
 
+	// Parser は出力を構造化するパーサープラグイン名（例: "nikto", "hydra"）。
+	// 空の場合はコマンド名と同名のパーサーがあればそれを使う。
+	Parser string `yaml:"parser,omitempty"`
+
 	// Docker はオプションの Docker 実行設定。
 	// 設定があれば Docker コンテナ内で実行し、ホストを保護する。
 	Docker *DockerConfig `yaml:"docker,omitempty"`
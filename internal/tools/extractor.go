@@ -5,38 +5,175 @@ import (
 	"strings"
 )
 
+// entityPattern は Entity 抽出パターン1件。
+type entityPattern struct {
+	typ        EntityType
+	pattern    *regexp.Regexp
+	confidence float64
+	detail     string                  // 固定の Detail（空ならハッシュは DetectHashType で判定）
+	format     func(m []string) string // 値の組み立て（nil なら m[1] or m[0]）
+}
+
 // universalPatterns はツール種別を問わず通用する汎用抽出パターン。
 // パーサーではなく「見つかれば拾う」greedy な抽出。
-var universalPatterns = []struct {
-	typ     EntityType
-	pattern *regexp.Regexp
-}{
+// 同じ値は文脈付きの（confidence の高い）パターンを優先するため、具体的なものから並べる。
+var universalPatterns = []entityPattern{
 	{
-		EntityPort,
-		regexp.MustCompile(`\b(\d{1,5}/(tcp|udp))\s+open`),
+		typ:        EntityPort,
+		pattern:    regexp.MustCompile(`\b(\d{1,5}/(tcp|udp))\s+open`),
+		confidence: 1.0,
 	},
 	{
-		EntityCVE,
-		regexp.MustCompile(`\b(CVE-\d{4}-\d{4,})\b`),
+		typ:        EntityCVE,
+		pattern:    regexp.MustCompile(`\b(CVE-\d{4}-\d{4,})\b`),
+		confidence: 1.0,
 	},
 	{
-		EntityURL,
-		regexp.MustCompile(`https?://[^\s"'<>]+`),
+		typ:        EntityURL,
+		pattern:    regexp.MustCompile(`https?://[^\s"'<>]+`),
+		confidence: 0.9,
 	},
 	{
-		EntityIP,
+		typ: EntityIP,
 		// プライベート + グローバル IP を広く拾う（ループバックは除外）
-		regexp.MustCompile(`\b((?:(?:25[0-5]|2[0-4]\d|[01]?\d\d?)\.){3}(?:25[0-5]|2[0-4]\d|[01]?\d\d?))\b`),
+		pattern:    regexp.MustCompile(`\b((?:(?:25[0-5]|2[0-4]\d|[01]?\d\d?)\.){3}(?:25[0-5]|2[0-4]\d|[01]?\d\d?))\b`),
+		confidence: 0.8,
+	},
+
+	// --- 認証情報まわり ---
+	{
+		// secretsdump: "Administrator:500:aad3b...:31d6c...:::"
+		typ:        EntityUsername,
+		pattern:    regexp.MustCompile(`^([^:\s]+):\d+:[0-9a-fA-F]{32}:[0-9a-fA-F]{32}:::`),
+		confidence: 0.9,
+	},
+	{
+		typ:        EntityHash,
+		pattern:    regexp.MustCompile(`^[^:\s]+:\d+:[0-9a-fA-F]{32}:([0-9a-fA-F]{32}):::`),
+		confidence: 0.95,
+		detail:     "ntlm",
+	},
+	{
+		// enum4linux: "user:[bob] rid:[0x3e8]"
+		typ:        EntityUsername,
+		pattern:    regexp.MustCompile(`user:\[([^\]]+)\]\s+rid:`),
+		confidence: 0.9,
+	},
+	{
+		// hydra: "login: admin   password: secret"
+		typ:        EntityUsername,
+		pattern:    regexp.MustCompile(`\blogin:\s+(\S+)\s+password:`),
+		confidence: 0.9,
+	},
+	{
+		// /etc/passwd: "bob:x:1000:1000:Bob:/home/bob:/bin/bash"
+		typ:        EntityUsername,
+		pattern:    regexp.MustCompile(`^([a-z_][a-z0-9_-]{0,31}):[^:\s]*:\d+:\d+:[^:]*:/[^:]*:`),
+		confidence: 0.8,
+	},
+	{
+		typ:        EntityUsername,
+		pattern:    regexp.MustCompile(`(?i)\b(?:username|user)\s*[:=]\s*['"]?([A-Za-z0-9._\\$-]{2,32})`),
+		confidence: 0.5,
+	},
+	{
+		// crypt(3) 形式: $1$ / $5$ / $6$ / $2y$ / $apr1$ / $y$
+		typ:        EntityHash,
+		pattern:    regexp.MustCompile(`(\$(?:2[abxy]|1|5|6|apr1|y)\$[^\s:'"]+)`),
+		confidence: 0.9,
+	},
+	{
+		// 生の hex ダイジェスト。文脈がないため confidence は低い
+		typ:        EntityHash,
+		pattern:    regexp.MustCompile(`\b([a-fA-F0-9]{128}|[a-fA-F0-9]{64}|[a-fA-F0-9]{40}|[a-fA-F0-9]{32})\b`),
+		confidence: 0.4,
+	},
+
+	// --- 秘密情報 ---
+	{
+		typ:        EntitySecret,
+		pattern:    regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,})`),
+		confidence: 0.95,
+		detail:     "jwt",
+	},
+	{
+		typ:        EntitySecret,
+		pattern:    regexp.MustCompile(`\b(AKIA[0-9A-Z]{16})\b`),
+		confidence: 0.95,
+		detail:     "aws_access_key",
+	},
+	{
+		typ:        EntitySecret,
+		pattern:    regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36})\b`),
+		confidence: 0.95,
+		detail:     "github_token",
+	},
+	{
+		typ:        EntitySecret,
+		pattern:    regexp.MustCompile(`\b(xox[baprs]-[A-Za-z0-9-]{10,})`),
+		confidence: 0.9,
+		detail:     "slack_token",
+	},
+	{
+		typ:        EntitySecret,
+		pattern:    regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})\b`),
+		confidence: 0.9,
+		detail:     "google_api_key",
+	},
+	{
+		typ:        EntitySecret,
+		pattern:    regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret[_-]?key|access[_-]?token|auth[_-]?token)["']?\s*[:=]\s*["']?([A-Za-z0-9_\-./+=]{16,})`),
+		confidence: 0.6,
+		detail:     "api_key",
+	},
+
+	// --- ホスト・ソフトウェア・パス ---
+	{
+		typ:        EntityEmail,
+		pattern:    regexp.MustCompile(`\b([A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,})\b`),
+		confidence: 0.9,
+	},
+	{
+		typ:        EntityDomain,
+		pattern:    regexp.MustCompile(`(?i)\b((?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+(?:htb|thm|local|lan|corp|internal|com|net|org|io|dev|edu|gov|co|jp|uk|de))\b`),
+		confidence: 0.6,
+		format:     func(m []string) string { return strings.ToLower(m[1]) },
+	},
+	{
+		// "Apache httpd 2.4.49", "OpenSSH_8.2p1", "PHP/7.4.3"
+		typ:        EntitySoftware,
+		pattern:    regexp.MustCompile(`\b(Apache(?: httpd| Tomcat)?|nginx|OpenSSH|Microsoft-IIS|PHP|MySQL|MariaDB|PostgreSQL|vsftpd|ProFTPD|Samba|Tomcat|Jetty|lighttpd|Exim|Postfix|OpenSSL|WordPress|Drupal|Joomla|Node\.js|Werkzeug|Python|Jenkins|Grafana|Redis|MongoDB|Elasticsearch)[/ _]v?(\d+(?:\.\d+)+[A-Za-z0-9]*)`),
+		confidence: 0.8,
+		format:     func(m []string) string { return m[1] + " " + m[2] },
+	},
+	{
+		typ:        EntityPath,
+		pattern:    regexp.MustCompile(`(?:^|[\s'"=(\[])(/(?:etc|home|root|var|opt|usr|tmp|srv|mnt|backup|www)/[^\s'"<>;,)\]]+)`),
+		confidence: 0.6,
+	},
+	{
+		typ:        EntityPath,
+		pattern:    regexp.MustCompile(`\b([A-Za-z]:\\[^\s"'<>|]+)`),
+		confidence: 0.6,
 	},
 }
 
 // loopbackPrefixes はEntity抽出で除外するIPプレフィックス。
 var loopbackPrefixes = []string{"127.", "0.0.0.0"}
 
+// emptyLMHash は LM 無効時に secretsdump 等が出力する固定値。ハッシュとしては無意味なので除外する。
+const emptyLMHash = "aad3b435b51404eeaad3b435b51404ee"
+
 // ExtractEntities は lines から全ユニバーサルパターンに一致する Entity を抽出し、
 // 重複を除いて返す。順序は出現順。
 func ExtractEntities(lines []string) []Entity {
-	seen := make(map[string]bool)
+	return ExtractEntitiesFrom("", lines)
+}
+
+// ExtractEntitiesFrom は ExtractEntities と同じだが、抽出元ツール名を Source に記録する。
+// 同じ値が複数のパターンに一致した場合は confidence の高い方を残す。
+func ExtractEntitiesFrom(source string, lines []string) []Entity {
+	seen := make(map[string]int) // key → result のインデックス
 	var result []Entity
 
 	for _, line := range lines {
@@ -48,23 +185,41 @@ func ExtractEntities(lines []string) []Entity {
 				if len(m) > 1 && m[1] != "" {
 					val = m[1]
 				}
+				if p.format != nil {
+					val = p.format(m)
+				}
 
 				// IPのループバック除外
 				if p.typ == EntityIP && isLoopback(val) {
 					continue
 				}
+				if p.typ == EntityHash && strings.EqualFold(val, emptyLMHash) {
+					continue
+				}
+
+				detail := p.detail
+				if p.typ == EntityHash && detail == "" {
+					detail = DetectHashType(val)
+				}
+
+				e := Entity{
+					Type:       p.typ,
+					Value:      val,
+					Context:    strings.TrimSpace(line),
+					Detail:     detail,
+					Confidence: p.confidence,
+					Source:     source,
+				}
 
 				key := string(p.typ) + ":" + val
-				if seen[key] {
+				if idx, ok := seen[key]; ok {
+					if e.Confidence > result[idx].Confidence {
+						result[idx] = e
+					}
 					continue
 				}
-				seen[key] = true
-
-				result = append(result, Entity{
-					Type:    p.typ,
-					Value:   val,
-					Context: strings.TrimSpace(line),
-				})
+				seen[key] = len(result)
+				result = append(result, e)
 			}
 		}
 	}
@@ -72,6 +227,53 @@ func ExtractEntities(lines []string) []Entity {
 	return result
 }
 
+// DetectHashType はハッシュ文字列の形式から種別を推定する。
+// 生の 32 桁 hex は MD5 と NTLM を区別できないため "md5" を返す（NTLM は文脈で判定する）。
+func DetectHashType(h string) string {
+	switch {
+	case strings.HasPrefix(h, "$2a$"), strings.HasPrefix(h, "$2b$"),
+		strings.HasPrefix(h, "$2x$"), strings.HasPrefix(h, "$2y$"):
+		return "bcrypt"
+	case strings.HasPrefix(h, "$1$"):
+		return "md5crypt"
+	case strings.HasPrefix(h, "$apr1$"):
+		return "apr1"
+	case strings.HasPrefix(h, "$5$"):
+		return "sha256crypt"
+	case strings.HasPrefix(h, "$6$"):
+		return "sha512crypt"
+	case strings.HasPrefix(h, "$y$"):
+		return "yescrypt"
+	}
+	if !isHex(h) {
+		return "unknown"
+	}
+	switch len(h) {
+	case 32:
+		return "md5"
+	case 40:
+		return "sha1"
+	case 64:
+		return "sha256"
+	case 128:
+		return "sha512"
+	default:
+		return "unknown"
+	}
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
 func isLoopback(ip string) bool {
 	for _, prefix := range loopbackPrefixes {
 		if strings.HasPrefix(ip, prefix) {
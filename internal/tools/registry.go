@@ -6,12 +6,15 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Registry はロード済みツール定義を管理する。
+// Reload による実行中の差し替えに備え、defs へのアクセスは mu で保護する。
 type Registry struct {
+	mu   sync.RWMutex
 	defs map[string]*ToolDef
 }
 
@@ -50,23 +53,44 @@ func (r *Registry) loadFile(path string) error {
 	if def.Name == "" {
 		return fmt.Errorf("tool definition missing 'name' field")
 	}
+	r.mu.Lock()
 	r.defs[def.Name] = &def
+	r.mu.Unlock()
+	return nil
+}
+
+// Reload は dir を読み直し、全定義をアトミックに差し替える。
+// 読み込みに失敗した場合は既存の定義を維持してエラーを返す。
+func (r *Registry) Reload(dir string) error {
+	fresh := NewRegistry()
+	if err := fresh.LoadDir(dir); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.defs = fresh.defs
+	r.mu.Unlock()
 	return nil
 }
 
 // Register はプログラム的にToolDefを登録する（テスト・組み込みツール向け）。
 func (r *Registry) Register(def *ToolDef) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.defs[def.Name] = def
 }
 
 // Get は名前でToolDefを取得する。見つからない場合は nil, false。
 func (r *Registry) Get(name string) (*ToolDef, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	d, ok := r.defs[name]
 	return d, ok
 }
 
 // All は登録済みの全ToolDefを返す。
 func (r *Registry) All() []*ToolDef {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	result := make([]*ToolDef, 0, len(r.defs))
 	for _, d := range r.defs {
 		result = append(result, d)
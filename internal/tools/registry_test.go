@@ -47,6 +47,51 @@ output:
 	}
 }
 
+func TestRegistry_LoadDir_Parser(t *testing.T) {
+	dir := t.TempDir()
+	yaml := `
+name: nikto
+timeout: 60
+parser: nikto
+`
+	if err := os.WriteFile(filepath.Join(dir, "nikto.yaml"), []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := tools.NewRegistry()
+	if err := r.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	def, _ := r.Get("nikto")
+	if def.Parser != "nikto" {
+		t.Errorf("Parser: got %q, want nikto", def.Parser)
+	}
+
+	// CommandRunner 経由でもパーサー名を解決できる
+	runner := tools.NewCommandRunner(r, nil, nil)
+	if d, ok := runner.LookupTool("nikto"); !ok || d.Parser != "nikto" {
+		t.Errorf("LookupTool: got %+v, %v", d, ok)
+	}
+}
+
+func TestCommandRunner_HasTool(t *testing.T) {
+	r := tools.NewRegistry()
+	r.Register(&tools.ToolDef{Name: "dockeronly-scanner"})
+	runner := tools.NewCommandRunner(r, nil, nil)
+
+	// Registry 定義があれば PATH になくても利用可能
+	if !runner.HasTool("dockeronly-scanner") {
+		t.Error("registered tool should be available")
+	}
+	// 定義がなくても PATH にあれば利用可能
+	if !runner.HasTool("sh") {
+		t.Error("sh in PATH should be available")
+	}
+	if runner.HasTool("no-such-tool-xyz") {
+		t.Error("unknown tool should not be available")
+	}
+}
+
 func TestRegistry_LoadDir_NonExistentDir(t *testing.T) {
 	r := tools.NewRegistry()
 	// 存在しないディレクトリはエラーにならない（起動時の柔軟性）
@@ -79,3 +124,46 @@ func TestRegistry_All(t *testing.T) {
 		t.Errorf("All(): got %d tools, want 2", len(all))
 	}
 }
+
+func TestRegistry_Reload(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) {
+		t.Helper()
+		y := "name: " + name + "\nbinary: echo\n"
+		if err := os.WriteFile(filepath.Join(dir, name+".yaml"), []byte(y), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("old")
+
+	r := tools.NewRegistry()
+	if err := r.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+
+	// 削除されたツールは消え、追加されたツールが見える
+	if err := os.Remove(filepath.Join(dir, "old.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	write("new")
+	if err := r.Reload(dir); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if _, ok := r.Get("old"); ok {
+		t.Error("old should be removed after reload")
+	}
+	if _, ok := r.Get("new"); !ok {
+		t.Error("new should be loaded after reload")
+	}
+
+	// 壊れた YAML があれば現在の定義を維持する
+	if err := os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("name: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(dir); err == nil {
+		t.Fatal("expected error for invalid YAML")
+	}
+	if _, ok := r.Get("new"); !ok {
+		t.Error("registry should keep previous definitions on reload error")
+	}
+}
@@ -15,16 +15,30 @@ type Entity struct {
 	Type    EntityType
 	Value   string
 	Context string // 抽出元の行（参照用）
+
+	// Detail は種別ごとの付加情報（ハッシュ種別 "ntlm"、秘密情報の種類 "jwt" 等）。
+	Detail string
+	// Confidence は抽出の確からしさ（0.0〜1.0）。文脈なしの汎用パターンほど低い。
+	Confidence float64
+	// Source は抽出元のツール名（"nmap", "enum4linux" 等）。
+	Source string
 }
 
 // EntityType は発見物の種別。
 type EntityType string
 
 const (
-	EntityPort EntityType = "port"
-	EntityCVE  EntityType = "cve"
-	EntityURL  EntityType = "url"
-	EntityIP   EntityType = "ip"
+	EntityPort     EntityType = "port"
+	EntityCVE      EntityType = "cve"
+	EntityURL      EntityType = "url"
+	EntityIP       EntityType = "ip"
+	EntityDomain   EntityType = "domain"   // ドメイン名・仮想ホスト
+	EntityEmail    EntityType = "email"    // メールアドレス
+	EntityUsername EntityType = "username" // ユーザー名
+	EntityHash     EntityType = "hash"     // パスワードハッシュ（Detail にハッシュ種別）
+	EntitySoftware EntityType = "software" // ソフトウェア + バージョン（"Apache 2.4.49"）
+	EntityPath     EntityType = "path"     // ファイルパス
+	EntitySecret   EntityType = "secret"   // JWT / API キー（Detail に種類）
 )
 
 // ToolResult はツール実行の完了結果をまとめたもの。
@@ -0,0 +1,301 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+	"github.com/0x6d61/pentecter/internal/tools"
+)
+
+// completion は Tab 補完・コマンドパレットの候補1件。
+type completion struct {
+	Value string // 入力欄に入れる文字列。末尾が空白のものは引数の入力を待つ
+	Help  string // 候補の説明（インライン表示）
+}
+
+// maxCompletionHints は入力欄の上に並べる補完候補の最大数。
+const maxCompletionHints = 6
+
+// slashCommands は組み込みのスラッシュコマンド。submitInput のコマンドと揃えること。
+var slashCommands = []completion{
+	{"/model", "Switch LLM provider/model"},
+	{"/model ", "Switch the model of a route: exploit | subagent | recon | extract | target"},
+	{"/models", "Show the model of every route"},
+	{"/approve", "Toggle auto-approve"},
+	{"/targets", "Select the active target"},
+	{"/target ", "Add a target host"},
+	{"/recontree", "Print the recon tree of the active target"},
+	{"/skip-recon", "Unlock the RECON phase for the active target"},
+	{"/panel ", "Side panel: recon | findings | tasks | off"},
+	{"/tasks", "List subtasks of the active target"},
+	{"/task ", "Subtask: <id> | msg <id> <text> | kill <id>"},
+	{"/search ", "Search the session log"},
+	{"/filter ", "Filter the log: all | commands | ai | failures"},
+	{"/turn ", "Jump to a turn number"},
+	{"/export ", "Export the log: md | cast [path]"},
+	{"/kb ", "Open a knowledge base file (source:path)"},
+	{"/mcp", "MCP server status"},
+	{"/mcp ", "MCP: resources | prompts | read | prompt"},
+	{"/reload", "Reload tools, skills, blacklist and MCP servers"},
+}
+
+// commandArgs は固定の引数を持つコマンドの第1引数の候補。
+var commandArgs = map[string][]completion{
+	"/panel": {
+		{"recon", "Recon tree"},
+		{"findings", "Findings by severity"},
+		{"tasks", "Subtasks"},
+		{"off", "Hide the panel"},
+	},
+	"/filter": {
+		{"all", "Show every block"},
+		{"commands", "Command blocks only"},
+		{"ai", "AI messages only"},
+		{"failures", "Failed commands and errors"},
+	},
+	"/export": {
+		{"md", "Markdown"},
+		{"cast", "asciinema v2 cast file"},
+	},
+	"/mcp": {
+		{"resources", "List resources [server]"},
+		{"prompts", "List prompts [server]"},
+		{"read", "Read a resource <server> <uri>"},
+		{"prompt", "Expand a prompt <server> <name> [k=v...]"},
+	},
+	"/model": {
+		{"exploit", "Main agent after a vulnerability is recorded"},
+		{"subagent", "SubAgents (spawn_task, skills)"},
+		{"recon", "Recon SubAgents"},
+		{"extract", "Target extraction from chat"},
+		{"target", "Active target only"},
+	},
+	"/task": {
+		{"msg", "Send a message <id> <text>"},
+		{"kill", "Cancel a subtask <id>"},
+	},
+}
+
+// splitLastWord は入力を最後の単語とそれより前（末尾の空白込み）に分ける。
+func splitLastWord(input string) (head, word string) {
+	i := strings.LastIndexAny(input, " \t\n")
+	return input[:i+1], input[i+1:]
+}
+
+// completions は入力の最後の単語を補完する候補を返す。Value は入力全体を置き換える文字列。
+// 先頭の単語はスラッシュコマンドとスキル、コマンドの引数はその種類に応じた候補、
+// それ以外の単語はターゲットのホストとナレッジのパスを補完する。
+func (m *Model) completions(input string) []completion {
+	head, word := splitLastWord(input)
+	fields := strings.Fields(head)
+
+	var cands []completion
+	switch {
+	case len(fields) == 0 && strings.HasPrefix(word, "/"):
+		cands = m.commandCompletions()
+	case len(fields) > 0 && strings.HasPrefix(fields[0], "/"):
+		cands = m.argCompletions(fields, word)
+	default:
+		cands = m.hostCompletions(false)
+		cands = append(cands, m.knowledgeCompletions(word)...)
+	}
+
+	var out []completion
+	for _, c := range cands {
+		if strings.HasPrefix(c.Value, word) && c.Value != word {
+			out = append(out, completion{Value: head + c.Value, Help: c.Help})
+		}
+	}
+	return out
+}
+
+// commandCompletions は組み込みコマンドとロード済みスキルを返す。
+func (m *Model) commandCompletions() []completion {
+	cands := append([]completion(nil), slashCommands...)
+	if m.Skills == nil {
+		return cands
+	}
+	all := m.Skills.All()
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	for _, sk := range all {
+		value := "/" + sk.Name
+		if len(sk.Params) > 0 {
+			value += " "
+		}
+		help := sk.Description
+		if sk.SubAgent {
+			help += " (subagent)"
+		}
+		cands = append(cands, completion{Value: value, Help: help})
+	}
+	return cands
+}
+
+// argCompletions はコマンド（fields[0]）の引数の候補を返す。
+// fields は入力済みの単語、word は補完中の単語。
+func (m *Model) argCompletions(fields []string, word string) []completion {
+	cmd := fields[0]
+	switch cmd {
+	case "/target":
+		return m.hostCompletions(true)
+	case "/task":
+		if len(fields) == 1 {
+			return append(append([]completion(nil), commandArgs[cmd]...), m.taskCompletions()...)
+		}
+		if len(fields) == 2 && (fields[1] == "msg" || fields[1] == "kill") {
+			return m.taskCompletions()
+		}
+	case "/mcp":
+		if len(fields) == 1 {
+			return commandArgs[cmd]
+		}
+		if len(fields) == 2 && m.MCPManager != nil {
+			var cands []completion
+			for _, st := range m.MCPManager.Status() {
+				cands = append(cands, completion{Value: st.Name, Help: fmt.Sprintf("%s, %d tools", st.Status, st.Tools)})
+			}
+			return cands
+		}
+	case "/kb":
+		if len(fields) == 1 {
+			return m.knowledgeCompletions(word)
+		}
+	default:
+		if args, ok := commandArgs[cmd]; ok && len(fields) == 1 {
+			return args
+		}
+		return m.skillArgCompletions(cmd)
+	}
+	return nil
+}
+
+// skillArgCompletions はスキル呼び出しの key= 引数の候補を返す。
+func (m *Model) skillArgCompletions(cmd string) []completion {
+	if m.Skills == nil {
+		return nil
+	}
+	sk, ok := m.Skills.Get(cmd)
+	if !ok {
+		return nil
+	}
+	cands := make([]completion, 0, len(sk.Params))
+	for _, p := range sk.Params {
+		help := p.Description
+		if p.Required {
+			help = "(required) " + help
+		}
+		cands = append(cands, completion{Value: p.Name + "=", Help: help})
+	}
+	return cands
+}
+
+// taskCompletions はアクティブターゲットのサブタスク ID を返す。
+func (m *Model) taskCompletions() []completion {
+	t := m.activeTarget()
+	if t == nil {
+		return nil
+	}
+	var cands []completion
+	for _, task := range m.targetTasks(t.ID) {
+		cands = append(cands, completion{Value: task.ID, Help: taskLine(task)})
+	}
+	return cands
+}
+
+// hostCompletions はターゲットのホストと、ツール出力から見つかった IP・ドメインを返す。
+// onlyNew が true なら既存ターゲットを除く（/target 用）。
+func (m *Model) hostCompletions(onlyNew bool) []completion {
+	seen := make(map[string]bool)
+	var cands []completion
+	for _, t := range m.targets {
+		seen[t.Host] = true
+		if !onlyNew {
+			cands = append(cands, completion{Value: t.Host, Help: fmt.Sprintf("target [%s]", t.GetStatus())})
+		}
+	}
+	for _, t := range m.targets {
+		for _, e := range t.SnapshotEntities() {
+			if (e.Type != tools.EntityIP && e.Type != tools.EntityDomain) || seen[e.Value] {
+				continue
+			}
+			seen[e.Value] = true
+			cands = append(cands, completion{Value: e.Value, Help: discoveredHelp(t, e)})
+		}
+	}
+	return cands
+}
+
+// discoveredHelp は発見済みホストの説明（どのターゲットのどのツールで見つけたか）を返す。
+func discoveredHelp(t *agent.Target, e tools.Entity) string {
+	if e.Source != "" {
+		return fmt.Sprintf("%s seen on %s (%s)", e.Type, t.Host, e.Source)
+	}
+	return fmt.Sprintf("%s seen on %s", e.Type, t.Host)
+}
+
+// knowledgeCompletions は "source:path" 形式のナレッジのパス候補を返す。
+func (m *Model) knowledgeCompletions(word string) []completion {
+	var cands []completion
+	for _, p := range m.Knowledge.CompletePaths(word) {
+		help := "knowledge file"
+		switch {
+		case strings.HasSuffix(p, ":"):
+			help = "knowledge source"
+		case strings.HasSuffix(p, "/"):
+			help = "knowledge directory"
+		}
+		cands = append(cands, completion{Value: p, Help: help})
+	}
+	return cands
+}
+
+// completeInput は Tab で入力欄の最後の単語を補完する。
+// 候補が1つなら確定し、複数なら共通部分まで補完して候補を入力欄の上に表示する。
+func (m *Model) completeInput() {
+	cands := m.completions(m.input.Value())
+	m.completionHints = nil
+	switch len(cands) {
+	case 0:
+		return
+	case 1:
+		value := cands[0].Value
+		if !strings.HasSuffix(value, " ") && !strings.HasSuffix(value, "/") &&
+			!strings.HasSuffix(value, ":") && !strings.HasSuffix(value, "=") {
+			value += " "
+		}
+		m.input.SetValue(value)
+		return
+	}
+	prefix := cands[0].Value
+	for _, c := range cands[1:] {
+		for !strings.HasPrefix(c.Value, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(prefix) > len(m.input.Value()) {
+		m.input.SetValue(prefix)
+	}
+	m.completionHints = cands
+}
+
+// renderCompletionHints は複数の補完候補を説明付きで返す（候補がなければ空文字列）。
+func (m Model) renderCompletionHints() string {
+	if len(m.completionHints) == 0 {
+		return ""
+	}
+	helpStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	var lines []string
+	for i, c := range m.completionHints {
+		if i == maxCompletionHints {
+			lines = append(lines, helpStyle.Render(fmt.Sprintf("  … +%d more", len(m.completionHints)-i)))
+			break
+		}
+		_, word := splitLastWord(strings.TrimRight(c.Value, " "))
+		lines = append(lines, fmt.Sprintf("  %-20s %s", word, helpStyle.Render(c.Help)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,171 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+	"github.com/0x6d61/pentecter/internal/knowledge"
+	"github.com/0x6d61/pentecter/internal/skills"
+	"github.com/0x6d61/pentecter/internal/tools"
+)
+
+const webReconSkill = `---
+name: web-recon
+description: "Web app initial recon"
+params:
+  - name: url
+    type: string
+    required: true
+    description: "Base URL"
+  - name: depth
+    type: int
+---
+
+Recon {{url}}.
+`
+
+// newCompleteTestModel はスキル・ナレッジ・サブタスク・発見済みホストを持つ Model を返す。
+func newCompleteTestModel(t *testing.T) Model {
+	t.Helper()
+	skillDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(skillDir, "web-recon.md"), []byte(webReconSkill), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reg := skills.NewRegistry()
+	if err := reg.LoadDir(skillDir); err != nil {
+		t.Fatal(err)
+	}
+	kbDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(kbDir, "pentesting-web"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(kbDir, "pentesting-web", "sql-injection.md"), []byte("# SQL injection\n\nUse sqlmap.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newPanelTestModel(120)
+	m.Skills = reg
+	m.Knowledge = knowledge.NewLibrary().Add("hacktricks", knowledge.NewStore(kbDir))
+	m.team = agent.NewTeam(agent.TeamConfig{Events: make(chan agent.Event, 10)})
+	injectSubTask(&m, "task-1", "web recon port 80")
+	m.targets[0].AddEntities([]tools.Entity{{Type: tools.EntityIP, Value: "10.0.0.9", Source: "nmap"}})
+	return m
+}
+
+// tab は入力欄に文字列を入れて Tab を押した後の入力を返す。
+func tab(m *Model, input string) string {
+	m.focus = FocusInput
+	m.input.SetValue(input)
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	*m = result.(Model)
+	return m.input.Value()
+}
+
+func TestComplete_Tab(t *testing.T) {
+	m := newCompleteTestModel(t)
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"/recon", "/recontree "},
+		{"/skip", "/skip-recon "},
+		{"/web", "/web-recon "},
+		{"/web-recon u", "/web-recon url="},
+		{"/task kill t", "/task kill task-1 "},
+		{"/target 10", "/target 10.0.0.9 "},
+		{"/filter fa", "/filter failures "},
+		{"/kb hack", "/kb hacktricks:"},
+		{"/kb hacktricks:pen", "/kb hacktricks:pentesting-web/"},
+		{"/kb hacktricks:pentesting-web/s", "/kb hacktricks:pentesting-web/sql-injection.md "},
+		{"scan 10.0.0.5", "scan 10.0.0.5"}, // 完全一致は補完しない
+		{"scan 10.0.0.", "scan 10.0.0."},   // 共通部分以上は進まない
+		{"/nope", "/nope"},
+	}
+	for _, tt := range tests {
+		if got := tab(&m, tt.input); got != tt.want {
+			t.Errorf("Tab on %q = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestComplete_MultipleCandidatesShowHelp(t *testing.T) {
+	m := newCompleteTestModel(t)
+
+	if got := tab(&m, "/ta"); got != "/ta" {
+		t.Errorf("ambiguous prefix should stay at the common part, got %q", got)
+	}
+	hints := stripANSI(m.renderInputBar())
+	for _, want := range []string{"/targets", "Select the active target", "/task", "Subtask: <id>"} {
+		if !strings.Contains(hints, want) {
+			t.Errorf("hints should contain %q:\n%s", want, hints)
+		}
+	}
+
+	// 別のキーで候補は消える
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = result.(Model)
+	if len(m.completionHints) != 0 {
+		t.Error("hints should be cleared on the next key")
+	}
+
+	// 入力が空なら Tab はフォーカス移動のまま
+	m.input.SetValue("")
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = result.(Model)
+	if m.focus != FocusViewport {
+		t.Errorf("Tab on empty input should cycle focus, got %d", m.focus)
+	}
+}
+
+func TestPalette_FilterAndRun(t *testing.T) {
+	m := newCompleteTestModel(t)
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = result.(Model)
+	if m.inputMode != InputPalette {
+		t.Fatal("Ctrl+P should open the palette")
+	}
+	for _, r := range "rectree" {
+		result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = result.(Model)
+	}
+	matches := m.paletteMatches()
+	if len(matches) == 0 || matches[0].Value != "/recontree" {
+		t.Fatalf("fuzzy query should match /recontree first: %+v", matches)
+	}
+	if !strings.Contains(stripANSI(m.View()), "Print the recon tree") {
+		t.Error("palette should show help text")
+	}
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(Model)
+	if m.inputMode != InputNormal {
+		t.Error("Enter should close the palette")
+	}
+	if !strings.Contains(m.targets[0].LastBlock().SystemMsg, "80/http") {
+		t.Errorf("/recontree should have run, got %+v", m.targets[0].LastBlock())
+	}
+
+	// 引数が必要な項目は入力欄に入れて待つ
+	m.openPalette()
+	m.paletteQuery = "web-recon"
+	m.handlePaletteKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.input.Value() != "/web-recon " || m.focus != FocusInput {
+		t.Errorf("skill with params should be inserted for editing, got %q", m.input.Value())
+	}
+
+	m.openPalette()
+	m.paletteQuery = "zzzz"
+	if !strings.Contains(stripANSI(m.renderPaletteBar()), "No matches") {
+		t.Error("empty result should be shown")
+	}
+	m.handlePaletteKey(tea.KeyMsg{Type: tea.KeyEscape})
+	if m.inputMode != InputNormal {
+		t.Error("Esc should close the palette")
+	}
+}
@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// キー割り当てのアクション名（config の tui.keys のキー）。
+const (
+	actionApprove     = "approve"      // Proposal を承認
+	actionReject      = "reject"       // Proposal を拒否
+	actionEdit        = "edit"         // Proposal のコマンドを入力欄で編集
+	actionFocusNext   = "focus_next"   // ペインのフォーカス移動（入力中は補完）
+	actionToggleLogs  = "toggle_logs"  // ログの折りたたみ切り替え
+	actionTogglePanel = "toggle_panel" // サイドパネルの表示切り替え
+	actionPalette     = "palette"      // コマンドパレット
+	actionBack        = "back"         // ドリルダウン・アタッチから戻る
+	actionSearch      = "search"       // ログ検索（Log ペイン）
+	actionNextMatch   = "next_match"   // 次の検索結果（Log ペイン）
+	actionPrevMatch   = "prev_match"   // 前の検索結果（Log ペイン）
+	actionKillTask    = "kill_task"    // サブタスクの kill（Tasks タブ）
+)
+
+// defaultKeys はアクションごとの既定のキー。キー名は tea.KeyMsg.String() の表記。
+var defaultKeys = map[string][]string{
+	actionApprove:     {"y", "Y"},
+	actionReject:      {"n", "N"},
+	actionEdit:        {"e", "E"},
+	actionFocusNext:   {"tab"},
+	actionToggleLogs:  {"ctrl+o"},
+	actionTogglePanel: {"ctrl+t"},
+	actionPalette:     {"ctrl+p"},
+	actionBack:        {"esc"},
+	actionSearch:      {"/"},
+	actionNextMatch:   {"]"},
+	actionPrevMatch:   {"["},
+	actionKillTask:    {"x"},
+}
+
+// globalActions はどのペインでも効くアクション。互いに同じキーを割り当てられない。
+var globalActions = []string{
+	actionApprove, actionReject, actionEdit, actionFocusNext,
+	actionToggleLogs, actionTogglePanel, actionPalette, actionBack,
+}
+
+// reservedKeys は再割り当てできないキー（終了確認）。
+var reservedKeys = map[string]bool{"ctrl+c": true}
+
+// KeyMap はアクションとキーの対応。ゼロ値は既定の割り当てとして振る舞う。
+type KeyMap struct {
+	keys map[string][]string
+}
+
+// DefaultKeyMap は既定のキー割り当てを返す。
+func DefaultKeyMap() KeyMap {
+	keys := make(map[string][]string, len(defaultKeys))
+	for action, k := range defaultKeys {
+		keys[action] = append([]string(nil), k...)
+	}
+	return KeyMap{keys: keys}
+}
+
+// NewKeyMap は既定の割り当てを overrides（アクション → キー）で置き換えた KeyMap を返す。
+// 未知のアクション・空の割り当て・予約キー・グローバルなアクション同士の重複はエラーにし、
+// その場合は既定の割り当てを返す。
+func NewKeyMap(overrides map[string][]string) (KeyMap, error) {
+	km := DefaultKeyMap()
+	for action, keys := range overrides {
+		if _, ok := defaultKeys[action]; !ok {
+			return DefaultKeyMap(), fmt.Errorf("tui: unknown key action %q (available: %s)", action, strings.Join(actionNames(), ", "))
+		}
+		if len(keys) == 0 {
+			return DefaultKeyMap(), fmt.Errorf("tui: no key bound to %q", action)
+		}
+		for _, k := range keys {
+			if reservedKeys[k] {
+				return DefaultKeyMap(), fmt.Errorf("tui: %q is reserved and cannot be bound to %q", k, action)
+			}
+		}
+		km.keys[action] = append([]string(nil), keys...)
+	}
+
+	owner := make(map[string]string)
+	for _, action := range globalActions {
+		for _, k := range km.keys[action] {
+			if prev, dup := owner[k]; dup {
+				return DefaultKeyMap(), fmt.Errorf("tui: key %q is bound to both %q and %q", k, prev, action)
+			}
+			owner[k] = action
+		}
+	}
+	return km, nil
+}
+
+// actionNames はアクション名を名前順で返す。
+func actionNames() []string {
+	names := make([]string, 0, len(defaultKeys))
+	for action := range defaultKeys {
+		names = append(names, action)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bound はアクションに割り当てられたキーを返す。
+func (k KeyMap) bound(action string) []string {
+	if keys, ok := k.keys[action]; ok {
+		return keys
+	}
+	return defaultKeys[action]
+}
+
+// matches はキーがアクションに割り当てられているかを返す。
+func (k KeyMap) matches(key, action string) bool {
+	for _, b := range k.bound(action) {
+		if b == key {
+			return true
+		}
+	}
+	return false
+}
+
+// label はヒント表示用にアクションの最初のキーを返す（例: "y", "Ctrl+T"）。
+func (k KeyMap) label(action string) string {
+	keys := k.bound(action)
+	if len(keys) == 0 {
+		return "?"
+	}
+	key := keys[0]
+	if rest, ok := strings.CutPrefix(key, "ctrl+"); ok {
+		return "Ctrl+" + strings.ToUpper(rest)
+	}
+	if rest, ok := strings.CutPrefix(key, "alt+"); ok {
+		return "Alt+" + rest
+	}
+	switch key {
+	case "esc":
+		return "Esc"
+	case "tab":
+		return "Tab"
+	}
+	return key
+}
@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+)
+
+func TestNewKeyMap_Validation(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string][]string
+		wantErr   string
+	}{
+		{"unknown action", map[string][]string{"launch": {"l"}}, `unknown key action "launch"`},
+		{"empty binding", map[string][]string{actionApprove: {}}, "no key bound"},
+		{"reserved key", map[string][]string{actionPalette: {"ctrl+c"}}, "reserved"},
+		{"global conflict", map[string][]string{actionApprove: {"n"}}, `bound to both`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := NewKeyMap(tt.overrides)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if !km.matches("y", actionApprove) {
+				t.Error("invalid config should fall back to the default bindings")
+			}
+		})
+	}
+
+	// ペイン固有のアクションはグローバルなキーと重なってもよい
+	km, err := NewKeyMap(map[string][]string{actionKillTask: {"n", "delete"}})
+	if err != nil {
+		t.Fatalf("pane-local binding should be allowed: %v", err)
+	}
+	if !km.matches("delete", actionKillTask) || km.matches("x", actionKillTask) {
+		t.Error("override should replace the default keys")
+	}
+}
+
+func TestKeyMap_RemappedProposalKeys(t *testing.T) {
+	t1 := agent.NewTarget(1, "10.0.0.1")
+	m := NewWithTargets([]*agent.Target{t1})
+	m.handleResize(120, 40)
+	m.ready = true
+	keys, err := NewKeyMap(map[string][]string{actionApprove: {"a"}, actionTogglePanel: {"f2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Keys = keys
+	approveCh := make(chan bool, 1)
+	m.agentApproveMap[t1.ID] = approveCh
+	t1.SetProposal(&agent.Proposal{Description: "Run nmap scan", Tool: "nmap", Args: []string{"-sV", "10.0.0.1"}})
+	m.rebuildViewport()
+
+	if !strings.Contains(stripANSI(m.viewport.View()), "[a] Approve  [n] Reject") {
+		t.Errorf("proposal hint should show the remapped key:\n%s", stripANSI(m.viewport.View()))
+	}
+
+	// 旧キーは承認しない（入力欄に入る）
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = result.(Model)
+	if t1.GetProposal() == nil {
+		t.Fatal("'y' should no longer approve")
+	}
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m = result.(Model)
+	if t1.GetProposal() != nil || len(approveCh) != 1 {
+		t.Error("'a' should approve the proposal")
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyF2})
+	m = result.(Model)
+	if !m.panelVisible {
+		t.Error("F2 should toggle the panel")
+	}
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = result.(Model)
+	if !m.panelVisible {
+		t.Error("Ctrl+T should no longer toggle the panel")
+	}
+}
+
+func TestSetTheme(t *testing.T) {
+	t.Cleanup(func() { _ = SetTheme("", false) })
+
+	if err := SetTheme("solarized", false); err == nil || !strings.Contains(err.Error(), "high-contrast") {
+		t.Errorf("unknown theme should list the available themes, got %v", err)
+	}
+	if err := SetTheme("light", false); err != nil {
+		t.Fatal(err)
+	}
+	if colorPrimary != themes["light"].Primary || glamourStyle != "light" {
+		t.Errorf("light theme not applied: primary=%s glamour=%s", colorPrimary, glamourStyle)
+	}
+	if err := SetTheme("dark", true); err != nil {
+		t.Fatal(err)
+	}
+	if colorPrimary != "" || colorBorder != "" || glamourStyle != "notty" {
+		t.Errorf("no-color should clear the palette: primary=%q border=%q glamour=%s", colorPrimary, colorBorder, glamourStyle)
+	}
+	if err := SetTheme("", false); err != nil || colorPrimary != themes["dark"].Primary {
+		t.Errorf("empty theme should default to dark (err=%v)", err)
+	}
+}
@@ -0,0 +1,169 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+)
+
+// exportUsage は /export コマンドの使い方。
+const exportUsage = "Usage: /export md|cast [path]"
+
+// handleExportCommand は /export md|cast [path] を処理する。
+// 現在のフィルターを通過したブロックを Markdown または asciinema v2 形式で書き出す。
+func (m *Model) handleExportCommand(args []string) {
+	t := m.activeTarget()
+	if t == nil {
+		m.logSystem("No target selected.")
+		return
+	}
+	if len(args) == 0 || len(args) > 2 || (args[0] != "md" && args[0] != "cast") {
+		m.logSystem(exportUsage)
+		return
+	}
+	format := args[0]
+	now := time.Now()
+	path := exportFileName(t.Host, format, now)
+	if len(args) == 2 {
+		path = args[1]
+	}
+
+	var blocks []*agent.DisplayBlock
+	for _, b := range t.Blocks {
+		if m.logFilter.match(b) {
+			blocks = append(blocks, b)
+		}
+	}
+
+	var data string
+	if format == "md" {
+		data = exportMarkdown(t.Host, blocks, now)
+	} else {
+		width, height := m.viewport.Width, m.viewport.Height
+		if width <= 0 {
+			width = 80
+		}
+		if height <= 0 {
+			height = 24
+		}
+		var err error
+		data, err = exportCast(t.Host, blocks, width, height, func(b *agent.DisplayBlock) string {
+			return renderBlocks([]*agent.DisplayBlock{b}, width, true, "")
+		})
+		if err != nil {
+			m.logSystem(fmt.Sprintf("Export failed: %v", err))
+			return
+		}
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		m.logSystem(fmt.Sprintf("Export failed: %v", err))
+		return
+	}
+	m.logSystem(fmt.Sprintf("Exported %d blocks to %s", len(blocks), path))
+}
+
+// exportFileName は既定の出力先（例: "pentecter-10.0.0.5-20260101-120000.md"）を返す。
+func exportFileName(host, format string, now time.Time) string {
+	safe := strings.NewReplacer("/", "_", ":", "_", "\\", "_").Replace(host)
+	return fmt.Sprintf("pentecter-%s-%s.%s", safe, now.Format("20060102-150405"), format)
+}
+
+// exportMarkdown はブロック列をターンごとの見出し付き Markdown に変換する。
+func exportMarkdown(host string, blocks []*agent.DisplayBlock, now time.Time) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Session log: %s\n\nExported: %s\n", host, now.Format(time.RFC3339))
+
+	turn := -1
+	for _, b := range blocks {
+		if b.Turn != turn {
+			turn = b.Turn
+			if turn == 0 {
+				sb.WriteString("\n## Setup\n")
+			} else {
+				fmt.Fprintf(&sb, "\n## Turn %d\n", turn)
+			}
+		}
+		sb.WriteString("\n")
+		switch b.Type {
+		case agent.BlockCommand:
+			status := "running"
+			if b.Completed {
+				status = fmt.Sprintf("exit %d, %s", b.ExitCode, formatDuration(b.Duration))
+			}
+			fmt.Fprintf(&sb, "**$ %s** (%s)\n", b.Command, status)
+			if len(b.Output) > 0 {
+				fmt.Fprintf(&sb, "\n```text\n%s\n```\n", strings.Join(b.Output, "\n"))
+			}
+		case agent.BlockThinking:
+			if b.ThinkingDone {
+				fmt.Fprintf(&sb, "_Thinking (%s)_\n", formatDuration(b.ThinkDuration))
+			} else {
+				sb.WriteString("_Thinking..._\n")
+			}
+		case agent.BlockAIMessage:
+			sb.WriteString(strings.TrimRight(b.Message, "\n") + "\n")
+		case agent.BlockMemory:
+			fmt.Fprintf(&sb, "- **[%s]** %s\n", strings.ToUpper(b.Severity), b.Title)
+		case agent.BlockSubTask:
+			fmt.Fprintf(&sb, "- Subtask %s: %s\n", b.TaskID, b.TaskGoal)
+		case agent.BlockUserInput:
+			sb.WriteString("> " + strings.ReplaceAll(b.UserText, "\n", "\n> ") + "\n")
+		case agent.BlockSystem:
+			fmt.Fprintf(&sb, "`SYSTEM` %s\n", b.SystemMsg)
+		}
+	}
+	return sb.String()
+}
+
+// castHeader は asciinema v2 形式のヘッダー行。
+type castHeader struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title"`
+}
+
+// exportCast はブロック列を asciinema v2 形式（ヘッダー + [秒, "o", 出力] の JSON Lines）に変換する。
+// 各ブロックは作成時刻のオフセットで出力され、render が返す端末表示をそのまま再生する。
+func exportCast(host string, blocks []*agent.DisplayBlock, width, height int, render func(*agent.DisplayBlock) string) (string, error) {
+	var start time.Time
+	if len(blocks) > 0 {
+		start = blocks[0].CreatedAt
+	}
+	header, err := json.Marshal(castHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: start.Unix(),
+		Title:     "pentecter " + host,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.Write(header)
+	sb.WriteString("\n")
+	last := 0.0
+	for _, b := range blocks {
+		// 時刻が前後しても再生が巻き戻らないようにする
+		offset := b.CreatedAt.Sub(start).Seconds()
+		if offset < last {
+			offset = last
+		}
+		last = offset
+		text := strings.ReplaceAll(render(b), "\n", "\r\n")
+		event, err := json.Marshal([]any{float64(int(offset*1000)) / 1000, "o", text})
+		if err != nil {
+			return "", err
+		}
+		sb.Write(event)
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+)
+
+func TestExportMarkdown(t *testing.T) {
+	cmd := agent.NewCommandBlock("nmap -sV 10.0.0.5")
+	cmd.Output = []string{"80/tcp open http"}
+	cmd.Completed = true
+	cmd.Duration = 3 * time.Second
+	cmd.Turn = 1
+	msg := agent.NewAIMessageBlock("Apache 2.4.49 is **vulnerable**")
+	msg.Turn = 2
+	user := agent.NewUserInputBlock("scan udp too")
+
+	md := exportMarkdown("10.0.0.5", []*agent.DisplayBlock{user, cmd, msg}, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	for _, want := range []string{
+		"# Session log: 10.0.0.5",
+		"## Setup\n\n> scan udp too",
+		"## Turn 1\n\n**$ nmap -sV 10.0.0.5** (exit 0, 3s)\n\n```text\n80/tcp open http\n```",
+		"## Turn 2\n\nApache 2.4.49 is **vulnerable**",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown should contain %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestExportCast(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := agent.NewSystemBlock("first\nline")
+	a.CreatedAt = start
+	b := agent.NewSystemBlock("second")
+	b.CreatedAt = start.Add(1500 * time.Millisecond)
+
+	cast, err := exportCast("10.0.0.5", []*agent.DisplayBlock{a, b}, 100, 30, func(b *agent.DisplayBlock) string {
+		return b.SystemMsg + "\n"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(cast), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header + 2 events, got %d lines:\n%s", len(lines), cast)
+	}
+	var header castHeader
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != 100 || header.Height != 30 || header.Timestamp != start.Unix() {
+		t.Errorf("unexpected header: %+v", header)
+	}
+	var event []any
+	if err := json.Unmarshal([]byte(lines[2]), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event[0] != 1.5 || event[1] != "o" || event[2] != "second\r\n" {
+		t.Errorf("unexpected event: %v", event)
+	}
+	if !strings.Contains(lines[1], `"first\r\nline\r\n"`) {
+		t.Errorf("newlines should be converted to CRLF: %s", lines[1])
+	}
+}
+
+func TestExportCommand_WritesFile(t *testing.T) {
+	m := newPanelTestModel(120)
+	m.targets[0].AddBlock(agent.NewAIMessageBlock("found sqli"))
+	path := filepath.Join(t.TempDir(), "log.md")
+
+	m.input.SetValue("/export md " + path)
+	m.submitInput()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("export should write the file: %v", err)
+	}
+	if !strings.Contains(string(data), "found sqli") {
+		t.Errorf("exported markdown missing block:\n%s", data)
+	}
+	if !strings.Contains(m.targets[0].LastBlock().SystemMsg, "Exported 1 blocks to "+path) {
+		t.Errorf("export should be reported, got %q", m.targets[0].LastBlock().SystemMsg)
+	}
+
+	m.input.SetValue("/export pdf")
+	m.submitInput()
+	if !strings.Contains(m.targets[0].LastBlock().SystemMsg, exportUsage) {
+		t.Error("unknown format should show usage")
+	}
+}
@@ -0,0 +1,249 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+)
+
+// logFilter はセッションログに表示するブロック種別の絞り込み。
+type logFilter int
+
+const (
+	filterAll      logFilter = iota // すべてのブロック
+	filterCommands                  // コマンド実行のみ
+	filterAI                        // AI メッセージのみ
+	filterFailures                  // 失敗したコマンドとエラーのみ
+)
+
+// logFilterNames は /filter の引数と logFilter の対応。
+var logFilterNames = []string{"all", "commands", "ai", "failures"}
+
+func (f logFilter) String() string {
+	if int(f) < len(logFilterNames) {
+		return logFilterNames[f]
+	}
+	return "?"
+}
+
+// match はブロックがフィルターを通過するかを返す。
+func (f logFilter) match(b *agent.DisplayBlock) bool {
+	switch f {
+	case filterCommands:
+		return b.Type == agent.BlockCommand
+	case filterAI:
+		return b.Type == agent.BlockAIMessage
+	case filterFailures:
+		if b.Type == agent.BlockCommand {
+			return b.Completed && b.ExitCode != 0
+		}
+		return b.Type == agent.BlockSystem && strings.HasPrefix(b.SystemMsg, "❌")
+	default:
+		return true
+	}
+}
+
+// filterUsage は /filter コマンドの使い方。
+const filterUsage = "Usage: /filter all|commands|ai|failures"
+
+// sgrRe はハイライト前に取り除く SGR エスケープシーケンス。
+var sgrRe = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// blockText は検索対象となるブロックの平文を返す。
+func blockText(b *agent.DisplayBlock) string {
+	switch b.Type {
+	case agent.BlockCommand:
+		return b.Command + "\n" + strings.Join(b.Output, "\n")
+	case agent.BlockThinking:
+		return b.ThoughtPreview
+	case agent.BlockAIMessage:
+		return b.Message
+	case agent.BlockMemory:
+		return b.Severity + " " + b.Title
+	case agent.BlockSubTask:
+		return b.TaskID + " " + b.TaskGoal
+	case agent.BlockUserInput:
+		return b.UserText
+	case agent.BlockSystem:
+		return b.SystemMsg
+	}
+	return ""
+}
+
+// renderLog はフィルターを適用してブロックを描画し、検索語をハイライトする。
+// 各ブロックの開始行（非表示は -1）と検索にマッチしたブロックのインデックスを Model に記録する。
+func (m *Model) renderLog(blocks []*agent.DisplayBlock, width int) string {
+	query := strings.ToLower(m.searchQuery)
+	m.blockLines = make([]int, len(blocks))
+	m.searchMatches = m.searchMatches[:0]
+
+	var sb strings.Builder
+	lines := 0
+	for i, b := range blocks {
+		m.blockLines[i] = -1
+		if !m.logFilter.match(b) {
+			continue
+		}
+		m.blockLines[i] = lines
+		rendered := renderBlocks(blocks[i:i+1], width, m.logsExpanded, m.spinner.View())
+		if query != "" && strings.Contains(strings.ToLower(blockText(b)), query) {
+			style := searchMatchStyle
+			if len(m.searchMatches) == m.searchIndex {
+				style = searchCurrentStyle
+			}
+			m.searchMatches = append(m.searchMatches, i)
+			rendered = highlightLines(rendered, query, style)
+		}
+		sb.WriteString(rendered)
+		lines += strings.Count(rendered, "\n")
+	}
+	if m.searchIndex >= len(m.searchMatches) {
+		m.searchIndex = 0
+	}
+	return sb.String()
+}
+
+// highlightLines は検索語を含む行の装飾を外し、出現箇所だけをハイライトする。
+// query は小文字であること。
+func highlightLines(rendered, query string, style lipgloss.Style) string {
+	lines := strings.Split(rendered, "\n")
+	for i, line := range lines {
+		plain := sgrRe.ReplaceAllString(line, "")
+		lower := strings.ToLower(plain)
+		if !strings.Contains(lower, query) || len(lower) != len(plain) {
+			continue
+		}
+		var sb strings.Builder
+		pos := 0
+		for {
+			idx := strings.Index(lower[pos:], query)
+			if idx < 0 {
+				break
+			}
+			start := pos + idx
+			sb.WriteString(plain[pos:start])
+			sb.WriteString(style.Render(plain[start : start+len(query)]))
+			pos = start + len(query)
+		}
+		sb.WriteString(plain[pos:])
+		lines[i] = sb.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jumpToBlock はブロックの開始行までビューポートをスクロールする。
+// フィルターで非表示のブロックなら false を返す。
+func (m *Model) jumpToBlock(idx int) bool {
+	if idx < 0 || idx >= len(m.blockLines) || m.blockLines[idx] < 0 {
+		return false
+	}
+	m.viewport.SetYOffset(m.blockLines[idx])
+	return true
+}
+
+// handleSearchCommand は /search <text> を処理する。
+// 現在位置以降で最初のマッチへ移動し、引数なしなら検索を解除する。
+func (m *Model) handleSearchCommand(query string) {
+	if m.detailBody != nil {
+		m.closeDetail()
+	}
+	m.searchQuery = query
+	m.searchIndex = 0
+	m.rebuildViewport()
+	if query == "" {
+		m.logSystem("Search cleared.")
+		return
+	}
+	if len(m.searchMatches) == 0 {
+		m.logSystem(fmt.Sprintf("No matches for %q.", query))
+		return
+	}
+	top := m.viewport.YOffset
+	for i, idx := range m.searchMatches {
+		if m.blockLines[idx] >= top {
+			m.searchIndex = i
+			break
+		}
+	}
+	m.rebuildViewport()
+	m.jumpToBlock(m.searchMatches[m.searchIndex])
+}
+
+// moveMatch は検索結果を delta 件進めて（負なら戻って）移動する。端では折り返す。
+func (m *Model) moveMatch(delta int) {
+	if m.searchQuery == "" || len(m.searchMatches) == 0 {
+		return
+	}
+	n := len(m.searchMatches)
+	m.searchIndex = ((m.searchIndex+delta)%n + n) % n
+	m.rebuildViewport()
+	m.jumpToBlock(m.searchMatches[m.searchIndex])
+}
+
+// handleFilterCommand は /filter <kind> を処理する。引数なしなら現在のフィルターを表示する。
+func (m *Model) handleFilterCommand(arg string) {
+	if arg == "" {
+		m.logSystem(fmt.Sprintf("Filter: %s\n%s", m.logFilter, filterUsage))
+		return
+	}
+	for i, name := range logFilterNames {
+		if arg == name {
+			m.logFilter = logFilter(i)
+			m.searchIndex = 0
+			if m.detailBody != nil {
+				m.closeDetail()
+			}
+			m.rebuildViewport()
+			m.viewport.GotoBottom()
+			return
+		}
+	}
+	m.logSystem(filterUsage)
+}
+
+// handleTurnCommand は /turn <n> を処理し、そのターン以降の最初の表示ブロックへ移動する。
+func (m *Model) handleTurnCommand(arg string) {
+	t := m.activeTarget()
+	if t == nil {
+		m.logSystem("No target selected.")
+		return
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		m.logSystem("Usage: /turn <number>")
+		return
+	}
+	if m.detailBody != nil {
+		m.closeDetail()
+	}
+	m.rebuildViewport()
+	for i, b := range t.Blocks {
+		if b.Turn >= n && m.jumpToBlock(i) {
+			m.focus = FocusViewport
+			m.input.Blur()
+			return
+		}
+	}
+	m.logSystem(fmt.Sprintf("Turn %d not found (current turn: %d).", n, t.Turn))
+}
+
+// logStatus はステータスバーに出す検索・フィルターの状態を返す（既定状態では空）。
+func (m Model) logStatus() string {
+	var parts []string
+	if m.logFilter != filterAll {
+		parts = append(parts, "Filter: "+m.logFilter.String())
+	}
+	if m.searchQuery != "" {
+		pos := 0
+		if len(m.searchMatches) > 0 {
+			pos = m.searchIndex + 1
+		}
+		parts = append(parts, fmt.Sprintf("Search: %q %d/%d", m.searchQuery, pos, len(m.searchMatches)))
+	}
+	return strings.Join(parts, "  ")
+}
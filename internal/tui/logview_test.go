@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+)
+
+// newLogTestModel はターンをまたいだコマンド・AI メッセージ・エラーを持つ Model を返す。
+func newLogTestModel() Model {
+	m := newPanelTestModel(120)
+	t := m.targets[0]
+	for turn := 1; turn <= 12; turn++ {
+		t.Turn = turn
+		cmd := agent.NewCommandBlock(fmt.Sprintf("curl http://10.0.0.5/page%d", turn))
+		cmd.Output = []string{"HTTP/1.1 200 OK", "Server: Apache"}
+		cmd.Completed = true
+		if turn == 7 {
+			cmd.Command = "nmap -sV 10.0.0.5"
+			cmd.ExitCode = 1
+		}
+		t.AddBlock(cmd)
+		t.AddBlock(agent.NewAIMessageBlock(fmt.Sprintf("Turn %d analysis", turn)))
+	}
+	t.AddBlock(agent.NewSystemBlock("❌ Brain error: rate limited"))
+	m.rebuildViewport()
+	return m
+}
+
+func TestLogView_SearchAndNavigate(t *testing.T) {
+	m := newLogTestModel()
+
+	// ログ上の "/" は検索語入力に移る
+	m.focus = FocusViewport
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	m = result.(Model)
+	if m.focus != FocusInput || m.input.Value() != "/search " {
+		t.Fatalf("'/' should start a search (focus=%d input=%q)", m.focus, m.input.Value())
+	}
+
+	m.input.SetValue("/search APACHE")
+	m.submitInput()
+	if len(m.searchMatches) != 12 {
+		t.Fatalf("expected 12 case-insensitive matches, got %d", len(m.searchMatches))
+	}
+	if !strings.Contains(stripANSI(m.viewport.View()), "Server: Apache") {
+		t.Error("viewport should scroll to the current match")
+	}
+	if !strings.Contains(stripANSI(m.renderStatusBar()), `Search: "APACHE" `) {
+		t.Errorf("status bar should show the search state: %q", stripANSI(m.renderStatusBar()))
+	}
+
+	m.focus = FocusViewport
+	first := m.searchIndex
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{']'}})
+	m = result.(Model)
+	if m.searchIndex != (first+1)%12 {
+		t.Errorf("']' should move to the next match, got %d", m.searchIndex)
+	}
+	if got, want := m.viewport.YOffset, m.blockLines[m.searchMatches[m.searchIndex]]; got != want && !m.viewport.AtBottom() {
+		t.Errorf("viewport offset = %d, want %d", got, want)
+	}
+	m.searchIndex = 0
+	m.moveMatch(-1)
+	if m.searchIndex != 11 {
+		t.Errorf("'[' should wrap to the last match, got %d", m.searchIndex)
+	}
+
+	m.handleSearchCommand("")
+	if m.searchQuery != "" || strings.Contains(m.renderStatusBar(), "Search:") {
+		t.Error("/search without text should clear the search")
+	}
+}
+
+func TestLogView_Filters(t *testing.T) {
+	tests := []struct {
+		filter  string
+		want    []string
+		exclude []string
+	}{
+		{"commands", []string{"page1", "nmap -sV"}, []string{"Turn 1 analysis", "Brain error"}},
+		{"ai", []string{"Turn 12 analysis"}, []string{"page1", "Brain error"}},
+		{"failures", []string{"nmap -sV", "Brain error"}, []string{"page1", "analysis"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			m := newLogTestModel()
+			m.viewport.Height = 200
+			m.handleFilterCommand(tt.filter)
+			content := stripANSI(m.viewport.View())
+			for _, w := range tt.want {
+				if !strings.Contains(content, w) {
+					t.Errorf("filter %s should show %q", tt.filter, w)
+				}
+			}
+			for _, x := range tt.exclude {
+				if strings.Contains(content, x) {
+					t.Errorf("filter %s should hide %q", tt.filter, x)
+				}
+			}
+		})
+	}
+
+	m := newLogTestModel()
+	m.handleFilterCommand("bogus")
+	if m.logFilter != filterAll || !strings.Contains(m.targets[0].LastBlock().SystemMsg, filterUsage) {
+		t.Error("unknown filter should show usage and keep the current filter")
+	}
+}
+
+func TestLogView_TurnJump(t *testing.T) {
+	m := newLogTestModel()
+	m.viewport.GotoTop()
+	m.handleTurnCommand("5")
+	target := m.targets[0]
+	for i, b := range target.Blocks {
+		if b.Turn == 5 {
+			if m.viewport.YOffset != m.blockLines[i] {
+				t.Errorf("/turn 5 should scroll to line %d, got %d", m.blockLines[i], m.viewport.YOffset)
+			}
+			break
+		}
+	}
+	if !strings.Contains(stripANSI(m.viewport.View()), "page5") {
+		t.Errorf("turn 5 should be visible:\n%s", stripANSI(m.viewport.View()))
+	}
+
+	m.handleTurnCommand("99")
+	if !strings.Contains(target.LastBlock().SystemMsg, "Turn 99 not found") {
+		t.Errorf("missing turn should be reported, got %q", target.LastBlock().SystemMsg)
+	}
+}
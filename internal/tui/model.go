@@ -13,6 +13,9 @@ import (
 
 	"github.com/0x6d61/pentecter/internal/agent"
 	"github.com/0x6d61/pentecter/internal/brain"
+	"github.com/0x6d61/pentecter/internal/knowledge"
+	"github.com/0x6d61/pentecter/internal/mcp"
+	"github.com/0x6d61/pentecter/internal/skills"
 	"github.com/0x6d61/pentecter/internal/tools"
 )
 
@@ -22,6 +25,7 @@ type FocusState int
 const (
 	FocusViewport FocusState = iota // main pane: session log
 	FocusInput                      // bottom: input bar
+	FocusPanel                      // right: side panel (recon tree / findings / tasks)
 )
 
 // InputMode tracks whether the input bar is in normal text mode or select mode.
@@ -31,6 +35,7 @@ const (
 	InputNormal     InputMode = iota // normal text input
 	InputSelect                      // interactive selection UI
 	InputConfirmQuit                 // quit confirmation dialog
+	InputPalette                     // command palette (Ctrl+P)
 )
 
 // SelectOption represents a single option in the select UI.
@@ -68,9 +73,32 @@ type Model struct {
 	// BrainFactory creates a new Brain from a ConfigHint (for /model command).
 	BrainFactory func(brain.ConfigHint) (brain.Brain, error)
 
+	// ModelRouter switches the model of a route (exploit, subagent, recon, extract or
+	// target:<host>) for /model <route>. A zero ConfigHint restores the default model.
+	ModelRouter func(route string, hint brain.ConfigHint) error
+
+	// ModelRoutes describes the current model of every route (for /models).
+	ModelRoutes func() []string
+
 	// Runner is the CommandRunner used for /approve command (auto-approve toggle).
 	Runner *tools.CommandRunner
 
+	// MCPManager is used for /mcp command (server status). nil = MCP disabled.
+	MCPManager *mcp.MCPManager
+
+	// Keys maps actions to key bindings (tui.keys in config). The zero value uses the defaults.
+	Keys KeyMap
+
+	// Skills is used to complete skill names and parameters. nil = no skill completion.
+	Skills *skills.Registry
+
+	// Knowledge is used for /kb and knowledge path completion. nil = knowledge disabled.
+	Knowledge *knowledge.Library
+
+	// Reloader reloads tools, skills, blacklist and MCP config (for /reload command).
+	// Returns a summary of what was reloaded. nil = /reload unavailable.
+	Reloader func() (string, error)
+
 	// spinner はアニメーション付きスピナー（Thinking / SubTask ブロック用）。
 	spinner  spinner.Model
 	spinning bool // true の場合、アクティブな thinking/subtask ブロックが存在する
@@ -81,6 +109,23 @@ type Model struct {
 	// viewportDirty が true の場合、次のスピナーティックまたはデバウンスタイマーでビューポートを再描画する。
 	viewportDirty bool
 
+	// サイドパネル（Ctrl+T / /panel で表示切り替え）
+	panelVisible bool
+	panelTab     PanelTab
+	panelCursor  int
+
+	// ドリルダウン表示（nil = ログ表示）。パネルの Enter で開き Esc で閉じる。
+	detailTitle  string
+	detailBody   func() string
+	attachedTask string // アタッチ中のサブタスク ID。入力はこのタスクへのメッセージになる
+
+	// セッションログの検索・絞り込み（/search, /filter, /turn）
+	logFilter     logFilter
+	searchQuery   string
+	searchMatches []int // 検索にマッチした Blocks のインデックス（表示順）
+	searchIndex   int   // searchMatches 内の現在位置
+	blockLines    []int // Blocks の各ブロックの開始行（フィルターで非表示は -1）
+
 	// Global system logs — shown when no target is active
 	globalLogs []string
 
@@ -94,6 +139,12 @@ type Model struct {
 	selectIndex    int
 	selectTitle    string
 	selectCallback func(m *Model, value string)
+
+	// Command palette (Ctrl+P) and Tab completion
+	paletteItems    []paletteItem
+	paletteQuery    string
+	paletteIndex    int
+	completionHints []completion // 直前の Tab で候補が複数あったときに入力欄の上に表示する
 }
 
 // AgentEventCmd は Agent イベントをバッチで回収する Bubble Tea コマンド。
@@ -189,7 +240,7 @@ func (m *Model) rebuildViewport() {
 		sb.WriteString("  No target selected.\n\n")
 		sb.WriteString("  Add a target by entering an IP address:\n")
 		sb.WriteString("    e.g. 10.0.0.5 / /target example.com\n\n")
-		sb.WriteString("  Commands: /targets, /model, /approve, /curl, /ssh\n")
+		sb.WriteString("  Commands: /targets, /model, /approve, /panel, /search, /curl, /ssh (Ctrl+P: palette)\n")
 		if len(m.globalLogs) > 0 {
 			sb.WriteString("\n")
 			for _, log := range m.globalLogs {
@@ -205,11 +256,20 @@ func (m *Model) rebuildViewport() {
 		vpWidth = 80 // fallback
 	}
 
+	if m.detailBody != nil {
+		atBottom := m.viewport.AtBottom()
+		m.viewport.SetContent(m.renderDetail(vpWidth))
+		if atBottom {
+			m.viewport.GotoBottom() // アタッチ中のサブタスク出力を追従する
+		}
+		return
+	}
+
 	// auto-scroll 判定: 現在底付近にいるかチェック（SetContent 前に取得）
 	atBottom := m.viewport.AtBottom()
 
 	// ブロックベースレンダリング（スピナーフレームを渡す）
-	content := renderBlocks(t.Blocks, vpWidth, m.logsExpanded, m.spinner.View())
+	content := m.renderLog(t.Blocks, vpWidth)
 
 	// プロポーザルをビューポートの末尾に追加
 	if p := t.GetProposal(); p != nil {
@@ -239,10 +299,14 @@ func (m *Model) renderProposal(p *agent.Proposal) string {
 		p.Tool,
 		strings.Join(p.Args, " "),
 	)
+	if p.MCPArgs != "" {
+		proposalBody += "\n  Args: " + strings.ReplaceAll(p.MCPArgs, "\n", "\n  ")
+	}
 
 	proposalControls := lipgloss.NewStyle().
 		Foreground(colorMuted).
-		Render("  [y] Approve  [n] Reject  [e] Edit")
+		Render(fmt.Sprintf("  [%s] Approve  [%s] Reject  [%s] Edit",
+			m.Keys.label(actionApprove), m.Keys.label(actionReject), m.Keys.label(actionEdit)))
 
 	boxWidth := m.viewport.Width - 2
 	if boxWidth < 10 {
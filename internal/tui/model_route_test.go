@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+	"github.com/0x6d61/pentecter/internal/brain"
+)
+
+func onlyAnthropic(t *testing.T) {
+	t.Helper()
+	t.Setenv("ANTHROPIC_API_KEY", "sk-test")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("CLAUDE_CODE_OAUTH_TOKEN", "")
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "")
+	t.Setenv("OLLAMA_BASE_URL", "")
+}
+
+func lastSystemMessage(target *agent.Target) string {
+	for i := len(target.Blocks) - 1; i >= 0; i-- {
+		if target.Blocks[i].Type == agent.BlockSystem {
+			return target.Blocks[i].SystemMsg
+		}
+	}
+	return ""
+}
+
+func TestModelCommand_RouteSelect(t *testing.T) {
+	onlyAnthropic(t)
+	target := agent.NewTarget(1, "10.0.0.1")
+	m := NewWithTargets([]*agent.Target{target})
+
+	var gotRoute string
+	var gotHint brain.ConfigHint
+	m.ModelRouter = func(route string, hint brain.ConfigHint) error {
+		gotRoute, gotHint = route, hint
+		return nil
+	}
+	m.BrainFactory = func(brain.ConfigHint) (brain.Brain, error) {
+		t.Error("route selection must not switch the main brain")
+		return nil, nil
+	}
+
+	m.handleModelCommand("/model exploit")
+	if m.selectTitle != "Select provider for exploit:" {
+		t.Fatalf("expected route provider select, got %q", m.selectTitle)
+	}
+	m.handleSelectKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m.handleSelectKey(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if gotRoute != "exploit" || gotHint.Provider != brain.ProviderAnthropic || gotHint.Model == "" {
+		t.Errorf("ModelRouter(%q, %+v), want exploit with an anthropic model", gotRoute, gotHint)
+	}
+	if msg := lastSystemMessage(target); !strings.HasPrefix(msg, "Switched exploit to anthropic/") {
+		t.Errorf("unexpected log: %q", msg)
+	}
+}
+
+func TestModelCommand_TargetDefault(t *testing.T) {
+	target := agent.NewTarget(1, "10.0.0.1")
+	m := NewWithTargets([]*agent.Target{target})
+
+	var gotRoute string
+	gotHint := brain.ConfigHint{Provider: "unset"}
+	m.ModelRouter = func(route string, hint brain.ConfigHint) error {
+		gotRoute, gotHint = route, hint
+		return nil
+	}
+
+	m.handleModelCommand("/model target default")
+
+	if m.inputMode == InputSelect {
+		t.Error("reset should not show the select UI")
+	}
+	if gotRoute != "target:10.0.0.1" || gotHint != (brain.ConfigHint{}) {
+		t.Errorf("ModelRouter(%q, %+v), want target:10.0.0.1 with a zero hint", gotRoute, gotHint)
+	}
+	if msg := lastSystemMessage(target); msg != "target:10.0.0.1 now uses the default model" {
+		t.Errorf("unexpected log: %q", msg)
+	}
+}
+
+func TestModelCommand_RouteError(t *testing.T) {
+	target := agent.NewTarget(1, "10.0.0.1")
+	m := NewWithTargets([]*agent.Target{target})
+	m.ModelRouter = func(string, brain.ConfigHint) error {
+		return errors.New("OLLAMA_BASE_URL is not set")
+	}
+
+	m.handleModelCommand("/model recon default")
+
+	if msg := lastSystemMessage(target); !strings.Contains(msg, "Failed to switch recon model") {
+		t.Errorf("unexpected log: %q", msg)
+	}
+}
+
+func TestModelCommand_RouteWithoutRouter(t *testing.T) {
+	onlyAnthropic(t)
+	target := agent.NewTarget(1, "10.0.0.1")
+	m := NewWithTargets([]*agent.Target{target})
+
+	m.handleModelCommand("/model extract")
+
+	if m.inputMode == InputSelect {
+		t.Error("should not show select without a ModelRouter")
+	}
+	if msg := lastSystemMessage(target); msg != "Model routing not available" {
+		t.Errorf("unexpected log: %q", msg)
+	}
+}
+
+func TestModelsCommand(t *testing.T) {
+	target := agent.NewTarget(1, "10.0.0.1")
+	m := NewWithTargets([]*agent.Target{target})
+	m.ModelRoutes = func() []string {
+		return []string{"main: anthropic/claude-sonnet-4-6", "recon: ollama/llama3.2"}
+	}
+
+	m.handleModelsCommand()
+
+	msg := lastSystemMessage(target)
+	for _, want := range []string{"main: anthropic/claude-sonnet-4-6", "recon: ollama/llama3.2", "/model <"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected %q in /models output, got %q", want, msg)
+		}
+	}
+}
+
+func TestHandleAgentEvent_ExtractedTarget(t *testing.T) {
+	m := NewWithTargets(nil)
+	m.handleResize(120, 40)
+	m.ready = true
+	m.team = agent.NewTeam(agent.TeamConfig{Events: make(chan agent.Event, 10)})
+
+	_ = m.handleAgentEvent(agent.Event{
+		Type:    agent.EventAddTarget,
+		NewHost: "staging.corp.local",
+		Message: "focus on the web app",
+	})
+
+	target := m.activeTarget()
+	if target == nil || target.Host != "staging.corp.local" {
+		t.Fatalf("expected the extracted target to be active, got %+v", target)
+	}
+	found := false
+	for _, b := range target.Blocks {
+		if b.Type == agent.BlockUserInput && b.UserText == "focus on the web app" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected the remaining instruction as a user input block")
+	}
+}
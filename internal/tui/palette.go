@@ -0,0 +1,187 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// paletteMaxRows はコマンドパレットに一度に表示する候補数。
+const paletteMaxRows = 8
+
+// paletteItem はコマンドパレットの1項目。
+// run が nil なら Value を入力欄に入れる（末尾が空白でなければそのまま実行する）。
+type paletteItem struct {
+	completion
+	run func(m *Model)
+}
+
+// openPalette は Ctrl+P のコマンドパレットを開く。
+// 候補はコマンド・スキル・アクティブターゲットのサブタスク・ターゲットの切り替え。
+func (m *Model) openPalette() {
+	var items []paletteItem
+	for _, c := range m.commandCompletions() {
+		items = append(items, paletteItem{completion: c})
+	}
+	for _, c := range m.taskCompletions() {
+		items = append(items, paletteItem{completion: completion{Value: "/task " + c.Value, Help: c.Help}})
+	}
+	for idx, t := range m.targets {
+		items = append(items, paletteItem{
+			completion: completion{Value: t.Host, Help: fmt.Sprintf("Switch to target [%s]", t.GetStatus())},
+			run: func(m *Model) {
+				m.selected = idx
+				m.closeDetail()
+				m.logSystem(fmt.Sprintf("Switched to target: %s", m.targets[idx].Host))
+			},
+		})
+	}
+	m.paletteItems = items
+	m.paletteQuery = ""
+	m.paletteIndex = 0
+	m.completionHints = nil
+	m.inputMode = InputPalette
+}
+
+// paletteMatches はクエリに一致する項目を返す。
+// 前方一致 → 部分一致 → 説明文の部分一致 → 文字の順序一致（あいまい検索）の順に並べる。
+func (m *Model) paletteMatches() []paletteItem {
+	q := strings.ToLower(strings.TrimSpace(m.paletteQuery))
+	if q == "" {
+		return m.paletteItems
+	}
+	type ranked struct {
+		item paletteItem
+		rank int
+	}
+	var list []ranked
+	for _, it := range m.paletteItems {
+		value := strings.ToLower(strings.TrimPrefix(it.Value, "/"))
+		rank := -1
+		switch {
+		case strings.HasPrefix(value, strings.TrimPrefix(q, "/")):
+			rank = 0
+		case strings.Contains(value, q):
+			rank = 1
+		case strings.Contains(strings.ToLower(it.Help), q):
+			rank = 2
+		case fuzzyMatch(value, q):
+			rank = 3
+		}
+		if rank >= 0 {
+			list = append(list, ranked{it, rank})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].rank < list[j].rank })
+	out := make([]paletteItem, len(list))
+	for i, r := range list {
+		out[i] = r.item
+	}
+	return out
+}
+
+// fuzzyMatch は q の文字が s に順番どおり現れるかを返す。
+func fuzzyMatch(s, q string) bool {
+	for _, r := range q {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(r)):]
+	}
+	return true
+}
+
+// handlePaletteKey はパレット表示中のキー入力を処理する。
+func (m *Model) handlePaletteKey(msg tea.KeyMsg) {
+	matches := m.paletteMatches()
+	switch msg.Type {
+	case tea.KeyEscape:
+		m.closePalette()
+	case tea.KeyUp, tea.KeyCtrlP:
+		if m.paletteIndex > 0 {
+			m.paletteIndex--
+		}
+	case tea.KeyDown, tea.KeyCtrlN:
+		if m.paletteIndex < len(matches)-1 {
+			m.paletteIndex++
+		}
+	case tea.KeyBackspace:
+		if q := []rune(m.paletteQuery); len(q) > 0 {
+			m.paletteQuery = string(q[:len(q)-1])
+			m.paletteIndex = 0
+		}
+	case tea.KeySpace:
+		m.paletteQuery += " "
+		m.paletteIndex = 0
+	case tea.KeyRunes:
+		m.paletteQuery += string(msg.Runes)
+		m.paletteIndex = 0
+	case tea.KeyEnter:
+		if m.paletteIndex >= len(matches) {
+			return
+		}
+		item := matches[m.paletteIndex]
+		m.closePalette()
+		m.runPaletteItem(item)
+	}
+}
+
+// runPaletteItem は選択した項目を実行する。引数が必要なコマンドは入力欄に入れて待つ。
+func (m *Model) runPaletteItem(item paletteItem) {
+	if item.run != nil {
+		item.run(m)
+		return
+	}
+	m.input.SetValue(item.Value)
+	m.focus = FocusInput
+	m.input.Focus()
+	if !strings.HasSuffix(item.Value, " ") {
+		m.submitInput()
+	}
+}
+
+// closePalette はパレットを閉じて通常の入力に戻る。
+func (m *Model) closePalette() {
+	m.inputMode = InputNormal
+	m.paletteItems = nil
+	m.paletteQuery = ""
+	m.paletteIndex = 0
+}
+
+// renderPaletteBar はコマンドパレットを入力バーの位置に描画する。
+func (m Model) renderPaletteBar() string {
+	var sb strings.Builder
+	title := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render("Command palette")
+	sb.WriteString(title + "  > " + m.paletteQuery + "█\n")
+
+	matches := m.paletteMatches()
+	start := 0
+	if m.paletteIndex >= paletteMaxRows {
+		start = m.paletteIndex - paletteMaxRows + 1
+	}
+	end := min(start+paletteMaxRows, len(matches))
+	helpStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	for i := start; i < end; i++ {
+		it := matches[i]
+		label := fmt.Sprintf("%-18s", strings.TrimSpace(it.Value))
+		if i == m.paletteIndex {
+			sb.WriteString("  " + lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render("> "+label))
+		} else {
+			sb.WriteString("    " + label)
+		}
+		sb.WriteString(" " + helpStyle.Render(it.Help) + "\n")
+	}
+	if len(matches) == 0 {
+		sb.WriteString(helpStyle.Render("    No matches") + "\n")
+	}
+
+	hint := helpStyle.Render(fmt.Sprintf("[Up/Down] Move  [Enter] Run  [Esc] Close  (%d/%d)", len(matches), len(m.paletteItems)))
+	sb.WriteString(hint)
+
+	w := m.width - 2
+	return inputBarActiveStyle.Width(w).Render(sb.String())
+}
@@ -0,0 +1,386 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+)
+
+// PanelTab はサイドパネルのタブ。
+type PanelTab int
+
+const (
+	PanelRecon    PanelTab = iota // 偵察ツリー
+	PanelFindings                 // 深刻度順の finding
+	PanelTasks                    // サブタスク一覧
+)
+
+// panelTabNames はタブの表示名（PanelTab の順）。
+var panelTabNames = []string{"Recon", "Findings", "Tasks"}
+
+// サイドパネルの幅（外枠込み）。端末幅に対する割合で決め、上下限で丸める。
+const (
+	panelWidthPercent = 35
+	panelMinWidth     = 28
+	panelMaxWidth     = 56
+	mainMinWidth      = 40 // メインペインがこれより狭くなる場合はパネルを表示しない
+
+	// detailMaxLines はドリルダウン表示で1つの出力から見せる末尾の行数
+	detailMaxLines = 300
+)
+
+// panelRow はパネルの1行。open はドリルダウン時に詳細本文を返す（nil = 開けない）。
+type panelRow struct {
+	text   string
+	color  lipgloss.Color
+	title  string
+	open   func() string
+	taskID string // Tasks タブの行のみ。Enter でアタッチ、x で kill
+}
+
+// panelWidth はサイドパネルの外枠込みの幅を返す。非表示または端末が狭い場合は 0。
+func (m *Model) panelWidth() int {
+	if !m.panelVisible {
+		return 0
+	}
+	w := m.width * panelWidthPercent / 100
+	if w < panelMinWidth {
+		w = panelMinWidth
+	}
+	if w > panelMaxWidth {
+		w = panelMaxWidth
+	}
+	if m.width-w < mainMinWidth {
+		return 0
+	}
+	return w
+}
+
+// togglePanel はサイドパネルの表示を切り替え、レイアウトを再計算する。
+func (m *Model) togglePanel() {
+	m.setPanelVisible(!m.panelVisible)
+}
+
+// setPanelVisible はサイドパネルの表示・非表示を設定する。
+// 非表示にするときパネルにフォーカスがあればログに戻す。
+func (m *Model) setPanelVisible(visible bool) {
+	m.panelVisible = visible
+	if !visible && m.focus == FocusPanel {
+		m.focus = FocusViewport
+	}
+	if m.width > 0 {
+		m.handleResize(m.width, m.height)
+	}
+	m.rebuildViewport()
+}
+
+// handlePanelCommand は /panel [recon|findings|tasks|off] を処理する。
+func (m *Model) handlePanelCommand(arg string) {
+	switch arg {
+	case "":
+		m.togglePanel()
+	case "off":
+		m.setPanelVisible(false)
+	default:
+		for i, name := range panelTabNames {
+			if strings.EqualFold(name, arg) {
+				m.panelTab = PanelTab(i)
+				m.panelCursor = 0
+				m.setPanelVisible(true)
+				return
+			}
+		}
+		m.logSystem("Usage: /panel [recon|findings|tasks|off]")
+		return
+	}
+	if m.panelVisible && m.panelWidth() == 0 {
+		m.logSystem("Terminal is too narrow for the side panel.")
+	}
+}
+
+// handlePanelKey はパネルにフォーカスがあるときのキー操作を処理する。
+func (m *Model) handlePanelKey(key string) {
+	rows := m.panelRows()
+	if m.panelCursor >= len(rows) {
+		m.panelCursor = max(len(rows)-1, 0)
+	}
+	switch key {
+	case "up", "k":
+		if m.panelCursor > 0 {
+			m.panelCursor--
+		}
+	case "down", "j":
+		if m.panelCursor < len(rows)-1 {
+			m.panelCursor++
+		}
+	case "left", "h":
+		m.panelTab = PanelTab((int(m.panelTab) + len(panelTabNames) - 1) % len(panelTabNames))
+		m.panelCursor = 0
+	case "right", "l":
+		m.panelTab = PanelTab((int(m.panelTab) + 1) % len(panelTabNames))
+		m.panelCursor = 0
+	case "1", "2", "3":
+		m.panelTab = PanelTab(key[0] - '1')
+		m.panelCursor = 0
+	case "enter":
+		if m.panelCursor >= len(rows) {
+			return
+		}
+		if row := rows[m.panelCursor]; row.taskID != "" {
+			m.attachTask(row.taskID)
+		} else if row.open != nil {
+			m.openDetail(row.title, row.open)
+		}
+	default:
+		if m.Keys.matches(key, actionKillTask) && m.panelCursor < len(rows) && rows[m.panelCursor].taskID != "" {
+			m.confirmKillTask(rows[m.panelCursor].taskID)
+		}
+	}
+}
+
+// openDetail はメインペインにドリルダウン表示を開き、ログにフォーカスを移す。
+// 本文は再描画のたびに取り直すため、実行中タスクの出力も追従する。Esc で閉じる。
+func (m *Model) openDetail(title string, body func() string) {
+	m.attachedTask = ""
+	m.detailTitle = title
+	m.detailBody = body
+	m.focus = FocusViewport
+	m.input.Blur()
+	m.rebuildViewport()
+	m.viewport.GotoTop()
+}
+
+// closeDetail はドリルダウン表示を閉じてログ表示に戻る。
+func (m *Model) closeDetail() {
+	m.attachedTask = ""
+	m.detailTitle = ""
+	m.detailBody = nil
+	m.rebuildViewport()
+	m.viewport.GotoBottom()
+}
+
+// renderDetail はドリルダウン表示の内容をビューポート幅で折り返して返す。
+func (m *Model) renderDetail(width int) string {
+	title := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render(m.detailTitle)
+	hintText := fmt.Sprintf("[%s] Back to log", m.Keys.label(actionBack))
+	if m.attachedTask != "" {
+		hintText = fmt.Sprintf("[Enter] Send message to %s  [%s] Detach", m.attachedTask, m.Keys.label(actionBack))
+	}
+	hint := lipgloss.NewStyle().Foreground(colorMuted).Render(hintText)
+	body := lipgloss.NewStyle().Width(width).Render(m.detailBody())
+	return title + "  " + hint + "\n\n" + body
+}
+
+// panelRows は現在のタブの行を返す。
+func (m *Model) panelRows() []panelRow {
+	t := m.activeTarget()
+	if t == nil {
+		return nil
+	}
+	switch m.panelTab {
+	case PanelFindings:
+		return m.findingRows(t)
+	case PanelTasks:
+		return m.taskRows(t.ID)
+	default:
+		return m.reconRows(t)
+	}
+}
+
+// reconRows は偵察ツリーのノード行を返す。ドリルダウンでノード詳細と該当ポートのサブタスク出力を表示する。
+func (m *Model) reconRows(t *agent.Target) []panelRow {
+	rt := t.GetReconTree()
+	if rt == nil {
+		return nil
+	}
+	var tm *agent.TaskManager
+	if m.team != nil {
+		tm = m.team.TaskManager()
+	}
+	outline := rt.Outline()
+	rows := make([]panelRow, 0, len(outline))
+	for _, r := range outline {
+		text := strings.Repeat("  ", r.Depth) + r.Label
+		if r.Findings > 0 {
+			text += fmt.Sprintf(" !%d", r.Findings)
+		}
+		var color lipgloss.Color
+		switch {
+		case r.Active:
+			color = colorWarning
+		case r.Findings > 0:
+			color = colorDanger
+		}
+		title := fmt.Sprintf("%s:%d%s", r.Host, r.Port, r.Path)
+		rows = append(rows, panelRow{text: text, color: color, title: title, open: func() string {
+			detail := rt.RenderNodeDetail(r.Host, r.Port, r.Path)
+			return detail + portTaskOutputs(tm, t.ID, r.Port)
+		}})
+	}
+	return rows
+}
+
+// findingRows は finding と既知の CVE を深刻度順に返す。
+func (m *Model) findingRows(t *agent.Target) []panelRow {
+	rt := t.GetReconTree()
+	if rt == nil {
+		return nil
+	}
+	findings := rt.FindingsBySeverity()
+	rows := make([]panelRow, 0, len(findings))
+	for _, f := range findings {
+		loc := fmt.Sprintf("%d%s", f.Port, f.Path)
+		rows = append(rows, panelRow{
+			text:  fmt.Sprintf("%-4s %s  %s", severityLabel(f.Severity), f.Title, loc),
+			color: severityColor(f.Severity),
+			title: fmt.Sprintf("%s — %s:%s", f.Title, f.Host, loc),
+			open: func() string {
+				return fmt.Sprintf("Severity: %s\n%s\n\n", f.Severity, f.Detail) +
+					rt.RenderNodeDetail(f.Host, f.Port, f.Path)
+			},
+		})
+	}
+	return rows
+}
+
+// portTaskOutputs は指定ポートのサブタスク（完了済みを含む）の出力を連結して返す。
+func portTaskOutputs(tm *agent.TaskManager, targetID, port int) string {
+	if tm == nil || port == 0 {
+		return ""
+	}
+	var tasks []*agent.SubTask
+	for _, task := range tm.AllTasks(targetID) {
+		if task.GetMetadata().Port == port {
+			tasks = append(tasks, task)
+		}
+	}
+	if len(tasks) == 0 {
+		return ""
+	}
+	sortTasks(tasks)
+	var sb strings.Builder
+	sb.WriteString("\nSubtasks:\n")
+	for _, task := range tasks {
+		fmt.Fprintf(&sb, "\n--- %s ---\n", task.Summary())
+		if out := tailLines(task.FullOutput(), detailMaxLines); out != "" {
+			sb.WriteString(out + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// sortTasks はサブタスクを開始時刻順（同時刻は ID 順）に並べる。
+func sortTasks(tasks []*agent.SubTask) {
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].StartedAt.Equal(tasks[j].StartedAt) {
+			return tasks[i].StartedAt.Before(tasks[j].StartedAt)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+}
+
+// tailLines は末尾 n 行を返す。省略した場合は先頭にその行数を示す。
+func tailLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return fmt.Sprintf("… %d earlier lines omitted\n", len(lines)-n) + strings.Join(lines[len(lines)-n:], "\n")
+}
+
+// severityLabel は深刻度の短縮表記。
+func severityLabel(s string) string {
+	switch s {
+	case "critical":
+		return "CRIT"
+	case "high":
+		return "HIGH"
+	case "medium":
+		return "MED"
+	case "low":
+		return "LOW"
+	case "info":
+		return "INFO"
+	default:
+		return "?"
+	}
+}
+
+// severityColor は深刻度の表示色。
+func severityColor(s string) lipgloss.Color {
+	switch s {
+	case "critical", "high":
+		return colorDanger
+	case "medium":
+		return colorWarning
+	default:
+		return colorMuted
+	}
+}
+
+// renderPanel はサイドパネル（タブ見出し + 行一覧）を height 行で描画する。
+func (m Model) renderPanel(width, height int) string {
+	innerW := width - 2
+	var tabs []string
+	for i, name := range panelTabNames {
+		if PanelTab(i) == m.panelTab {
+			tabs = append(tabs, lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Underline(true).Render(name))
+		} else {
+			tabs = append(tabs, lipgloss.NewStyle().Foreground(colorMuted).Render(name))
+		}
+	}
+	lines := []string{strings.Join(tabs, " │ "), ""}
+
+	rows := m.panelRows()
+	listH := height - len(lines)
+	if len(rows) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Foreground(colorMuted).Render(m.panelEmptyText()))
+	}
+
+	cursor := m.panelCursor
+	if cursor >= len(rows) {
+		cursor = len(rows) - 1
+	}
+	start := 0
+	if listH > 0 && cursor >= listH {
+		start = cursor - listH + 1
+	}
+	for i := start; i < len(rows) && i-start < listH; i++ {
+		text := runewidth.Truncate(rows[i].text, innerW-2, "…")
+		style := lipgloss.NewStyle()
+		if rows[i].color != "" {
+			style = style.Foreground(rows[i].color)
+		}
+		if i == cursor && m.focus == FocusPanel {
+			lines = append(lines, style.Bold(true).Render("> "+text))
+		} else {
+			lines = append(lines, "  "+style.Render(text))
+		}
+	}
+
+	style := rightPaneStyle
+	if m.focus == FocusPanel {
+		style = rightPaneActiveStyle
+	}
+	return style.Width(innerW).Height(height).Render(strings.Join(lines, "\n"))
+}
+
+// panelEmptyText は行がないときの表示。
+func (m Model) panelEmptyText() string {
+	if m.activeTarget() == nil {
+		return "No target selected."
+	}
+	switch m.panelTab {
+	case PanelFindings:
+		return "No findings yet."
+	case PanelTasks:
+		return "No subtasks."
+	default:
+		return "No recon tree yet."
+	}
+}
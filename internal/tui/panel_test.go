@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+)
+
+// newPanelTestModel は偵察ツリー付きのターゲットを1つ持つ表示可能な Model を返す。
+func newPanelTestModel(width int) Model {
+	target := agent.NewTarget(1, "10.0.0.5")
+	rt := agent.NewReconTree("10.0.0.5", 2)
+	rt.AddPort(80, "http", "Apache 2.4.49")
+	rt.AddEndpoint("10.0.0.5", 80, "/", "/search")
+	rt.AddFinding("10.0.0.5", 80, "/search", agent.Finding{Category: "info_leak", Evidence: "stack trace", Severity: "low"})
+	rt.AddFinding("10.0.0.5", 80, "/search", agent.Finding{Param: "q", Category: "sqli", Evidence: "MySQL error", Severity: "high"})
+	target.SetReconTree(rt)
+
+	m := NewWithTargets([]*agent.Target{target})
+	m.handleResize(width, 40)
+	m.ready = true
+	m.rebuildViewport()
+	return m
+}
+
+func TestPanel_ToggleLayout(t *testing.T) {
+	m := newPanelTestModel(120)
+	fullW := m.viewport.Width
+
+	m.input.SetValue("/panel")
+	m.submitInput()
+	if !m.panelVisible {
+		t.Fatal("/panel should show the side panel")
+	}
+	if m.viewport.Width != fullW-m.panelWidth() {
+		t.Errorf("viewport width = %d, want %d", m.viewport.Width, fullW-m.panelWidth())
+	}
+	view := stripANSI(m.View())
+	for _, want := range []string{"Recon │ Findings │ Tasks", "80/http Apache 2.4.49", "/search"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view should contain %q", want)
+		}
+	}
+
+	// Tab で Input → Viewport → Panel → Input
+	m.cycleFocus()
+	m.cycleFocus()
+	if m.focus != FocusPanel {
+		t.Fatalf("expected FocusPanel, got %d", m.focus)
+	}
+	m.cycleFocus()
+	if m.focus != FocusInput {
+		t.Errorf("expected FocusInput after the panel, got %d", m.focus)
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = result.(Model)
+	if m.panelVisible || m.viewport.Width != fullW {
+		t.Errorf("Ctrl+T should hide the panel and restore the width (visible=%v width=%d)", m.panelVisible, m.viewport.Width)
+	}
+}
+
+func TestPanel_NarrowTerminal(t *testing.T) {
+	m := newPanelTestModel(60)
+	m.handlePanelCommand("")
+	if m.panelWidth() != 0 {
+		t.Errorf("panel should not be laid out on a narrow terminal, width=%d", m.panelWidth())
+	}
+	if strings.Contains(stripANSI(m.View()), "Recon │ Findings") {
+		t.Error("narrow terminal should not render the panel")
+	}
+}
+
+func TestPanel_FindingsSortedAndDrillDown(t *testing.T) {
+	m := newPanelTestModel(140)
+	m.handlePanelCommand("findings")
+	m.focus = FocusPanel
+
+	rows := m.panelRows()
+	if len(rows) != 2 || !strings.HasPrefix(rows[0].text, "HIGH sqli on q") || !strings.HasPrefix(rows[1].text, "LOW") {
+		t.Fatalf("findings should be sorted by severity: %+v", rows)
+	}
+
+	m.handlePanelKey("enter")
+	if m.detailBody == nil || m.focus != FocusViewport {
+		t.Fatal("Enter should open the drill-down view in the main pane")
+	}
+	content := stripANSI(m.viewport.View())
+	if !strings.Contains(content, "MySQL error") || !strings.Contains(content, "10.0.0.5:80/search") {
+		t.Errorf("drill-down should show the finding and its node:\n%s", content)
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = result.(Model)
+	if m.detailBody != nil {
+		t.Error("Esc should close the drill-down view")
+	}
+}
+
+func TestPanel_ReconDrillDownShowsSubtaskOutput(t *testing.T) {
+	m := newPanelTestModel(140)
+	m.team = agent.NewTeam(agent.TeamConfig{Events: make(chan agent.Event, 10)})
+	task := agent.NewSubTask("task-1", agent.TaskKindSmart, "web recon port 80")
+	task.TargetID = 1
+	task.Status = agent.TaskStatusRunning
+	task.Metadata.Port = 80
+	task.AppendOutput("200 /search")
+	m.team.TaskManager().InjectTask(task.ID, task)
+
+	m.handlePanelCommand("tasks")
+	if rows := m.panelRows(); len(rows) != 1 || !strings.Contains(rows[0].text, "task-1 running") {
+		t.Fatalf("tasks tab should list the subtask: %+v", rows)
+	}
+
+	m.handlePanelKey("left") // Tasks → Findings
+	m.handlePanelKey("left") // Findings → Recon
+	if m.panelTab != PanelRecon {
+		t.Fatalf("expected recon tab, got %d", m.panelTab)
+	}
+	m.handlePanelKey("enter") // 80/http
+	content := stripANSI(m.viewport.View())
+	for _, want := range []string{"Service: http Apache 2.4.49", "Subtasks:", "200 /search"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("recon drill-down should contain %q:\n%s", want, content)
+		}
+	}
+}
@@ -17,6 +17,7 @@ import (
 var (
 	glamourCacheMu    sync.Mutex
 	glamourCacheWidth int
+	glamourCacheStyle string
 	glamourCacheRdr   *glamour.TermRenderer
 )
 
@@ -52,7 +53,7 @@ func renderCommandBlock(b *agent.DisplayBlock, width int, expanded bool) string
 		lines = lines[:previewLines]
 	}
 
-	outputStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#AAAAAA"))
+	outputStyle := lipgloss.NewStyle().Foreground(colorOutput)
 	for i, line := range lines {
 		prefix := contPrefix
 		if i == 0 {
@@ -102,7 +103,7 @@ func renderAIMessageBlock(b *agent.DisplayBlock, width int) string {
 }
 
 // renderMarkdown は glamour を使って Markdown をターミナル用にレンダリングする。
-// ダークスタイルを明示指定（TUI は常にダークターミナルで使用される想定）。
+// スタイルはテーマで明示指定する（既定は dark）。
 // WithAutoStyle() は非 TTY 環境（テスト・CI）で plain にフォールバックするため使用しない。
 // glamour の dark スタイルは左右マージンを追加するため、width を縮小して渡す。
 func renderMarkdown(text string, width int) (string, error) {
@@ -115,10 +116,10 @@ func renderMarkdown(text string, width int) (string, error) {
 	glamourCacheMu.Lock()
 	defer glamourCacheMu.Unlock()
 
-	// width が変わった場合のみレンダラーを再生成する
-	if glamourCacheRdr == nil || glamourCacheWidth != wrapWidth {
+	// width かテーマが変わった場合のみレンダラーを再生成する
+	if glamourCacheRdr == nil || glamourCacheWidth != wrapWidth || glamourCacheStyle != glamourStyle {
 		r, err := glamour.NewTermRenderer(
-			glamour.WithStylePath("dark"),
+			glamour.WithStylePath(glamourStyle),
 			glamour.WithWordWrap(wrapWidth),
 		)
 		if err != nil {
@@ -126,6 +127,7 @@ func renderMarkdown(text string, width int) (string, error) {
 		}
 		glamourCacheRdr = r
 		glamourCacheWidth = wrapWidth
+		glamourCacheStyle = glamourStyle
 	}
 
 	out, err := glamourCacheRdr.Render(text)
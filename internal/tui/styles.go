@@ -1,66 +1,200 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+	"strings"
 
-// Color palette
-var (
-	colorPrimary      = lipgloss.Color("#00D7FF") // cyan  — focus / AI
-	colorSecondary    = lipgloss.Color("#AF87FF") // purple — AI source label
-	colorSuccess      = lipgloss.Color("#87FF5F") // green — PWNED / USER
-	colorWarning      = lipgloss.Color("#FFD700") // yellow — PAUSED / proposal
-	colorDanger       = lipgloss.Color("#FF5555") // red — FAILED
-	colorMuted        = lipgloss.Color("#555577") // dim gray — timestamps / hints
-	colorBorder       = lipgloss.Color("#333355") // default border
-	colorBorderActive = lipgloss.Color("#00D7FF") // focused border
+	"github.com/charmbracelet/lipgloss"
 )
 
-// Pane borders
+// Theme は TUI の配色。tui.theme で選び、SetTheme で適用する。
+type Theme struct {
+	Primary      lipgloss.Color // focus / AI
+	Secondary    lipgloss.Color // AI source label / spinner
+	Success      lipgloss.Color // PWNED / USER
+	Warning      lipgloss.Color // PAUSED / proposal
+	Danger       lipgloss.Color // FAILED
+	Muted        lipgloss.Color // timestamps / hints
+	Border       lipgloss.Color // default border
+	BorderActive lipgloss.Color // focused border
+	Output       lipgloss.Color // command output
+	StatusBg     lipgloss.Color // status bar background
+	UserBg       lipgloss.Color // user input block background
+	MatchFg      lipgloss.Color // search highlight text
+	Glamour      string         // glamour のスタイル名（AI メッセージの Markdown）
+}
+
+// themes は組み込みのテーマ。
+var themes = map[string]Theme{
+	"dark": {
+		Primary:      "#00D7FF", // cyan
+		Secondary:    "#AF87FF", // purple
+		Success:      "#87FF5F", // green
+		Warning:      "#FFD700", // yellow
+		Danger:       "#FF5555", // red
+		Muted:        "#555577", // dim gray
+		Border:       "#333355",
+		BorderActive: "#00D7FF",
+		Output:       "#AAAAAA",
+		StatusBg:     "#0D0D1A",
+		UserBg:       "#1A1A2E",
+		MatchFg:      "#000000",
+		Glamour:      "dark",
+	},
+	"light": {
+		Primary:      "#005F87",
+		Secondary:    "#5F00AF",
+		Success:      "#005F00",
+		Warning:      "#875F00",
+		Danger:       "#AF0000",
+		Muted:        "#6C6C6C",
+		Border:       "#BCBCBC",
+		BorderActive: "#005F87",
+		Output:       "#444444",
+		StatusBg:     "#E4E4E4",
+		UserBg:       "#EEEEEE",
+		MatchFg:      "#FFFFFF",
+		Glamour:      "light",
+	},
+	// 背景は端末のまま、前景は最大コントラストの原色と白だけを使う
+	"high-contrast": {
+		Primary:      "#00FFFF",
+		Secondary:    "#FF00FF",
+		Success:      "#00FF00",
+		Warning:      "#FFFF00",
+		Danger:       "#FF0000",
+		Muted:        "#D0D0D0",
+		Border:       "#FFFFFF",
+		BorderActive: "#FFFF00",
+		Output:       "#FFFFFF",
+		StatusBg:     "#000000",
+		UserBg:       "#000000",
+		MatchFg:      "#000000",
+		Glamour:      "dark",
+	},
+}
+
+// noColorTheme は色を使わないテーマ。空の Color は lipgloss で無色になる。
+// 太字・枠線・ハイライトの反転などの装飾は残る。
+var noColorTheme = Theme{Glamour: "notty"}
+
+// ThemeNames は組み込みテーマ名を返す。
+func ThemeNames() []string {
+	return []string{"dark", "light", "high-contrast"}
+}
+
+// Color palette（SetTheme で差し替える）
 var (
-	rightPaneStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorBorder)
+	colorPrimary      lipgloss.Color
+	colorSecondary    lipgloss.Color
+	colorSuccess      lipgloss.Color
+	colorWarning      lipgloss.Color
+	colorDanger       lipgloss.Color
+	colorMuted        lipgloss.Color
+	colorBorder       lipgloss.Color
+	colorBorderActive lipgloss.Color
+	colorOutput       lipgloss.Color
+	colorMatchFg      lipgloss.Color
 
-	rightPaneActiveStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(colorBorderActive)
+	// glamourStyle は AI メッセージの Markdown レンダリングに使う glamour のスタイル名。
+	glamourStyle string
 )
 
-// Input bar
+// Pane borders / input bar / status bar / dialogs（applyTheme で再構築する）
 var (
-	inputBarStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorBorder)
+	rightPaneStyle       lipgloss.Style
+	rightPaneActiveStyle lipgloss.Style
+	inputBarStyle        lipgloss.Style
+	inputBarActiveStyle  lipgloss.Style
+	statusBarStyle       lipgloss.Style
+	proposalBoxStyle     lipgloss.Style // rendered inside viewport
+	confirmQuitBoxStyle  lipgloss.Style // centered overlay
 
-	inputBarActiveStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(colorBorderActive)
+	// foldIndicatorStyle は折りたたみ行の「⋯ +N Lines (Ctrl+O)」スタイル。
+	foldIndicatorStyle lipgloss.Style
+
+	// userInputBlockStyle はハイライト背景でユーザー入力を目立たせる。
+	userInputBlockStyle lipgloss.Style
+
+	// 検索にマッチした語（現在のマッチは強調）
+	searchMatchStyle   lipgloss.Style
+	searchCurrentStyle lipgloss.Style
 )
 
-// Status bar (top)
-var statusBarStyle = lipgloss.NewStyle().
-	Background(lipgloss.Color("#0D0D1A")).
-	Foreground(colorPrimary).
-	Padding(0, 1)
-
-// Proposal box (rendered inside viewport)
-var proposalBoxStyle = lipgloss.NewStyle().
-	Border(lipgloss.RoundedBorder()).
-	BorderForeground(colorWarning).
-	Padding(0, 1)
-
-// Quit confirmation dialog (centered overlay)
-var confirmQuitBoxStyle = lipgloss.NewStyle().
-	Border(lipgloss.DoubleBorder()).
-	BorderForeground(colorDanger).
-	Padding(0, 2)
-
-
-// foldIndicatorStyle は折りたたみ行の「⋯ +N Lines (Ctrl+O)」スタイル。
-var foldIndicatorStyle = lipgloss.NewStyle().Foreground(colorMuted).Italic(true)
-
-// User input block style — ハイライト背景でユーザー入力を目立たせる
-var userInputBlockStyle = lipgloss.NewStyle().
-	Background(lipgloss.Color("#1A1A2E")).
-	Foreground(colorSuccess).
-	Bold(true).
-	Padding(0, 1)
+func init() {
+	applyTheme(themes["dark"])
+}
+
+// SetTheme はテーマを名前で選んで適用する（空 = dark）。noColor が true なら色を使わない。
+// TUI の起動前に呼ぶこと（描画済みブロックのキャッシュは更新されない）。
+func SetTheme(name string, noColor bool) error {
+	if noColor {
+		applyTheme(noColorTheme)
+		return nil
+	}
+	if name == "" {
+		name = "dark"
+	}
+	t, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("tui: unknown theme %q (available: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	applyTheme(t)
+	return nil
+}
+
+// applyTheme はパレットを差し替えてスタイルを作り直す。
+func applyTheme(t Theme) {
+	colorPrimary = t.Primary
+	colorSecondary = t.Secondary
+	colorSuccess = t.Success
+	colorWarning = t.Warning
+	colorDanger = t.Danger
+	colorMuted = t.Muted
+	colorBorder = t.Border
+	colorBorderActive = t.BorderActive
+	colorOutput = t.Output
+	colorMatchFg = t.MatchFg
+	glamourStyle = t.Glamour
+
+	rightPaneStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBorder)
+	rightPaneActiveStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBorderActive)
+
+	inputBarStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBorder)
+	inputBarActiveStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBorderActive)
+
+	statusBarStyle = lipgloss.NewStyle().
+		Background(t.StatusBg).
+		Foreground(colorPrimary).
+		Padding(0, 1)
+
+	proposalBoxStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorWarning).
+		Padding(0, 1)
+
+	confirmQuitBoxStyle = lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(colorDanger).
+		Padding(0, 2)
+
+	foldIndicatorStyle = lipgloss.NewStyle().Foreground(colorMuted).Italic(true)
+
+	userInputBlockStyle = lipgloss.NewStyle().
+		Background(t.UserBg).
+		Foreground(colorSuccess).
+		Bold(true).
+		Padding(0, 1)
+
+	// 無色テーマでは背景色が付かないため、反転で位置が分かるようにする
+	searchMatchStyle = lipgloss.NewStyle().Foreground(colorMatchFg).Background(colorMuted).Underline(true)
+	searchCurrentStyle = lipgloss.NewStyle().Foreground(colorMatchFg).Background(colorWarning).Bold(true).Reverse(t.Warning == "")
+}
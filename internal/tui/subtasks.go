@@ -0,0 +1,177 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+)
+
+// taskUsage は /task コマンドの使い方。
+const taskUsage = "Usage: /tasks | /task <id> | /task msg <id> <message> | /task kill <id>"
+
+// taskManager は Team の TaskManager を返す（デモモードでは nil）。
+func (m *Model) taskManager() *agent.TaskManager {
+	if m.team == nil {
+		return nil
+	}
+	return m.team.TaskManager()
+}
+
+// targetTasks は指定ターゲットの全サブタスクを開始順に返す。
+func (m *Model) targetTasks(targetID int) []*agent.SubTask {
+	tm := m.taskManager()
+	if tm == nil {
+		return nil
+	}
+	tasks := tm.AllTasks(targetID)
+	sortTasks(tasks)
+	return tasks
+}
+
+// taskRows は Tasks タブの行（状態・ターン数・経過時間）を返す。Enter でアタッチする。
+func (m *Model) taskRows(targetID int) []panelRow {
+	tasks := m.targetTasks(targetID)
+	rows := make([]panelRow, 0, len(tasks))
+	for _, task := range tasks {
+		icon, color := m.taskStatusIcon(task.Status)
+		rows = append(rows, panelRow{
+			text:   icon + taskLine(task),
+			color:  color,
+			taskID: task.ID,
+		})
+	}
+	return rows
+}
+
+// taskLine はサブタスクの1行表示を返す（例: "task-2 running 3/10 1m5s web recon port 80"）。
+func taskLine(task *agent.SubTask) string {
+	turns := ""
+	if task.MaxTurns > 0 {
+		turns = fmt.Sprintf(" %d/%d", task.TurnCount, task.MaxTurns)
+	}
+	return fmt.Sprintf("%s %s%s %s %s", task.ID, task.Status, turns, formatDuration(task.Elapsed()), task.Goal)
+}
+
+// taskStatusIcon はサブタスクの状態アイコン（末尾の空白込み）と表示色を返す。
+// 実行中はスピナーのフレームを使う（フレーム自体が末尾に空白を持つ）。
+func (m *Model) taskStatusIcon(s agent.TaskStatus) (string, lipgloss.Color) {
+	switch s {
+	case agent.TaskStatusRunning:
+		return m.spinner.View(), colorWarning
+	case agent.TaskStatusCompleted:
+		return "✓ ", colorSuccess
+	case agent.TaskStatusFailed:
+		return "✗ ", colorDanger
+	case agent.TaskStatusCancelled:
+		return "⊘ ", colorMuted
+	default:
+		return "… ", colorMuted
+	}
+}
+
+// attachTask はサブタスクの出力をメインペインに表示し、入力をそのタスクへのメッセージにする。
+// 出力は再描画のたびに FullOutput から取り直す。Esc でデタッチする。
+func (m *Model) attachTask(id string) {
+	tm := m.taskManager()
+	if tm == nil {
+		m.logSystem("Subtasks are not available.")
+		return
+	}
+	task, ok := tm.GetTask(id)
+	if !ok {
+		m.logSystem(fmt.Sprintf("Task not found: %s", id))
+		return
+	}
+	m.openDetail("Subtask "+id, func() string {
+		return taskLine(task) + "\n\n" + tailLines(task.FullOutput(), detailMaxLines)
+	})
+	m.attachedTask = id
+	m.viewport.GotoBottom()
+	m.focus = FocusInput
+	m.input.Focus()
+}
+
+// sendTaskMessage はサブタスクにメッセージを送り、結果をログに残す。
+func (m *Model) sendTaskMessage(id, msg string) {
+	tm := m.taskManager()
+	if tm == nil {
+		m.logSystem("Subtasks are not available.")
+		return
+	}
+	if err := tm.SendMessage(id, msg); err != nil {
+		m.logSystem(fmt.Sprintf("Failed to send message: %v", err))
+		return
+	}
+	if t := m.activeTarget(); t != nil {
+		t.AddBlock(agent.NewUserInputBlock(fmt.Sprintf("[%s] %s", id, msg)))
+	}
+	m.rebuildViewport()
+}
+
+// confirmKillTask は確認の選択UIを出してからサブタスクを kill する。
+func (m *Model) confirmKillTask(id string) {
+	m.showSelect(
+		fmt.Sprintf("Kill %s?", id),
+		[]SelectOption{
+			{Label: "No", Value: "no"},
+			{Label: "Yes -- cancel the subtask", Value: "yes"},
+		},
+		func(m *Model, value string) {
+			if value == "yes" {
+				m.killTask(id)
+			}
+		},
+	)
+}
+
+// killTask は TaskManager.KillTask でサブタスクをキャンセルする。
+func (m *Model) killTask(id string) {
+	tm := m.taskManager()
+	if tm == nil {
+		m.logSystem("Subtasks are not available.")
+		return
+	}
+	if err := tm.KillTask(id); err != nil {
+		m.logSystem(fmt.Sprintf("Failed to kill task: %v", err))
+		return
+	}
+	m.logSystem(fmt.Sprintf("Task %s cancelled by user", id))
+}
+
+// handleTasksCommand は /tasks を処理し、アクティブターゲットのサブタスク一覧を表示する。
+func (m *Model) handleTasksCommand() {
+	t := m.activeTarget()
+	if t == nil {
+		m.logSystem("No target selected.")
+		return
+	}
+	tasks := m.targetTasks(t.ID)
+	if len(tasks) == 0 {
+		m.logSystem("No subtasks for this target.")
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("Subtasks:")
+	for _, task := range tasks {
+		sb.WriteString("\n  " + taskLine(task))
+	}
+	sb.WriteString("\n" + taskUsage)
+	m.logSystem(sb.String())
+}
+
+// handleTaskCommand は /task <id> | msg <id> <message> | kill <id> を処理する。
+func (m *Model) handleTaskCommand(args []string) {
+	switch {
+	case len(args) == 1 && args[0] != "msg" && args[0] != "kill":
+		m.attachTask(args[0])
+	case len(args) >= 3 && args[0] == "msg":
+		m.sendTaskMessage(args[1], strings.Join(args[2:], " "))
+	case len(args) == 2 && args[0] == "kill":
+		m.killTask(args[1])
+	default:
+		m.logSystem(taskUsage)
+	}
+}
@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/0x6d61/pentecter/internal/agent"
+)
+
+// injectSubTask はターゲット 1 の実行中サブタスクを TaskManager に注入する。
+func injectSubTask(m *Model, id, goal string) *agent.SubTask {
+	task := agent.NewSubTask(id, agent.TaskKindSmart, goal)
+	task.TargetID = 1
+	task.Status = agent.TaskStatusRunning
+	task.StartedAt = time.Now().Add(-65 * time.Second)
+	task.MaxTurns = 10
+	task.TurnCount = 3
+	m.team.TaskManager().InjectTask(id, task)
+	return task
+}
+
+func TestSubtasks_ListAndAttach(t *testing.T) {
+	m := newPanelTestModel(140)
+	m.team = agent.NewTeam(agent.TeamConfig{Events: make(chan agent.Event, 10)})
+	task := injectSubTask(&m, "task-1", "web recon port 80")
+	task.AppendOutput("[think] enumerate directories")
+
+	m.input.SetValue("/tasks")
+	m.submitInput()
+	last := m.targets[0].LastBlock()
+	if last == nil || !strings.Contains(last.SystemMsg, "task-1 running 3/10 1m5s web recon port 80") {
+		t.Fatalf("/tasks should list status, turns and elapsed time, got %+v", last)
+	}
+
+	m.handlePanelCommand("tasks")
+	m.focus = FocusPanel
+	m.handlePanelKey("enter")
+	if m.attachedTask != "task-1" || m.focus != FocusInput {
+		t.Fatalf("Enter should attach to the subtask (attached=%q focus=%d)", m.attachedTask, m.focus)
+	}
+	if !strings.Contains(stripANSI(m.viewport.View()), "enumerate directories") {
+		t.Errorf("attached pane should show the subtask output:\n%s", stripANSI(m.viewport.View()))
+	}
+
+	// 出力の追記は再描画で反映される
+	task.AppendOutput("200 /admin")
+	m.rebuildViewport()
+	if !strings.Contains(stripANSI(m.viewport.View()), "200 /admin") {
+		t.Error("attached pane should follow new output")
+	}
+
+	// アタッチ中の入力はサブタスクへのメッセージになる
+	m.input.SetValue("focus on /admin")
+	m.submitInput()
+	if !strings.Contains(m.targets[0].LastBlock().UserText, "[task-1] focus on /admin") {
+		t.Errorf("message should be echoed in the target log, got %+v", m.targets[0].LastBlock())
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = result.(Model)
+	if m.attachedTask != "" || m.detailBody != nil {
+		t.Error("Esc should detach from the subtask")
+	}
+}
+
+func TestSubtasks_MessageAndKillCommands(t *testing.T) {
+	m := newPanelTestModel(140)
+	m.team = agent.NewTeam(agent.TeamConfig{Events: make(chan agent.Event, 10)})
+	task := injectSubTask(&m, "task-2", "smb enum")
+	ctx, cancel := context.WithCancel(context.Background())
+	task.SetCancel(cancel)
+
+	m.input.SetValue("/task msg task-2 try null session")
+	m.submitInput()
+	if !strings.Contains(m.targets[0].LastBlock().UserText, "[task-2] try null session") {
+		t.Errorf("/task msg should send the message, got %+v", m.targets[0].LastBlock())
+	}
+
+	m.input.SetValue("/task msg task-9 hello")
+	m.submitInput()
+	if !strings.Contains(m.targets[0].LastBlock().SystemMsg, "task not found") {
+		t.Errorf("unknown task should be reported, got %+v", m.targets[0].LastBlock())
+	}
+
+	// パネルの x は確認を挟む
+	m.handlePanelCommand("tasks")
+	m.focus = FocusPanel
+	m.handlePanelKey("x")
+	if m.inputMode != InputSelect {
+		t.Fatal("x should ask for confirmation before killing")
+	}
+	m.selectIndex = 1 // Yes
+	m.handleSelectKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if ctx.Err() == nil {
+		t.Error("confirming should cancel the subtask")
+	}
+	if !strings.Contains(m.targets[0].LastBlock().SystemMsg, "Task task-2 cancelled by user") {
+		t.Errorf("kill should be logged, got %+v", m.targets[0].LastBlock())
+	}
+
+	m.input.SetValue("/task kill")
+	m.submitInput()
+	if !strings.Contains(m.targets[0].LastBlock().SystemMsg, "Usage: /tasks") {
+		t.Errorf("malformed /task should show usage, got %+v", m.targets[0].LastBlock())
+	}
+}
@@ -1,10 +1,12 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -12,6 +14,7 @@ import (
 
 	"github.com/0x6d61/pentecter/internal/agent"
 	"github.com/0x6d61/pentecter/internal/brain"
+	"github.com/0x6d61/pentecter/internal/mcp"
 )
 
 // ipv4Re matches an IPv4 address in text.
@@ -124,25 +127,59 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// Command palette intercepts all keys while open.
+		if m.inputMode == InputPalette {
+			m.handlePaletteKey(msg)
+			return m, nil
+		}
+
+		// 補完候補の表示は次のキー入力まで
+		if !m.Keys.matches(msg.String(), actionFocusNext) {
+			m.completionHints = nil
+		}
+
+		// Global: Ctrl+P opens the command palette.
+		if m.Keys.matches(msg.String(), actionPalette) {
+			m.openPalette()
+			return m, nil
+		}
+
 		// Global: Tab cycles focus between panes.
-		if msg.String() == "tab" {
+		// 入力欄に文字があるときは補完（コマンド・スキル・ホスト・サブタスク・ナレッジのパス）。
+		if m.Keys.matches(msg.String(), actionFocusNext) {
+			if m.focus == FocusInput && strings.TrimSpace(m.input.Value()) != "" {
+				m.completeInput()
+				return m, nil
+			}
 			m.cycleFocus()
 			return m, nil
 		}
 
 		// Global: Ctrl+O toggles log folding (works from any pane).
-		if msg.String() == "ctrl+o" {
+		if m.Keys.matches(msg.String(), actionToggleLogs) {
 			m.logsExpanded = !m.logsExpanded
 			m.rebuildViewport()
 			return m, nil
 		}
 
+		// Global: Ctrl+T toggles the side panel (recon tree / findings / tasks).
+		if m.Keys.matches(msg.String(), actionTogglePanel) {
+			m.togglePanel()
+			return m, nil
+		}
+
+		// Esc closes the drill-down view and returns to the session log.
+		if m.Keys.matches(msg.String(), actionBack) && m.detailBody != nil {
+			m.closeDetail()
+			return m, nil
+		}
+
 		// Proposal approval keys — handled regardless of which pane is focused,
 		// as long as the active target has a pending proposal.
 		if t := m.activeTarget(); t != nil {
 			if prop := t.GetProposal(); prop != nil {
-				switch msg.String() {
-				case "y", "Y":
+				switch key := msg.String(); {
+				case m.Keys.matches(key, actionApprove):
 					t.AddBlock(agent.NewUserInputBlock("Approved: " + prop.Description))
 					t.SetStatusSafe(agent.StatusRunning)
 					t.ClearProposal()
@@ -155,7 +192,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						}
 					}
 					return m, nil
-				case "n", "N":
+				case m.Keys.matches(key, actionReject):
 					t.AddBlock(agent.NewUserInputBlock("Rejected: " + prop.Description))
 					t.SetStatusSafe(agent.StatusIdle)
 					t.ClearProposal()
@@ -168,7 +205,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						}
 					}
 					return m, nil
-				case "e", "E":
+				case m.Keys.matches(key, actionEdit):
 					// Populate the input box with the proposal command for editing.
 					m.input.SetValue(prop.Tool + " " + strings.Join(prop.Args, " "))
 					m.focus = FocusInput
@@ -181,8 +218,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Focus-specific key handling.
 		switch m.focus {
 		case FocusViewport:
-			m.viewport, cmd = m.viewport.Update(msg)
-			cmds = append(cmds, cmd)
+			switch key := msg.String(); {
+			case m.Keys.matches(key, actionSearch):
+				// 検索語の入力に移る（Enter で /search を実行）
+				m.input.SetValue("/search ")
+				m.focus = FocusInput
+				m.input.Focus()
+			case m.Keys.matches(key, actionNextMatch):
+				m.moveMatch(1)
+			case m.Keys.matches(key, actionPrevMatch):
+				m.moveMatch(-1)
+			default:
+				m.viewport, cmd = m.viewport.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+
+		case FocusPanel:
+			m.handlePanelKey(msg.String())
 
 		case FocusInput:
 			switch msg.String() {
@@ -216,8 +268,8 @@ func (m *Model) handleResize(w, h int) {
 		paneH = 4
 	}
 
-	// Main pane: viewport (full width)
-	vpW := w - 4 // subtract 2 borders + 2 side margins
+	// Main pane: viewport (full width minus the side panel when visible)
+	vpW := w - m.panelWidth() - 4 // subtract 2 borders + 2 side margins
 	vpH := paneH - 2
 	if vpW < 10 {
 		vpW = 10
@@ -234,9 +286,17 @@ func (m *Model) handleResize(w, h int) {
 }
 
 // cycleFocus toggles focus between Viewport and Input.
+// When the side panel is visible, the order is Input → Viewport → Panel → Input.
 func (m *Model) cycleFocus() {
 	switch m.focus {
 	case FocusViewport:
+		if m.panelWidth() > 0 {
+			m.focus = FocusPanel
+			return
+		}
+		m.focus = FocusInput
+		m.input.Focus()
+	case FocusPanel:
 		m.focus = FocusInput
 		m.input.Focus()
 	case FocusInput:
@@ -260,12 +320,68 @@ func (m *Model) submitInput() {
 		return
 	}
 
-	// /model command — switch LLM provider/model
+	// /models command — show the model of every route
+	if fullText == "/models" {
+		m.handleModelsCommand()
+		return
+	}
+
+	// /model command — switch LLM provider/model (optionally for one route)
 	if strings.HasPrefix(fullText, "/model") {
 		m.handleModelCommand(fullText)
 		return
 	}
 
+	// /reload command — hot-reload tools, skills, blacklist and MCP servers
+	if fullText == "/reload" {
+		m.handleReloadCommand()
+		return
+	}
+
+	// /mcp command — show MCP server status, resources and prompts
+	if fullText == "/mcp" {
+		m.handleMCPCommand()
+		return
+	}
+	if strings.HasPrefix(fullText, "/mcp ") {
+		m.handleMCPSubcommand(strings.Fields(strings.TrimPrefix(fullText, "/mcp ")))
+		return
+	}
+
+	// /panel command — toggle the side panel or switch its tab
+	if fullText == "/panel" || strings.HasPrefix(fullText, "/panel ") {
+		m.handlePanelCommand(strings.TrimSpace(strings.TrimPrefix(fullText, "/panel")))
+		return
+	}
+
+	// /tasks, /task command — list, attach to, message or kill subtasks
+	if fullText == "/tasks" {
+		m.handleTasksCommand()
+		return
+	}
+	if strings.HasPrefix(fullText, "/task ") {
+		m.handleTaskCommand(strings.Fields(strings.TrimPrefix(fullText, "/task ")))
+		return
+	}
+
+	// /search, /filter, /turn, /export — navigate and export the session log
+	if fullText == "/search" || strings.HasPrefix(fullText, "/search ") {
+		m.handleSearchCommand(strings.TrimSpace(strings.TrimPrefix(fullText, "/search")))
+		return
+	}
+	if fullText == "/filter" || strings.HasPrefix(fullText, "/filter ") {
+		m.handleFilterCommand(strings.TrimSpace(strings.TrimPrefix(fullText, "/filter")))
+		return
+	}
+	if fullText == "/turn" || strings.HasPrefix(fullText, "/turn ") {
+		m.handleTurnCommand(strings.TrimSpace(strings.TrimPrefix(fullText, "/turn")))
+		return
+	}
+	if fullText == "/export" || strings.HasPrefix(fullText, "/export ") {
+		m.handleExportCommand(strings.Fields(strings.TrimPrefix(fullText, "/export")))
+		return
+	}
+
 	// /targets command — show target list for selection
 	if fullText == "/targets" {
 		m.handleTargetsCommand()
@@ -278,12 +394,24 @@ func (m *Model) submitInput() {
 		return
 	}
 
+	// /kb command — open a knowledge base file in the main pane
+	if fullText == "/kb" || strings.HasPrefix(fullText, "/kb ") {
+		m.handleKBCommand(strings.TrimSpace(strings.TrimPrefix(fullText, "/kb")))
+		return
+	}
+
 	// /skip-recon command — unlock RECON phase for the active target
 	if fullText == "/skip-recon" {
 		m.handleSkipReconCommand()
 		return
 	}
 
+	// サブタスクにアタッチ中は入力をそのタスクへのメッセージとして送る
+	if m.attachedTask != "" && !strings.HasPrefix(fullText, "/") {
+		m.sendTaskMessage(m.attachedTask, fullText)
+		return
+	}
+
 	// ターゲット追加: IP アドレスまたは /target <host>
 	if host, ok := parseTargetInput(fullText); ok && m.team != nil {
 		m.addTarget(host)
@@ -312,6 +440,10 @@ func (m *Model) submitInput() {
 			m.rebuildViewport()
 			return
 		}
+		// 正規表現で見つからなければ Brain に抽出させる（結果は EventAddTarget で届く）
+		m.logSystem("Looking for a target in your message...")
+		m.team.ExtractTarget(fullText)
+		return
 	}
 
 	if t := m.activeTarget(); t != nil {
@@ -368,6 +500,27 @@ func (m *Model) addTarget(host string) {
 	m.rebuildViewport()
 }
 
+// addTargetFromEvent は EventAddTarget のホストを追加する。
+// Message（ターゲット抽出で残った指示）があれば新しいターゲットの Agent に送る。
+func (m *Model) addTargetFromEvent(e agent.Event) {
+	if e.NewHost == "" || m.team == nil {
+		return
+	}
+	m.addTarget(e.NewHost)
+	t := m.activeTarget()
+	if e.Message == "" || t == nil || t.Host != e.NewHost {
+		return
+	}
+	t.AddBlock(agent.NewUserInputBlock(e.Message))
+	if ch, ok := m.agentUserMsgMap[t.ID]; ok {
+		select {
+		case ch <- e.Message:
+		default:
+		}
+	}
+	m.rebuildViewport()
+}
+
 // handleApproveCommand processes /approve commands.
 // Always shows interactive select UI (ON/OFF).
 func (m *Model) handleApproveCommand(_ string) {
@@ -431,7 +584,23 @@ func modelsForProvider(p brain.Provider) []SelectOption {
 
 // handleModelCommand processes /model commands.
 // Always shows interactive 2-step select UI (provider → model).
-func (m *Model) handleModelCommand(_ string) {
+// With a route (/model exploit, /model target, ...) the selection applies to that route only,
+// and /model <route> default restores the route's default model.
+func (m *Model) handleModelCommand(text string) {
+	route, reset, ok := m.parseModelRoute(text)
+	if !ok {
+		m.logSystem("No target selected.")
+		return
+	}
+	if route != "" && m.ModelRouter == nil {
+		m.logSystem("Model routing not available")
+		return
+	}
+	if reset {
+		m.routeModel(route, "", "")
+		return
+	}
+
 	detected := brain.DetectAvailableProviders()
 	if len(detected) == 0 {
 		m.logSystem("No providers detected. Set ANTHROPIC_API_KEY, OPENAI_API_KEY, or OLLAMA_BASE_URL.")
@@ -446,27 +615,93 @@ func (m *Model) handleModelCommand(_ string) {
 		}
 	}
 
+	apply := func(m *Model, provider brain.Provider, model string) {
+		if route == "" {
+			m.switchModel(provider, model)
+			return
+		}
+		m.routeModel(route, provider, model)
+	}
+	title := "Select provider:"
+	if route != "" {
+		title = fmt.Sprintf("Select provider for %s:", route)
+	}
 	m.showSelect(
-		"Select provider:",
+		title,
 		options,
 		func(m *Model, providerValue string) {
 			provider := brain.Provider(providerValue)
 			models := modelsForProvider(provider)
 			if len(models) == 0 {
-				m.switchModel(provider, "")
+				apply(m, provider, "")
 				return
 			}
 			m.showSelect(
 				fmt.Sprintf("Select model (%s):", providerValue),
 				models,
 				func(m *Model, modelValue string) {
-					m.switchModel(provider, modelValue)
+					apply(m, provider, modelValue)
 				},
 			)
 		},
 	)
 }
 
+// parseModelRoute は /model の引数からルートを取り出す。
+// "target" はアクティブなターゲット（target:<host>）になり、ターゲットがなければ ok = false。
+// 第2引数 default で reset を返す。ルート名でない引数は無視してメインのモデルを切り替える。
+func (m *Model) parseModelRoute(text string) (route string, reset, ok bool) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return "", false, true
+	}
+	switch fields[1] {
+	case "target":
+		t := m.activeTarget()
+		if t == nil {
+			return "", false, false
+		}
+		route = "target:" + t.Host
+	case "exploit", "subagent", "recon", "extract":
+		route = fields[1]
+	default:
+		return "", false, true
+	}
+	return route, len(fields) > 2 && fields[2] == "default", true
+}
+
+// routeModel はルートのモデルを ModelRouter で切り替える（provider が空なら既定に戻す）。
+func (m *Model) routeModel(route string, provider brain.Provider, model string) {
+	hint := brain.ConfigHint{Provider: provider, Model: model}
+	if err := m.ModelRouter(route, hint); err != nil {
+		m.logSystem(fmt.Sprintf("Failed to switch %s model: %v", route, err))
+		return
+	}
+	if provider == "" {
+		m.logSystem(fmt.Sprintf("%s now uses the default model", route))
+		return
+	}
+	msg := fmt.Sprintf("Switched %s to %s", route, provider)
+	if model != "" {
+		msg += "/" + model
+	}
+	m.logSystem(msg)
+}
+
+// handleModelsCommand は /models を処理し、ルートごとのモデルを表示する。
+func (m *Model) handleModelsCommand() {
+	if m.ModelRoutes == nil {
+		label := m.CurrentProvider
+		if m.CurrentModel != "" {
+			label += "/" + m.CurrentModel
+		}
+		m.logSystem("Models:\n  main: " + label)
+		return
+	}
+	m.logSystem("Models:\n  " + strings.Join(m.ModelRoutes(), "\n  ") +
+		"\nSwitch with /model <exploit|subagent|recon|extract|target> [default]")
+}
+
 // switchModel executes the actual model switch via BrainFactory.
 func (m *Model) switchModel(provider brain.Provider, model string) {
 	if m.BrainFactory == nil {
@@ -530,6 +765,28 @@ func (m *Model) handleTargetsCommand() {
 	)
 }
 
+// kbMaxBytes は /kb で表示するナレッジファイルの上限サイズ。
+const kbMaxBytes = 64 * 1024
+
+// handleKBCommand は /kb <source:path> を処理し、ナレッジのファイルをドリルダウン表示する。
+// パスは Tab で補完できる。
+func (m *Model) handleKBCommand(path string) {
+	if m.Knowledge.Len() == 0 {
+		m.logSystem("Knowledge base is not configured.")
+		return
+	}
+	if path == "" {
+		m.logSystem(fmt.Sprintf("Usage: /kb <source:path> (sources: %s)", strings.Join(m.Knowledge.Sources(), ", ")))
+		return
+	}
+	content, err := m.Knowledge.ReadFile(path, kbMaxBytes)
+	if err != nil {
+		m.logSystem(fmt.Sprintf("Failed to read %s: %v", path, err))
+		return
+	}
+	m.openDetail("Knowledge "+path, func() string { return content })
+}
+
 // handleReconTreeCommand は /recontree コマンドを処理する。
 func (m *Model) handleReconTreeCommand() {
 	if m.selected < 0 || m.selected >= len(m.targets) {
@@ -568,6 +825,125 @@ func (m *Model) handleSkipReconCommand() {
 	m.logSystem(fmt.Sprintf("RECON phase unlocked (%d pending tasks skipped). Agent will proceed to ANALYZE.", pending))
 }
 
+// handleReloadCommand は /reload コマンドを処理する。
+// 設定を読み直し、反映内容と失敗した項目をシステムメッセージで表示する。
+func (m *Model) handleReloadCommand() {
+	if m.Reloader == nil {
+		m.logSystem("Reload is not available.")
+		return
+	}
+	summary, err := m.Reloader()
+	m.logSystem(summary)
+	if err != nil {
+		m.logSystem(fmt.Sprintf("Reload errors: %v", err))
+	}
+}
+
+// handleMCPCommand は /mcp コマンドを処理する。
+// 各 MCP サーバーの稼働状態・ツール数・再起動回数・直近のエラーを表示する。
+func (m *Model) handleMCPCommand() {
+	if m.MCPManager == nil {
+		m.logSystem("MCP is not configured (config/mcp.yaml).")
+		return
+	}
+	statuses := m.MCPManager.Status()
+	if len(statuses) == 0 {
+		m.logSystem("No MCP servers configured.")
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("MCP servers:")
+	for _, st := range statuses {
+		fmt.Fprintf(&sb, "\n  %-16s %-5s %-8s tools=%d restarts=%d", st.Name, st.Transport, st.Status, st.Tools, st.Restarts)
+		if st.Status == mcp.StatusDown && !st.NextRetry.IsZero() {
+			fmt.Fprintf(&sb, " retry in %s", time.Until(st.NextRetry).Round(time.Second))
+		}
+		if st.LastError != "" {
+			fmt.Fprintf(&sb, "\n    last error: %s", st.LastError)
+		}
+	}
+	m.logSystem(sb.String())
+}
+
+// mcpRequestTimeout は /mcp サブコマンドの MCP リクエストのタイムアウト。
+const mcpRequestTimeout = 30 * time.Second
+
+// mcpUsage は /mcp サブコマンドの使い方。
+const mcpUsage = "Usage: /mcp | /mcp resources [server] | /mcp read <server> <uri> | /mcp prompts [server] | /mcp prompt <server> <name> [key=value ...]"
+
+// handleMCPSubcommand は /mcp resources / read / prompts / prompt を処理する。
+// /mcp prompt は展開したプロンプトをアクティブなターゲットの Agent に送る。
+func (m *Model) handleMCPSubcommand(args []string) {
+	if m.MCPManager == nil {
+		m.logSystem("MCP is not configured (config/mcp.yaml).")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), mcpRequestTimeout)
+	defer cancel()
+
+	server := ""
+	if len(args) > 1 {
+		server = args[1]
+	}
+	switch {
+	case args[0] == "resources":
+		resources, err := m.MCPManager.ListResources(ctx, server)
+		if err != nil {
+			m.logSystem(fmt.Sprintf("MCP error: %v", err))
+			return
+		}
+		m.logSystem(strings.TrimRight(agent.FormatMCPCatalog(resources, nil), "\n"))
+
+	case args[0] == "prompts":
+		prompts, err := m.MCPManager.ListPrompts(ctx, server)
+		if err != nil {
+			m.logSystem(fmt.Sprintf("MCP error: %v", err))
+			return
+		}
+		m.logSystem(strings.TrimRight(agent.FormatMCPCatalog(nil, prompts), "\n"))
+
+	case args[0] == "read" && len(args) == 3:
+		contents, err := m.MCPManager.ReadResource(ctx, args[1], args[2])
+		if err != nil {
+			m.logSystem(fmt.Sprintf("MCP error: %v", err))
+			return
+		}
+		m.logSystem(agent.FormatMCPContents(contents, 8000))
+
+	case args[0] == "prompt" && len(args) >= 3:
+		promptArgs := make(map[string]string)
+		for _, kv := range args[3:] {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				m.logSystem(mcpUsage)
+				return
+			}
+			promptArgs[k] = v
+		}
+		prompt, err := m.MCPManager.GetPrompt(ctx, args[1], args[2], promptArgs)
+		if err != nil {
+			m.logSystem(fmt.Sprintf("MCP error: %v", err))
+			return
+		}
+		t := m.activeTarget()
+		if t == nil {
+			m.logSystem(prompt.Text())
+			return
+		}
+		t.AddBlock(agent.NewUserInputBlock(fmt.Sprintf("/mcp prompt %s/%s", args[1], args[2])))
+		m.rebuildViewport()
+		if ch, ok := m.agentUserMsgMap[t.ID]; ok {
+			select {
+			case ch <- prompt.Text():
+			default:
+			}
+		}
+
+	default:
+		m.logSystem(mcpUsage)
+	}
+}
+
 // logSystem adds a system message to the active target as a Block.
 func (m *Model) logSystem(msg string) {
 	if t := m.activeTarget(); t != nil {
@@ -615,6 +991,13 @@ func (m *Model) handleAgentEvent(e agent.Event) tea.Cmd {
 		t = m.activeTarget() // フォールバック
 	}
 	if t == nil {
+		// ターゲット追加前（自然文からのターゲット抽出の結果）
+		switch e.Type {
+		case agent.EventAddTarget:
+			m.addTargetFromEvent(e)
+		case agent.EventLog:
+			m.logSystem(e.Message)
+		}
 		return nil
 	}
 
@@ -654,16 +1037,16 @@ func (m *Model) handleAgentEvent(e agent.Event) tea.Cmd {
 
 	case agent.EventAddTarget:
 		// AI が横展開で新ターゲットを追加
-		if e.NewHost != "" && m.team != nil {
-			m.addTarget(e.NewHost)
-		}
+		m.addTargetFromEvent(e)
 
 	case agent.EventStalled:
 		t.AddBlock(agent.NewSystemBlock("⚠ " + e.Message))
 		t.AddBlock(agent.NewSystemBlock("Type a message to give the agent new direction."))
 
 	case agent.EventTurnStart:
-		// ターン開始はブロックを追加しない（新UIではターンは暗黙的）
+		// ターン開始はブロックを追加しない（新UIではターンは暗黙的）。
+		// 番号だけ記録し、以降のブロックに付与する（/turn で使う）
+		t.Turn = e.TurnNumber
 
 	case agent.EventThinkStart:
 		// 新しい ThinkingBlock を追加
@@ -1,7 +1,10 @@
 package tui
 
 import (
+	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -11,6 +14,7 @@ import (
 
 	"github.com/0x6d61/pentecter/internal/agent"
 	"github.com/0x6d61/pentecter/internal/brain"
+	"github.com/0x6d61/pentecter/internal/mcp"
 	"github.com/0x6d61/pentecter/internal/tools"
 )
 
@@ -3677,3 +3681,95 @@ func TestView_SelectMode(t *testing.T) {
 		t.Error("expected 'Alpha' in select mode view")
 	}
 }
+
+// TestHandleReloadCommand tests /reload with and without a Reloader.
+func TestHandleReloadCommand(t *testing.T) {
+	m := NewWithTargets(nil)
+	m.handleReloadCommand()
+	if len(m.globalLogs) == 0 || !strings.Contains(m.globalLogs[len(m.globalLogs)-1], "not available") {
+		t.Errorf("expected 'not available' message, got: %v", m.globalLogs)
+	}
+
+	m = NewWithTargets(nil)
+	m.Reloader = func() (string, error) {
+		return "Reloaded: 3 tools, 1 skills", errors.New("mcp: bad config")
+	}
+	m.input.SetValue("/reload")
+	m.submitInput()
+
+	joined := strings.Join(m.globalLogs, "\n")
+	if !strings.Contains(joined, "Reloaded: 3 tools, 1 skills") {
+		t.Errorf("expected reload summary, got: %v", m.globalLogs)
+	}
+	if !strings.Contains(joined, "Reload errors: mcp: bad config") {
+		t.Errorf("expected reload errors, got: %v", m.globalLogs)
+	}
+}
+
+// TestHandleMCPCommand tests /mcp server status output.
+func TestHandleMCPCommand(t *testing.T) {
+	m := NewWithTargets(nil)
+	m.handleMCPCommand()
+	if len(m.globalLogs) == 0 || !strings.Contains(m.globalLogs[0], "not configured") {
+		t.Errorf("expected 'not configured' message, got: %v", m.globalLogs)
+	}
+
+	path := filepath.Join(t.TempDir(), "mcp.yaml")
+	cfg := "servers:\n  - name: broken\n    command: /nonexistent/mcp-server-binary\n"
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := mcp.NewManager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = mgr.StartAll(context.Background())
+	defer mgr.Close()
+
+	m = NewWithTargets(nil)
+	m.MCPManager = mgr
+	m.input.SetValue("/mcp")
+	m.submitInput()
+
+	out := strings.Join(m.globalLogs, "\n")
+	for _, want := range []string{"broken", "stdio", "down", "retry in", "last error:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in /mcp output, got:\n%s", want, out)
+		}
+	}
+}
+
+// TestHandleMCPSubcommand tests /mcp resources / prompts / read / prompt argument handling.
+func TestHandleMCPSubcommand(t *testing.T) {
+	m := NewWithTargets(nil)
+	m.input.SetValue("/mcp resources")
+	m.submitInput()
+	if len(m.globalLogs) == 0 || !strings.Contains(m.globalLogs[0], "not configured") {
+		t.Errorf("expected 'not configured' message, got: %v", m.globalLogs)
+	}
+
+	path := filepath.Join(t.TempDir(), "mcp.yaml")
+	if err := os.WriteFile(path, []byte("servers: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := mcp.NewManager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m = NewWithTargets(nil)
+	m.MCPManager = mgr
+	for _, input := range []string{"/mcp resources", "/mcp prompts", "/mcp read only-server", "/mcp prompt s p notkeyvalue", "/mcp read missing x://y"} {
+		m.input.SetValue(input)
+		m.submitInput()
+	}
+	out := strings.Join(m.globalLogs, "\n")
+	for _, want := range []string{"No MCP resources.", "No MCP prompts.", "Usage: /mcp", `unknown server "missing"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "Usage: /mcp") != 2 {
+		t.Errorf("malformed read/prompt should print usage, got:\n%s", out)
+	}
+}
@@ -17,8 +17,9 @@ func (m Model) View() string {
 	// ── Status bar (1 line) ──────────────────────────────────────────────────
 	statusBar := m.renderStatusBar()
 
-	// ── Main pane: session log (full width) ─────────────────────────────────
-	mainOuterW := m.width
+	// ── Main pane: session log (full width, or left of the side panel) ──────
+	panelW := m.panelWidth()
+	mainOuterW := m.width - panelW
 	mainContentW := mainOuterW - 2 // subtract left+right borders
 	mainContent := m.viewport.View()
 	var mainStyle lipgloss.Style
@@ -28,6 +29,9 @@ func (m Model) View() string {
 		mainStyle = rightPaneStyle.Width(mainContentW)
 	}
 	panesRow := mainStyle.Render(mainContent)
+	if panelW > 0 {
+		panesRow = lipgloss.JoinHorizontal(lipgloss.Top, panesRow, m.renderPanel(panelW, m.viewport.Height))
+	}
 
 	// ── Input bar (3 lines) ──────────────────────────────────────────────────
 	inputBar := m.renderInputBar()
@@ -76,6 +80,9 @@ func (m Model) renderStatusBar() string {
 	if modelInfo != "" {
 		left += "  " + modelInfo
 	}
+	if logInfo := m.logStatus(); logInfo != "" {
+		left += "  " + lipgloss.NewStyle().Foreground(colorSecondary).Render(logInfo)
+	}
 
 	return statusBarStyle.Width(m.width).Render(left)
 }
@@ -87,16 +94,29 @@ func (m Model) renderInputBar() string {
 	if m.inputMode == InputSelect {
 		return m.renderSelectBar()
 	}
+	if m.inputMode == InputPalette {
+		return m.renderPaletteBar()
+	}
 
 	var prefix string
 	switch m.focus {
 	case FocusViewport:
-		prefix = lipgloss.NewStyle().Foreground(colorMuted).Render("[Log]  ↑↓ Scroll")
+		prefix = lipgloss.NewStyle().Foreground(colorMuted).Render(fmt.Sprintf("[Log]  ↑↓ Scroll  %s Search  %s %s Prev/Next match",
+			m.Keys.label(actionSearch), m.Keys.label(actionPrevMatch), m.Keys.label(actionNextMatch)))
+	case FocusPanel:
+		prefix = lipgloss.NewStyle().Foreground(colorMuted).Render("[Panel]  ←→ Tab  ↑↓ Move  Enter Open")
 	case FocusInput:
-		prefix = lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render("> ")
+		prompt := "> "
+		if m.attachedTask != "" {
+			prompt = "[" + m.attachedTask + "] > "
+		}
+		prefix = lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render(prompt)
 	}
 
 	content := prefix + " " + m.input.View()
+	if hints := m.renderCompletionHints(); hints != "" {
+		content = hints + "\n" + content
+	}
 	w := m.width - 2
 	return inputBarStyle.Width(w).Render(content)
 }
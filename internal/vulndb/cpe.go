@@ -0,0 +1,171 @@
+package vulndb
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CPE は CPE 2.3 の vendor/product/version 部分。
+type CPE struct {
+	Vendor  string
+	Product string
+	Version string
+}
+
+// String は CPE 2.3 形式の文字列を返す。
+func (c CPE) String() string {
+	version := c.Version
+	if version == "" {
+		version = "*"
+	}
+	return "cpe:2.3:a:" + c.Vendor + ":" + c.Product + ":" + version + ":*:*:*:*:*:*:*"
+}
+
+// ParseCPE は "cpe:2.3:a:apache:http_server:2.4.49:*:..." をパースする。
+func ParseCPE(s string) (CPE, bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 6 || parts[0] != "cpe" || parts[1] != "2.3" {
+		return CPE{}, false
+	}
+	return CPE{Vendor: parts[3], Product: parts[4], Version: parts[5]}, true
+}
+
+// productRule はバナー中の製品名 → CPE vendor/product の対応。
+type productRule struct {
+	pattern *regexp.Regexp // 1 番目のキャプチャがバージョン
+	vendor  string
+	product string
+}
+
+// productRules はバナー → CPE の対応表。nmap -sV / HTTP Server ヘッダー / SSH バナーの表記揺れを吸収する。
+// 具体的なものを先に並べる（"Apache Tomcat" を "Apache httpd" より先に判定する等）。
+var productRules = []productRule{
+	{regexp.MustCompile(`(?i)apache[ -]tomcat[/ ]v?(\d[\w.]*)`), "apache", "tomcat"},
+	{regexp.MustCompile(`(?i)\bapache(?: httpd)?[/ ](\d[\w.]*)`), "apache", "http_server"},
+	{regexp.MustCompile(`(?i)openssh[_ /-]?(\d[\w.]*)`), "openbsd", "openssh"},
+	{regexp.MustCompile(`(?i)\bnginx[/ ](\d[\w.]*)`), "f5", "nginx"},
+	{regexp.MustCompile(`(?i)microsoft[- ]iis(?: httpd)?[/ ](\d[\w.]*)`), "microsoft", "internet_information_services"},
+	{regexp.MustCompile(`(?i)\bvsftpd[/ ](\d[\w.]*)`), "vsftpd_project", "vsftpd"},
+	{regexp.MustCompile(`(?i)\bproftpd[/ ](\d[\w.]*)`), "proftpd", "proftpd"},
+	{regexp.MustCompile(`(?i)\bsamba(?: smbd)?[/ ](\d[\w.]*)`), "samba", "samba"},
+	{regexp.MustCompile(`(?i)\bmariadb[/ -](\d[\w.]*)`), "mariadb", "mariadb"},
+	{regexp.MustCompile(`(?i)\bmysql[/ ](\d[\w.]*)`), "oracle", "mysql"},
+	{regexp.MustCompile(`(?i)\bpostgresql(?: db)?[/ ](\d[\w.]*)`), "postgresql", "postgresql"},
+	{regexp.MustCompile(`(?i)\bphp[/ ](\d[\w.]*)`), "php", "php"},
+	{regexp.MustCompile(`(?i)\bopenssl[/ ](\d[\w.]*)`), "openssl", "openssl"},
+	{regexp.MustCompile(`(?i)\bexim(?: smtpd)?[/ ](\d[\w.]*)`), "exim", "exim"},
+	{regexp.MustCompile(`(?i)\bpostfix[/ ](\d[\w.]*)`), "postfix", "postfix"},
+	{regexp.MustCompile(`(?i)\bjetty[/ (]+(\d[\w.]*)`), "eclipse", "jetty"},
+	{regexp.MustCompile(`(?i)\blighttpd[/ ](\d[\w.]*)`), "lighttpd", "lighttpd"},
+	{regexp.MustCompile(`(?i)\bwordpress[/ ](\d[\w.]*)`), "wordpress", "wordpress"},
+	{regexp.MustCompile(`(?i)\bdrupal[/ ](\d[\w.]*)`), "drupal", "drupal"},
+	{regexp.MustCompile(`(?i)\bjoomla!?[/ ](\d[\w.]*)`), "joomla", "joomla\\!"},
+	{regexp.MustCompile(`(?i)\bjenkins[/ ](\d[\w.]*)`), "jenkins", "jenkins"},
+	{regexp.MustCompile(`(?i)\bgrafana[/ ]v?(\d[\w.]*)`), "grafana", "grafana"},
+	{regexp.MustCompile(`(?i)\bredis(?: key-value store)?[/ ]v?(\d[\w.]*)`), "redis", "redis"},
+	{regexp.MustCompile(`(?i)\bwerkzeug[/ ](\d[\w.]*)`), "palletsprojects", "werkzeug"},
+	{regexp.MustCompile(`(?i)\bnode\.js[/ ]v?(\d[\w.]*)`), "nodejs", "node.js"},
+}
+
+// BannerToCPE はバナー文字列（"Apache httpd 2.4.49", "OpenSSH 8.2p1 Ubuntu"）から CPE を推定する。
+func BannerToCPE(banner string) (CPE, bool) {
+	for _, r := range productRules {
+		if m := r.pattern.FindStringSubmatch(banner); m != nil {
+			return CPE{Vendor: r.vendor, Product: r.product, Version: strings.TrimRight(m[1], ".")}, true
+		}
+	}
+	return CPE{}, false
+}
+
+// cpeMatch は NVD の configurations に含まれる脆弱バージョン条件。
+type cpeMatch struct {
+	vendor  string
+	product string
+	version string // "*" or "-" なら範囲条件を使う
+
+	startIncl, startExcl string
+	endIncl, endExcl     string
+}
+
+// matchesVersion は version が条件に合致するかを返す。バージョン不明の場合は一致しない。
+func (m cpeMatch) matchesVersion(version string) bool {
+	if version == "" {
+		return false
+	}
+	if m.version != "" && m.version != "*" && m.version != "-" {
+		return compareVersions(version, m.version) == 0
+	}
+	hasRange := false
+	if m.startIncl != "" {
+		hasRange = true
+		if compareVersions(version, m.startIncl) < 0 {
+			return false
+		}
+	}
+	if m.startExcl != "" {
+		hasRange = true
+		if compareVersions(version, m.startExcl) <= 0 {
+			return false
+		}
+	}
+	if m.endIncl != "" {
+		hasRange = true
+		if compareVersions(version, m.endIncl) > 0 {
+			return false
+		}
+	}
+	if m.endExcl != "" {
+		hasRange = true
+		if compareVersions(version, m.endExcl) >= 0 {
+			return false
+		}
+	}
+	// バージョン条件なし（"*" 単独）は全バージョン該当だが誤検知が多いため採用しない
+	return hasRange
+}
+
+// compareVersions は "2.4.49" と "2.4.50"、"8.2p1" と "8.3" のようなバージョンを比較する。
+// 数字列は数値として、英字列は辞書順で比較する。
+func compareVersions(a, b string) int {
+	as, bs := versionSegments(a), versionSegments(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		if i >= len(as) {
+			return -1
+		}
+		if i >= len(bs) {
+			return 1
+		}
+		if c := compareSegment(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+var versionSegmentRe = regexp.MustCompile(`\d+|[A-Za-z]+`)
+
+func versionSegments(v string) []string {
+	return versionSegmentRe.FindAllString(strings.ToLower(v), -1)
+}
+
+func compareSegment(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		}
+		return 0
+	case aErr == nil:
+		// 同じ位置に数字と英字がある場合は数字を大きいとみなす
+		return 1
+	case bErr == nil:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
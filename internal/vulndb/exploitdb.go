@@ -0,0 +1,86 @@
+package vulndb
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var cveCodeRe = regexp.MustCompile(`CVE-\d{4}-\d{4,}`)
+
+// LoadExploitDBFile は exploit-db の files_exploits.csv を読み込む。
+func (db *DB) LoadExploitDBFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("vulndb: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if err := db.LoadExploitDB(f); err != nil {
+		return fmt.Errorf("vulndb: %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadExploitDB は exploit-db CSV を読み込み、codes 列の CVE に Exploit を紐付ける。
+// NVD に存在しない CVE でも、エクスプロイト情報だけを持つエントリとして登録する。
+func (db *DB) LoadExploitDB(r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("read exploit-db header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.TrimSpace(strings.ToLower(h))] = i
+	}
+	idCol, ok := col["id"]
+	codesCol, ok2 := col["codes"]
+	if !ok || !ok2 {
+		return fmt.Errorf("exploit-db CSV must have id and codes columns")
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return rec[i]
+		}
+		return ""
+	}
+
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read exploit-db CSV: %w", err)
+		}
+		if codesCol >= len(rec) || idCol >= len(rec) {
+			continue
+		}
+		cveIDs := cveCodeRe.FindAllString(rec[codesCol], -1)
+		if len(cveIDs) == 0 {
+			continue
+		}
+		e := Exploit{
+			ID:       rec[idCol],
+			Title:    field(rec, "description"),
+			Type:     field(rec, "type"),
+			Platform: field(rec, "platform"),
+			File:     field(rec, "file"),
+		}
+		for _, id := range cveIDs {
+			c, ok := db.cves[id]
+			if !ok {
+				c = &CVE{ID: id}
+				db.cves[id] = c
+			}
+			c.Exploits = append(c.Exploits, e)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package vulndb
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// --- NVD JSON 1.1 フィード（nvdcve-1.1-2021.json） ---
+
+type nvdFeed11 struct {
+	Items []struct {
+		CVE struct {
+			Meta struct {
+				ID string `json:"ID"`
+			} `json:"CVE_data_meta"`
+			Description struct {
+				Data []struct {
+					Lang  string `json:"lang"`
+					Value string `json:"value"`
+				} `json:"description_data"`
+			} `json:"description"`
+		} `json:"cve"`
+		Configurations struct {
+			Nodes []nvdNode11 `json:"nodes"`
+		} `json:"configurations"`
+		Impact struct {
+			V3 struct {
+				CVSS struct {
+					BaseScore    float64 `json:"baseScore"`
+					BaseSeverity string  `json:"baseSeverity"`
+				} `json:"cvssV3"`
+			} `json:"baseMetricV3"`
+			V2 struct {
+				CVSS struct {
+					BaseScore float64 `json:"baseScore"`
+				} `json:"cvssV2"`
+				Severity string `json:"severity"`
+			} `json:"baseMetricV2"`
+		} `json:"impact"`
+	} `json:"CVE_Items"`
+}
+
+type nvdNode11 struct {
+	CPEMatch []struct {
+		Vulnerable bool   `json:"vulnerable"`
+		URI        string `json:"cpe23Uri"`
+		StartIncl  string `json:"versionStartIncluding"`
+		StartExcl  string `json:"versionStartExcluding"`
+		EndIncl    string `json:"versionEndIncluding"`
+		EndExcl    string `json:"versionEndExcluding"`
+	} `json:"cpe_match"`
+	Children []nvdNode11 `json:"children"`
+}
+
+// --- NVD API 2.0 形式（/rest/json/cves/2.0 のレスポンスを保存したもの） ---
+
+type nvdFeed20 struct {
+	Vulnerabilities []struct {
+		CVE struct {
+			ID           string `json:"id"`
+			Descriptions []struct {
+				Lang  string `json:"lang"`
+				Value string `json:"value"`
+			} `json:"descriptions"`
+			Metrics struct {
+				V31 []nvdMetric20 `json:"cvssMetricV31"`
+				V30 []nvdMetric20 `json:"cvssMetricV30"`
+				V2  []struct {
+					CVSSData struct {
+						BaseScore float64 `json:"baseScore"`
+					} `json:"cvssData"`
+					BaseSeverity string `json:"baseSeverity"`
+				} `json:"cvssMetricV2"`
+			} `json:"metrics"`
+			Configurations []struct {
+				Nodes []struct {
+					CPEMatch []struct {
+						Vulnerable bool   `json:"vulnerable"`
+						Criteria   string `json:"criteria"`
+						StartIncl  string `json:"versionStartIncluding"`
+						StartExcl  string `json:"versionStartExcluding"`
+						EndIncl    string `json:"versionEndIncluding"`
+						EndExcl    string `json:"versionEndExcluding"`
+					} `json:"cpeMatch"`
+				} `json:"nodes"`
+			} `json:"configurations"`
+		} `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+type nvdMetric20 struct {
+	CVSSData struct {
+		BaseScore    float64 `json:"baseScore"`
+		BaseSeverity string  `json:"baseSeverity"`
+	} `json:"cvssData"`
+}
+
+// LoadNVDFile は NVD フィードファイル（.json / .json.gz）を読み込む。
+// JSON 1.1 フィードと API 2.0 形式の両方に対応する。
+func (db *DB) LoadNVDFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("vulndb: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("vulndb: %s: %w", path, err)
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+	if err := db.LoadNVD(r); err != nil {
+		return fmt.Errorf("vulndb: %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadNVD は NVD JSON を読み込む。
+func (db *DB) LoadNVD(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var probe struct {
+		Items           json.RawMessage `json:"CVE_Items"`
+		Vulnerabilities json.RawMessage `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return fmt.Errorf("parse NVD JSON: %w", err)
+	}
+	switch {
+	case probe.Items != nil:
+		var feed nvdFeed11
+		if err := json.Unmarshal(data, &feed); err != nil {
+			return fmt.Errorf("parse NVD 1.1 feed: %w", err)
+		}
+		db.addFeed11(&feed)
+	case probe.Vulnerabilities != nil:
+		var feed nvdFeed20
+		if err := json.Unmarshal(data, &feed); err != nil {
+			return fmt.Errorf("parse NVD 2.0 feed: %w", err)
+		}
+		db.addFeed20(&feed)
+	default:
+		return fmt.Errorf("unknown NVD feed format")
+	}
+	return nil
+}
+
+func (db *DB) addFeed11(feed *nvdFeed11) {
+	for _, item := range feed.Items {
+		c := &CVE{ID: strings.ToUpper(item.CVE.Meta.ID)}
+		for _, d := range item.CVE.Description.Data {
+			if d.Lang == "en" {
+				c.Description = d.Value
+				break
+			}
+		}
+		if v3 := item.Impact.V3.CVSS; v3.BaseScore > 0 {
+			c.CVSS, c.Severity = v3.BaseScore, v3.BaseSeverity
+		} else {
+			c.CVSS, c.Severity = item.Impact.V2.CVSS.BaseScore, item.Impact.V2.Severity
+		}
+		var walk func(nodes []nvdNode11)
+		walk = func(nodes []nvdNode11) {
+			for _, n := range nodes {
+				for _, m := range n.CPEMatch {
+					if m.Vulnerable {
+						c.appendMatch(m.URI, m.StartIncl, m.StartExcl, m.EndIncl, m.EndExcl)
+					}
+				}
+				walk(n.Children)
+			}
+		}
+		walk(item.Configurations.Nodes)
+		if c.ID != "" {
+			db.add(c)
+		}
+	}
+}
+
+func (db *DB) addFeed20(feed *nvdFeed20) {
+	for _, v := range feed.Vulnerabilities {
+		c := &CVE{ID: strings.ToUpper(v.CVE.ID)}
+		for _, d := range v.CVE.Descriptions {
+			if d.Lang == "en" {
+				c.Description = d.Value
+				break
+			}
+		}
+		m := v.CVE.Metrics
+		switch {
+		case len(m.V31) > 0:
+			c.CVSS, c.Severity = m.V31[0].CVSSData.BaseScore, m.V31[0].CVSSData.BaseSeverity
+		case len(m.V30) > 0:
+			c.CVSS, c.Severity = m.V30[0].CVSSData.BaseScore, m.V30[0].CVSSData.BaseSeverity
+		case len(m.V2) > 0:
+			c.CVSS, c.Severity = m.V2[0].CVSSData.BaseScore, m.V2[0].BaseSeverity
+		}
+		for _, conf := range v.CVE.Configurations {
+			for _, n := range conf.Nodes {
+				for _, cm := range n.CPEMatch {
+					if cm.Vulnerable {
+						c.appendMatch(cm.Criteria, cm.StartIncl, cm.StartExcl, cm.EndIncl, cm.EndExcl)
+					}
+				}
+			}
+		}
+		if c.ID != "" {
+			db.add(c)
+		}
+	}
+}
+
+func (c *CVE) appendMatch(uri, startIncl, startExcl, endIncl, endExcl string) {
+	cpe, ok := ParseCPE(uri)
+	if !ok {
+		return
+	}
+	c.matches = append(c.matches, cpeMatch{
+		vendor:    cpe.Vendor,
+		product:   cpe.Product,
+		version:   cpe.Version,
+		startIncl: startIncl,
+		startExcl: startExcl,
+		endIncl:   endIncl,
+		endExcl:   endExcl,
+	})
+}
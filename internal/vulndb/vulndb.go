@@ -0,0 +1,203 @@
+// Package vulndb はオフラインの脆弱性データベース（NVD JSON フィード + exploit-db CSV）を
+// 読み込み、バナー → CPE → CVE の突き合わせを提供する。
+package vulndb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CVE は脆弱性1件を表す。
+type CVE struct {
+	ID          string    // "CVE-2021-41773"
+	Description string    // 英語の概要
+	CVSS        float64   // CVSS v3 ベーススコア（なければ v2）
+	Severity    string    // "CRITICAL", "HIGH", "MEDIUM", "LOW"
+	Exploits    []Exploit // exploit-db の公開エクスプロイト
+
+	matches []cpeMatch
+}
+
+// HasExploit は公開エクスプロイトが存在するかを返す。
+func (c *CVE) HasExploit() bool {
+	return len(c.Exploits) > 0
+}
+
+// Exploit は exploit-db のエントリ。
+type Exploit struct {
+	ID       string // EDB-ID（"50383"）
+	Title    string
+	Type     string // "remote", "webapps", "local", "dos"
+	Platform string
+	File     string // "exploits/multiple/webapps/50383.sh"
+}
+
+// Ref は "EDB-50383" 形式の参照文字列を返す。
+func (e Exploit) Ref() string {
+	return "EDB-" + e.ID
+}
+
+// URL は exploit-db の URL を返す。
+func (e Exploit) URL() string {
+	return "https://www.exploit-db.com/exploits/" + e.ID
+}
+
+// DB はオフライン脆弱性データベース。読み込み後は読み取り専用なので並行アクセス可能。
+type DB struct {
+	cves      map[string]*CVE
+	byProduct map[string][]*CVE // "vendor:product" → 該当 CVE
+}
+
+// New は空の DB を返す。
+func New() *DB {
+	return &DB{
+		cves:      make(map[string]*CVE),
+		byProduct: make(map[string][]*CVE),
+	}
+}
+
+// Open は NVD フィード（ファイルまたはディレクトリ）と exploit-db CSV を読み込む。
+// すべてのパスが空または存在しない場合は nil, nil を返す（graceful skip）。
+func Open(nvdPaths []string, exploitDBPath string) (*DB, error) {
+	db := New()
+	loaded := false
+	for _, p := range nvdPaths {
+		files, err := feedFiles(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if err := db.LoadNVDFile(f); err != nil {
+				return nil, err
+			}
+			loaded = true
+		}
+	}
+	if exploitDBPath != "" {
+		if _, err := os.Stat(exploitDBPath); err == nil {
+			if err := db.LoadExploitDBFile(exploitDBPath); err != nil {
+				return nil, err
+			}
+			loaded = true
+		}
+	}
+	if !loaded {
+		return nil, nil
+	}
+	return db, nil
+}
+
+// feedFiles はパスがディレクトリなら配下の *.json / *.json.gz を、ファイルならそれ自体を返す。
+func feedFiles(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("vulndb: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("vulndb: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && (strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".json.gz")) {
+			files = append(files, filepath.Join(path, name))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Len は登録済み CVE 数を返す。
+func (db *DB) Len() int {
+	if db == nil {
+		return 0
+	}
+	return len(db.cves)
+}
+
+// Get は CVE ID で検索する。
+func (db *DB) Get(id string) (*CVE, bool) {
+	if db == nil {
+		return nil, false
+	}
+	c, ok := db.cves[strings.ToUpper(id)]
+	return c, ok
+}
+
+// Lookup は CPE に該当する CVE を CVSS 降順で返す。
+func (db *DB) Lookup(cpe CPE) []*CVE {
+	if db == nil || cpe.Product == "" {
+		return nil
+	}
+	var out []*CVE
+	for _, c := range db.byProduct[cpe.Vendor+":"+cpe.Product] {
+		for _, m := range c.matches {
+			if m.vendor == cpe.Vendor && m.product == cpe.Product && m.matchesVersion(cpe.Version) {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	sortByCVSS(out)
+	return out
+}
+
+// LookupBanner はバナー文字列から CPE を推定して CVE を返す。
+func (db *DB) LookupBanner(banner string) (CPE, []*CVE) {
+	cpe, ok := BannerToCPE(banner)
+	if !ok {
+		return CPE{}, nil
+	}
+	return cpe, db.Lookup(cpe)
+}
+
+// add は CVE を登録し、CPE インデックスを更新する。同じ ID は後勝ち（エクスプロイトは引き継ぐ）。
+func (db *DB) add(c *CVE) {
+	if old, ok := db.cves[c.ID]; ok {
+		c.Exploits = append(c.Exploits, old.Exploits...)
+		for key, list := range db.byProduct {
+			db.byProduct[key] = removeCVE(list, old)
+		}
+	}
+	db.cves[c.ID] = c
+	seen := make(map[string]bool)
+	for _, m := range c.matches {
+		key := m.vendor + ":" + m.product
+		if !seen[key] {
+			seen[key] = true
+			db.byProduct[key] = append(db.byProduct[key], c)
+		}
+	}
+}
+
+func removeCVE(list []*CVE, target *CVE) []*CVE {
+	out := list[:0]
+	for _, c := range list {
+		if c != target {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func sortByCVSS(cves []*CVE) {
+	sort.SliceStable(cves, func(i, j int) bool {
+		if cves[i].CVSS != cves[j].CVSS {
+			return cves[i].CVSS > cves[j].CVSS
+		}
+		return cves[i].ID > cves[j].ID
+	})
+}
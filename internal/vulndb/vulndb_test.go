@@ -0,0 +1,219 @@
+package vulndb
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testNVD11 = `{
+  "CVE_data_type": "CVE",
+  "CVE_Items": [
+    {
+      "cve": {
+        "CVE_data_meta": {"ID": "CVE-2021-41773"},
+        "description": {"description_data": [{"lang": "en", "value": "A flaw was found in a change made to path normalization in Apache HTTP Server 2.4.49."}]}
+      },
+      "configurations": {
+        "nodes": [{"operator": "OR", "children": [], "cpe_match": [
+          {"vulnerable": true, "cpe23Uri": "cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*"}
+        ]}]
+      },
+      "impact": {"baseMetricV3": {"cvssV3": {"baseScore": 7.5, "baseSeverity": "HIGH"}}}
+    },
+    {
+      "cve": {
+        "CVE_data_meta": {"ID": "CVE-2021-42013"},
+        "description": {"description_data": [{"lang": "en", "value": "Path traversal and RCE in Apache HTTP Server 2.4.49 and 2.4.50."}]}
+      },
+      "configurations": {
+        "nodes": [{"operator": "OR", "children": [], "cpe_match": [
+          {"vulnerable": true, "cpe23Uri": "cpe:2.3:a:apache:http_server:*:*:*:*:*:*:*:*", "versionStartIncluding": "2.4.49", "versionEndIncluding": "2.4.50"}
+        ]}]
+      },
+      "impact": {"baseMetricV3": {"cvssV3": {"baseScore": 9.8, "baseSeverity": "CRITICAL"}}}
+    }
+  ]
+}
+`
+
+const testNVD20 = `{
+  "format": "NVD_CVE",
+  "version": "2.0",
+  "vulnerabilities": [
+    {
+      "cve": {
+        "id": "CVE-2023-38408",
+        "descriptions": [{"lang": "en", "value": "The PKCS#11 feature in ssh-agent in OpenSSH before 9.3p2 has an insufficiently trustworthy search path."}],
+        "metrics": {"cvssMetricV31": [{"cvssData": {"baseScore": 9.8, "baseSeverity": "CRITICAL"}}]},
+        "configurations": [{"nodes": [{"operator": "OR", "cpeMatch": [
+          {"vulnerable": true, "criteria": "cpe:2.3:a:openbsd:openssh:*:*:*:*:*:*:*:*", "versionEndExcluding": "9.3"}
+        ]}]}]
+      }
+    }
+  ]
+}
+`
+
+const testExploitCSV = `id,file,description,date_published,author,type,platform,port,date_added,date_updated,verified,codes,tags,aliases,screenshot_url,application_url,source_url
+50383,exploits/multiple/webapps/50383.sh,"Apache HTTP Server 2.4.49 - Path Traversal & Remote Code Execution (RCE)",2021-10-06,Lucas Souza,webapps,multiple,,2021-10-06,2021-10-06,0,CVE-2021-41773,,,,,
+50406,exploits/multiple/webapps/50406.sh,"Apache HTTP Server 2.4.50 - Remote Code Execution (RCE) (2)",2021-10-11,Valentin Lobstein,webapps,multiple,,2021-10-11,2021-10-11,0,CVE-2021-42013;CVE-2021-41773,,,,,
+1,exploits/windows/remote/1.c,"Some exploit without CVE",2003-01-01,someone,remote,windows,,2003-01-01,2003-01-01,1,OSVDB-1,,,,,
+`
+
+// writeTestFiles はテスト用のフィードを一時ディレクトリに書き出し、そのパスを返す。
+func writeTestFiles(t *testing.T) (nvd11, nvd20, exploitCSV string) {
+	t.Helper()
+	dir := t.TempDir()
+	nvd11 = filepath.Join(dir, "nvdcve-1.1-2021.json")
+	nvd20 = filepath.Join(dir, "nvd-api-2.0.json")
+	exploitCSV = filepath.Join(dir, "files_exploits.csv")
+	for path, content := range map[string]string{nvd11: testNVD11, nvd20: testNVD20, exploitCSV: testExploitCSV} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return
+}
+
+func loadTestDB(t *testing.T) *DB {
+	t.Helper()
+	nvd11, nvd20, exploitCSV := writeTestFiles(t)
+	db, err := Open([]string{nvd11, nvd20}, exploitCSV)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if db == nil {
+		t.Fatal("Open returned nil DB")
+	}
+	return db
+}
+
+func TestOpen_LoadsBothFeedFormats(t *testing.T) {
+	db := loadTestDB(t)
+	if db.Len() != 3 {
+		t.Errorf("Len = %d, want 3", db.Len())
+	}
+
+	c, ok := db.Get("cve-2023-38408")
+	if !ok {
+		t.Fatal("CVE-2023-38408 (API 2.0 format) not found")
+	}
+	if c.CVSS != 9.8 || c.Severity != "CRITICAL" {
+		t.Errorf("CVSS = %v %s, want 9.8 CRITICAL", c.CVSS, c.Severity)
+	}
+}
+
+func TestOpen_MissingPaths(t *testing.T) {
+	db, err := Open([]string{"/nonexistent/nvd"}, "/nonexistent/files_exploits.csv")
+	if err != nil {
+		t.Fatalf("missing paths should not error: %v", err)
+	}
+	if db != nil {
+		t.Error("Open with no data should return nil")
+	}
+}
+
+func TestOpen_DirectoryAndGzip(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "nvdcve-1.1-2021.json.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(testNVD11)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := Open([]string{dir}, "")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if db.Len() != 2 {
+		t.Errorf("Len = %d, want 2", db.Len())
+	}
+}
+
+func TestLoadNVD_UnknownFormat(t *testing.T) {
+	if err := New().LoadNVD(strings.NewReader(`{"foo": []}`)); err == nil {
+		t.Error("expected error for unknown feed format")
+	}
+}
+
+func TestExploitDB_LinksCVEs(t *testing.T) {
+	db := loadTestDB(t)
+
+	c, _ := db.Get("CVE-2021-41773")
+	if len(c.Exploits) != 2 {
+		t.Fatalf("exploits = %d, want 2", len(c.Exploits))
+	}
+	if c.Exploits[0].Ref() != "EDB-50383" {
+		t.Errorf("Ref = %q, want EDB-50383", c.Exploits[0].Ref())
+	}
+	if c.Exploits[0].URL() != "https://www.exploit-db.com/exploits/50383" {
+		t.Errorf("URL = %q", c.Exploits[0].URL())
+	}
+}
+
+func TestExploitDB_BeforeNVD(t *testing.T) {
+	// exploit-db を先に読んでもエクスプロイト情報は NVD エントリに引き継がれる
+	nvd11, _, _ := writeTestFiles(t)
+	db := New()
+	if err := db.LoadExploitDB(strings.NewReader(testExploitCSV)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.LoadNVDFile(nvd11); err != nil {
+		t.Fatal(err)
+	}
+	c, _ := db.Get("CVE-2021-42013")
+	if c.CVSS != 9.8 || len(c.Exploits) != 1 {
+		t.Errorf("got CVSS=%v exploits=%d, want 9.8 / 1", c.CVSS, len(c.Exploits))
+	}
+}
+
+func TestLookupBanner(t *testing.T) {
+	db := loadTestDB(t)
+
+	tests := []struct {
+		banner string
+		want   []string
+	}{
+		{"Apache httpd 2.4.49 ((Unix))", []string{"CVE-2021-42013", "CVE-2021-41773"}},
+		{"Apache/2.4.50 (Unix)", []string{"CVE-2021-42013"}},
+		{"Apache httpd 2.4.51", nil},
+		{"OpenSSH 8.2p1 Ubuntu 4ubuntu0.5", []string{"CVE-2023-38408"}},
+		{"OpenSSH 9.3p2", nil},
+		{"unknown service", nil},
+	}
+	for _, tt := range tests {
+		_, cves := db.LookupBanner(tt.banner)
+		var got []string
+		for _, c := range cves {
+			got = append(got, c.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("LookupBanner(%q) = %v, want %v", tt.banner, got, tt.want)
+		}
+	}
+}
+
+func TestNilDB(t *testing.T) {
+	var db *DB
+	if db.Len() != 0 {
+		t.Error("nil DB Len should be 0")
+	}
+	if _, ok := db.Get("CVE-2021-41773"); ok {
+		t.Error("nil DB Get should return false")
+	}
+	if cves := db.Lookup(CPE{Vendor: "apache", Product: "http_server", Version: "2.4.49"}); cves != nil {
+		t.Error("nil DB Lookup should return nil")
+	}
+}
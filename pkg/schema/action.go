@@ -44,6 +44,18 @@ const (
 
 	// ActionReadKnowledge はナレッジベースの特定記事を読み込む。
 	ActionReadKnowledge ActionType = "read_knowledge"
+
+	// ActionUseSkill は登録済みスキル（手順テンプレート）を読み込む、または SubTask として起動する。
+	ActionUseSkill ActionType = "use_skill"
+
+	// ActionListMCPResources は MCP サーバーが公開するリソースとプロンプトの一覧を取得する。
+	ActionListMCPResources ActionType = "list_mcp_resources"
+
+	// ActionReadMCPResource は MCP リソース（ワードリスト・ペイロード集等）を読み込む。
+	ActionReadMCPResource ActionType = "read_mcp_resource"
+
+	// ActionGetMCPPrompt は MCP プロンプト（手法テンプレート）を引数を展開して取得する。
+	ActionGetMCPPrompt ActionType = "get_mcp_prompt"
 )
 
 // Action is the JSON payload emitted by the Brain (LLM).
@@ -66,12 +78,22 @@ type Action struct {
 	MCPServer string         `json:"mcp_server,omitempty"`
 	// MCPTool は呼び出す MCP ツールの名前（ActionCallMCP 時に使用）。
 	MCPTool   string         `json:"mcp_tool,omitempty"`
-	// MCPArgs は MCP ツールに渡す引数（ActionCallMCP 時に使用）。
+	// MCPArgs は MCP ツールに渡す引数（ActionCallMCP / ActionGetMCPPrompt 時に使用）。
 	MCPArgs   map[string]any `json:"mcp_args,omitempty"`
+	// MCPURI は読み込むリソースの URI（ActionReadMCPResource 時に使用）。
+	MCPURI    string         `json:"mcp_uri,omitempty"`
+	// MCPPrompt は取得するプロンプト名（ActionGetMCPPrompt 時に使用）。
+	MCPPrompt string         `json:"mcp_prompt,omitempty"`
 
 	// Knowledge 関連フィールド
-	KnowledgeQuery string `json:"knowledge_query,omitempty"` // search_knowledge 用
-	KnowledgePath  string `json:"knowledge_path,omitempty"`  // read_knowledge 用
+	KnowledgeQuery  string `json:"knowledge_query,omitempty"`  // search_knowledge 用
+	KnowledgeSource string `json:"knowledge_source,omitempty"` // search_knowledge 用: 検索対象のソース名（空 = 全ソース）
+	KnowledgePath   string `json:"knowledge_path,omitempty"`   // read_knowledge 用
+
+	// Skill 関連フィールド
+	Skill     string         `json:"skill,omitempty"`      // use_skill: スキル名
+	SkillArgs map[string]any `json:"skill_args,omitempty"` // use_skill: スキルのパラメータ
+	SkillMode string         `json:"skill_mode,omitempty"` // use_skill: "inline" | "task"（空 = スキルの既定）
 
 	// SubTask 関連フィールド
 	TaskID       string `json:"task_id,omitempty"`        // wait/kill_task: 対象タスクID